@@ -10,8 +10,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func Run() {
-	err := apiserver.Start()
+func Run(opts apiserver.APIServerOpts) {
+	err := apiserver.Start(opts)
 	if err != nil {
 		slog.Error(fmt.Sprintf("failed to start apiserver: %v", err))
 		os.Exit(1)
@@ -19,13 +19,18 @@ func Run() {
 }
 
 func NewAPIServerCommand() *cobra.Command {
+	var opts apiserver.APIServerOpts
 	cmd := &cobra.Command{
 		Use:   "apiserver",
 		Short: "apiserver",
 		Run: func(cmd *cobra.Command, args []string) {
-			Run()
+			Run(opts)
 		},
 	}
+	cmd.Flags().StringVar(&opts.Addr, "addr", ":8080", "address the apiserver listens on")
+	cmd.Flags().StringVar(&opts.StorageBackend, "storage-backend", apiserver.StorageBackendMemory, "storage backend to use (memory, redis, etcd)")
+	cmd.Flags().StringVar(&opts.RedisAddr, "redis-addr", "localhost:6379", "address of the redis server when using the redis backend")
+	cmd.Flags().StringSliceVar(&opts.EtcdEndpoints, "etcd-endpoints", []string{"localhost:2379"}, "etcd endpoints when using the etcd backend")
 
 	return cmd
 }
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"superminikube/pkg/client"
+	"superminikube/pkg/controller/cronjob"
+	"superminikube/pkg/controller/daemonset"
+	"superminikube/pkg/controller/deployment"
+	"superminikube/pkg/controller/job"
+	"superminikube/pkg/controller/nodelifecycle"
+	"superminikube/pkg/controller/replicaset"
+	"superminikube/pkg/controller/statefulset"
+
+	"github.com/spf13/cobra"
+)
+
+type ControllerManagerOpts struct {
+	APIServerURL  string
+	NodeLifecycle nodelifecycle.Opts
+	ReplicaSet    replicaset.Opts
+	Deployment    deployment.Opts
+	Job           job.Opts
+	CronJob       cronjob.Opts
+	DaemonSet     daemonset.Opts
+	StatefulSet   statefulset.Opts
+}
+
+func NewControllerManagerCommand() *cobra.Command {
+	var opts ControllerManagerOpts
+	cmd := &cobra.Command{
+		Use:   "controller-manager",
+		Short: "Runs the controllers that drive the cluster towards its desired state",
+		Run: func(cmd *cobra.Command, args []string) {
+			Run(opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.APIServerURL, "apiserver", "http://localhost:8080", "url of the apiserver")
+	cmd.Flags().DurationVar(&opts.NodeLifecycle.MonitorPeriod, "node-monitor-period", nodelifecycle.DefaultMonitorPeriod, "how often node heartbeats are checked")
+	cmd.Flags().DurationVar(&opts.NodeLifecycle.GracePeriod, "node-monitor-grace-period", nodelifecycle.DefaultGracePeriod, "how long a node can go without a heartbeat before it's marked NotReady")
+	cmd.Flags().DurationVar(&opts.NodeLifecycle.PodEvictionTimeout, "pod-eviction-timeout", nodelifecycle.DefaultPodEvictionTimeout, "how long pods stay on an unreachable node before they're deleted")
+	cmd.Flags().DurationVar(&opts.ReplicaSet.ResyncPeriod, "replicaset-resync-period", replicaset.DefaultResyncPeriod, "how often every replica set is synced regardless of events")
+	cmd.Flags().DurationVar(&opts.Deployment.ResyncPeriod, "deployment-resync-period", deployment.DefaultResyncPeriod, "how often every deployment is synced regardless of events, and progress deadlines are checked")
+	cmd.Flags().DurationVar(&opts.Job.ResyncPeriod, "job-resync-period", job.DefaultResyncPeriod, "how often every job is synced regardless of events, and deadlines, ttls and backoffs are checked")
+	cmd.Flags().DurationVar(&opts.CronJob.ResyncPeriod, "cronjob-resync-period", cronjob.DefaultResyncPeriod, "how often every cron job is synced regardless of events, runs start at most this late")
+	cmd.Flags().DurationVar(&opts.DaemonSet.ResyncPeriod, "daemonset-resync-period", daemonset.DefaultResyncPeriod, "how often every daemon set is synced regardless of events")
+	cmd.Flags().DurationVar(&opts.StatefulSet.ResyncPeriod, "statefulset-resync-period", statefulset.DefaultResyncPeriod, "how often every stateful set is synced regardless of events")
+
+	return cmd
+}
+
+func Run(opts ControllerManagerOpts) {
+	slog.Info("Starting Controller Manager...")
+	ctx, stop := signal.NotifyContext(context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	// controllers don't watch pods for a node, they list everything
+	c := client.NewHTTPClient(opts.APIServerURL, "")
+	controllers := map[string]interface{ Start(context.Context) error }{
+		"nodelifecycle": nodelifecycle.NewController(c, opts.NodeLifecycle),
+		"replicaset":    replicaset.NewController(c, opts.ReplicaSet),
+		"deployment":    deployment.NewController(c, opts.Deployment),
+		"job":           job.NewController(c, opts.Job),
+		"cronjob":       cronjob.NewController(c, opts.CronJob),
+		"daemonset":     daemonset.NewController(c, opts.DaemonSet),
+		"statefulset":   statefulset.NewController(c, opts.StatefulSet),
+	}
+	// one controller failing takes the rest down with it, same as if the process had crashed
+	errs := make(chan error, len(controllers))
+	for name, controller := range controllers {
+		go func() {
+			if err := controller.Start(ctx); err != nil {
+				errs <- fmt.Errorf("%s controller: %w", name, err)
+				return
+			}
+			errs <- nil
+		}()
+	}
+	for range controllers {
+		if err := <-errs; err != nil {
+			slog.Error("Controller Manager failed:", "error", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	slog.SetDefault(logger)
+	cmd := NewControllerManagerCommand()
+	if err := cmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -15,27 +15,32 @@ import (
 
 // TODO: Return error in Run
 func NewAgentCommand() *cobra.Command {
+	hostname, _ := os.Hostname()
+	opts := kubelet.KubeletOpts{}
 	cmd := &cobra.Command{
 		Use:   "kubelet",
 		Short: "Node agent, sole purpose is running and maintaining pods",
 		Run: func(cmd *cobra.Command, args []string) {
-			Run()
+			Run(opts)
 		},
 	}
+	cmd.Flags().StringVar(&opts.APIServerURL, "apiserver", "http://localhost:8080", "url of the apiserver")
+	cmd.Flags().StringVar(&opts.NodeName, "node-name", hostname, "name the node registers as")
+	cmd.Flags().StringToStringVar(&opts.NodeLabels, "node-labels", nil, "extra labels for the node, key=value pairs")
+	cmd.Flags().Int64Var(&opts.MaxPods, "max-pods", 110, "most pods the node runs at once")
+	cmd.Flags().Int32Var(&opts.Port, "port", 10250, "port the kubelet serves logs on")
+	cmd.Flags().BoolVar(&opts.KeepPodsOnShutdown, "keep-pods-on-shutdown", false, "leave pods running when the kubelet stops, they're adopted on the next start")
 
 	return cmd
 }
 
-func Run() {
+func Run(opts kubelet.KubeletOpts) {
 	slog.Info("Starting Kubelet...")
 	ctx, stop := signal.NotifyContext(context.Background(),
 		os.Interrupt,
 		syscall.SIGTERM)
 	defer stop()
-	// TODO: more things that need to be configured
-	// how do i plan on generating nodenames
-	// maybe i should verify nodes exist as well on server side
-	k, err := kubelet.NewKubelet("http://localhost:8080", "agent-node-0")
+	k, err := kubelet.NewKubelet(opts)
 	if err != nil {
 		slog.Error("Failed to start Kubelet:", "error", err)
 		os.Exit(1)
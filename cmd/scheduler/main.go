@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"superminikube/pkg/scheduler"
+
+	"github.com/spf13/cobra"
+)
+
+func NewSchedulerCommand() *cobra.Command {
+	var apiServerURL string
+	cmd := &cobra.Command{
+		Use:   "scheduler",
+		Short: "Assigns pods without a node to one that can run them",
+		Run: func(cmd *cobra.Command, args []string) {
+			Run(apiServerURL)
+		},
+	}
+	cmd.Flags().StringVar(&apiServerURL, "apiserver", "http://localhost:8080", "url of the apiserver")
+
+	return cmd
+}
+
+func Run(apiServerURL string) {
+	slog.Info("Starting Scheduler...")
+	ctx, stop := signal.NotifyContext(context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	s := scheduler.NewScheduler(apiServerURL)
+	if err := s.Start(ctx); err != nil {
+		slog.Error("Failed to start Scheduler:", "error", err)
+		os.Exit(1)
+	}
+}
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	slog.SetDefault(logger)
+	cmd := NewSchedulerCommand()
+	if err := cmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"superminikube/pkg/smkctl"
+)
+
+func main() {
+	// the client's debug logging is for components, users only need to hear about trouble
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	slog.SetDefault(logger)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cmd := smkctl.NewCommand()
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		var exitErr smkctl.ExitError
+		if errors.As(err, &exitErr) {
+			stop()
+			os.Exit(exitErr.Code)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,105 @@
+// Package apitest builds the API objects tests start from.
+// They come out the way the apiserver hands them to controllers, except their containers aren't named,
+// so the apiserver tests can check the defaults being filled in.
+package apitest
+
+import (
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+)
+
+// Ptr points at a copy of v, for the optional fields of a spec
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// NewReplicaSet is a replica set of nginx pods labelled app=name
+func NewReplicaSet(name string, replicas int32) api.ReplicaSet {
+	return api.ReplicaSet{
+		ObjectMeta: newObjectMeta(name),
+		Spec: api.ReplicaSetSpec{
+			Replicas: &replicas,
+			Selector: api.LabelSelector{MatchLabels: labels(name)},
+			Template: newTemplate(name, api.Container{Image: "nginx:latest"}),
+		},
+	}
+}
+
+// NewDeployment is a deployment of nginx:1.0 pods labelled app=name
+func NewDeployment(name string, replicas int32) api.Deployment {
+	return api.Deployment{
+		ObjectMeta: newObjectMeta(name),
+		Spec: api.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: api.LabelSelector{MatchLabels: labels(name)},
+			Template: newTemplate(name, api.Container{Image: "nginx:1.0"}),
+		},
+	}
+}
+
+// NewJob is a job running a busybox pod once
+func NewJob(name string) api.Job {
+	return api.Job{
+		ObjectMeta: newObjectMeta(name),
+		Spec: api.JobSpec{
+			Template: newTemplate(name, api.Container{Image: "busybox"}),
+		},
+	}
+}
+
+// NewCronJob is a cron job starting NewJob's busybox pod on schedule
+func NewCronJob(name, schedule string) api.CronJob {
+	return api.CronJob{
+		ObjectMeta: newObjectMeta(name),
+		Spec: api.CronJobSpec{
+			Schedule: schedule,
+			JobTemplate: api.JobTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: labels(name)},
+				Spec:       NewJob(name).Spec,
+			},
+		},
+	}
+}
+
+// NewDaemonSet is a daemon set of fluentd pods labelled app=name
+func NewDaemonSet(name string) api.DaemonSet {
+	return api.DaemonSet{
+		ObjectMeta: newObjectMeta(name),
+		Spec: api.DaemonSetSpec{
+			Selector: api.LabelSelector{MatchLabels: labels(name)},
+			Template: newTemplate(name, api.Container{Image: "fluentd:1"}),
+		},
+	}
+}
+
+// NewStatefulSet is a stateful set of postgres pods labelled app=name, each with a data volume
+func NewStatefulSet(name string, replicas int32) api.StatefulSet {
+	return api.StatefulSet{
+		ObjectMeta: newObjectMeta(name),
+		Spec: api.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: api.LabelSelector{MatchLabels: labels(name)},
+			Template: newTemplate(name, api.Container{
+				Image:        "postgres:16",
+				VolumeMounts: []api.VolumeMount{{Name: "data", MountPath: "/var/lib/postgresql/data"}},
+			}),
+			VolumeClaimTemplates: []api.VolumeClaimTemplate{{Name: "data"}},
+		},
+	}
+}
+
+func newObjectMeta(name string) api.ObjectMeta {
+	return api.ObjectMeta{Name: name, Namespace: "default", Uid: uuid.New(), Generation: 1}
+}
+
+func newTemplate(name string, c api.Container) api.PodTemplateSpec {
+	return api.PodTemplateSpec{
+		ObjectMeta: api.ObjectMeta{Labels: labels(name)},
+		Spec:       api.PodSpec{Containers: []api.Container{c}},
+	}
+}
+
+func labels(name string) map[string]string {
+	return map[string]string{"app": name}
+}
@@ -0,0 +1,62 @@
+package api
+
+// DaemonSet runs a copy of its pod on every node that matches the template's nodeSelector and tolerations.
+// The controller picks the node itself, so its pods never go through the scheduler.
+type DaemonSet struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       DaemonSetSpec   `json:"spec"`
+	Status     DaemonSetStatus `json:"status"`
+}
+
+type DaemonSetSpec struct {
+	// pods the daemon set counts as its own, has to match the template's labels and can't be changed
+	Selector       LabelSelector           `json:"selector"`
+	Template       PodTemplateSpec         `json:"template"`
+	UpdateStrategy DaemonSetUpdateStrategy `json:"updateStrategy"`
+}
+
+type DaemonSetUpdateStrategyType string
+
+const (
+	// replace old pods node by node, keeping at most maxUnavailable nodes without a ready pod
+	RollingUpdateDaemonSetStrategyType DaemonSetUpdateStrategyType = "RollingUpdate"
+	// old pods are only replaced once someone deletes them
+	OnDeleteDaemonSetStrategyType DaemonSetUpdateStrategyType = "OnDelete"
+)
+
+type DaemonSetUpdateStrategy struct {
+	// defaults to RollingUpdate
+	Type          DaemonSetUpdateStrategyType `json:"type,omitempty"`
+	RollingUpdate *RollingUpdateDaemonSet     `json:"rollingUpdate,omitempty"`
+}
+
+type RollingUpdateDaemonSet struct {
+	// how many nodes can be without a ready pod during an update, a count or a percentage of the
+	// nodes the daemon set should run on, percentages round up, defaults to 1
+	MaxUnavailable *IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// DaemonSetStatus is reported by the daemon set controller
+type DaemonSetStatus struct {
+	// generation of the spec the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// nodes that should run the daemon pod
+	DesiredNumberScheduled int32 `json:"desiredNumberScheduled"`
+	// of those, the ones that do
+	CurrentNumberScheduled int32 `json:"currentNumberScheduled"`
+	// nodes that run the daemon pod but shouldn't, they're on their way out
+	NumberMisscheduled int32 `json:"numberMisscheduled"`
+	// nodes that should run the daemon pod and have it Ready
+	NumberReady int32 `json:"numberReady"`
+	// nodes running a pod of the current template
+	UpdatedNumberScheduled int32 `json:"updatedNumberScheduled"`
+	// nodes that should run the daemon pod but have none Ready
+	NumberUnavailable int32 `json:"numberUnavailable"`
+}
+
+type DaemonSetList struct {
+	// store revision the list was read at, watch from here to pick up later changes
+	ResourceVersion string      `json:"resourceVersion"`
+	Items           []DaemonSet `json:"items"`
+}
@@ -0,0 +1,136 @@
+package api
+
+import "time"
+
+const (
+	// set on every replica set of a deployment, counting up from 1 each time a template is rolled out
+	DeploymentRevisionAnnotation = "deployment.superminikube.io/revision"
+	// hash of the pod template a deployment's replica set was made from,
+	// it's added to the replica set's selector so replica sets of one deployment don't share pods.
+	// Daemon sets and stateful sets put it on their pods to tell which ones a rolling update still has to replace.
+	PodTemplateHashLabel = "pod-template-hash"
+)
+
+// Deployment rolls out changes to its pod template by moving pods from an old replica set over to a new one
+type Deployment struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       DeploymentSpec   `json:"spec"`
+	Status     DeploymentStatus `json:"status"`
+}
+
+type DeploymentSpec struct {
+	// how many pods should be running, defaults to 1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// pods the deployment counts as its own, has to match the template's labels and can't be changed
+	Selector LabelSelector      `json:"selector"`
+	Template PodTemplateSpec    `json:"template"`
+	Strategy DeploymentStrategy `json:"strategy"`
+	// how many old replica sets are kept around to roll back to, defaults to 10
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+	// a paused deployment doesn't roll out template changes until it's resumed
+	Paused bool `json:"paused,omitempty"`
+	// how long a rollout can go without making progress before it's reported as failed, defaults to 600
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+}
+
+type DeploymentStrategyType string
+
+const (
+	// kill every old pod before starting new ones
+	RecreateDeploymentStrategyType DeploymentStrategyType = "Recreate"
+	// replace old pods a few at a time, keeping the deployment available throughout
+	RollingUpdateDeploymentStrategyType DeploymentStrategyType = "RollingUpdate"
+)
+
+type DeploymentStrategy struct {
+	// defaults to RollingUpdate
+	Type          DeploymentStrategyType   `json:"type,omitempty"`
+	RollingUpdate *RollingUpdateDeployment `json:"rollingUpdate,omitempty"`
+}
+
+// RollingUpdateDeployment bounds a rolling update, both fields take a count or a percentage of replicas
+type RollingUpdateDeployment struct {
+	// how many pods can be missing from the replica count, percentages round down, defaults to 25%
+	MaxUnavailable *IntOrString `json:"maxUnavailable,omitempty"`
+	// how many pods can exist above the replica count, percentages round up, defaults to 25%
+	MaxSurge *IntOrString `json:"maxSurge,omitempty"`
+}
+
+// DeploymentStatus is reported by the deployment controller
+type DeploymentStatus struct {
+	// generation of the spec the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// pods across every replica set of the deployment
+	Replicas int32 `json:"replicas"`
+	// pods of the replica set for the current template
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+	ReadyReplicas   int32 `json:"readyReplicas"`
+	// how many pods short of the replica count the deployment is
+	UnavailableReplicas int32                 `json:"unavailableReplicas"`
+	Conditions          []DeploymentCondition `json:"conditions,omitempty"`
+}
+
+type DeploymentConditionType string
+
+const (
+	// enough pods are ready to satisfy maxUnavailable
+	DeploymentAvailable DeploymentConditionType = "Available"
+	// the rollout is moving, finished, or stuck past its progress deadline
+	DeploymentProgressing DeploymentConditionType = "Progressing"
+)
+
+type DeploymentCondition struct {
+	Type   DeploymentConditionType `json:"type"`
+	Status ConditionStatus         `json:"status"`
+	// last time anything about the condition changed
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+	// last time Status flipped
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+}
+
+// GetCondition returns the condition of type t, nil if it isn't set
+func (s *DeploymentStatus) GetCondition(t DeploymentConditionType) *DeploymentCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds c or replaces the condition of the same type, reporting whether anything changed.
+// LastUpdateTime moves to now on any change, LastTransitionTime only when the condition's status flips.
+func (s *DeploymentStatus) SetCondition(c DeploymentCondition, now time.Time) bool {
+	prev := s.GetCondition(c.Type)
+	if prev == nil {
+		c.LastUpdateTime = now
+		c.LastTransitionTime = now
+		s.Conditions = append(s.Conditions, c)
+		return true
+	}
+	if prev.Status == c.Status && prev.Reason == c.Reason && prev.Message == c.Message {
+		return false
+	}
+	c.LastUpdateTime = now
+	c.LastTransitionTime = now
+	if prev.Status == c.Status {
+		c.LastTransitionTime = prev.LastTransitionTime
+	}
+	*prev = c
+	return true
+}
+
+// DeploymentRollback is posted to a deployment's rollback subresource
+type DeploymentRollback struct {
+	// revision whose template to go back to, 0 means the one before the current
+	Revision int64 `json:"revision"`
+}
+
+type DeploymentList struct {
+	// store revision the list was read at, watch from here to pick up later changes
+	ResourceVersion string       `json:"resourceVersion"`
+	Items           []Deployment `json:"items"`
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// PodExecOptions pick the container and the streams of exec and attach requests, they're sent as query parameters.
+// Attach leaves out Command, it connects to the container's own process.
+type PodExecOptions struct {
+	// can be left out for pods with a single container
+	Container string `json:"container,omitempty"`
+	// the command and its arguments, run without a shell
+	Command []string `json:"command,omitempty"`
+	Stdin   bool     `json:"stdin,omitempty"`
+	Stdout  bool     `json:"stdout,omitempty"`
+	// a TTY has a single output stream, stderr comes out of stdout
+	Stderr bool `json:"stderr,omitempty"`
+	TTY    bool `json:"tty,omitempty"`
+}
+
+// Query encodes o the way ParsePodExecOptions reads it back
+func (o PodExecOptions) Query() url.Values {
+	q := url.Values{}
+	if o.Container != "" {
+		q.Set("container", o.Container)
+	}
+	for _, arg := range o.Command {
+		q.Add("command", arg)
+	}
+	if o.Stdin {
+		q.Set("stdin", "true")
+	}
+	if o.Stdout {
+		q.Set("stdout", "true")
+	}
+	if o.Stderr {
+		q.Set("stderr", "true")
+	}
+	if o.TTY {
+		q.Set("tty", "true")
+	}
+	return q
+}
+
+// ParsePodExecOptions reads ?container=&command=&stdin=&stdout=&stderr=&tty=, command is repeated once per argument.
+// At least one stream has to be asked for.
+func ParsePodExecOptions(q url.Values) (PodExecOptions, error) {
+	opts := PodExecOptions{Container: q.Get("container"), Command: q["command"]}
+	for name, field := range map[string]*bool{"stdin": &opts.Stdin, "stdout": &opts.Stdout, "stderr": &opts.Stderr, "tty": &opts.TTY} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return PodExecOptions{}, fmt.Errorf("invalid %s %q", name, v)
+		}
+		*field = b
+	}
+	if !opts.Stdin && !opts.Stdout && !opts.Stderr {
+		return PodExecOptions{}, errors.New("at least one of stdin, stdout and stderr is needed")
+	}
+	if opts.TTY && opts.Stderr {
+		return PodExecOptions{}, errors.New("stderr can't be separate from stdout with a tty")
+	}
+	return opts, nil
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// IntOrString holds either a number or a string, in json it's whichever of the two was given.
+// It's used for fields like maxSurge that take an absolute count or a percentage such as "25%".
+type IntOrString struct {
+	IsString bool
+	IntVal   int32
+	StrVal   string
+}
+
+func FromInt(i int32) IntOrString {
+	return IntOrString{IntVal: i}
+}
+
+func FromString(s string) IntOrString {
+	return IntOrString{IsString: true, StrVal: s}
+}
+
+func (v IntOrString) MarshalJSON() ([]byte, error) {
+	if v.IsString {
+		return json.Marshal(v.StrVal)
+	}
+	return json.Marshal(v.IntVal)
+}
+
+func (v *IntOrString) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		v.IsString = true
+		v.IntVal = 0
+		return json.Unmarshal(b, &v.StrVal)
+	}
+	v.IsString = false
+	v.StrVal = ""
+	return json.Unmarshal(b, &v.IntVal)
+}
+
+func (v IntOrString) String() string {
+	if v.IsString {
+		return v.StrVal
+	}
+	return strconv.Itoa(int(v.IntVal))
+}
+
+// ScaledValue resolves v against total, a percentage is taken of total and rounded up or down as asked.
+// Strings that aren't a percentage are an error.
+func (v IntOrString) ScaledValue(total int, roundUp bool) (int, error) {
+	if !v.IsString {
+		return int(v.IntVal), nil
+	}
+	s, ok := strings.CutSuffix(v.StrVal, "%")
+	if !ok {
+		return 0, fmt.Errorf("invalid value %q, expected a number or a percentage", v.StrVal)
+	}
+	percent, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage %q", v.StrVal)
+	}
+	scaled := float64(percent) * float64(total) / 100
+	if roundUp {
+		return int(math.Ceil(scaled)), nil
+	}
+	return int(math.Floor(scaled)), nil
+}
@@ -0,0 +1,146 @@
+package api
+
+import "time"
+
+// set on every pod of a job, naming the job
+const JobNameLabel = "job-name"
+
+// Job runs pods until enough of them finish successfully
+type Job struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       JobSpec   `json:"spec"`
+	Status     JobStatus `json:"status"`
+}
+
+type JobSpec struct {
+	// how many pods run at once, defaults to 1
+	Parallelism *int32 `json:"parallelism,omitempty"`
+	// how many pods have to succeed for the job to complete, can't be changed.
+	// Defaults to 1 unless parallelism is set, then nil means the job completes once any pod succeeded and the rest are done.
+	Completions *int32 `json:"completions,omitempty"`
+	// how many pods can fail before the job is failed, defaults to 6.
+	// Containers the kubelet restarts under the OnFailure restart policy count as failures too.
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+	// how long the job can run, counted from when it started, before it's failed and its pods stopped
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	// how long a finished job is kept before it's deleted along with its pods, nil keeps it until someone deletes it
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// what every pod of the job runs, can't be changed. Its restartPolicy defaults to Never and can't be Always.
+	Template PodTemplateSpec `json:"template"`
+}
+
+// JobStatus is reported by the job controller
+type JobStatus struct {
+	// when the controller first picked the job up
+	StartTime *time.Time `json:"startTime,omitempty"`
+	// when the job completed, failed jobs don't get one
+	CompletionTime *time.Time `json:"completionTime,omitempty"`
+	// pods that are pending or running
+	Active int32 `json:"active"`
+	// pods that finished, they're kept until the job is deleted
+	Succeeded  int32          `json:"succeeded"`
+	Failed     int32          `json:"failed"`
+	Conditions []JobCondition `json:"conditions,omitempty"`
+}
+
+type JobConditionType string
+
+const (
+	// enough pods succeeded
+	JobComplete JobConditionType = "Complete"
+	// the job ran out of retries or time
+	JobFailed JobConditionType = "Failed"
+)
+
+type JobCondition struct {
+	Type   JobConditionType `json:"type"`
+	Status ConditionStatus  `json:"status"`
+	// last time Status flipped
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+}
+
+// GetCondition returns the condition of type t, nil if it isn't set
+func (s *JobStatus) GetCondition(t JobConditionType) *JobCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// Finished returns the Complete or Failed condition once the job has one, nil while it's still running
+func (s *JobStatus) Finished() *JobCondition {
+	for _, t := range []JobConditionType{JobComplete, JobFailed} {
+		if c := s.GetCondition(t); c != nil && c.Status == ConditionTrue {
+			return c
+		}
+	}
+	return nil
+}
+
+type JobList struct {
+	// store revision the list was read at, watch from here to pick up later changes
+	ResourceVersion string `json:"resourceVersion"`
+	Items           []Job  `json:"items"`
+}
+
+// CronJob creates a job from its template every time its schedule comes round
+type CronJob struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       CronJobSpec   `json:"spec"`
+	Status     CronJobStatus `json:"status"`
+}
+
+type ConcurrencyPolicy string
+
+const (
+	// jobs from different runs can overlap, the default
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+	// a run is skipped while the previous job is still going
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+	// a run deletes the job that's still going and starts over
+	ReplaceConcurrent ConcurrencyPolicy = "Replace"
+)
+
+type CronJobSpec struct {
+	// standard five field cron schedule, or a macro like @hourly, evaluated in UTC
+	Schedule string `json:"schedule"`
+	// how late a run can still be started, e.g. after the controller was down, nil means however late
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+	// what to do when a run comes round while an earlier job is still going, defaults to Allow
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	// a suspended cron job starts no new jobs, the ones already going carry on
+	Suspend bool `json:"suspend,omitempty"`
+	// how many finished jobs are kept around, defaults to 3 successful and 1 failed
+	SuccessfulJobsHistoryLimit *int32          `json:"successfulJobsHistoryLimit,omitempty"`
+	FailedJobsHistoryLimit     *int32          `json:"failedJobsHistoryLimit,omitempty"`
+	JobTemplate                JobTemplateSpec `json:"jobTemplate"`
+}
+
+// JobTemplateSpec is what a cron job stamps its jobs out of
+type JobTemplateSpec struct {
+	// labels and annotations given to every job, the name is picked by the controller
+	ObjectMeta `json:"metadata"`
+	Spec       JobSpec `json:"spec"`
+}
+
+// CronJobStatus is reported by the cron job controller
+type CronJobStatus struct {
+	// names of the cron job's jobs that haven't finished
+	Active []string `json:"active,omitempty"`
+	// the scheduled time of the last run a job was started for
+	LastScheduleTime *time.Time `json:"lastScheduleTime,omitempty"`
+	// when the most recent successful job completed
+	LastSuccessfulTime *time.Time `json:"lastSuccessfulTime,omitempty"`
+}
+
+type CronJobList struct {
+	// store revision the list was read at, watch from here to pick up later changes
+	ResourceVersion string    `json:"resourceVersion"`
+	Items           []CronJob `json:"items"`
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// PodLogOptions pick which of a container's output to read, they're sent as query parameters
+type PodLogOptions struct {
+	// can be left out for pods with a single container
+	Container string `json:"container,omitempty"`
+	// keeps the stream open for output that's still to come
+	Follow bool `json:"follow,omitempty"`
+	// output of the container's earlier runs rather than the current one
+	Previous bool `json:"previous,omitempty"`
+	// only output from this many seconds back
+	SinceSeconds *int64 `json:"sinceSeconds,omitempty"`
+	// only this many of the last lines
+	TailLines *int64 `json:"tailLines,omitempty"`
+	// prefixes every line with the RFC3339Nano time it was written
+	Timestamps bool `json:"timestamps,omitempty"`
+}
+
+// Query encodes o the way ParsePodLogOptions reads it back
+func (o PodLogOptions) Query() url.Values {
+	q := url.Values{}
+	if o.Container != "" {
+		q.Set("container", o.Container)
+	}
+	if o.Follow {
+		q.Set("follow", "true")
+	}
+	if o.Previous {
+		q.Set("previous", "true")
+	}
+	if o.SinceSeconds != nil {
+		q.Set("sinceSeconds", strconv.FormatInt(*o.SinceSeconds, 10))
+	}
+	if o.TailLines != nil {
+		q.Set("tailLines", strconv.FormatInt(*o.TailLines, 10))
+	}
+	if o.Timestamps {
+		q.Set("timestamps", "true")
+	}
+	return q
+}
+
+// ParsePodLogOptions reads ?container=&follow=&previous=&sinceSeconds=&tailLines=&timestamps=
+func ParsePodLogOptions(q url.Values) (PodLogOptions, error) {
+	opts := PodLogOptions{Container: q.Get("container")}
+	for name, field := range map[string]*bool{"follow": &opts.Follow, "previous": &opts.Previous, "timestamps": &opts.Timestamps} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return PodLogOptions{}, fmt.Errorf("invalid %s %q", name, v)
+		}
+		*field = b
+	}
+	for name, field := range map[string]**int64{"sinceSeconds": &opts.SinceSeconds, "tailLines": &opts.TailLines} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 || (name == "sinceSeconds" && n == 0) {
+			return PodLogOptions{}, fmt.Errorf("invalid %s %q", name, v)
+		}
+		*field = &n
+	}
+	return opts, nil
+}
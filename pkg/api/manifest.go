@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"sigs.k8s.io/yaml"
+)
+
+// APIVersion is the apiVersion manifests are written against, every kind is served under /api/v1
+const APIVersion = "v1"
+
+// Object is a top-level API object, the kind of thing a manifest holds
+type Object interface {
+	GetTypeMeta() *TypeMeta
+	GetObjectMeta() *ObjectMeta
+}
+
+var kinds = map[string]func() Object{
+	"Pod":         func() Object { return &Pod{} },
+	"Node":        func() Object { return &Node{} },
+	"ReplicaSet":  func() Object { return &ReplicaSet{} },
+	"Deployment":  func() Object { return &Deployment{} },
+	"Job":         func() Object { return &Job{} },
+	"CronJob":     func() Object { return &CronJob{} },
+	"DaemonSet":   func() Object { return &DaemonSet{} },
+	"StatefulSet": func() Object { return &StatefulSet{} },
+}
+
+// NewObject is an empty object of kind with its TypeMeta filled in
+func NewObject(kind string) (Object, error) {
+	newObject, ok := kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %q", kind)
+	}
+	obj := newObject()
+	*obj.GetTypeMeta() = TypeMeta{APIVersion: APIVersion, Kind: kind}
+	return obj, nil
+}
+
+// KindOf is the kind of obj, whatever its TypeMeta says
+func KindOf(obj Object) string {
+	return reflect.TypeOf(obj).Elem().Name()
+}
+
+// SetTypeMeta fills in obj's apiVersion and kind, objects from the apiserver come without them
+func SetTypeMeta(obj Object) {
+	*obj.GetTypeMeta() = TypeMeta{APIVersion: APIVersion, Kind: KindOf(obj)}
+}
+
+// CheckTypeMeta rejects an apiVersion or kind that doesn't fit obj, either can be left out
+func CheckTypeMeta(obj Object) error {
+	t := obj.GetTypeMeta()
+	if t.APIVersion != "" && t.APIVersion != APIVersion {
+		return fmt.Errorf("unsupported apiVersion %q, expected %s", t.APIVersion, APIVersion)
+	}
+	if kind := KindOf(obj); t.Kind != "" && t.Kind != kind {
+		return fmt.Errorf("kind %s doesn't match %s", t.Kind, kind)
+	}
+	return nil
+}
+
+// DecodeStrict decodes a single JSON object into v. Fields v doesn't have are rejected,
+// so are typos in field names and anything after the object.
+func DecodeStrict(data []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if _, err := d.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("unexpected data after the object")
+	}
+	return nil
+}
+
+// YAMLToJSON converts a single YAML document to JSON. JSON is YAML, it comes out the same.
+// Keys that appear twice are rejected rather than one of them winning.
+func YAMLToJSON(data []byte) ([]byte, error) {
+	docs := 0
+	for _, doc := range SplitYAML(data) {
+		if len(bytes.TrimSpace(doc)) > 0 {
+			docs++
+		}
+	}
+	if docs > 1 {
+		return nil, errors.New("expected a single YAML document")
+	}
+	return yaml.YAMLToJSONStrict(data)
+}
+
+// DecodeObject strictly decodes a single YAML or JSON document into the type its kind names.
+// apiVersion and kind are required, fields the type doesn't have are rejected.
+func DecodeObject(data []byte) (Object, error) {
+	j, err := YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var t TypeMeta
+	if err := json.Unmarshal(j, &t); err != nil {
+		return nil, errors.New("expected an object")
+	}
+	if t.APIVersion == "" {
+		return nil, errors.New("apiVersion is missing")
+	}
+	if t.Kind == "" {
+		return nil, errors.New("kind is missing")
+	}
+	obj, err := NewObject(t.Kind)
+	if err != nil {
+		return nil, err
+	}
+	if err := DecodeStrict(j, obj); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", t.Kind, err)
+	}
+	if err := CheckTypeMeta(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// DecodeManifest decodes every object of a manifest, YAML documents split by --- lines or a JSON object,
+// the way DecodeObject does. Empty documents are skipped.
+func DecodeManifest(data []byte) ([]Object, error) {
+	var objs []Object
+	for i, doc := range SplitYAML(data) {
+		if isEmptyDocument(doc) {
+			continue
+		}
+		obj, err := DecodeObject(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %v", i+1, err)
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// EncodeManifest writes objs as a YAML manifest, a document each. Their apiVersion and kind are filled in on the way.
+func EncodeManifest(objs ...Object) ([]byte, error) {
+	var out bytes.Buffer
+	for i, obj := range objs {
+		SetTypeMeta(obj)
+		j, err := json.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s %s: %v", KindOf(obj), obj.GetObjectMeta().Name, err)
+		}
+		y, err := yaml.JSONToYAML(j)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s %s: %v", KindOf(obj), obj.GetObjectMeta().Name, err)
+		}
+		if i > 0 {
+			out.WriteString("---\n")
+		}
+		out.Write(y)
+	}
+	return out.Bytes(), nil
+}
+
+// SplitYAML splits a stream of YAML documents on the --- lines between them
+func SplitYAML(data []byte) [][]byte {
+	var docs [][]byte
+	var doc bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "---" || bytes.HasPrefix([]byte(line), []byte("--- ")) {
+			docs = append(docs, bytes.Clone(doc.Bytes()))
+			doc.Reset()
+			continue
+		}
+		doc.WriteString(line)
+		doc.WriteByte('\n')
+	}
+	return append(docs, doc.Bytes())
+}
+
+// isEmptyDocument reports whether doc holds nothing but blank lines and comments
+func isEmptyDocument(doc []byte) bool {
+	j, err := yaml.YAMLToJSON(doc)
+	return err == nil && string(j) == "null"
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeManifest(t *testing.T) {
+	b, err := os.ReadFile("../../example-configs/config.yml")
+	if err != nil {
+		t.Fatalf("failed to read the example config: %v", err)
+	}
+	objs, err := DecodeManifest(b)
+	if err != nil {
+		t.Fatalf("failed to decode the example config: %v", err)
+	}
+	var names []string
+	for _, obj := range objs {
+		names = append(names, KindOf(obj)+"/"+obj.GetObjectMeta().Name)
+	}
+	if expected := "Pod/postgres Pod/redis Pod/nginx"; strings.Join(names, " ") != expected {
+		t.Fatalf("got %v, expected %s", names, expected)
+	}
+	postgres := objs[0].(*Pod).Spec.Containers[0]
+	if postgres.Env["POSTGRES_PASSWORD"] != "password" || postgres.Ports[0] != (Port{Hostport: "9995", Containerport: "8081"}) {
+		t.Errorf("got container %+v, expected its env and ports decoded", postgres)
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		testCases := map[string]string{
+			"no apiVersion":   "kind: Pod\nmetadata:\n  name: web\n",
+			"no kind":         "apiVersion: v1\nmetadata:\n  name: web\n",
+			"unknown version": "apiVersion: v2\nkind: Pod\nmetadata:\n  name: web\n",
+			"unknown kind":    "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n",
+			"unknown field":   "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - image: nginx\n    imagePullPolicy: Always\n",
+			"duplicate key":   "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\n  name: db\n",
+			"wrong type":      "apiVersion: v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: two\n",
+			"not an object":   "- apiVersion: v1\n",
+			// one bad document fails the whole manifest
+			"second document": "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\n---\nkind: Pod\n",
+		}
+		for name, manifest := range testCases {
+			if _, err := DecodeManifest([]byte(manifest)); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		objs, err := DecodeManifest([]byte(`{"apiVersion": "v1", "kind": "Node", "metadata": {"name": "worker"}}`))
+		if err != nil || len(objs) != 1 || objs[0].GetObjectMeta().Name != "worker" {
+			t.Errorf("got %v, %v", objs, err)
+		}
+	})
+
+	t.Run("empty documents", func(t *testing.T) {
+		objs, err := DecodeManifest([]byte("# nothing yet\n---\n---\n"))
+		if err != nil || len(objs) != 0 {
+			t.Errorf("got %v, %v", objs, err)
+		}
+	})
+}
+
+func TestEncodeManifest(t *testing.T) {
+	replicas := int32(2)
+	objs := []Object{
+		&Pod{ObjectMeta: ObjectMeta{Name: "web"}, Spec: PodSpec{Containers: []Container{{Name: "app", Image: "nginx"}}}},
+		&Deployment{ObjectMeta: ObjectMeta{Name: "api", Namespace: "prod"}, Spec: DeploymentSpec{Replicas: &replicas}},
+	}
+	b, err := EncodeManifest(objs...)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if docs := SplitYAML(b); len(docs) != 2 || !strings.Contains(string(docs[1]), "kind: Deployment\n") {
+		t.Errorf("got %s, expected a document per object", b)
+	}
+	decoded, err := DecodeManifest(b)
+	if err != nil {
+		t.Fatalf("failed to decode what was encoded: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, objs) {
+		t.Errorf("got %+v back, expected %+v", decoded, objs)
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	var p Pod
+	if err := DecodeStrict([]byte(`{"metadata": {"name": "web"}} {}`), &p); err == nil {
+		t.Errorf("expected data after the object to be rejected")
+	}
+	if err := DecodeStrict([]byte(`{"spec": {"containers": [{"image": "nginx"}]}}`), &p); err != nil || p.Spec.Containers[0].Image != "nginx" {
+		t.Errorf("got %+v, %v", p, err)
+	}
+}
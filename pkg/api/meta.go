@@ -0,0 +1,111 @@
+package api
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TypeMeta says what an object is. It's what manifests are decoded by, the apiserver knows from the url
+// and doesn't store it.
+type TypeMeta struct {
+	// version of the API the object is written against, always APIVersion for now
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+}
+
+func (t *TypeMeta) GetTypeMeta() *TypeMeta {
+	return t
+}
+
+// ObjectMeta is embedded by every API object.
+// Uid, CreationTimestamp, DeletionTimestamp, DeletionGracePeriodSeconds, Generation and ResourceVersion
+// are owned by the apiserver, whatever clients send for them is overwritten.
+type ObjectMeta struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"` // only default namespace will exist for now
+	Uid         uuid.UUID         `json:"uid"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// prefix for a random name when Name is empty, lets controllers create objects without picking names
+	GenerateName string `json:"generateName,omitempty"`
+
+	CreationTimestamp time.Time `json:"creationTimestamp"`
+	// set once the object is being deleted, it stays readable until it's gone from storage
+	DeletionTimestamp *time.Time `json:"deletionTimestamp,omitempty"`
+	// time the object has to shut down once deleted, 0 means nothing is holding up removal
+	DeletionGracePeriodSeconds *int64 `json:"deletionGracePeriodSeconds,omitempty"`
+	// bumped every time the spec changes
+	Generation int64 `json:"generation"`
+	// store revision the object was last written at
+	ResourceVersion string           `json:"resourceVersion"`
+	OwnerReferences []OwnerReference `json:"ownerReferences,omitempty"`
+	// a deleted object is only removed from storage once every finalizer has been cleared
+	Finalizers []string `json:"finalizers,omitempty"`
+}
+
+func (m *ObjectMeta) SetResourceVersion(rv string) {
+	m.ResourceVersion = rv
+}
+
+func (m *ObjectMeta) GetObjectMeta() *ObjectMeta {
+	return m
+}
+
+// OwnerReference points at the object responsible for this one, e.g. the controller that created it
+type OwnerReference struct {
+	Kind string    `json:"kind"`
+	Name string    `json:"name"`
+	Uid  uuid.UUID `json:"uid"`
+	// set when the owner is the managing controller
+	Controller bool `json:"controller,omitempty"`
+}
+
+// LabelSelector picks out objects by their labels
+type LabelSelector struct {
+	// every label here has to be on the object with the same value
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+// Matches reports whether labels satisfy the selector, an empty selector matches nothing
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	if len(s.MatchLabels) == 0 {
+		return false
+	}
+	for k, v := range s.MatchLabels {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// IsControlledBy reports whether the object's managing controller is the object with uid
+func (m ObjectMeta) IsControlledBy(uid uuid.UUID) bool {
+	for _, ref := range m.OwnerReferences {
+		if ref.Controller && ref.Uid == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// ControllerRef returns the owner reference of the managing controller, nil if there isn't one
+func (m ObjectMeta) ControllerRef() *OwnerReference {
+	for i := range m.OwnerReferences {
+		if m.OwnerReferences[i].Controller {
+			return &m.OwnerReferences[i]
+		}
+	}
+	return nil
+}
+
+// DeleteOptions are sent as query parameters on delete requests
+type DeleteOptions struct {
+	// overrides the object's own grace period, 0 removes it without waiting
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+	// only delete if the object is still at this resourceVersion
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// only delete if the object still has this uid, so a recreated object of the same name is left alone
+	Uid uuid.UUID `json:"uid"`
+}
@@ -0,0 +1,122 @@
+package api
+
+import "time"
+
+// labels the kubelet puts on its node, handy for nodeSelectors
+const (
+	LabelHostname = "superminikube.io/hostname"
+	LabelOS       = "superminikube.io/os"
+	LabelArch     = "superminikube.io/arch"
+)
+
+// Node is a machine pods can be scheduled onto, nodes aren't namespaced
+type Node struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       NodeSpec   `json:"spec"`
+	Status     NodeStatus `json:"status"`
+}
+
+type NodeSpec struct {
+	// cordoned nodes keep their pods but don't get new ones
+	Unschedulable bool `json:"unschedulable,omitempty"`
+	// keep pods off the node unless they tolerate them
+	Taints []Taint `json:"taints,omitempty"`
+}
+
+// NodeStatus is reported by the node's kubelet through the status subresource
+type NodeStatus struct {
+	// everything the node has
+	Capacity ResourceList `json:"capacity"`
+	// what's left for pods once the system takes its share, defaults to Capacity
+	Allocatable ResourceList    `json:"allocatable"`
+	Conditions  []NodeCondition `json:"conditions,omitempty"`
+	Addresses   []NodeAddress   `json:"addresses,omitempty"`
+	// where the node's daemons listen, on any of Addresses
+	DaemonEndpoints NodeDaemonEndpoints `json:"daemonEndpoints"`
+	NodeInfo        NodeSystemInfo      `json:"nodeInfo"`
+}
+
+type NodeDaemonEndpoints struct {
+	// the kubelet's HTTP server, the apiserver goes through it for logs
+	KubeletEndpoint DaemonEndpoint `json:"kubeletEndpoint"`
+}
+
+type DaemonEndpoint struct {
+	Port int32 `json:"port"`
+}
+
+// GetCondition returns the condition of type t, nil if it isn't set
+func (s *NodeStatus) GetCondition(t NodeConditionType) *NodeCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+type NodeConditionType string
+
+const (
+	// the kubelet is healthy and can run pods
+	NodeReady NodeConditionType = "Ready"
+)
+
+type NodeCondition struct {
+	Type   NodeConditionType `json:"type"`
+	Status ConditionStatus   `json:"status"`
+	// last time the kubelet reported in, a node that stops heartbeating is presumed gone
+	LastHeartbeatTime time.Time `json:"lastHeartbeatTime"`
+	// last time Status flipped
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+}
+
+type NodeAddressType string
+
+const (
+	NodeHostName   NodeAddressType = "Hostname"
+	NodeInternalIP NodeAddressType = "InternalIP"
+)
+
+type NodeAddress struct {
+	Type    NodeAddressType `json:"type"`
+	Address string          `json:"address"`
+}
+
+type NodeSystemInfo struct {
+	OperatingSystem         string `json:"operatingSystem,omitempty"`
+	Architecture            string `json:"architecture,omitempty"`
+	KernelVersion           string `json:"kernelVersion,omitempty"`
+	ContainerRuntimeVersion string `json:"containerRuntimeVersion,omitempty"`
+}
+
+type NodeList struct {
+	// store revision the list was read at, watch from here to pick up later changes
+	ResourceVersion string `json:"resourceVersion"`
+	Items           []Node `json:"items"`
+}
+
+type TaintEffect string
+
+const (
+	// pods that don't tolerate the taint aren't scheduled onto the node
+	TaintEffectNoSchedule TaintEffect = "NoSchedule"
+	// the scheduler avoids the node for pods that don't tolerate the taint, but may still use it
+	TaintEffectPreferNoSchedule TaintEffect = "PreferNoSchedule"
+	// like NoSchedule, and pods already on the node that don't tolerate it are evicted
+	TaintEffectNoExecute TaintEffect = "NoExecute"
+)
+
+// added by the node lifecycle controller to nodes that stopped heartbeating
+const TaintNodeUnreachable = "node.unreachable"
+
+type Taint struct {
+	Key    string      `json:"key"`
+	Value  string      `json:"value,omitempty"`
+	Effect TaintEffect `json:"effect"`
+	// when the taint was put on the node, NoExecute toleration periods count from here
+	TimeAdded *time.Time `json:"timeAdded,omitempty"`
+}
@@ -0,0 +1,33 @@
+package api
+
+// ReplicaSet keeps a fixed number of identical pods running
+type ReplicaSet struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       ReplicaSetSpec   `json:"spec"`
+	Status     ReplicaSetStatus `json:"status"`
+}
+
+type ReplicaSetSpec struct {
+	// how many pods should be running, defaults to 1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// pods the replica set counts as its own, has to match the template's labels
+	Selector LabelSelector   `json:"selector"`
+	Template PodTemplateSpec `json:"template"`
+}
+
+// ReplicaSetStatus is reported by the replica set controller
+type ReplicaSetStatus struct {
+	// pods the controller has that aren't being deleted or finished
+	Replicas int32 `json:"replicas"`
+	// those of Replicas that are Ready
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// generation of the spec the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+type ReplicaSetList struct {
+	// store revision the list was read at, watch from here to pick up later changes
+	ResourceVersion string       `json:"resourceVersion"`
+	Items           []ReplicaSet `json:"items"`
+}
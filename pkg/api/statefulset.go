@@ -0,0 +1,94 @@
+package api
+
+const (
+	// set on every pod of a stateful set, naming the pod, so a selector can pick out a single ordinal
+	StatefulSetPodNameLabel = "statefulset.superminikube.io/pod-name"
+	// where volume claim templates put their directories unless they say otherwise
+	DefaultVolumeClaimDir = "/var/lib/superminikube/volumes"
+)
+
+// StatefulSet runs pods with a stable identity: pod i is always named <name>-i and always gets the same volume directories.
+// Pods are started in ordinal order and stopped in reverse unless the set asks for Parallel.
+type StatefulSet struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	Spec       StatefulSetSpec   `json:"spec"`
+	Status     StatefulSetStatus `json:"status"`
+}
+
+type StatefulSetSpec struct {
+	// how many pods should be running, ordinals 0 to replicas-1, defaults to 1
+	Replicas *int32 `json:"replicas,omitempty"`
+	// pods the stateful set counts as its own, has to match the template's labels and can't be changed
+	Selector LabelSelector   `json:"selector"`
+	Template PodTemplateSpec `json:"template"`
+	// every pod gets a directory of its own for each of these, mounted as a volume named after the template.
+	// The directories are left behind when pods or the set are deleted, so a replacement pod picks up where the old one left off.
+	// Can't be changed.
+	VolumeClaimTemplates []VolumeClaimTemplate `json:"volumeClaimTemplates,omitempty"`
+	// defaults to OrderedReady, can't be changed
+	PodManagementPolicy PodManagementPolicyType   `json:"podManagementPolicy,omitempty"`
+	UpdateStrategy      StatefulSetUpdateStrategy `json:"updateStrategy"`
+}
+
+// VolumeClaimTemplate gives every pod of a stateful set a directory <dir>/<namespace>/<name>-<set>-<ordinal> on its node.
+// Like any hostPath it's local to the node, a pod only finds its data again if it's kept to the same node, e.g. with a nodeSelector.
+type VolumeClaimTemplate struct {
+	// name of the volume in the pod, containers mount it by this name
+	Name string `json:"name"`
+	// absolute path on the node the directories go under, defaults to DefaultVolumeClaimDir
+	Dir string `json:"dir,omitempty"`
+}
+
+type PodManagementPolicyType string
+
+const (
+	// pods are created one at a time in ordinal order, each once the one before is ready, and deleted in reverse
+	OrderedReadyPodManagement PodManagementPolicyType = "OrderedReady"
+	// pods are created and deleted all at once
+	ParallelPodManagement PodManagementPolicyType = "Parallel"
+)
+
+type StatefulSetUpdateStrategyType string
+
+const (
+	// replace pods one at a time from the highest ordinal down, each once the rest are ready
+	RollingUpdateStatefulSetStrategyType StatefulSetUpdateStrategyType = "RollingUpdate"
+	// old pods are only replaced once someone deletes them
+	OnDeleteStatefulSetStrategyType StatefulSetUpdateStrategyType = "OnDelete"
+)
+
+type StatefulSetUpdateStrategy struct {
+	// defaults to RollingUpdate
+	Type          StatefulSetUpdateStrategyType     `json:"type,omitempty"`
+	RollingUpdate *RollingUpdateStatefulSetStrategy `json:"rollingUpdate,omitempty"`
+}
+
+type RollingUpdateStatefulSetStrategy struct {
+	// only pods with an ordinal at or above it are updated, the rest stay on the current revision even if they're deleted,
+	// as long as one of them is left to copy the old template from. Lowering it step by step rolls out a canary, defaults to 0.
+	Partition *int32 `json:"partition,omitempty"`
+}
+
+// StatefulSetStatus is reported by the stateful set controller
+type StatefulSetStatus struct {
+	// generation of the spec the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// pods the controller has that aren't being deleted
+	Replicas      int32 `json:"replicas"`
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// pods on CurrentRevision
+	CurrentReplicas int32 `json:"currentReplicas"`
+	// pods on UpdateRevision
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+	// template hash every pod was on before the update in progress, it catches up with UpdateRevision once every pod is updated
+	CurrentRevision string `json:"currentRevision,omitempty"`
+	// template hash of the current template
+	UpdateRevision string `json:"updateRevision,omitempty"`
+}
+
+type StatefulSetList struct {
+	// store revision the list was read at, watch from here to pick up later changes
+	ResourceVersion string        `json:"resourceVersion"`
+	Items           []StatefulSet `json:"items"`
+}
@@ -1,32 +1,358 @@
 package api
 
-import (
-	"github.com/google/uuid"
-)
+import "time"
+
+// Requests sums what the pod's containers request. Init containers run one at a time before the rest,
+// so the pod needs whichever is larger, the biggest init container or every app container together.
+func (p Pod) Requests() ResourceList {
+	var total ResourceList
+	for _, c := range p.Spec.Containers {
+		total = total.Add(c.Resources.Requests)
+	}
+	for _, c := range p.Spec.InitContainers {
+		total.MilliCPU = max(total.MilliCPU, c.Resources.Requests.MilliCPU)
+		total.Memory = max(total.Memory, c.Resources.Requests.Memory)
+	}
+	return total
+}
 
 type PodSpec struct {
-	Container Container
+	// run to completion in order before any of Containers start
+	InitContainers []Container `json:"initContainers,omitempty"`
+	// started together once init containers are done, sharing a network namespace
+	Containers []Container `json:"containers"`
+	// time containers get between SIGTERM and SIGKILL when the pod is deleted
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+	// the pod only fits on nodes carrying every one of these labels
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// lets the pod onto nodes with matching taints
+	Tolerations []Toleration `json:"tolerations,omitempty"`
+	// directories the pod's containers can mount through their volumeMounts
+	Volumes []Volume `json:"volumes,omitempty"`
+	// what the kubelet does when an app container exits, defaults to Always.
+	// Init containers aren't restarted, one failing fails the pod.
+	RestartPolicy RestartPolicy `json:"restartPolicy,omitempty"`
+}
+
+type RestartPolicy string
+
+const (
+	// restart containers whenever they exit, the pod never finishes
+	RestartPolicyAlways RestartPolicy = "Always"
+	// restart containers that exit non-zero, the pod succeeds once every container exited zero
+	RestartPolicyOnFailure RestartPolicy = "OnFailure"
+	// leave exited containers be, the pod finishes once every container exited
+	RestartPolicyNever RestartPolicy = "Never"
+)
+
+// ShouldRestart reports whether a container that exited with exitCode gets restarted under the policy
+func (r RestartPolicy) ShouldRestart(exitCode int) bool {
+	switch r {
+	case RestartPolicyNever:
+		return false
+	case RestartPolicyOnFailure:
+		return exitCode != 0
+	default:
+		return true
+	}
+}
+
+// Volume is a directory the containers of a pod can share, and that can outlive the pod
+type Volume struct {
+	// unique within the pod, volumeMounts refer to it by it
+	Name     string                `json:"name"`
+	HostPath *HostPathVolumeSource `json:"hostPath,omitempty"`
+}
+
+// HostPathVolumeSource is a directory on the node the pod runs on, created if it's missing.
+// It's left behind when the pod goes, a pod on another node sees a different directory.
+type HostPathVolumeSource struct {
+	// absolute path on the node
+	Path string `json:"path"`
+}
+
+// VolumeMount puts one of the pod's volumes into a container's filesystem
+type VolumeMount struct {
+	// name of a volume of the pod
+	Name string `json:"name"`
+	// absolute path inside the container
+	MountPath string `json:"mountPath"`
+}
+
+// PodTemplateSpec is what workload controllers stamp their pods out of
+type PodTemplateSpec struct {
+	// labels and annotations given to every pod, the name is generated
+	ObjectMeta `json:"metadata"`
+	Spec       PodSpec `json:"spec"`
+}
+
+type TolerationOperator string
+
+const (
+	// key and value have to match, the default
+	TolerationOpEqual TolerationOperator = "Equal"
+	// any value of the key matches, an empty key matches every taint
+	TolerationOpExists TolerationOperator = "Exists"
+)
+
+type Toleration struct {
+	Key      string             `json:"key,omitempty"`
+	Operator TolerationOperator `json:"operator,omitempty"`
+	Value    string             `json:"value,omitempty"`
+	// empty matches every effect
+	Effect TaintEffect `json:"effect,omitempty"`
+	// how long the pod stays on a node after a matching NoExecute taint is added, nil means forever
+	TolerationSeconds *int64 `json:"tolerationSeconds,omitempty"`
+}
+
+// Tolerates reports whether the toleration matches taint
+func (t Toleration) Tolerates(taint Taint) bool {
+	if t.Effect != "" && t.Effect != taint.Effect {
+		return false
+	}
+	if t.Operator == TolerationOpExists {
+		return t.Key == "" || t.Key == taint.Key
+	}
+	return t.Key == taint.Key && t.Value == taint.Value
+}
+
+// ResourceList is an amount of compute resources
+type ResourceList struct {
+	// thousandths of a cpu core
+	MilliCPU int64 `json:"milliCPU,omitempty"`
+	// bytes
+	Memory int64 `json:"memory,omitempty"`
+	// how many pods fit, only meaningful for nodes, zero means no limit
+	Pods int64 `json:"pods,omitempty"`
+}
+
+func (r ResourceList) Add(o ResourceList) ResourceList {
+	return ResourceList{MilliCPU: r.MilliCPU + o.MilliCPU, Memory: r.Memory + o.Memory, Pods: r.Pods + o.Pods}
+}
+
+type ResourceRequirements struct {
+	// what the scheduler reserves on a node for the container
+	Requests ResourceList `json:"requests,omitempty"`
+	// hard cap the runtime enforces, zero means unlimited
+	Limits ResourceList `json:"limits,omitempty"`
 }
 
 type Port struct {
-	Hostport      string `yaml:"hostport"`
-	Containerport string `yaml:"containerport"`
+	Hostport      string `json:"hostport"`
+	Containerport string `json:"containerport"`
 }
 
 type Pod struct {
-	// TODO: create metadata type
-	// metadata
-	Nodename  string    `json:"nodename"`
-	Uid       uuid.UUID `json:"uid"`
-	Namespace string    `json:"namespace"` // only default namespace will exist for now
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata"`
+	// set by the scheduler through the binding subresource, or up front to skip scheduling
+	Nodename string `json:"nodename"`
 	// innards
-	Spec          PodSpec
+	Spec PodSpec `json:"spec"`
+	// reported by the kubelet through the status subresource
+	Status PodStatus `json:"status"`
+}
+
+// Binding assigns a pod to a node, it's posted to the pod's binding subresource
+type Binding struct {
+	// name and namespace of the pod, uid and resourceVersion are optional preconditions
+	ObjectMeta `json:"metadata"`
+	// name of the node
+	Target string `json:"target"`
+}
+
+type PodList struct {
+	// store revision the list was read at, watch from here to pick up later changes
+	ResourceVersion string `json:"resourceVersion"`
+	Items           []Pod  `json:"items"`
 }
 
 type Container struct {
-	ContainerId string `json:"containerid"`
-	Image       string
-	Env         map[string]string
-	Ports       []Port
-	Volumes     []string
+	// unique within the pod, init containers included
+	Name        string            `json:"name"`
+	ContainerId string            `json:"containerid"`
+	Image       string            `json:"image"`
+	Env         map[string]string `json:"env,omitempty"`
+	Ports       []Port            `json:"ports,omitempty"`
+	Volumes     []string          `json:"volumes,omitempty"`
+	// the pod's volumes to mount, unlike Volumes they aren't removed with the container
+	VolumeMounts []VolumeMount        `json:"volumeMounts,omitempty"`
+	Resources    ResourceRequirements `json:"resources,omitempty"`
+	// the kubelet kills the container once it fails this often enough in a row, the restart policy decides what happens next
+	LivenessProbe *Probe `json:"livenessProbe,omitempty"`
+	// the container only counts as ready while this passes
+	ReadinessProbe *Probe `json:"readinessProbe,omitempty"`
+	// liveness and readiness probes only start once this passed, the container is killed if it doesn't in time.
+	// Gives slow starting containers room without loosening the liveness probe.
+	StartupProbe *Probe `json:"startupProbe,omitempty"`
+	// keeps the container's stdin open so it can be attached to
+	Stdin bool `json:"stdin,omitempty"`
+	// runs the container on a TTY, attaching to it needs one too
+	TTY bool `json:"tty,omitempty"`
+}
+
+// Probe is a check the kubelet runs against a container every period, exactly one of its handlers has to be set.
+// Only app containers can have probes.
+type Probe struct {
+	Exec      *ExecAction      `json:"exec,omitempty"`
+	HTTPGet   *HTTPGetAction   `json:"httpGet,omitempty"`
+	TCPSocket *TCPSocketAction `json:"tcpSocket,omitempty"`
+	// how long after the container started the first probe runs
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	// how long a single probe can take before it counts as failed, defaults to 1
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// how often the probe runs, defaults to 10
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+	// successes in a row it takes to pass after failing, defaults to 1 and has to be 1 for liveness and startup probes
+	SuccessThreshold int32 `json:"successThreshold,omitempty"`
+	// failures in a row it takes to fail after passing, defaults to 3
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// ExecAction runs a command in the container, exiting 0 passes
+type ExecAction struct {
+	Command []string `json:"command"`
+}
+
+type URIScheme string
+
+const (
+	URISchemeHTTP  URIScheme = "HTTP"
+	URISchemeHTTPS URIScheme = "HTTPS"
+)
+
+// HTTPGetAction sends a GET request, any status from 200 to 399 passes
+type HTTPGetAction struct {
+	// defaults to /
+	Path string `json:"path,omitempty"`
+	Port int32  `json:"port"`
+	// defaults to the pod's IP
+	Host string `json:"host,omitempty"`
+	// defaults to HTTP, certificates aren't verified with HTTPS
+	Scheme URIScheme `json:"scheme,omitempty"`
+}
+
+// TCPSocketAction opens a connection, managing to passes
+type TCPSocketAction struct {
+	Port int32 `json:"port"`
+	// defaults to the pod's IP
+	Host string `json:"host,omitempty"`
+}
+
+type PodPhase string
+
+const (
+	// accepted but not every container is running yet, includes time spent on init containers
+	PodPending PodPhase = "Pending"
+	// every container has been started and at least one is still running
+	PodRunning PodPhase = "Running"
+	// every container exited successfully
+	PodSucceeded PodPhase = "Succeeded"
+	// every container exited and at least one failed
+	PodFailed PodPhase = "Failed"
+	// the kubelet couldn't work out the state of the pod
+	PodUnknown PodPhase = "Unknown"
+)
+
+type PodConditionType string
+
+const (
+	PodScheduled    PodConditionType = "PodScheduled"
+	PodInitialized  PodConditionType = "Initialized"
+	ContainersReady PodConditionType = "ContainersReady"
+	PodReady        PodConditionType = "Ready"
+)
+
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+type PodCondition struct {
+	Type   PodConditionType `json:"type"`
+	Status ConditionStatus  `json:"status"`
+	// last time Status flipped
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+}
+
+type PodStatus struct {
+	Phase                 PodPhase          `json:"phase,omitempty"`
+	Conditions            []PodCondition    `json:"conditions,omitempty"`
+	Reason                string            `json:"reason,omitempty"`
+	Message               string            `json:"message,omitempty"`
+	PodIP                 string            `json:"podIP,omitempty"`
+	StartTime             *time.Time        `json:"startTime,omitempty"`
+	InitContainerStatuses []ContainerStatus `json:"initContainerStatuses,omitempty"`
+	ContainerStatuses     []ContainerStatus `json:"containerStatuses,omitempty"`
+}
+
+// GetCondition returns the condition of type t, nil if it isn't set
+func (s *PodStatus) GetCondition(t PodConditionType) *PodCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds c or replaces the condition of the same type, reporting whether anything changed.
+// LastTransitionTime is only moved to now when the condition's status flips.
+func (s *PodStatus) SetCondition(c PodCondition, now time.Time) bool {
+	prev := s.GetCondition(c.Type)
+	if prev == nil {
+		c.LastTransitionTime = now
+		s.Conditions = append(s.Conditions, c)
+		return true
+	}
+	if prev.Status == c.Status && prev.Reason == c.Reason && prev.Message == c.Message {
+		return false
+	}
+	c.LastTransitionTime = now
+	if prev.Status == c.Status {
+		c.LastTransitionTime = prev.LastTransitionTime
+	}
+	*prev = c
+	return true
+}
+
+type ContainerStatus struct {
+	Name        string         `json:"name"`
+	ContainerId string         `json:"containerID,omitempty"`
+	Image       string         `json:"image"`
+	State       ContainerState `json:"state"`
+	// how the container exited the last time, before it was restarted
+	LastTerminationState ContainerState `json:"lastState"`
+	Ready                bool           `json:"ready"`
+	// how many times the kubelet restarted the container
+	RestartCount int32 `json:"restartCount"`
+}
+
+// ContainerState holds exactly one of its members
+type ContainerState struct {
+	Waiting    *ContainerStateWaiting    `json:"waiting,omitempty"`
+	Running    *ContainerStateRunning    `json:"running,omitempty"`
+	Terminated *ContainerStateTerminated `json:"terminated,omitempty"`
+}
+
+type ContainerStateWaiting struct {
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type ContainerStateRunning struct {
+	StartedAt time.Time `json:"startedAt"`
+}
+
+type ContainerStateTerminated struct {
+	ExitCode   int       `json:"exitCode"`
+	Reason     string    `json:"reason,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
 }
@@ -9,11 +9,17 @@ import (
 	"os/signal"
 	"syscall"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/mux"
-	etcdClient "go.etcd.io/etcd/client/v3"
 
+	"superminikube/pkg/apiserver/cronjob"
+	"superminikube/pkg/apiserver/daemonset"
+	"superminikube/pkg/apiserver/deployment"
+	"superminikube/pkg/apiserver/job"
+	"superminikube/pkg/apiserver/node"
 	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/replicaset"
+	"superminikube/pkg/apiserver/statefulset"
+	"superminikube/pkg/apiserver/storage"
 	"superminikube/pkg/apiserver/watch"
 )
 
@@ -32,6 +38,9 @@ func loggingMiddleware(next http.Handler) http.Handler {
 func (s *APIServer) Shutdown() {
 	slog.Info("shutting down apiserver")
 	s.server.Close()
+	if err := s.store.Close(); err != nil {
+		slog.Error("failed to close storage", "error", err)
+	}
 }
 
 // Setup configures routes and initializes the HTTP server.
@@ -39,14 +48,84 @@ func (s *APIServer) Setup() {
 	r := mux.NewRouter()
 	api := r.PathPrefix("/api/v1").Subrouter()
 	r.Use(loggingMiddleware)
-	// TODO: This is proof that notify needs to exist elsewhere...
-	watchService := watch.NewService(s.etcdClient)
-	podService := pod.NewService(s.redisClient, watchService)
+	watchService := watch.NewService(s.store)
+	podService := pod.NewService(s.store)
 	podHandler := pod.NewHandler(podService)
-	api.HandleFunc("/pod", podHandler.CreatePod).Queries("nodename", "{nodename}")
-	api.HandleFunc("/pod", podHandler.GetPod).Queries("nodename", "{nodename}", "uid", "{uid}")
+	api.HandleFunc("/pods", podHandler.ListPods).Methods(http.MethodGet)
+	api.HandleFunc("/pods/{namespace}", podHandler.ListPods).Methods(http.MethodGet)
+	api.HandleFunc("/pods/{namespace}", podHandler.CreatePod).Methods(http.MethodPost)
+	api.HandleFunc("/pods/{namespace}/{name}", podHandler.GetPod).Methods(http.MethodGet)
+	api.HandleFunc("/pods/{namespace}/{name}", podHandler.UpdatePod).Methods(http.MethodPut)
+	api.HandleFunc("/pods/{namespace}/{name}", podHandler.PatchPod).Methods(http.MethodPatch)
+	api.HandleFunc("/pods/{namespace}/{name}", podHandler.DeletePod).Methods(http.MethodDelete)
+	api.HandleFunc("/pods/{namespace}/{name}/status", podHandler.UpdatePodStatus).Methods(http.MethodPut)
+	api.HandleFunc("/pods/{namespace}/{name}/binding", podHandler.BindPod).Methods(http.MethodPost)
+	api.HandleFunc("/pods/{namespace}/{name}/log", podHandler.GetPodLog).Methods(http.MethodGet)
+	api.HandleFunc("/pods/{namespace}/{name}/exec", podHandler.GetPodExec).Methods(http.MethodGet)
+	api.HandleFunc("/pods/{namespace}/{name}/attach", podHandler.GetPodAttach).Methods(http.MethodGet)
+	api.HandleFunc("/pods/{namespace}/{name}/portforward", podHandler.GetPodPortForward).Methods(http.MethodGet)
+	nodeHandler := node.NewHandler(node.NewService(s.store))
+	api.HandleFunc("/nodes", nodeHandler.ListNodes).Methods(http.MethodGet)
+	api.HandleFunc("/nodes", nodeHandler.CreateNode).Methods(http.MethodPost)
+	api.HandleFunc("/nodes/{name}", nodeHandler.GetNode).Methods(http.MethodGet)
+	api.HandleFunc("/nodes/{name}", nodeHandler.UpdateNode).Methods(http.MethodPut)
+	api.HandleFunc("/nodes/{name}", nodeHandler.DeleteNode).Methods(http.MethodDelete)
+	api.HandleFunc("/nodes/{name}/status", nodeHandler.UpdateNodeStatus).Methods(http.MethodPut)
+	rsHandler := replicaset.NewHandler(replicaset.NewService(s.store))
+	api.HandleFunc("/replicasets", rsHandler.ListReplicaSets).Methods(http.MethodGet)
+	api.HandleFunc("/replicasets/{namespace}", rsHandler.ListReplicaSets).Methods(http.MethodGet)
+	api.HandleFunc("/replicasets/{namespace}", rsHandler.CreateReplicaSet).Methods(http.MethodPost)
+	api.HandleFunc("/replicasets/{namespace}/{name}", rsHandler.GetReplicaSet).Methods(http.MethodGet)
+	api.HandleFunc("/replicasets/{namespace}/{name}", rsHandler.UpdateReplicaSet).Methods(http.MethodPut)
+	api.HandleFunc("/replicasets/{namespace}/{name}", rsHandler.DeleteReplicaSet).Methods(http.MethodDelete)
+	api.HandleFunc("/replicasets/{namespace}/{name}/status", rsHandler.UpdateReplicaSetStatus).Methods(http.MethodPut)
+	deploymentHandler := deployment.NewHandler(deployment.NewService(s.store))
+	api.HandleFunc("/deployments", deploymentHandler.ListDeployments).Methods(http.MethodGet)
+	api.HandleFunc("/deployments/{namespace}", deploymentHandler.ListDeployments).Methods(http.MethodGet)
+	api.HandleFunc("/deployments/{namespace}", deploymentHandler.CreateDeployment).Methods(http.MethodPost)
+	api.HandleFunc("/deployments/{namespace}/{name}", deploymentHandler.GetDeployment).Methods(http.MethodGet)
+	api.HandleFunc("/deployments/{namespace}/{name}", deploymentHandler.UpdateDeployment).Methods(http.MethodPut)
+	api.HandleFunc("/deployments/{namespace}/{name}", deploymentHandler.DeleteDeployment).Methods(http.MethodDelete)
+	api.HandleFunc("/deployments/{namespace}/{name}/status", deploymentHandler.UpdateDeploymentStatus).Methods(http.MethodPut)
+	api.HandleFunc("/deployments/{namespace}/{name}/rollback", deploymentHandler.RollbackDeployment).Methods(http.MethodPost)
+	jobHandler := job.NewHandler(job.NewService(s.store))
+	api.HandleFunc("/jobs", jobHandler.ListJobs).Methods(http.MethodGet)
+	api.HandleFunc("/jobs/{namespace}", jobHandler.ListJobs).Methods(http.MethodGet)
+	api.HandleFunc("/jobs/{namespace}", jobHandler.CreateJob).Methods(http.MethodPost)
+	api.HandleFunc("/jobs/{namespace}/{name}", jobHandler.GetJob).Methods(http.MethodGet)
+	api.HandleFunc("/jobs/{namespace}/{name}", jobHandler.UpdateJob).Methods(http.MethodPut)
+	api.HandleFunc("/jobs/{namespace}/{name}", jobHandler.DeleteJob).Methods(http.MethodDelete)
+	api.HandleFunc("/jobs/{namespace}/{name}/status", jobHandler.UpdateJobStatus).Methods(http.MethodPut)
+	cronJobHandler := cronjob.NewHandler(cronjob.NewService(s.store))
+	api.HandleFunc("/cronjobs", cronJobHandler.ListCronJobs).Methods(http.MethodGet)
+	api.HandleFunc("/cronjobs/{namespace}", cronJobHandler.ListCronJobs).Methods(http.MethodGet)
+	api.HandleFunc("/cronjobs/{namespace}", cronJobHandler.CreateCronJob).Methods(http.MethodPost)
+	api.HandleFunc("/cronjobs/{namespace}/{name}", cronJobHandler.GetCronJob).Methods(http.MethodGet)
+	api.HandleFunc("/cronjobs/{namespace}/{name}", cronJobHandler.UpdateCronJob).Methods(http.MethodPut)
+	api.HandleFunc("/cronjobs/{namespace}/{name}", cronJobHandler.DeleteCronJob).Methods(http.MethodDelete)
+	api.HandleFunc("/cronjobs/{namespace}/{name}/status", cronJobHandler.UpdateCronJobStatus).Methods(http.MethodPut)
+	daemonSetHandler := daemonset.NewHandler(daemonset.NewService(s.store))
+	api.HandleFunc("/daemonsets", daemonSetHandler.ListDaemonSets).Methods(http.MethodGet)
+	api.HandleFunc("/daemonsets/{namespace}", daemonSetHandler.ListDaemonSets).Methods(http.MethodGet)
+	api.HandleFunc("/daemonsets/{namespace}", daemonSetHandler.CreateDaemonSet).Methods(http.MethodPost)
+	api.HandleFunc("/daemonsets/{namespace}/{name}", daemonSetHandler.GetDaemonSet).Methods(http.MethodGet)
+	api.HandleFunc("/daemonsets/{namespace}/{name}", daemonSetHandler.UpdateDaemonSet).Methods(http.MethodPut)
+	api.HandleFunc("/daemonsets/{namespace}/{name}", daemonSetHandler.DeleteDaemonSet).Methods(http.MethodDelete)
+	api.HandleFunc("/daemonsets/{namespace}/{name}/status", daemonSetHandler.UpdateDaemonSetStatus).Methods(http.MethodPut)
+	statefulSetHandler := statefulset.NewHandler(statefulset.NewService(s.store))
+	api.HandleFunc("/statefulsets", statefulSetHandler.ListStatefulSets).Methods(http.MethodGet)
+	api.HandleFunc("/statefulsets/{namespace}", statefulSetHandler.ListStatefulSets).Methods(http.MethodGet)
+	api.HandleFunc("/statefulsets/{namespace}", statefulSetHandler.CreateStatefulSet).Methods(http.MethodPost)
+	api.HandleFunc("/statefulsets/{namespace}/{name}", statefulSetHandler.GetStatefulSet).Methods(http.MethodGet)
+	api.HandleFunc("/statefulsets/{namespace}/{name}", statefulSetHandler.UpdateStatefulSet).Methods(http.MethodPut)
+	api.HandleFunc("/statefulsets/{namespace}/{name}", statefulSetHandler.DeleteStatefulSet).Methods(http.MethodDelete)
+	api.HandleFunc("/statefulsets/{namespace}/{name}/status", statefulSetHandler.UpdateStatefulSetStatus).Methods(http.MethodPut)
 	// post is probably the better verb here
 	api.HandleFunc("/watch", watchService.WatchHandler).Methods(http.MethodGet)
+	// what client.Ping checks before a component starts
+	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
 
 	s.server = &http.Server{
 		Addr:    s.opts.Addr,
@@ -64,11 +143,11 @@ func (s *APIServer) ListenAndServe() error {
 	return nil
 }
 
-func Start() error {
+func Start(opts APIServerOpts) error {
 	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	s, err := NewAPIServer(APIServerOpts{Addr: ":8080"})
+	s, err := NewAPIServer(opts)
 	if err != nil {
 		return fmt.Errorf("failed to create API server: %w", err)
 	}
@@ -84,21 +163,53 @@ func Start() error {
 }
 
 func NewAPIServer(opts APIServerOpts) (*APIServer, error) {
+	store, err := newStorage(opts)
+	if err != nil {
+		return nil, err
+	}
 	return &APIServer{
-		redisClient: redis.NewClient(&redis.Options{
-			Addr: "localhost:6379", // TODO: make configurable, got so many options to worry about now
-		}),
-		opts: opts,
+		store: store,
+		opts:  opts,
 	}, nil
 }
 
+func newStorage(opts APIServerOpts) (storage.Interface, error) {
+	switch opts.StorageBackend {
+	case "", StorageBackendMemory:
+		return storage.NewMemoryStore(), nil
+	case StorageBackendRedis:
+		addr := opts.RedisAddr
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		return storage.NewRedisStore(addr), nil
+	case StorageBackendEtcd:
+		endpoints := opts.EtcdEndpoints
+		if len(endpoints) == 0 {
+			endpoints = []string{"localhost:2379"}
+		}
+		return storage.NewEtcdStore(endpoints)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", opts.StorageBackend)
+	}
+}
+
 type APIServer struct {
-	server      *http.Server
-	redisClient *redis.Client
-	etcdClient  *etcdClient.Client
-	opts        APIServerOpts
+	server *http.Server
+	store  storage.Interface
+	opts   APIServerOpts
 }
 
+const (
+	StorageBackendMemory = "memory"
+	StorageBackendRedis  = "redis"
+	StorageBackendEtcd   = "etcd"
+)
+
 type APIServerOpts struct {
 	Addr string
+	// one of StorageBackendMemory, StorageBackendRedis or StorageBackendEtcd, defaults to memory
+	StorageBackend string
+	RedisAddr      string
+	EtcdEndpoints  []string
 }
@@ -0,0 +1,125 @@
+package cronjob
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/job"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+const resource = "cronjobs"
+
+type Service interface {
+	GetCronJob(ctx context.Context, namespace, name string) (api.CronJob, error)
+	ListCronJobs(ctx context.Context, namespace string) (api.CronJobList, error)
+	CreateCronJob(ctx context.Context, cj api.CronJob) (api.CronJob, error)
+	UpdateCronJob(ctx context.Context, cj api.CronJob) (api.CronJob, error)
+	UpdateCronJobStatus(ctx context.Context, cj api.CronJob) (api.CronJob, error)
+	DeleteCronJob(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.CronJob, error)
+}
+
+// CronJobService persists cron jobs through storage.
+// Jobs are left to the cron job controller, nothing here touches them.
+type CronJobService struct {
+	store storage.Interface
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewService(store storage.Interface) *CronJobService {
+	return &CronJobService{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (s *CronJobService) GetCronJob(ctx context.Context, namespace, name string) (api.CronJob, error) {
+	return utils.GetObject[api.CronJob](ctx, s.store, storage.Key(resource, namespace, name))
+}
+
+// ListCronJobs lists cron jobs in namespace, or every namespace if it's empty
+func (s *CronJobService) ListCronJobs(ctx context.Context, namespace string) (api.CronJobList, error) {
+	items, rv, err := utils.ListObjects[api.CronJob](ctx, s.store, storage.Prefix(resource, namespace))
+	if err != nil {
+		return api.CronJobList{}, err
+	}
+	return api.CronJobList{ResourceVersion: rv, Items: items}, nil
+}
+
+func (s *CronJobService) CreateCronJob(ctx context.Context, cj api.CronJob) (api.CronJob, error) {
+	utils.PrepareObjectMetaForCreate(&cj.ObjectMeta, s.now())
+	setDefaults(&cj)
+	if err := validateCronJob(cj); err != nil {
+		return api.CronJob{}, err
+	}
+	// status belongs to the controller
+	cj.Status = api.CronJobStatus{}
+	cj, err := utils.CreateObject(ctx, s.store, storage.Key(resource, cj.Namespace, cj.Name), cj)
+	if err != nil {
+		return api.CronJob{}, err
+	}
+	slog.Info("Created CronJob", "namespace", cj.Namespace, "name", cj.Name)
+	return cj, nil
+}
+
+// UpdateCronJob replaces the stored cron job's spec and metadata, status is left alone.
+// If cj carries a resourceVersion the update is rejected with storage.ErrConflict unless it is still current.
+func (s *CronJobService) UpdateCronJob(ctx context.Context, cj api.CronJob) (api.CronJob, error) {
+	cj, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, cj.Namespace, cj.Name), cj.ResourceVersion, func(old api.CronJob) (api.CronJob, error) {
+		updated := cj
+		updated.Status = old.Status
+		setDefaults(&updated)
+		utils.PrepareObjectMetaForUpdate(&updated.ObjectMeta, old.ObjectMeta, !reflect.DeepEqual(updated.Spec, old.Spec))
+		return updated, validateCronJob(updated)
+	})
+	if err != nil {
+		return api.CronJob{}, err
+	}
+	slog.Info("Updated CronJob", "namespace", cj.Namespace, "name", cj.Name)
+	return cj, nil
+}
+
+// UpdateCronJobStatus replaces only the status of the stored cron job, it's how the controller reports back.
+// cj's resourceVersion is a precondition, same as UpdateCronJob.
+func (s *CronJobService) UpdateCronJobStatus(ctx context.Context, cj api.CronJob) (api.CronJob, error) {
+	cj, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, cj.Namespace, cj.Name), cj.ResourceVersion, func(old api.CronJob) (api.CronJob, error) {
+		old.Status = cj.Status
+		return old, validateStatus(old.Status)
+	})
+	if err != nil {
+		return api.CronJob{}, err
+	}
+	slog.Debug("Updated CronJob status", "namespace", cj.Namespace, "name", cj.Name)
+	return cj, nil
+}
+
+// DeleteCronJob removes the cron job right away.
+// Its jobs still point at it through their ownerReferences, the controller cleans those up once it sees it's gone.
+func (s *CronJobService) DeleteCronJob(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.CronJob, error) {
+	cj, err := utils.DeleteObject[api.CronJob](ctx, s.store, storage.Key(resource, namespace, name), opts)
+	if err != nil {
+		return api.CronJob{}, err
+	}
+	slog.Info("Deleted CronJob", "namespace", namespace, "name", name)
+	return cj, nil
+}
+
+func setDefaults(cj *api.CronJob) {
+	if cj.Spec.ConcurrencyPolicy == "" {
+		cj.Spec.ConcurrencyPolicy = api.AllowConcurrent
+	}
+	if cj.Spec.SuccessfulJobsHistoryLimit == nil {
+		three := int32(3)
+		cj.Spec.SuccessfulJobsHistoryLimit = &three
+	}
+	if cj.Spec.FailedJobsHistoryLimit == nil {
+		one := int32(1)
+		cj.Spec.FailedJobsHistoryLimit = &one
+	}
+	job.SetSpecDefaults(&cj.Spec.JobTemplate.Spec)
+}
@@ -0,0 +1,77 @@
+package cronjob
+
+import (
+	"errors"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func TestCreateCronJob(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	badPolicy := apitest.NewCronJob("bad-policy", "@daily")
+	badPolicy.Spec.ConcurrencyPolicy = "Sometimes"
+	negativeLimit := apitest.NewCronJob("negative-limit", "@daily")
+	minusOne := int32(-1)
+	negativeLimit.Spec.FailedJobsHistoryLimit = &minusOne
+	badJob := apitest.NewCronJob("bad-job", "@daily")
+	badJob.Spec.JobTemplate.Spec.BackoffLimit = &minusOne
+
+	testCases := []struct {
+		name    string
+		cj      api.CronJob
+		wantErr error
+	}{
+		{name: "basic cron job", cj: apitest.NewCronJob("nightly", "0 3 * * *")},
+		{name: "macro schedule", cj: apitest.NewCronJob("hourly", "@hourly")},
+		{name: "duplicate name", cj: apitest.NewCronJob("nightly", "0 3 * * *"), wantErr: storage.ErrKeyExists},
+		{name: "bad schedule", cj: apitest.NewCronJob("bad-schedule", "every day"), wantErr: utils.ErrInvalid},
+		{name: "unknown concurrency policy", cj: badPolicy, wantErr: utils.ErrInvalid},
+		{name: "negative history limit", cj: negativeLimit, wantErr: utils.ErrInvalid},
+		{name: "invalid job template", cj: badJob, wantErr: utils.ErrInvalid},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cj, err := service.CreateCronJob(t.Context(), tc.cj)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cj.Spec.ConcurrencyPolicy != api.AllowConcurrent || *cj.Spec.SuccessfulJobsHistoryLimit != 3 || *cj.Spec.FailedJobsHistoryLimit != 1 {
+				t.Errorf("cron job spec wasn't defaulted: %+v", cj.Spec)
+			}
+			if cj.Spec.JobTemplate.Spec.BackoffLimit == nil {
+				t.Errorf("job template wasn't defaulted")
+			}
+		})
+	}
+}
+
+func TestUpdateCronJobStatus(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	cj, err := service.CreateCronJob(t.Context(), apitest.NewCronJob("nightly", "0 3 * * *"))
+	if err != nil {
+		t.Fatalf("failed to create cron job: %v", err)
+	}
+	cj.Spec.Suspend = true
+	cj.Status.Active = []string{"nightly-29000000"}
+	cj, err = service.UpdateCronJobStatus(t.Context(), cj)
+	if err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if cj.Spec.Suspend || len(cj.Status.Active) != 1 {
+		t.Errorf("unexpected cron job after status update: %+v", cj)
+	}
+	cj.Status.Active = []string{"Not A Name"}
+	if _, err := service.UpdateCronJobStatus(t.Context(), cj); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a bad job name, got %v", err)
+	}
+}
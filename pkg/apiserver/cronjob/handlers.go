@@ -0,0 +1,111 @@
+package cronjob
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func (h *handler) GetCronJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	cj, err := h.service.GetCronJob(r.Context(), vars["namespace"], vars["name"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, cj)
+}
+
+// ListCronJobs lists cron jobs in the namespace from the url, or every namespace if there is none
+func (h *handler) ListCronJobs(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListCronJobs(r.Context(), mux.Vars(r)["namespace"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, list)
+}
+
+func (h *handler) CreateCronJob(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	var cj api.CronJob
+	if err := utils.DecodeBody(r, &cj); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if cj.Namespace != "" && cj.Namespace != namespace {
+		http.Error(w, "cron job namespace does not match url", http.StatusBadRequest)
+		return
+	}
+	cj.Namespace = namespace
+	cj, err := h.service.CreateCronJob(r.Context(), cj)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusCreated, cj)
+}
+
+func (h *handler) UpdateCronJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var cj api.CronJob
+	if err := utils.DecodeBody(r, &cj); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&cj, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cj, err := h.service.UpdateCronJob(r.Context(), cj)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, cj)
+}
+
+// UpdateCronJobStatus takes the full cron job but only its status is written
+func (h *handler) UpdateCronJobStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var cj api.CronJob
+	if err := utils.DecodeBody(r, &cj); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&cj, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cj, err := h.service.UpdateCronJobStatus(r.Context(), cj)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, cj)
+}
+
+// DeleteCronJob takes an optional ?resourceVersion= precondition
+func (h *handler) DeleteCronJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	opts := api.DeleteOptions{ResourceVersion: r.URL.Query().Get("resourceVersion")}
+	cj, err := h.service.DeleteCronJob(r.Context(), vars["namespace"], vars["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, cj)
+}
+
+func NewHandler(service Service) handler {
+	return handler{
+		service: service,
+	}
+}
+
+type handler struct {
+	service Service
+}
@@ -0,0 +1,40 @@
+package cronjob
+
+import (
+	"fmt"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/job"
+	"superminikube/pkg/apiserver/utils"
+	"superminikube/pkg/cron"
+)
+
+func validateCronJob(cj api.CronJob) error {
+	if err := utils.ValidateObjectMeta(cj.ObjectMeta); err != nil {
+		return err
+	}
+	if _, err := cron.Parse(cj.Spec.Schedule); err != nil {
+		return fmt.Errorf("%w: %v", utils.ErrInvalid, err)
+	}
+	switch cj.Spec.ConcurrencyPolicy {
+	case api.AllowConcurrent, api.ForbidConcurrent, api.ReplaceConcurrent:
+	default:
+		return fmt.Errorf("%w: unknown concurrencyPolicy %q", utils.ErrInvalid, cj.Spec.ConcurrencyPolicy)
+	}
+	if cj.Spec.StartingDeadlineSeconds != nil && *cj.Spec.StartingDeadlineSeconds < 0 {
+		return fmt.Errorf("%w: startingDeadlineSeconds can't be negative", utils.ErrInvalid)
+	}
+	if *cj.Spec.SuccessfulJobsHistoryLimit < 0 || *cj.Spec.FailedJobsHistoryLimit < 0 {
+		return fmt.Errorf("%w: history limits can't be negative", utils.ErrInvalid)
+	}
+	return job.ValidateSpec(cj.Spec.JobTemplate.Spec)
+}
+
+func validateStatus(status api.CronJobStatus) error {
+	for _, name := range status.Active {
+		if err := utils.ValidateName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package daemonset
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+const resource = "daemonsets"
+
+type Service interface {
+	GetDaemonSet(ctx context.Context, namespace, name string) (api.DaemonSet, error)
+	ListDaemonSets(ctx context.Context, namespace string) (api.DaemonSetList, error)
+	CreateDaemonSet(ctx context.Context, ds api.DaemonSet) (api.DaemonSet, error)
+	UpdateDaemonSet(ctx context.Context, ds api.DaemonSet) (api.DaemonSet, error)
+	UpdateDaemonSetStatus(ctx context.Context, ds api.DaemonSet) (api.DaemonSet, error)
+	DeleteDaemonSet(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.DaemonSet, error)
+}
+
+// DaemonSetService persists daemon sets through storage.
+// Pods are left to the daemon set controller, nothing here touches them.
+type DaemonSetService struct {
+	store storage.Interface
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewService(store storage.Interface) *DaemonSetService {
+	return &DaemonSetService{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (s *DaemonSetService) GetDaemonSet(ctx context.Context, namespace, name string) (api.DaemonSet, error) {
+	return utils.GetObject[api.DaemonSet](ctx, s.store, storage.Key(resource, namespace, name))
+}
+
+// ListDaemonSets lists daemon sets in namespace, or every namespace if it's empty
+func (s *DaemonSetService) ListDaemonSets(ctx context.Context, namespace string) (api.DaemonSetList, error) {
+	items, rv, err := utils.ListObjects[api.DaemonSet](ctx, s.store, storage.Prefix(resource, namespace))
+	if err != nil {
+		return api.DaemonSetList{}, err
+	}
+	return api.DaemonSetList{ResourceVersion: rv, Items: items}, nil
+}
+
+func (s *DaemonSetService) CreateDaemonSet(ctx context.Context, ds api.DaemonSet) (api.DaemonSet, error) {
+	utils.PrepareObjectMetaForCreate(&ds.ObjectMeta, s.now())
+	setDefaults(&ds)
+	if err := validateDaemonSet(ds); err != nil {
+		return api.DaemonSet{}, err
+	}
+	// status belongs to the controller
+	ds.Status = api.DaemonSetStatus{}
+	ds, err := utils.CreateObject(ctx, s.store, storage.Key(resource, ds.Namespace, ds.Name), ds)
+	if err != nil {
+		return api.DaemonSet{}, err
+	}
+	slog.Info("Created DaemonSet", "namespace", ds.Namespace, "name", ds.Name)
+	return ds, nil
+}
+
+// UpdateDaemonSet replaces the stored daemon set's spec and metadata, status is left alone.
+// If ds carries a resourceVersion the update is rejected with storage.ErrConflict unless it is still current.
+func (s *DaemonSetService) UpdateDaemonSet(ctx context.Context, ds api.DaemonSet) (api.DaemonSet, error) {
+	ds, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, ds.Namespace, ds.Name), ds.ResourceVersion, func(old api.DaemonSet) (api.DaemonSet, error) {
+		updated := ds
+		updated.Status = old.Status
+		setDefaults(&updated)
+		// pods are matched to the daemon set by it, changing it would orphan them
+		if !reflect.DeepEqual(updated.Spec.Selector, old.Spec.Selector) {
+			return api.DaemonSet{}, fmt.Errorf("%w: selector can't be changed", utils.ErrInvalid)
+		}
+		utils.PrepareObjectMetaForUpdate(&updated.ObjectMeta, old.ObjectMeta, !reflect.DeepEqual(updated.Spec, old.Spec))
+		return updated, validateDaemonSet(updated)
+	})
+	if err != nil {
+		return api.DaemonSet{}, err
+	}
+	slog.Info("Updated DaemonSet", "namespace", ds.Namespace, "name", ds.Name)
+	return ds, nil
+}
+
+// UpdateDaemonSetStatus replaces only the status of the stored daemon set, it's how the controller reports back.
+// ds's resourceVersion is a precondition, same as UpdateDaemonSet.
+func (s *DaemonSetService) UpdateDaemonSetStatus(ctx context.Context, ds api.DaemonSet) (api.DaemonSet, error) {
+	ds, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, ds.Namespace, ds.Name), ds.ResourceVersion, func(old api.DaemonSet) (api.DaemonSet, error) {
+		old.Status = ds.Status
+		return old, validateStatus(old.Status)
+	})
+	if err != nil {
+		return api.DaemonSet{}, err
+	}
+	slog.Debug("Updated DaemonSet status", "namespace", ds.Namespace, "name", ds.Name)
+	return ds, nil
+}
+
+// DeleteDaemonSet removes the daemon set right away.
+// Its pods still point at it through their ownerReferences, the controller cleans those up once it sees it's gone.
+func (s *DaemonSetService) DeleteDaemonSet(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.DaemonSet, error) {
+	ds, err := utils.DeleteObject[api.DaemonSet](ctx, s.store, storage.Key(resource, namespace, name), opts)
+	if err != nil {
+		return api.DaemonSet{}, err
+	}
+	slog.Info("Deleted DaemonSet", "namespace", namespace, "name", name)
+	return ds, nil
+}
+
+func setDefaults(ds *api.DaemonSet) {
+	strategy := &ds.Spec.UpdateStrategy
+	if strategy.Type == "" {
+		strategy.Type = api.RollingUpdateDaemonSetStrategyType
+	}
+	if strategy.Type == api.RollingUpdateDaemonSetStrategyType {
+		if strategy.RollingUpdate == nil {
+			strategy.RollingUpdate = &api.RollingUpdateDaemonSet{}
+		}
+		if strategy.RollingUpdate.MaxUnavailable == nil {
+			one := api.FromInt(1)
+			strategy.RollingUpdate.MaxUnavailable = &one
+		}
+	}
+	pod.SetSpecDefaults(&ds.Spec.Template.Spec)
+}
@@ -0,0 +1,159 @@
+package daemonset
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func TestCreateDaemonSet(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	noSelector := apitest.NewDaemonSet("no-selector")
+	noSelector.Spec.Selector = api.LabelSelector{}
+	mismatch := apitest.NewDaemonSet("mismatch")
+	mismatch.Spec.Template.Labels = map[string]string{"app": "other"}
+	hashLabel := apitest.NewDaemonSet("hash-label")
+	hashLabel.Spec.Template.Labels[api.PodTemplateHashLabel] = "abc"
+	noContainers := apitest.NewDaemonSet("no-containers")
+	noContainers.Spec.Template.Spec.Containers = nil
+	zero := api.FromInt(0)
+	zeroUnavailable := apitest.NewDaemonSet("zero-unavailable")
+	zeroUnavailable.Spec.UpdateStrategy.RollingUpdate = &api.RollingUpdateDaemonSet{MaxUnavailable: &zero}
+	onDeleteWithRollingUpdate := apitest.NewDaemonSet("on-delete")
+	onDeleteWithRollingUpdate.Spec.UpdateStrategy = api.DaemonSetUpdateStrategy{Type: api.OnDeleteDaemonSetStrategyType, RollingUpdate: &api.RollingUpdateDaemonSet{}}
+	unknownStrategy := apitest.NewDaemonSet("unknown-strategy")
+	unknownStrategy.Spec.UpdateStrategy.Type = "Sometimes"
+	defaulted := apitest.NewDaemonSet("defaulted")
+	defaulted.Status.DesiredNumberScheduled = 3
+
+	testCases := []struct {
+		name    string
+		ds      api.DaemonSet
+		wantErr error
+	}{
+		{name: "basic daemon set", ds: apitest.NewDaemonSet("logs")},
+		{name: "strategy and status defaulted", ds: defaulted},
+		{name: "duplicate name", ds: apitest.NewDaemonSet("logs"), wantErr: storage.ErrKeyExists},
+		{name: "no selector", ds: noSelector, wantErr: utils.ErrInvalid},
+		{name: "selector does not match template", ds: mismatch, wantErr: utils.ErrInvalid},
+		{name: "template sets the hash label", ds: hashLabel, wantErr: utils.ErrInvalid},
+		{name: "template without containers", ds: noContainers, wantErr: utils.ErrInvalid},
+		{name: "zero maxUnavailable", ds: zeroUnavailable, wantErr: utils.ErrInvalid},
+		{name: "rollingUpdate with OnDelete", ds: onDeleteWithRollingUpdate, wantErr: utils.ErrInvalid},
+		{name: "unknown strategy", ds: unknownStrategy, wantErr: utils.ErrInvalid},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ds, err := service.CreateDaemonSet(t.Context(), tc.ds)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ds.Namespace != utils.DefaultNamespace || ds.ResourceVersion == "" {
+				t.Errorf("unexpected daemon set: %+v", ds)
+			}
+			strategy := ds.Spec.UpdateStrategy
+			if strategy.Type != api.RollingUpdateDaemonSetStrategyType || strategy.RollingUpdate == nil || *strategy.RollingUpdate.MaxUnavailable != api.FromInt(1) {
+				t.Errorf("update strategy wasn't defaulted: %+v", strategy)
+			}
+			if ds.Status != (api.DaemonSetStatus{}) {
+				t.Errorf("status %+v should start out empty", ds.Status)
+			}
+			if ds.Spec.Template.Spec.Containers[0].Name == "" {
+				t.Errorf("template containers weren't defaulted")
+			}
+		})
+	}
+}
+
+func TestUpdateDaemonSet(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	created, err := service.CreateDaemonSet(t.Context(), apitest.NewDaemonSet("logs"))
+	if err != nil {
+		t.Fatalf("failed to create daemon set: %v", err)
+	}
+
+	// status updates leave spec and metadata alone
+	ds := created
+	ds.Spec.Template.Spec.Containers[0].Image = "fluentd:2"
+	ds.Status = api.DaemonSetStatus{DesiredNumberScheduled: 2, CurrentNumberScheduled: 2, ObservedGeneration: 1}
+	ds, err = service.UpdateDaemonSetStatus(t.Context(), ds)
+	if err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if ds.Spec.Template.Spec.Containers[0].Image != "fluentd:1" || ds.Status.DesiredNumberScheduled != 2 {
+		t.Errorf("unexpected daemon set after status update: %+v", ds)
+	}
+	ds.Status.NumberReady = -1
+	if _, err := service.UpdateDaemonSetStatus(t.Context(), ds); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a negative count, got %v", err)
+	}
+
+	// spec updates leave the status alone and bump the generation
+	ds.Spec.Template.Spec.Containers = []api.Container{{Name: "fluentd", Image: "fluentd:2"}}
+	ds.Status = api.DaemonSetStatus{}
+	ds, err = service.UpdateDaemonSet(t.Context(), ds)
+	if err != nil {
+		t.Fatalf("failed to update daemon set: %v", err)
+	}
+	if ds.Spec.Template.Spec.Containers[0].Image != "fluentd:2" || ds.Generation != 2 || ds.Status.DesiredNumberScheduled != 2 {
+		t.Errorf("unexpected daemon set after update: %+v", ds)
+	}
+	if _, err := service.UpdateDaemonSet(t.Context(), created); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict on stale update, got %v", err)
+	}
+
+	ds.Spec.Selector = api.LabelSelector{MatchLabels: map[string]string{"tier": "node"}}
+	ds.Spec.Template.Labels = map[string]string{"tier": "node"}
+	if _, err := service.UpdateDaemonSet(t.Context(), ds); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a changed selector, got %v", err)
+	}
+}
+
+func TestListAndDeleteDaemonSets(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	for _, name := range []string{"logs", "metrics"} {
+		if _, err := service.CreateDaemonSet(t.Context(), apitest.NewDaemonSet(name)); err != nil {
+			t.Fatalf("failed to create daemon set: %v", err)
+		}
+	}
+	list, err := service.ListDaemonSets(t.Context(), "")
+	if err != nil {
+		t.Fatalf("failed to list daemon sets: %v", err)
+	}
+	if len(list.Items) != 2 || list.ResourceVersion == "" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+	if _, err := service.DeleteDaemonSet(t.Context(), utils.DefaultNamespace, "logs", api.DeleteOptions{ResourceVersion: "99"}); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict for a stale precondition, got %v", err)
+	}
+	if _, err := service.DeleteDaemonSet(t.Context(), utils.DefaultNamespace, "logs", api.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete daemon set: %v", err)
+	}
+	if _, err := service.GetDaemonSet(t.Context(), utils.DefaultNamespace, "logs"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestGenerateName(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	ds := apitest.NewDaemonSet("")
+	ds.GenerateName = "logs-"
+	created, err := service.CreateDaemonSet(t.Context(), ds)
+	if err != nil {
+		t.Fatalf("failed to create daemon set: %v", err)
+	}
+	if !strings.HasPrefix(created.Name, "logs-") || len(created.Name) != len("logs-")+5 {
+		t.Errorf("expected a generated name starting with logs-, got %q", created.Name)
+	}
+}
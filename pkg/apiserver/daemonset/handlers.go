@@ -0,0 +1,111 @@
+package daemonset
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func (h *handler) GetDaemonSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ds, err := h.service.GetDaemonSet(r.Context(), vars["namespace"], vars["name"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, ds)
+}
+
+// ListDaemonSets lists daemon sets in the namespace from the url, or every namespace if there is none
+func (h *handler) ListDaemonSets(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListDaemonSets(r.Context(), mux.Vars(r)["namespace"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, list)
+}
+
+func (h *handler) CreateDaemonSet(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	var ds api.DaemonSet
+	if err := utils.DecodeBody(r, &ds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ds.Namespace != "" && ds.Namespace != namespace {
+		http.Error(w, "daemon set namespace does not match url", http.StatusBadRequest)
+		return
+	}
+	ds.Namespace = namespace
+	ds, err := h.service.CreateDaemonSet(r.Context(), ds)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusCreated, ds)
+}
+
+func (h *handler) UpdateDaemonSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var ds api.DaemonSet
+	if err := utils.DecodeBody(r, &ds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&ds, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ds, err := h.service.UpdateDaemonSet(r.Context(), ds)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, ds)
+}
+
+// UpdateDaemonSetStatus takes the full daemon set but only its status is written
+func (h *handler) UpdateDaemonSetStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var ds api.DaemonSet
+	if err := utils.DecodeBody(r, &ds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&ds, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ds, err := h.service.UpdateDaemonSetStatus(r.Context(), ds)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, ds)
+}
+
+// DeleteDaemonSet takes an optional ?resourceVersion= precondition
+func (h *handler) DeleteDaemonSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	opts := api.DeleteOptions{ResourceVersion: r.URL.Query().Get("resourceVersion")}
+	ds, err := h.service.DeleteDaemonSet(r.Context(), vars["namespace"], vars["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, ds)
+}
+
+func NewHandler(service Service) handler {
+	return handler{
+		service: service,
+	}
+}
+
+type handler struct {
+	service Service
+}
@@ -0,0 +1,69 @@
+package daemonset
+
+import (
+	"fmt"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func validateDaemonSet(ds api.DaemonSet) error {
+	if err := utils.ValidateObjectMeta(ds.ObjectMeta); err != nil {
+		return err
+	}
+	if len(ds.Spec.Selector.MatchLabels) == 0 {
+		return fmt.Errorf("%w: daemon set needs a selector", utils.ErrInvalid)
+	}
+	// otherwise the pods it creates aren't counted and it creates them forever
+	if !ds.Spec.Selector.Matches(ds.Spec.Template.Labels) {
+		return fmt.Errorf("%w: selector does not match template labels", utils.ErrInvalid)
+	}
+	// the controller sets it to tell pods of the current template from old ones
+	if _, ok := ds.Spec.Template.Labels[api.PodTemplateHashLabel]; ok {
+		return fmt.Errorf("%w: template can't set the %s label", utils.ErrInvalid, api.PodTemplateHashLabel)
+	}
+	if err := validateStrategy(ds.Spec.UpdateStrategy); err != nil {
+		return err
+	}
+	return pod.ValidateSpec(ds.Spec.Template.Spec)
+}
+
+func validateStrategy(strategy api.DaemonSetUpdateStrategy) error {
+	switch strategy.Type {
+	case api.OnDeleteDaemonSetStrategyType:
+		if strategy.RollingUpdate != nil {
+			return fmt.Errorf("%w: rollingUpdate can't be set with the OnDelete strategy", utils.ErrInvalid)
+		}
+		return nil
+	case api.RollingUpdateDaemonSetStrategyType:
+	default:
+		return fmt.Errorf("%w: unknown update strategy type %q", utils.ErrInvalid, strategy.Type)
+	}
+	// resolved against 100 nodes, which is enough to tell whether it would round to 0
+	unavailable, err := strategy.RollingUpdate.MaxUnavailable.ScaledValue(100, true)
+	if err != nil {
+		return fmt.Errorf("%w: maxUnavailable: %v", utils.ErrInvalid, err)
+	}
+	if unavailable <= 0 {
+		return fmt.Errorf("%w: maxUnavailable has to be positive", utils.ErrInvalid)
+	}
+	return nil
+}
+
+func validateStatus(status api.DaemonSetStatus) error {
+	counts := []int32{
+		status.DesiredNumberScheduled,
+		status.CurrentNumberScheduled,
+		status.NumberMisscheduled,
+		status.NumberReady,
+		status.UpdatedNumberScheduled,
+		status.NumberUnavailable,
+	}
+	for _, n := range counts {
+		if n < 0 {
+			return fmt.Errorf("%w: node counts can't be negative", utils.ErrInvalid)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"strconv"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+const resource = "deployments"
+
+type Service interface {
+	GetDeployment(ctx context.Context, namespace, name string) (api.Deployment, error)
+	ListDeployments(ctx context.Context, namespace string) (api.DeploymentList, error)
+	CreateDeployment(ctx context.Context, d api.Deployment) (api.Deployment, error)
+	UpdateDeployment(ctx context.Context, d api.Deployment) (api.Deployment, error)
+	UpdateDeploymentStatus(ctx context.Context, d api.Deployment) (api.Deployment, error)
+	DeleteDeployment(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.Deployment, error)
+	RollbackDeployment(ctx context.Context, namespace, name string, rollback api.DeploymentRollback) (api.Deployment, error)
+}
+
+// DeploymentService persists deployments through storage.
+// Replica sets are left to the deployment controller, rollbacks only read them.
+type DeploymentService struct {
+	store storage.Interface
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewService(store storage.Interface) *DeploymentService {
+	return &DeploymentService{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (s *DeploymentService) GetDeployment(ctx context.Context, namespace, name string) (api.Deployment, error) {
+	return utils.GetObject[api.Deployment](ctx, s.store, storage.Key(resource, namespace, name))
+}
+
+// ListDeployments lists deployments in namespace, or every namespace if it's empty
+func (s *DeploymentService) ListDeployments(ctx context.Context, namespace string) (api.DeploymentList, error) {
+	items, rv, err := utils.ListObjects[api.Deployment](ctx, s.store, storage.Prefix(resource, namespace))
+	if err != nil {
+		return api.DeploymentList{}, err
+	}
+	return api.DeploymentList{ResourceVersion: rv, Items: items}, nil
+}
+
+func (s *DeploymentService) CreateDeployment(ctx context.Context, d api.Deployment) (api.Deployment, error) {
+	utils.PrepareObjectMetaForCreate(&d.ObjectMeta, s.now())
+	setDefaults(&d)
+	if err := validateDeployment(d); err != nil {
+		return api.Deployment{}, err
+	}
+	// status belongs to the controller
+	d.Status = api.DeploymentStatus{}
+	d, err := utils.CreateObject(ctx, s.store, storage.Key(resource, d.Namespace, d.Name), d)
+	if err != nil {
+		return api.Deployment{}, err
+	}
+	slog.Info("Created Deployment", "namespace", d.Namespace, "name", d.Name)
+	return d, nil
+}
+
+// UpdateDeployment replaces the stored deployment's spec and metadata, status is left alone.
+// If d carries a resourceVersion the update is rejected with storage.ErrConflict unless it is still current.
+func (s *DeploymentService) UpdateDeployment(ctx context.Context, d api.Deployment) (api.Deployment, error) {
+	d, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, d.Namespace, d.Name), d.ResourceVersion, func(old api.Deployment) (api.Deployment, error) {
+		updated := d
+		updated.Status = old.Status
+		setDefaults(&updated)
+		// replica sets are matched to the deployment by it, changing it would orphan them
+		if !reflect.DeepEqual(updated.Spec.Selector, old.Spec.Selector) {
+			return api.Deployment{}, fmt.Errorf("%w: selector can't be changed", utils.ErrInvalid)
+		}
+		utils.PrepareObjectMetaForUpdate(&updated.ObjectMeta, old.ObjectMeta, !reflect.DeepEqual(updated.Spec, old.Spec))
+		return updated, validateDeployment(updated)
+	})
+	if err != nil {
+		return api.Deployment{}, err
+	}
+	slog.Info("Updated Deployment", "namespace", d.Namespace, "name", d.Name)
+	return d, nil
+}
+
+// UpdateDeploymentStatus replaces only the status of the stored deployment, it's how the controller reports back.
+// d's resourceVersion is a precondition, same as UpdateDeployment.
+func (s *DeploymentService) UpdateDeploymentStatus(ctx context.Context, d api.Deployment) (api.Deployment, error) {
+	d, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, d.Namespace, d.Name), d.ResourceVersion, func(old api.Deployment) (api.Deployment, error) {
+		old.Status = d.Status
+		return old, validateStatus(old.Status)
+	})
+	if err != nil {
+		return api.Deployment{}, err
+	}
+	slog.Debug("Updated Deployment status", "namespace", d.Namespace, "name", d.Name)
+	return d, nil
+}
+
+// DeleteDeployment removes the deployment right away.
+// Its replica sets still point at it through their ownerReferences, the controller cleans those up once it sees it's gone.
+func (s *DeploymentService) DeleteDeployment(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.Deployment, error) {
+	d, err := utils.DeleteObject[api.Deployment](ctx, s.store, storage.Key(resource, namespace, name), opts)
+	if err != nil {
+		return api.Deployment{}, err
+	}
+	slog.Info("Deleted Deployment", "namespace", namespace, "name", name)
+	return d, nil
+}
+
+// RollbackDeployment puts the template of an earlier revision back into the deployment's spec,
+// the controller then rolls it out like any other template change.
+// Revisions are read off the deployment's replica sets, a revision of 0 is the one before the current.
+func (s *DeploymentService) RollbackDeployment(ctx context.Context, namespace, name string, rollback api.DeploymentRollback) (api.Deployment, error) {
+	if rollback.Revision < 0 {
+		return api.Deployment{}, fmt.Errorf("%w: revision can't be negative", utils.ErrInvalid)
+	}
+	d, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, namespace, name), "", func(old api.Deployment) (api.Deployment, error) {
+		if old.Spec.Paused {
+			return api.Deployment{}, fmt.Errorf("%w: deployment %s is paused, resume it before rolling back", utils.ErrInvalid, old.Name)
+		}
+		template, err := s.revisionTemplate(ctx, old, rollback.Revision)
+		if err != nil {
+			return api.Deployment{}, err
+		}
+		updated := old
+		updated.Spec.Template = template
+		utils.PrepareObjectMetaForUpdate(&updated.ObjectMeta, old.ObjectMeta, !reflect.DeepEqual(updated.Spec, old.Spec))
+		return updated, validateDeployment(updated)
+	})
+	if err != nil {
+		return api.Deployment{}, err
+	}
+	slog.Info("Rolled back Deployment", "namespace", d.Namespace, "name", d.Name, "revision", rollback.Revision)
+	return d, nil
+}
+
+// revisionTemplate finds the pod template d's replica set for revision was made from
+func (s *DeploymentService) revisionTemplate(ctx context.Context, d api.Deployment, revision int64) (api.PodTemplateSpec, error) {
+	replicaSets, _, err := utils.ListObjects[api.ReplicaSet](ctx, s.store, storage.Prefix("replicasets", d.Namespace))
+	if err != nil {
+		return api.PodTemplateSpec{}, err
+	}
+	revisions := map[int64]api.ReplicaSet{}
+	var current int64
+	for _, rs := range replicaSets {
+		if !rs.IsControlledBy(d.Uid) {
+			continue
+		}
+		rev, err := strconv.ParseInt(rs.Annotations[api.DeploymentRevisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		revisions[rev] = rs
+		current = max(current, rev)
+	}
+	if revision == 0 {
+		// the highest revision below the current one, numbers can have gaps once history is trimmed
+		for rev := range revisions {
+			if rev < current && rev > revision {
+				revision = rev
+			}
+		}
+		if revision == 0 {
+			return api.PodTemplateSpec{}, fmt.Errorf("%w: deployment %s has no previous revision", utils.ErrInvalid, d.Name)
+		}
+	}
+	rs, ok := revisions[revision]
+	if !ok {
+		return api.PodTemplateSpec{}, fmt.Errorf("%w: deployment %s has no revision %d", utils.ErrInvalid, d.Name, revision)
+	}
+	template := rs.Spec.Template
+	template.Labels = maps.Clone(template.Labels)
+	delete(template.Labels, api.PodTemplateHashLabel)
+	return template, nil
+}
+
+func setDefaults(d *api.Deployment) {
+	if d.Spec.Replicas == nil {
+		one := int32(1)
+		d.Spec.Replicas = &one
+	}
+	if d.Spec.Strategy.Type == "" {
+		d.Spec.Strategy.Type = api.RollingUpdateDeploymentStrategyType
+	}
+	if d.Spec.Strategy.Type == api.RollingUpdateDeploymentStrategyType {
+		if d.Spec.Strategy.RollingUpdate == nil {
+			d.Spec.Strategy.RollingUpdate = &api.RollingUpdateDeployment{}
+		}
+		quarter := api.FromString("25%")
+		if d.Spec.Strategy.RollingUpdate.MaxUnavailable == nil {
+			d.Spec.Strategy.RollingUpdate.MaxUnavailable = &quarter
+		}
+		if d.Spec.Strategy.RollingUpdate.MaxSurge == nil {
+			d.Spec.Strategy.RollingUpdate.MaxSurge = &quarter
+		}
+	}
+	if d.Spec.RevisionHistoryLimit == nil {
+		limit := int32(10)
+		d.Spec.RevisionHistoryLimit = &limit
+	}
+	if d.Spec.ProgressDeadlineSeconds == nil {
+		deadline := int32(600)
+		d.Spec.ProgressDeadlineSeconds = &deadline
+	}
+	pod.SetSpecDefaults(&d.Spec.Template.Spec)
+}
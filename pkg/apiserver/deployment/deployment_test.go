@@ -0,0 +1,180 @@
+package deployment
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func TestCreateDeployment(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	withStrategy := func(name string, strategy api.DeploymentStrategy) api.Deployment {
+		d := apitest.NewDeployment(name, 1)
+		d.Spec.Strategy = strategy
+		return d
+	}
+	zero := api.FromInt(0)
+	fraction := api.FromString("half")
+	hashLabel := apitest.NewDeployment("hash-label", 1)
+	hashLabel.Spec.Template.Labels[api.PodTemplateHashLabel] = "abc"
+	mismatch := apitest.NewDeployment("mismatch", 1)
+	mismatch.Spec.Template.Labels = map[string]string{"app": "other"}
+
+	testCases := []struct {
+		name    string
+		d       api.Deployment
+		wantErr error
+	}{
+		{name: "basic deployment", d: apitest.NewDeployment("web", 3)},
+		{name: "recreate", d: withStrategy("recreate", api.DeploymentStrategy{Type: api.RecreateDeploymentStrategyType})},
+		{name: "duplicate name", d: apitest.NewDeployment("web", 1), wantErr: storage.ErrKeyExists},
+		{name: "negative replicas", d: apitest.NewDeployment("negative", -1), wantErr: utils.ErrInvalid},
+		{name: "selector does not match template", d: mismatch, wantErr: utils.ErrInvalid},
+		{name: "template sets the hash label", d: hashLabel, wantErr: utils.ErrInvalid},
+		{
+			name:    "unknown strategy",
+			d:       withStrategy("unknown", api.DeploymentStrategy{Type: "BlueGreen"}),
+			wantErr: utils.ErrInvalid,
+		},
+		{
+			name: "rolling update options on recreate",
+			d: withStrategy("recreate-rolling", api.DeploymentStrategy{
+				Type:          api.RecreateDeploymentStrategyType,
+				RollingUpdate: &api.RollingUpdateDeployment{},
+			}),
+			wantErr: utils.ErrInvalid,
+		},
+		{
+			name: "surge and unavailable both zero",
+			d: withStrategy("zero", api.DeploymentStrategy{
+				RollingUpdate: &api.RollingUpdateDeployment{MaxSurge: &zero, MaxUnavailable: &zero},
+			}),
+			wantErr: utils.ErrInvalid,
+		},
+		{
+			name: "surge not a percentage",
+			d: withStrategy("fraction", api.DeploymentStrategy{
+				RollingUpdate: &api.RollingUpdateDeployment{MaxSurge: &fraction},
+			}),
+			wantErr: utils.ErrInvalid,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := service.CreateDeployment(t.Context(), tc.d)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d.Spec.RevisionHistoryLimit == nil || d.Spec.ProgressDeadlineSeconds == nil || d.Spec.Strategy.Type == "" {
+				t.Errorf("spec wasn't defaulted: %+v", d.Spec)
+			}
+			if d.Spec.Strategy.Type == api.RollingUpdateDeploymentStrategyType && d.Spec.Strategy.RollingUpdate.MaxSurge.String() != "25%" {
+				t.Errorf("rolling update wasn't defaulted: %+v", d.Spec.Strategy.RollingUpdate)
+			}
+		})
+	}
+}
+
+func TestUpdateDeploymentSelectorIsImmutable(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	d, err := service.CreateDeployment(t.Context(), apitest.NewDeployment("web", 1))
+	if err != nil {
+		t.Fatalf("failed to create deployment: %v", err)
+	}
+	d.Spec.Template.Labels = map[string]string{"app": "web", "tier": "frontend"}
+	d.Spec.Selector = api.LabelSelector{MatchLabels: map[string]string{"tier": "frontend"}}
+	if _, err := service.UpdateDeployment(t.Context(), d); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a selector change, got %v", err)
+	}
+}
+
+func TestRollbackDeployment(t *testing.T) {
+	store := storage.NewMemoryStore()
+	service := NewService(store)
+	d, err := service.CreateDeployment(t.Context(), apitest.NewDeployment("web", 1))
+	if err != nil {
+		t.Fatalf("failed to create deployment: %v", err)
+	}
+	// replica sets the controller would have made for revisions 1 to 3
+	for rev, image := range map[int]string{1: "nginx:1.0", 2: "nginx:2.0", 3: "nginx:3.0"} {
+		template := d.Spec.Template
+		template.Labels = map[string]string{"app": "web", api.PodTemplateHashLabel: image}
+		template.Spec.Containers = []api.Container{{Name: "container-0", Image: image}}
+		rs := api.ReplicaSet{
+			ObjectMeta: api.ObjectMeta{
+				Name:            "web-" + strconv.Itoa(rev),
+				Namespace:       d.Namespace,
+				Annotations:     map[string]string{api.DeploymentRevisionAnnotation: strconv.Itoa(rev)},
+				OwnerReferences: []api.OwnerReference{{Kind: "Deployment", Name: d.Name, Uid: d.Uid, Controller: true}},
+			},
+			Spec: api.ReplicaSetSpec{Template: template},
+		}
+		b, _ := json.Marshal(rs)
+		if _, err := store.Create(t.Context(), storage.Key("replicasets", rs.Namespace, rs.Name), b); err != nil {
+			t.Fatalf("failed to store replica set: %v", err)
+		}
+	}
+	// belongs to some other deployment, its revisions don't count
+	stranger := api.ReplicaSet{ObjectMeta: api.ObjectMeta{
+		Name: "other-9", Namespace: d.Namespace,
+		Annotations:     map[string]string{api.DeploymentRevisionAnnotation: "9"},
+		OwnerReferences: []api.OwnerReference{{Kind: "Deployment", Name: "other", Uid: uuid.New(), Controller: true}},
+	}}
+	b, _ := json.Marshal(stranger)
+	store.Create(t.Context(), storage.Key("replicasets", stranger.Namespace, stranger.Name), b)
+
+	testCases := []struct {
+		name          string
+		revision      int64
+		expectedImage string
+		wantErr       error
+	}{
+		{name: "previous revision", revision: 0, expectedImage: "nginx:2.0"},
+		{name: "specific revision", revision: 1, expectedImage: "nginx:1.0"},
+		{name: "revision of another deployment", revision: 9, wantErr: utils.ErrInvalid},
+		{name: "negative revision", revision: -1, wantErr: utils.ErrInvalid},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := service.RollbackDeployment(t.Context(), d.Namespace, d.Name, api.DeploymentRollback{Revision: tc.revision})
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if image := got.Spec.Template.Spec.Containers[0].Image; image != tc.expectedImage {
+				t.Errorf("rolled back to %s, expected %s", image, tc.expectedImage)
+			}
+			if _, ok := got.Spec.Template.Labels[api.PodTemplateHashLabel]; ok {
+				t.Errorf("hash label carried over from the replica set")
+			}
+		})
+	}
+
+	d, _ = service.GetDeployment(t.Context(), d.Namespace, d.Name)
+	d.Spec.Paused = true
+	if _, err := service.UpdateDeployment(t.Context(), d); err != nil {
+		t.Fatalf("failed to pause deployment: %v", err)
+	}
+	if _, err := service.RollbackDeployment(t.Context(), d.Namespace, d.Name, api.DeploymentRollback{}); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid rolling back a paused deployment, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package deployment
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func (h *handler) GetDeployment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	d, err := h.service.GetDeployment(r.Context(), vars["namespace"], vars["name"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, d)
+}
+
+// ListDeployments lists deployments in the namespace from the url, or every namespace if there is none
+func (h *handler) ListDeployments(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListDeployments(r.Context(), mux.Vars(r)["namespace"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, list)
+}
+
+func (h *handler) CreateDeployment(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	var d api.Deployment
+	if err := utils.DecodeBody(r, &d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if d.Namespace != "" && d.Namespace != namespace {
+		http.Error(w, "deployment namespace does not match url", http.StatusBadRequest)
+		return
+	}
+	d.Namespace = namespace
+	d, err := h.service.CreateDeployment(r.Context(), d)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusCreated, d)
+}
+
+func (h *handler) UpdateDeployment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var d api.Deployment
+	if err := utils.DecodeBody(r, &d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&d, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	d, err := h.service.UpdateDeployment(r.Context(), d)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, d)
+}
+
+// UpdateDeploymentStatus takes the full deployment but only its status is written
+func (h *handler) UpdateDeploymentStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var d api.Deployment
+	if err := utils.DecodeBody(r, &d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&d, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	d, err := h.service.UpdateDeploymentStatus(r.Context(), d)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, d)
+}
+
+// DeleteDeployment takes an optional ?resourceVersion= precondition
+func (h *handler) DeleteDeployment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	opts := api.DeleteOptions{ResourceVersion: r.URL.Query().Get("resourceVersion")}
+	d, err := h.service.DeleteDeployment(r.Context(), vars["namespace"], vars["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, d)
+}
+
+// RollbackDeployment takes a DeploymentRollback naming the revision to go back to
+func (h *handler) RollbackDeployment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var rollback api.DeploymentRollback
+	if err := utils.DecodeBody(r, &rollback); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	d, err := h.service.RollbackDeployment(r.Context(), vars["namespace"], vars["name"], rollback)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, d)
+}
+
+func NewHandler(service Service) handler {
+	return handler{
+		service: service,
+	}
+}
+
+type handler struct {
+	service Service
+}
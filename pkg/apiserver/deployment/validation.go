@@ -0,0 +1,74 @@
+package deployment
+
+import (
+	"fmt"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func validateDeployment(d api.Deployment) error {
+	if err := utils.ValidateObjectMeta(d.ObjectMeta); err != nil {
+		return err
+	}
+	if *d.Spec.Replicas < 0 {
+		return fmt.Errorf("%w: replicas can't be negative", utils.ErrInvalid)
+	}
+	if *d.Spec.RevisionHistoryLimit < 0 {
+		return fmt.Errorf("%w: revisionHistoryLimit can't be negative", utils.ErrInvalid)
+	}
+	if *d.Spec.ProgressDeadlineSeconds <= 0 {
+		return fmt.Errorf("%w: progressDeadlineSeconds has to be positive", utils.ErrInvalid)
+	}
+	if len(d.Spec.Selector.MatchLabels) == 0 {
+		return fmt.Errorf("%w: deployment needs a selector", utils.ErrInvalid)
+	}
+	if !d.Spec.Selector.Matches(d.Spec.Template.Labels) {
+		return fmt.Errorf("%w: selector does not match template labels", utils.ErrInvalid)
+	}
+	// the controller sets it to tell its replica sets apart
+	if _, ok := d.Spec.Template.Labels[api.PodTemplateHashLabel]; ok {
+		return fmt.Errorf("%w: template can't set the %s label", utils.ErrInvalid, api.PodTemplateHashLabel)
+	}
+	if err := validateStrategy(d.Spec.Strategy); err != nil {
+		return err
+	}
+	return pod.ValidateSpec(d.Spec.Template.Spec)
+}
+
+func validateStrategy(strategy api.DeploymentStrategy) error {
+	switch strategy.Type {
+	case api.RecreateDeploymentStrategyType:
+		if strategy.RollingUpdate != nil {
+			return fmt.Errorf("%w: rollingUpdate can't be set with the Recreate strategy", utils.ErrInvalid)
+		}
+		return nil
+	case api.RollingUpdateDeploymentStrategyType:
+	default:
+		return fmt.Errorf("%w: unknown strategy type %q", utils.ErrInvalid, strategy.Type)
+	}
+	// resolved against 100 replicas, which is enough to tell whether both would round to 0
+	surge, err := strategy.RollingUpdate.MaxSurge.ScaledValue(100, true)
+	if err != nil {
+		return fmt.Errorf("%w: maxSurge: %v", utils.ErrInvalid, err)
+	}
+	unavailable, err := strategy.RollingUpdate.MaxUnavailable.ScaledValue(100, false)
+	if err != nil {
+		return fmt.Errorf("%w: maxUnavailable: %v", utils.ErrInvalid, err)
+	}
+	if surge < 0 || unavailable < 0 {
+		return fmt.Errorf("%w: maxSurge and maxUnavailable can't be negative", utils.ErrInvalid)
+	}
+	if surge == 0 && unavailable == 0 {
+		return fmt.Errorf("%w: maxSurge and maxUnavailable can't both be 0", utils.ErrInvalid)
+	}
+	return nil
+}
+
+func validateStatus(status api.DeploymentStatus) error {
+	if status.Replicas < 0 || status.UpdatedReplicas < 0 || status.ReadyReplicas < 0 || status.UnavailableReplicas < 0 {
+		return fmt.Errorf("%w: replica counts can't be negative", utils.ErrInvalid)
+	}
+	return nil
+}
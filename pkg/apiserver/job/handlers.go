@@ -0,0 +1,111 @@
+package job
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func (h *handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	j, err := h.service.GetJob(r.Context(), vars["namespace"], vars["name"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, j)
+}
+
+// ListJobs lists jobs in the namespace from the url, or every namespace if there is none
+func (h *handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListJobs(r.Context(), mux.Vars(r)["namespace"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, list)
+}
+
+func (h *handler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	var j api.Job
+	if err := utils.DecodeBody(r, &j); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if j.Namespace != "" && j.Namespace != namespace {
+		http.Error(w, "job namespace does not match url", http.StatusBadRequest)
+		return
+	}
+	j.Namespace = namespace
+	j, err := h.service.CreateJob(r.Context(), j)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusCreated, j)
+}
+
+func (h *handler) UpdateJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var j api.Job
+	if err := utils.DecodeBody(r, &j); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&j, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	j, err := h.service.UpdateJob(r.Context(), j)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, j)
+}
+
+// UpdateJobStatus takes the full job but only its status is written
+func (h *handler) UpdateJobStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var j api.Job
+	if err := utils.DecodeBody(r, &j); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&j, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	j, err := h.service.UpdateJobStatus(r.Context(), j)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, j)
+}
+
+// DeleteJob takes an optional ?resourceVersion= precondition
+func (h *handler) DeleteJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	opts := api.DeleteOptions{ResourceVersion: r.URL.Query().Get("resourceVersion")}
+	j, err := h.service.DeleteJob(r.Context(), vars["namespace"], vars["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, j)
+}
+
+func NewHandler(service Service) handler {
+	return handler{
+		service: service,
+	}
+}
+
+type handler struct {
+	service Service
+}
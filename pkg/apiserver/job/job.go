@@ -0,0 +1,140 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+const resource = "jobs"
+
+type Service interface {
+	GetJob(ctx context.Context, namespace, name string) (api.Job, error)
+	ListJobs(ctx context.Context, namespace string) (api.JobList, error)
+	CreateJob(ctx context.Context, j api.Job) (api.Job, error)
+	UpdateJob(ctx context.Context, j api.Job) (api.Job, error)
+	UpdateJobStatus(ctx context.Context, j api.Job) (api.Job, error)
+	DeleteJob(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.Job, error)
+}
+
+// JobService persists jobs through storage.
+// Pods are left to the job controller, nothing here touches them.
+type JobService struct {
+	store storage.Interface
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewService(store storage.Interface) *JobService {
+	return &JobService{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (s *JobService) GetJob(ctx context.Context, namespace, name string) (api.Job, error) {
+	return utils.GetObject[api.Job](ctx, s.store, storage.Key(resource, namespace, name))
+}
+
+// ListJobs lists jobs in namespace, or every namespace if it's empty
+func (s *JobService) ListJobs(ctx context.Context, namespace string) (api.JobList, error) {
+	items, rv, err := utils.ListObjects[api.Job](ctx, s.store, storage.Prefix(resource, namespace))
+	if err != nil {
+		return api.JobList{}, err
+	}
+	return api.JobList{ResourceVersion: rv, Items: items}, nil
+}
+
+func (s *JobService) CreateJob(ctx context.Context, j api.Job) (api.Job, error) {
+	utils.PrepareObjectMetaForCreate(&j.ObjectMeta, s.now())
+	SetSpecDefaults(&j.Spec)
+	if err := validateJob(j); err != nil {
+		return api.Job{}, err
+	}
+	// status belongs to the controller
+	j.Status = api.JobStatus{}
+	j, err := utils.CreateObject(ctx, s.store, storage.Key(resource, j.Namespace, j.Name), j)
+	if err != nil {
+		return api.Job{}, err
+	}
+	slog.Info("Created Job", "namespace", j.Namespace, "name", j.Name)
+	return j, nil
+}
+
+// UpdateJob replaces the stored job's spec and metadata, status is left alone.
+// Only parallelism, backoffLimit, activeDeadlineSeconds and ttlSecondsAfterFinished can change,
+// pods already running were made from the template and counted against completions.
+// If j carries a resourceVersion the update is rejected with storage.ErrConflict unless it is still current.
+func (s *JobService) UpdateJob(ctx context.Context, j api.Job) (api.Job, error) {
+	j, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, j.Namespace, j.Name), j.ResourceVersion, func(old api.Job) (api.Job, error) {
+		updated := j
+		updated.Status = old.Status
+		SetSpecDefaults(&updated.Spec)
+		if !reflect.DeepEqual(updated.Spec.Completions, old.Spec.Completions) {
+			return api.Job{}, fmt.Errorf("%w: completions can't be changed", utils.ErrInvalid)
+		}
+		if !reflect.DeepEqual(updated.Spec.Template, old.Spec.Template) {
+			return api.Job{}, fmt.Errorf("%w: template can't be changed", utils.ErrInvalid)
+		}
+		utils.PrepareObjectMetaForUpdate(&updated.ObjectMeta, old.ObjectMeta, !reflect.DeepEqual(updated.Spec, old.Spec))
+		return updated, validateJob(updated)
+	})
+	if err != nil {
+		return api.Job{}, err
+	}
+	slog.Info("Updated Job", "namespace", j.Namespace, "name", j.Name)
+	return j, nil
+}
+
+// UpdateJobStatus replaces only the status of the stored job, it's how the controller reports back.
+// j's resourceVersion is a precondition, same as UpdateJob.
+func (s *JobService) UpdateJobStatus(ctx context.Context, j api.Job) (api.Job, error) {
+	j, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, j.Namespace, j.Name), j.ResourceVersion, func(old api.Job) (api.Job, error) {
+		old.Status = j.Status
+		return old, validateStatus(old.Status)
+	})
+	if err != nil {
+		return api.Job{}, err
+	}
+	slog.Debug("Updated Job status", "namespace", j.Namespace, "name", j.Name)
+	return j, nil
+}
+
+// DeleteJob removes the job right away.
+// Its pods still point at it through their ownerReferences, the controller cleans those up once it sees it's gone.
+func (s *JobService) DeleteJob(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.Job, error) {
+	j, err := utils.DeleteObject[api.Job](ctx, s.store, storage.Key(resource, namespace, name), opts)
+	if err != nil {
+		return api.Job{}, err
+	}
+	slog.Info("Deleted Job", "namespace", namespace, "name", name)
+	return j, nil
+}
+
+// SetSpecDefaults fills in a job spec, it's shared with cron jobs for their job template
+func SetSpecDefaults(spec *api.JobSpec) {
+	if spec.Completions == nil && spec.Parallelism == nil {
+		one := int32(1)
+		spec.Completions = &one
+	}
+	if spec.Parallelism == nil {
+		one := int32(1)
+		spec.Parallelism = &one
+	}
+	if spec.BackoffLimit == nil {
+		six := int32(6)
+		spec.BackoffLimit = &six
+	}
+	// pods of a job have to finish, so unlike bare pods they aren't restarted by default
+	if spec.Template.Spec.RestartPolicy == "" {
+		spec.Template.Spec.RestartPolicy = api.RestartPolicyNever
+	}
+	pod.SetSpecDefaults(&spec.Template.Spec)
+}
@@ -0,0 +1,120 @@
+package job
+
+import (
+	"errors"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func TestCreateJob(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	negativeParallelism := apitest.NewJob("negative-parallelism")
+	negativeParallelism.Spec.Parallelism = apitest.Ptr(int32(-1))
+	negativeCompletions := apitest.NewJob("negative-completions")
+	negativeCompletions.Spec.Completions = apitest.Ptr(int32(-1))
+	negativeBackoff := apitest.NewJob("negative-backoff")
+	negativeBackoff.Spec.BackoffLimit = apitest.Ptr(int32(-1))
+	zeroDeadline := apitest.NewJob("zero-deadline")
+	zero := int64(0)
+	zeroDeadline.Spec.ActiveDeadlineSeconds = &zero
+	noContainers := apitest.NewJob("no-containers")
+	noContainers.Spec.Template.Spec.Containers = nil
+	restartAlways := apitest.NewJob("restart-always")
+	restartAlways.Spec.Template.Spec.RestartPolicy = api.RestartPolicyAlways
+
+	testCases := []struct {
+		name    string
+		job     api.Job
+		wantErr error
+	}{
+		{name: "basic job", job: apitest.NewJob("batch")},
+		{name: "duplicate name", job: apitest.NewJob("batch"), wantErr: storage.ErrKeyExists},
+		{name: "negative parallelism", job: negativeParallelism, wantErr: utils.ErrInvalid},
+		{name: "negative completions", job: negativeCompletions, wantErr: utils.ErrInvalid},
+		{name: "negative backoff limit", job: negativeBackoff, wantErr: utils.ErrInvalid},
+		{name: "zero active deadline", job: zeroDeadline, wantErr: utils.ErrInvalid},
+		{name: "template without containers", job: noContainers, wantErr: utils.ErrInvalid},
+		{name: "pods restart always", job: restartAlways, wantErr: utils.ErrInvalid},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			j, err := service.CreateJob(t.Context(), tc.job)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *j.Spec.Parallelism != 1 || *j.Spec.Completions != 1 || *j.Spec.BackoffLimit != 6 {
+				t.Errorf("job spec wasn't defaulted: %+v", j.Spec)
+			}
+			if j.Spec.Template.Spec.Containers[0].Name == "" || j.Spec.Template.Spec.RestartPolicy != api.RestartPolicyNever {
+				t.Errorf("template wasn't defaulted: %+v", j.Spec.Template.Spec)
+			}
+		})
+	}
+}
+
+func TestCreateJobWithoutCompletions(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	j := apitest.NewJob("queue")
+	j.Spec.Parallelism = apitest.Ptr(int32(3))
+	j, err := service.CreateJob(t.Context(), j)
+	if err != nil {
+		t.Fatalf("failed to create job: %v", err)
+	}
+	// parallelism without completions is a work queue, completions stays unset
+	if j.Spec.Completions != nil {
+		t.Errorf("completions defaulted to %d", *j.Spec.Completions)
+	}
+}
+
+func TestUpdateJob(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	created, err := service.CreateJob(t.Context(), apitest.NewJob("batch"))
+	if err != nil {
+		t.Fatalf("failed to create job: %v", err)
+	}
+
+	j := created
+	j.Spec.Parallelism = apitest.Ptr(int32(2))
+	j.Spec.TTLSecondsAfterFinished = apitest.Ptr(int32(60))
+	j, err = service.UpdateJob(t.Context(), j)
+	if err != nil {
+		t.Fatalf("failed to update job: %v", err)
+	}
+	if *j.Spec.Parallelism != 2 || j.Generation != 2 {
+		t.Errorf("unexpected job after update: %+v", j)
+	}
+
+	completions := j
+	completions.Spec.Completions = apitest.Ptr(int32(5))
+	if _, err := service.UpdateJob(t.Context(), completions); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for changed completions, got %v", err)
+	}
+	template := j
+	template.Spec.Template.Spec.Containers = []api.Container{{Name: "container-0", Image: "alpine"}}
+	if _, err := service.UpdateJob(t.Context(), template); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for changed template, got %v", err)
+	}
+
+	j.Status = api.JobStatus{Active: 2}
+	j, err = service.UpdateJobStatus(t.Context(), j)
+	if err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if j.Status.Active != 2 {
+		t.Errorf("unexpected status %+v", j.Status)
+	}
+	j.Status.Failed = -1
+	if _, err := service.UpdateJobStatus(t.Context(), j); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a negative count, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package job
+
+import (
+	"fmt"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func validateJob(j api.Job) error {
+	if err := utils.ValidateObjectMeta(j.ObjectMeta); err != nil {
+		return err
+	}
+	return ValidateSpec(j.Spec)
+}
+
+// ValidateSpec checks a defaulted job spec, it's shared with cron jobs for their job template
+func ValidateSpec(spec api.JobSpec) error {
+	if *spec.Parallelism < 0 {
+		return fmt.Errorf("%w: parallelism can't be negative", utils.ErrInvalid)
+	}
+	if spec.Completions != nil && *spec.Completions < 0 {
+		return fmt.Errorf("%w: completions can't be negative", utils.ErrInvalid)
+	}
+	if *spec.BackoffLimit < 0 {
+		return fmt.Errorf("%w: backoffLimit can't be negative", utils.ErrInvalid)
+	}
+	if spec.ActiveDeadlineSeconds != nil && *spec.ActiveDeadlineSeconds <= 0 {
+		return fmt.Errorf("%w: activeDeadlineSeconds has to be positive", utils.ErrInvalid)
+	}
+	if spec.TTLSecondsAfterFinished != nil && *spec.TTLSecondsAfterFinished < 0 {
+		return fmt.Errorf("%w: ttlSecondsAfterFinished can't be negative", utils.ErrInvalid)
+	}
+	// a pod that restarts its containers forever never completes the job
+	if spec.Template.Spec.RestartPolicy == api.RestartPolicyAlways {
+		return fmt.Errorf("%w: job pods need restartPolicy OnFailure or Never", utils.ErrInvalid)
+	}
+	return pod.ValidateSpec(spec.Template.Spec)
+}
+
+func validateStatus(status api.JobStatus) error {
+	if status.Active < 0 || status.Succeeded < 0 || status.Failed < 0 {
+		return fmt.Errorf("%w: pod counts can't be negative", utils.ErrInvalid)
+	}
+	return nil
+}
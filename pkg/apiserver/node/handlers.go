@@ -0,0 +1,99 @@
+package node
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func (h *handler) GetNode(w http.ResponseWriter, r *http.Request) {
+	node, err := h.service.GetNode(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, node)
+}
+
+func (h *handler) ListNodes(w http.ResponseWriter, r *http.Request) {
+	nodes, err := h.service.ListNodes(r.Context())
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, nodes)
+}
+
+func (h *handler) CreateNode(w http.ResponseWriter, r *http.Request) {
+	var node api.Node
+	if err := utils.DecodeBody(r, &node); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	node, err := h.service.CreateNode(r.Context(), node)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusCreated, node)
+}
+
+func (h *handler) UpdateNode(w http.ResponseWriter, r *http.Request) {
+	var node api.Node
+	if err := utils.DecodeBody(r, &node); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&node, "", mux.Vars(r)["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	node, err := h.service.UpdateNode(r.Context(), node)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, node)
+}
+
+func (h *handler) UpdateNodeStatus(w http.ResponseWriter, r *http.Request) {
+	var node api.Node
+	if err := utils.DecodeBody(r, &node); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&node, "", mux.Vars(r)["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	node, err := h.service.UpdateNodeStatus(r.Context(), node)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, node)
+}
+
+// DeleteNode takes an optional ?resourceVersion= precondition
+func (h *handler) DeleteNode(w http.ResponseWriter, r *http.Request) {
+	opts := api.DeleteOptions{ResourceVersion: r.URL.Query().Get("resourceVersion")}
+	node, err := h.service.DeleteNode(r.Context(), mux.Vars(r)["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, node)
+}
+
+func NewHandler(service Service) handler {
+	return handler{
+		service: service,
+	}
+}
+
+type handler struct {
+	service Service
+}
@@ -0,0 +1,118 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+const resource = "nodes"
+
+type Service interface {
+	GetNode(ctx context.Context, name string) (api.Node, error)
+	ListNodes(ctx context.Context) (api.NodeList, error)
+	CreateNode(ctx context.Context, node api.Node) (api.Node, error)
+	UpdateNode(ctx context.Context, node api.Node) (api.Node, error)
+	UpdateNodeStatus(ctx context.Context, node api.Node) (api.Node, error)
+	DeleteNode(ctx context.Context, name string, opts api.DeleteOptions) (api.Node, error)
+}
+
+// NodeService persists nodes through storage.
+// Nodes aren't namespaced, they're keyed as "nodes//name".
+type NodeService struct {
+	store storage.Interface
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewService(store storage.Interface) *NodeService {
+	return &NodeService{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (s *NodeService) GetNode(ctx context.Context, name string) (api.Node, error) {
+	return utils.GetObject[api.Node](ctx, s.store, storage.Key(resource, "", name))
+}
+
+func (s *NodeService) ListNodes(ctx context.Context) (api.NodeList, error) {
+	items, rv, err := utils.ListObjects[api.Node](ctx, s.store, storage.Prefix(resource, ""))
+	if err != nil {
+		return api.NodeList{}, err
+	}
+	return api.NodeList{ResourceVersion: rv, Items: items}, nil
+}
+
+func (s *NodeService) CreateNode(ctx context.Context, node api.Node) (api.Node, error) {
+	if node.Name == "" {
+		return api.Node{}, fmt.Errorf("%w: node needs a name", utils.ErrInvalid)
+	}
+	utils.PrepareObjectMetaForCreate(&node.ObjectMeta, s.now())
+	node.Namespace = ""
+	setDefaults(&node)
+	if err := validateNode(node); err != nil {
+		return api.Node{}, err
+	}
+	node, err := utils.CreateObject(ctx, s.store, storage.Key(resource, "", node.Name), node)
+	if err != nil {
+		return api.Node{}, err
+	}
+	slog.Info("Created Node", "node", node.Name)
+	return node, nil
+}
+
+// UpdateNode replaces the stored node's spec and metadata, status is left alone.
+// If node carries a resourceVersion the update is rejected with storage.ErrConflict unless it is still current.
+func (s *NodeService) UpdateNode(ctx context.Context, node api.Node) (api.Node, error) {
+	node, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, "", node.Name), node.ResourceVersion, func(old api.Node) (api.Node, error) {
+		updated := node
+		updated.Status = old.Status
+		setDefaults(&updated)
+		utils.PrepareObjectMetaForUpdate(&updated.ObjectMeta, old.ObjectMeta, !reflect.DeepEqual(updated.Spec, old.Spec))
+		return updated, validateNode(updated)
+	})
+	if err != nil {
+		return api.Node{}, err
+	}
+	slog.Debug("Updated Node", "node", node.Name)
+	return node, nil
+}
+
+// UpdateNodeStatus replaces only the status of the stored node, it's how the kubelet heartbeats.
+// node's resourceVersion is a precondition, same as UpdateNode.
+func (s *NodeService) UpdateNodeStatus(ctx context.Context, node api.Node) (api.Node, error) {
+	node, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, "", node.Name), node.ResourceVersion, func(old api.Node) (api.Node, error) {
+		old.Status = node.Status
+		setDefaults(&old)
+		return old, validateNode(old)
+	})
+	if err != nil {
+		return api.Node{}, err
+	}
+	slog.Debug("Updated Node status", "node", node.Name)
+	return node, nil
+}
+
+// DeleteNode removes the node right away, pods bound to it are left alone
+func (s *NodeService) DeleteNode(ctx context.Context, name string, opts api.DeleteOptions) (api.Node, error) {
+	n, err := utils.DeleteObject[api.Node](ctx, s.store, storage.Key(resource, "", name), opts)
+	if err != nil {
+		return api.Node{}, err
+	}
+	slog.Info("Deleted Node", "node", name)
+	return n, nil
+}
+
+// setDefaults makes everything a node has available to pods unless told otherwise
+func setDefaults(node *api.Node) {
+	if node.Status.Allocatable == (api.ResourceList{}) {
+		node.Status.Allocatable = node.Status.Capacity
+	}
+}
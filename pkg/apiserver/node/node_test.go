@@ -0,0 +1,159 @@
+package node
+
+import (
+	"errors"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func TestCreateNode(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	testCases := []struct {
+		name    string
+		node    api.Node
+		wantErr error
+	}{
+		{
+			name: "basic node",
+			node: api.Node{
+				ObjectMeta: api.ObjectMeta{Name: "node-1"},
+				Status:     api.NodeStatus{Capacity: api.ResourceList{MilliCPU: 2000, Memory: 4 << 30}},
+			},
+		},
+		{
+			name: "duplicate name",
+			node: api.Node{
+				ObjectMeta: api.ObjectMeta{Name: "node-1"},
+			},
+			wantErr: storage.ErrKeyExists,
+		},
+		{
+			name:    "no name",
+			node:    api.Node{},
+			wantErr: utils.ErrInvalid,
+		},
+		{
+			name: "bad taint effect",
+			node: api.Node{
+				ObjectMeta: api.ObjectMeta{Name: "node-2"},
+				Spec:       api.NodeSpec{Taints: []api.Taint{{Key: "gpu", Effect: "Sometimes"}}},
+			},
+			wantErr: utils.ErrInvalid,
+		},
+		{
+			name: "negative capacity",
+			node: api.Node{
+				ObjectMeta: api.ObjectMeta{Name: "node-3"},
+				Status:     api.NodeStatus{Capacity: api.ResourceList{MilliCPU: -1}},
+			},
+			wantErr: utils.ErrInvalid,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := service.CreateNode(t.Context(), tc.node)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n.Namespace != "" || n.Uid.String() == "" || n.ResourceVersion == "" {
+				t.Errorf("unexpected metadata: %+v", n.ObjectMeta)
+			}
+			if n.Status.Allocatable != n.Status.Capacity {
+				t.Errorf("allocatable %+v not defaulted to capacity %+v", n.Status.Allocatable, n.Status.Capacity)
+			}
+		})
+	}
+}
+
+func TestUpdateNode(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	created, err := service.CreateNode(t.Context(), api.Node{ObjectMeta: api.ObjectMeta{Name: "node-1"}})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	n := created
+	n.Spec.Unschedulable = true
+	n, err = service.UpdateNode(t.Context(), n)
+	if err != nil {
+		t.Fatalf("failed to update node: %v", err)
+	}
+	if !n.Spec.Unschedulable || n.Generation != 2 || n.Uid != created.Uid {
+		t.Errorf("unexpected node after update: %+v", n)
+	}
+	if _, err := service.UpdateNode(t.Context(), created); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict on stale update, got %v", err)
+	}
+	if _, err := service.UpdateNode(t.Context(), api.Node{ObjectMeta: api.ObjectMeta{Name: "missing"}}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestListAndDeleteNodes(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	for _, name := range []string{"node-b", "node-a"} {
+		if _, err := service.CreateNode(t.Context(), api.Node{ObjectMeta: api.ObjectMeta{Name: name}}); err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+	}
+	list, err := service.ListNodes(t.Context())
+	if err != nil {
+		t.Fatalf("failed to list nodes: %v", err)
+	}
+	if len(list.Items) != 2 || list.Items[0].Name != "node-a" {
+		t.Errorf("unexpected node list: %+v", list.Items)
+	}
+	if _, err := service.DeleteNode(t.Context(), "node-a", api.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete node: %v", err)
+	}
+	if _, err := service.GetNode(t.Context(), "node-a"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestUpdateNodeStatus(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	created, err := service.CreateNode(t.Context(), api.Node{
+		ObjectMeta: api.ObjectMeta{Name: "node-1"},
+		Status:     api.NodeStatus{Capacity: api.ResourceList{MilliCPU: 1000}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+
+	// status updates leave spec and metadata alone
+	n := created
+	n.Spec.Unschedulable = true
+	n.Labels = map[string]string{"a": "b"}
+	n.Status.Capacity.MilliCPU = 2000
+	n.Status.Conditions = []api.NodeCondition{{Type: api.NodeReady, Status: api.ConditionTrue}}
+	n, err = service.UpdateNodeStatus(t.Context(), n)
+	if err != nil {
+		t.Fatalf("failed to update node status: %v", err)
+	}
+	if n.Spec.Unschedulable || n.Labels != nil || n.Generation != created.Generation {
+		t.Errorf("status update changed more than the status: %+v", n)
+	}
+	if n.Status.Capacity.MilliCPU != 2000 || n.Status.GetCondition(api.NodeReady) == nil {
+		t.Errorf("status wasn't updated: %+v", n.Status)
+	}
+
+	// spec updates leave the status alone
+	n.Spec.Unschedulable = true
+	n.Status = api.NodeStatus{}
+	n, err = service.UpdateNode(t.Context(), n)
+	if err != nil {
+		t.Fatalf("failed to update node: %v", err)
+	}
+	if !n.Spec.Unschedulable || n.Status.Capacity.MilliCPU != 2000 {
+		t.Errorf("unexpected node after update: %+v", n)
+	}
+}
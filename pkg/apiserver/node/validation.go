@@ -0,0 +1,26 @@
+package node
+
+import (
+	"fmt"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func validateNode(n api.Node) error {
+	if err := utils.ValidateClusterObjectMeta(n.ObjectMeta); err != nil {
+		return err
+	}
+	for _, t := range n.Spec.Taints {
+		if t.Key == "" {
+			return fmt.Errorf("%w: taint needs a key", utils.ErrInvalid)
+		}
+		if err := utils.ValidateTaintEffect(t.Effect, false); err != nil {
+			return err
+		}
+	}
+	if err := utils.ValidateResourceList("capacity", n.Status.Capacity); err != nil {
+		return err
+	}
+	return utils.ValidateResourceList("allocatable", n.Status.Allocatable)
+}
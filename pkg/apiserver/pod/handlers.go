@@ -1,80 +1,269 @@
 package pod
 
 import (
-	"encoding/json"
-	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 
 	"superminikube/pkg/api"
 	"superminikube/pkg/apiserver/utils"
 )
 
 func (h *handler) GetPod(w http.ResponseWriter, r *http.Request) {
-	nodename := r.URL.Query().Get("nodename")
-	if nodename == "" {
-		http.Error(w, "nodename required", http.StatusBadRequest)
+	vars := mux.Vars(r)
+	pod, err := h.service.GetPod(r.Context(), vars["namespace"], vars["name"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, pod)
+}
+
+// ListPods lists pods in the namespace from the url, or every namespace if there is none.
+// ?nodename= narrows it down to the pods bound to that node, or the ones waiting on the scheduler if it's empty, like watch does.
+func (h *handler) ListPods(w http.ResponseWriter, r *http.Request) {
+	pods, err := h.service.ListPods(r.Context(), mux.Vars(r)["namespace"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	if query := r.URL.Query(); query.Has("nodename") {
+		pods.Items = slices.DeleteFunc(pods.Items, func(p api.Pod) bool { return p.Nodename != query.Get("nodename") })
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, pods)
+}
+
+func (h *handler) CreatePod(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	var pod api.Pod
+	if err := utils.DecodeBody(r, &pod); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	uid := r.URL.Query().Get("uid")
-	if uid == "" {
-		http.Error(w, "uid required", http.StatusBadRequest)
+	if pod.Namespace != "" && pod.Namespace != namespace {
+		http.Error(w, "pod namespace does not match url", http.StatusBadRequest)
 		return
 	}
-	// TODO: custom errors for potential cases (key not found or internal error)
-	pod, err := h.service.GetPodByUid(r.Context(), nodename, uid)
+	pod.Namespace = namespace
+	slog.Debug("request body", "body", pod)
+	pod, err := h.service.CreatePod(r.Context(), pod)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		utils.WriteError(w, err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(pod)
+	utils.WriteJSONResponse(w, http.StatusCreated, pod)
 }
 
-func (h *handler) ListPods(w http.ResponseWriter, r *http.Request) {
-	pods, err := h.service.ListAllNamespacePods(r.Context())
+func (h *handler) UpdatePod(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var pod api.Pod
+	if err := utils.DecodeBody(r, &pod); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&pod, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pod, err := h.service.UpdatePod(r.Context(), pod)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		utils.WriteError(w, err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(pods)
+	utils.WriteJSONResponse(w, http.StatusOK, pod)
 }
 
-func (h *handler) CreatePod(w http.ResponseWriter, r *http.Request) {
-	nodename := r.URL.Query().Get("nodename")
-	if nodename == "" {
-		http.Error(w, "nodename required", http.StatusBadRequest)
+// PatchPod picks the patch strategy from the request content type
+func (h *handler) PatchPod(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	patchType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if patchType != utils.MergePatchType && patchType != utils.StrategicMergePatchType {
+		http.Error(w, fmt.Sprintf("unsupported patch type %q", patchType), http.StatusUnsupportedMediaType)
 		return
 	}
 	defer r.Body.Close()
-	var spec api.PodSpec
-	err := json.NewDecoder(r.Body).Decode(&spec)
-	// TODO: better request body handling
-	if err != nil {
-		if errors.Is(err, io.EOF) {
-			http.Error(w, "Empty request body", http.StatusBadRequest)
-		} else {
-			http.Error(w, "Malformed request", http.StatusBadRequest)
-			slog.Error("failed to decode", "msg", err)
-		}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil || len(patch) == 0 {
+		http.Error(w, "Empty request body", http.StatusBadRequest)
 		return
 	}
-	slog.Debug("request body", "body", spec)
-	pod, err := h.service.CreatePod(r.Context(), nodename, spec)
+	pod, err := h.service.PatchPod(r.Context(), vars["namespace"], vars["name"], patchType, patch)
 	if err != nil {
-		http.Error(w, "Failed to process request", http.StatusInternalServerError)
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, pod)
+}
+
+// UpdatePodStatus takes the full pod but only its status is written
+func (h *handler) UpdatePodStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var pod api.Pod
+	if err := utils.DecodeBody(r, &pod); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&pod, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pod, err := h.service.UpdatePodStatus(r.Context(), pod)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, pod)
+}
+
+// BindPod assigns the pod in the url to the node named by the posted binding
+func (h *handler) BindPod(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var binding api.Binding
+	if err := utils.DecodeBody(r, &binding); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if (binding.Namespace != "" && binding.Namespace != vars["namespace"]) || (binding.Name != "" && binding.Name != vars["name"]) {
+		http.Error(w, "binding does not match url", http.StatusBadRequest)
+		return
+	}
+	binding.Namespace = vars["namespace"]
+	binding.Name = vars["name"]
+	pod, err := h.service.BindPod(r.Context(), binding)
+	if err != nil {
+		utils.WriteError(w, err)
 		return
 	}
-	// w.WriteHeader(http.StatusCreated)
-	// json.NewEncoder(w).Encode(pod)
 	utils.WriteJSONResponse(w, http.StatusCreated, pod)
 }
 
+// DeletePod reads DeleteOptions from the query,
+// ?gracePeriodSeconds= along with ?resourceVersion= and ?uid= preconditions
 func (h *handler) DeletePod(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("DELETE Pod\n"))
+	vars := mux.Vars(r)
+	opts, err := deleteOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pod, err := h.service.DeletePod(r.Context(), vars["namespace"], vars["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, pod)
+}
+
+// GetPodLog proxies to the kubelet of the pod's node, streaming the logs as they come.
+// PodLogOptions are read from the query.
+func (h *handler) GetPodLog(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	opts, err := api.ParsePodLogOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	loc, err := h.service.LogLocation(r.Context(), vars["namespace"], vars["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	proxyToKubelet(w, r, loc)
+}
+
+// GetPodExec proxies a command to the kubelet of the pod's node, the connection is upgraded to a WebSocket
+// carrying the command's streams. PodExecOptions are read from the query.
+func (h *handler) GetPodExec(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	opts, err := api.ParsePodExecOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(opts.Command) == 0 {
+		http.Error(w, "exec needs a command", http.StatusBadRequest)
+		return
+	}
+	loc, err := h.service.ExecLocation(r.Context(), vars["namespace"], vars["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	proxyToKubelet(w, r, loc)
+}
+
+// GetPodAttach proxies to the kubelet of the pod's node like GetPodExec, attaching to the container's process
+func (h *handler) GetPodAttach(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	opts, err := api.ParsePodExecOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	loc, err := h.service.AttachLocation(r.Context(), vars["namespace"], vars["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	proxyToKubelet(w, r, loc)
+}
+
+// GetPodPortForward proxies to the kubelet of the pod's node, the connection is upgraded to a WebSocket
+// carrying a stream for every connection forwarded to the pod's ports
+func (h *handler) GetPodPortForward(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	loc, err := h.service.PortForwardLocation(r.Context(), vars["namespace"], vars["name"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	proxyToKubelet(w, r, loc)
+}
+
+// proxyToKubelet passes the request on to loc, upgraded connections included
+func proxyToKubelet(w http.ResponseWriter, r *http.Request, loc *url.URL) {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL = loc
+			pr.Out.Host = loc.Host
+		},
+		// followed logs are passed on line by line
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			slog.Error("failed to reach kubelet", "url", loc, "error", err)
+			http.Error(w, "failed to reach the pod's kubelet", http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+func deleteOptions(r *http.Request) (api.DeleteOptions, error) {
+	q := r.URL.Query()
+	opts := api.DeleteOptions{ResourceVersion: q.Get("resourceVersion")}
+	if v := q.Get("gracePeriodSeconds"); v != "" {
+		grace, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return api.DeleteOptions{}, fmt.Errorf("invalid gracePeriodSeconds %q", v)
+		}
+		opts.GracePeriodSeconds = &grace
+	}
+	if v := q.Get("uid"); v != "" {
+		uid, err := uuid.Parse(v)
+		if err != nil {
+			return api.DeleteOptions{}, fmt.Errorf("invalid uid %q", v)
+		}
+		opts.Uid = uid
+	}
+	return opts, nil
 }
 
 func NewHandler(service Service) handler {
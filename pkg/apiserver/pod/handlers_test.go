@@ -0,0 +1,372 @@
+package pod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/node"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+	"superminikube/pkg/portforward"
+	"superminikube/pkg/remotecommand"
+)
+
+func newTestRouter(t *testing.T) *mux.Router {
+	store := storage.NewMemoryStore()
+	registerNodes(t, store, "node-1", "node-2")
+	h := NewHandler(NewService(store))
+	r := mux.NewRouter()
+	r.HandleFunc("/pods", h.ListPods).Methods(http.MethodGet)
+	r.HandleFunc("/pods/{namespace}", h.ListPods).Methods(http.MethodGet)
+	r.HandleFunc("/pods/{namespace}", h.CreatePod).Methods(http.MethodPost)
+	r.HandleFunc("/pods/{namespace}/{name}", h.GetPod).Methods(http.MethodGet)
+	r.HandleFunc("/pods/{namespace}/{name}", h.UpdatePod).Methods(http.MethodPut)
+	r.HandleFunc("/pods/{namespace}/{name}", h.PatchPod).Methods(http.MethodPatch)
+	r.HandleFunc("/pods/{namespace}/{name}", h.DeletePod).Methods(http.MethodDelete)
+	r.HandleFunc("/pods/{namespace}/{name}/binding", h.BindPod).Methods(http.MethodPost)
+	return r
+}
+
+func TestPodHandlers(t *testing.T) {
+	router := newTestRouter(t)
+	web := `{"metadata":{"name":"web"},"spec":{"containers":[{"image":"nginx"}]}}`
+	// steps run in order against the same store
+	steps := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		expected    int
+	}{
+		{"create", http.MethodPost, "/pods/default", "", web, http.StatusCreated},
+		{"create duplicate", http.MethodPost, "/pods/default", "", web, http.StatusConflict},
+		{"create malformed", http.MethodPost, "/pods/default", "", `{`, http.StatusBadRequest},
+		{"create empty body", http.MethodPost, "/pods/default", "", ``, http.StatusBadRequest},
+		{"create namespace mismatch", http.MethodPost, "/pods/default", "", `{"metadata":{"namespace":"other"}}`, http.StatusBadRequest},
+		{"create invalid", http.MethodPost, "/pods/default", "", `{"metadata":{"name":"empty"}}`, http.StatusUnprocessableEntity},
+		{"create unknown field", http.MethodPost, "/pods/default", "", `{"metadata":{"name":"typo"},"spec":{"containers":[{"image":"nginx"}]},"stauts":{}}`, http.StatusBadRequest},
+		{"create yaml", http.MethodPost, "/pods/default", "application/yaml", "apiVersion: v1\nkind: Pod\nmetadata:\n  name: cache\nspec:\n  containers:\n  - image: redis\n", http.StatusCreated},
+		{"create yaml duplicate key", http.MethodPost, "/pods/default", "application/yaml", "metadata:\n  name: a\n  name: b\n", http.StatusBadRequest},
+		{"create wrong kind", http.MethodPost, "/pods/default", "", `{"kind":"Node","metadata":{"name":"node"},"spec":{"containers":[{"image":"nginx"}]}}`, http.StatusBadRequest},
+		{"create wrong apiVersion", http.MethodPost, "/pods/default", "", `{"apiVersion":"v2","metadata":{"name":"v2"},"spec":{"containers":[{"image":"nginx"}]}}`, http.StatusBadRequest},
+		{"bind to unregistered node", http.MethodPost, "/pods/default/web/binding", "", `{"target":"node-9"}`, http.StatusUnprocessableEntity},
+		{"bind without target", http.MethodPost, "/pods/default/web/binding", "", `{}`, http.StatusUnprocessableEntity},
+		{"bind", http.MethodPost, "/pods/default/web/binding", "", `{"target":"node-1"}`, http.StatusCreated},
+		{"bind again", http.MethodPost, "/pods/default/web/binding", "", `{"target":"node-2"}`, http.StatusConflict},
+		{"bind mismatch", http.MethodPost, "/pods/default/web/binding", "", `{"metadata":{"name":"other"},"target":"node-1"}`, http.StatusBadRequest},
+		{"bind missing", http.MethodPost, "/pods/default/missing/binding", "", `{"target":"node-1"}`, http.StatusNotFound},
+		{"get", http.MethodGet, "/pods/default/web", "", "", http.StatusOK},
+		{"get missing", http.MethodGet, "/pods/default/missing", "", "", http.StatusNotFound},
+		{"list all", http.MethodGet, "/pods", "", "", http.StatusOK},
+		{"list namespace", http.MethodGet, "/pods/default", "", "", http.StatusOK},
+		{"update", http.MethodPut, "/pods/default/web", "", web, http.StatusOK},
+		{"update name mismatch", http.MethodPut, "/pods/default/other", "", web, http.StatusBadRequest},
+		{"update missing", http.MethodPut, "/pods/default/missing", "", `{"spec":{"containers":[{"image":"nginx"}]}}`, http.StatusNotFound},
+		{"merge patch", http.MethodPatch, "/pods/default/web", utils.MergePatchType, `{"metadata":{"labels":{"a":"b"}}}`, http.StatusOK},
+		{"strategic patch", http.MethodPatch, "/pods/default/web", utils.StrategicMergePatchType + "; charset=utf-8", `{"spec":{"containers":[{"name":"container-0","image":"nginx:2"}]}}`, http.StatusOK},
+		{"json patch unsupported", http.MethodPatch, "/pods/default/web", "application/json-patch+json", `[]`, http.StatusUnsupportedMediaType},
+		{"invalid patch", http.MethodPatch, "/pods/default/web", utils.MergePatchType, `{"spec":{"containers":null}}`, http.StatusUnprocessableEntity},
+		{"stale update", http.MethodPut, "/pods/default/web", "", `{"metadata":{"resourceVersion":"1"},"spec":{"containers":[{"image":"nginx"}]}}`, http.StatusConflict},
+		{"stale delete", http.MethodDelete, "/pods/default/web?resourceVersion=1", "", "", http.StatusConflict},
+		{"delete", http.MethodDelete, "/pods/default/web", "", "", http.StatusOK},
+		{"delete bad grace period", http.MethodDelete, "/pods/default/web?gracePeriodSeconds=soon", "", "", http.StatusBadRequest},
+		{"delete bad uid", http.MethodDelete, "/pods/default/web?uid=nope", "", "", http.StatusBadRequest},
+		{"confirm deletion", http.MethodDelete, "/pods/default/web?gracePeriodSeconds=0", "", "", http.StatusOK},
+		{"delete missing", http.MethodDelete, "/pods/default/web", "", "", http.StatusNotFound},
+	}
+	for _, s := range steps {
+		req := httptest.NewRequest(s.method, s.path, strings.NewReader(s.body))
+		if s.contentType != "" {
+			req.Header.Set("Content-Type", s.contentType)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != s.expected {
+			t.Errorf("%s: got status %d, expected %d: %s", s.name, rec.Code, s.expected, rec.Body.String())
+		}
+	}
+}
+
+func TestListPodsByNode(t *testing.T) {
+	router := newTestRouter(t)
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	for _, name := range []string{"web", "db"} {
+		if rec := serve(http.MethodPost, "/pods/default", `{"metadata":{"name":"`+name+`"},"spec":{"containers":[{"image":"nginx"}]}}`); rec.Code != http.StatusCreated {
+			t.Fatalf("failed to create %s: %s", name, rec.Body.String())
+		}
+	}
+	if rec := serve(http.MethodPost, "/pods/default/web/binding", `{"target":"node-1"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("failed to bind web: %s", rec.Body.String())
+	}
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{query: "", expected: []string{"db", "web"}},
+		{query: "?nodename=node-1", expected: []string{"web"}},
+		{query: "?nodename=node-2", expected: nil},
+		// like watch, an empty nodename is the pods waiting on the scheduler
+		{query: "?nodename=", expected: []string{"db"}},
+	}
+	for _, tc := range testCases {
+		rec := serve(http.MethodGet, "/pods"+tc.query, "")
+		var list api.PodList
+		if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+			t.Fatalf("%q: failed to decode %s: %v", tc.query, rec.Body.String(), err)
+		}
+		var got []string
+		for _, p := range list.Items {
+			got = append(got, p.Name)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tc.expected) {
+			t.Errorf("%q: got %v, expected %v", tc.query, got, tc.expected)
+		}
+	}
+}
+
+func registerNodes(t *testing.T, store storage.Interface, names ...string) {
+	t.Helper()
+	nodes := node.NewService(store)
+	for _, name := range names {
+		if _, err := nodes.CreateNode(t.Context(), api.Node{ObjectMeta: api.ObjectMeta{Name: name}}); err != nil {
+			t.Fatalf("failed to register node %s: %v", name, err)
+		}
+	}
+}
+
+func TestGetPodLog(t *testing.T) {
+	// stands in for the kubelet, echoing what it was asked for
+	kubelet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s?%s\n", r.URL.Path, r.URL.RawQuery)
+	}))
+	defer kubelet.Close()
+	host, rawPort, _ := net.SplitHostPort(strings.TrimPrefix(kubelet.URL, "http://"))
+	port, _ := strconv.Atoi(rawPort)
+
+	store := storage.NewMemoryStore()
+	nodes := node.NewService(store)
+	_, err := nodes.CreateNode(t.Context(), api.Node{
+		ObjectMeta: api.ObjectMeta{Name: "node-1"},
+		Status: api.NodeStatus{
+			Addresses:       []api.NodeAddress{{Type: api.NodeHostName, Address: "node-1"}, {Type: api.NodeInternalIP, Address: host}},
+			DaemonEndpoints: api.NodeDaemonEndpoints{KubeletEndpoint: api.DaemonEndpoint{Port: int32(port)}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register node: %v", err)
+	}
+	pods := NewService(store)
+	for _, p := range []api.Pod{
+		{ObjectMeta: api.ObjectMeta{Name: "web"}, Nodename: "node-1", Spec: api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}}},
+		{ObjectMeta: api.ObjectMeta{Name: "pending"}, Spec: api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}}},
+		{ObjectMeta: api.ObjectMeta{Name: "sidecar"}, Nodename: "node-1", Spec: api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}, {Name: "proxy", Image: "envoy"}}}},
+	} {
+		if _, err := pods.CreatePod(t.Context(), p); err != nil {
+			t.Fatalf("failed to create pod: %v", err)
+		}
+	}
+	h := NewHandler(pods)
+	r := mux.NewRouter()
+	r.HandleFunc("/pods/{namespace}/{name}/log", h.GetPodLog).Methods(http.MethodGet)
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected int
+		body     string
+	}{
+		{
+			name:     "only container",
+			path:     "/pods/default/web/log?tailLines=3&follow=true",
+			expected: http.StatusOK,
+			body:     "/containerLogs/default/web/app?follow=true&tailLines=3\n",
+		},
+		{
+			name:     "named container",
+			path:     "/pods/default/sidecar/log?container=proxy&timestamps=1",
+			expected: http.StatusOK,
+			body:     "/containerLogs/default/sidecar/proxy?timestamps=true\n",
+		},
+		{name: "container has to be picked", path: "/pods/default/sidecar/log", expected: http.StatusUnprocessableEntity},
+		{name: "unknown container", path: "/pods/default/web/log?container=db", expected: http.StatusUnprocessableEntity},
+		{name: "unscheduled pod", path: "/pods/default/pending/log", expected: http.StatusUnprocessableEntity},
+		{name: "missing pod", path: "/pods/default/missing/log", expected: http.StatusNotFound},
+		{name: "bad since", path: "/pods/default/web/log?sinceSeconds=0", expected: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rec.Code != tc.expected {
+				t.Fatalf("got status %d, expected %d: %s", rec.Code, tc.expected, rec.Body.String())
+			}
+			if tc.body != "" && rec.Body.String() != tc.body {
+				t.Errorf("got %q, expected %q", rec.Body.String(), tc.body)
+			}
+		})
+	}
+}
+
+func TestGetPodExec(t *testing.T) {
+	// stands in for the kubelet, writing what it was asked for on stdout
+	kubelet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts, err := api.ParsePodExecOptions(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		remotecommand.Serve(w, r, opts, func(ctx context.Context, streams remotecommand.Streams) (int, error) {
+			fmt.Fprintf(streams.Stdout, "%s?%s\n", r.URL.Path, r.URL.RawQuery)
+			return 0, nil
+		})
+	}))
+	defer kubelet.Close()
+	host, rawPort, _ := net.SplitHostPort(strings.TrimPrefix(kubelet.URL, "http://"))
+	port, _ := strconv.Atoi(rawPort)
+
+	store := storage.NewMemoryStore()
+	nodes := node.NewService(store)
+	_, err := nodes.CreateNode(t.Context(), api.Node{
+		ObjectMeta: api.ObjectMeta{Name: "node-1"},
+		Status: api.NodeStatus{
+			Addresses:       []api.NodeAddress{{Type: api.NodeInternalIP, Address: host}},
+			DaemonEndpoints: api.NodeDaemonEndpoints{KubeletEndpoint: api.DaemonEndpoint{Port: int32(port)}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register node: %v", err)
+	}
+	pods := NewService(store)
+	for _, p := range []api.Pod{
+		{ObjectMeta: api.ObjectMeta{Name: "web"}, Nodename: "node-1", Spec: api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}}},
+		{ObjectMeta: api.ObjectMeta{Name: "sidecar"}, Nodename: "node-1", Spec: api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}, {Name: "proxy", Image: "envoy"}}}},
+	} {
+		if _, err := pods.CreatePod(t.Context(), p); err != nil {
+			t.Fatalf("failed to create pod: %v", err)
+		}
+	}
+	h := NewHandler(pods)
+	r := mux.NewRouter()
+	r.HandleFunc("/pods/{namespace}/{name}/exec", h.GetPodExec).Methods(http.MethodGet)
+	r.HandleFunc("/pods/{namespace}/{name}/attach", h.GetPodAttach).Methods(http.MethodGet)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	testCases := []struct {
+		name string
+		path string
+		// what the kubelet was asked for, or the status the request fails with
+		body   string
+		status int
+	}{
+		{
+			name: "exec",
+			path: "/pods/default/web/exec?command=ls&command=-l&stdout=true",
+			body: "/exec/default/web/app?command=ls&command=-l&stdout=true\n",
+		},
+		{
+			name: "attach",
+			path: "/pods/default/sidecar/attach?container=proxy&stdout=true&command=ignored",
+			body: "/attach/default/sidecar/proxy?stdout=true\n",
+		},
+		{name: "no command", path: "/pods/default/web/exec?stdout=true", status: http.StatusBadRequest},
+		{name: "container has to be picked", path: "/pods/default/sidecar/exec?command=ls&stdout=true", status: http.StatusUnprocessableEntity},
+		{name: "missing pod", path: "/pods/default/missing/exec?command=ls&stdout=true", status: http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, _ := url.Parse(srv.URL + tc.path)
+			var stdout strings.Builder
+			_, err := remotecommand.Stream(t.Context(), u, nil, remotecommand.Streams{Stdout: &stdout})
+			if tc.status != 0 {
+				if err == nil || !strings.Contains(err.Error(), strconv.Itoa(tc.status)) {
+					t.Errorf("got %v, expected status %d", err, tc.status)
+				}
+				return
+			}
+			if err != nil || stdout.String() != tc.body {
+				t.Errorf("got %q, %v, expected %q", stdout.String(), err, tc.body)
+			}
+		})
+	}
+}
+
+func TestGetPodPortForward(t *testing.T) {
+	// stands in for the kubelet, answering every connection with the path it was asked for
+	kubelet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		portforward.Serve(w, r, func(ctx context.Context, port uint16, stream portforward.Stream) error {
+			fmt.Fprintf(stream, "%s:%d", r.URL.Path, port)
+			return stream.CloseWrite()
+		})
+	}))
+	defer kubelet.Close()
+	host, rawPort, _ := net.SplitHostPort(strings.TrimPrefix(kubelet.URL, "http://"))
+	port, _ := strconv.Atoi(rawPort)
+
+	store := storage.NewMemoryStore()
+	_, err := node.NewService(store).CreateNode(t.Context(), api.Node{
+		ObjectMeta: api.ObjectMeta{Name: "node-1"},
+		Status: api.NodeStatus{
+			Addresses:       []api.NodeAddress{{Type: api.NodeInternalIP, Address: host}},
+			DaemonEndpoints: api.NodeDaemonEndpoints{KubeletEndpoint: api.DaemonEndpoint{Port: int32(port)}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register node: %v", err)
+	}
+	pods := NewService(store)
+	for _, p := range []api.Pod{
+		{ObjectMeta: api.ObjectMeta{Name: "web"}, Nodename: "node-1", Spec: api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}}},
+		{ObjectMeta: api.ObjectMeta{Name: "pending"}, Spec: api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}}},
+	} {
+		if _, err := pods.CreatePod(t.Context(), p); err != nil {
+			t.Fatalf("failed to create pod: %v", err)
+		}
+	}
+	h := NewHandler(pods)
+	r := mux.NewRouter()
+	r.HandleFunc("/pods/{namespace}/{name}/portforward", h.GetPodPortForward).Methods(http.MethodGet)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL + "/pods/default/web/portforward")
+	f, err := portforward.Forward(t.Context(), u, nil, []portforward.Port{{Remote: 80}})
+	if err != nil {
+		t.Fatalf("failed to forward: %v", err)
+	}
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", f.Ports()[0].Local))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	if got, _ := io.ReadAll(conn); string(got) != "/portForward/default/web:80" {
+		t.Errorf("got %q", got)
+	}
+
+	for path, status := range map[string]int{"/pods/default/pending/portforward": http.StatusUnprocessableEntity, "/pods/default/missing/portforward": http.StatusNotFound} {
+		u, _ := url.Parse(srv.URL + path)
+		if _, err := portforward.Forward(t.Context(), u, nil, nil); err == nil || !strings.Contains(err.Error(), strconv.Itoa(status)) {
+			t.Errorf("%s: got %v, expected status %d", path, err, status)
+		}
+	}
+}
@@ -1,94 +1,435 @@
 package pod
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 
 	"superminikube/pkg/api"
-	"superminikube/pkg/apiserver/watch"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
 )
 
+const resource = "pods"
+
+// seconds a pod gets to shut down when it doesn't set TerminationGracePeriodSeconds
+const defaultTerminationGracePeriodSeconds = 30
+
+// lists in a pod merged element by element on strategic merge patches, keyed by field name
+var patchMergeKeys = map[string]string{
+	"containers":     "name",
+	"initContainers": "name",
+}
+
 type Service interface {
-	GetPodByUid(ctx context.Context, nodename, uid string) (api.Pod, error)
-	ListAllNamespacePods(ctx context.Context) ([]api.Pod, error)
-	CreatePod(ctx context.Context, nodename string, spec api.PodSpec) (api.Pod, error)
+	GetPod(ctx context.Context, namespace, name string) (api.Pod, error)
+	// ListPods lists pods in namespace, an empty namespace lists every namespace
+	ListPods(ctx context.Context, namespace string) (api.PodList, error)
+	// CreatePod stores a new pod, pods without a node are left for the scheduler to bind
+	CreatePod(ctx context.Context, pod api.Pod) (api.Pod, error)
+	UpdatePod(ctx context.Context, pod api.Pod) (api.Pod, error)
+	PatchPod(ctx context.Context, namespace, name, patchType string, patch []byte) (api.Pod, error)
+	UpdatePodStatus(ctx context.Context, pod api.Pod) (api.Pod, error)
+	BindPod(ctx context.Context, binding api.Binding) (api.Pod, error)
+	DeletePod(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.Pod, error)
+	// LogLocation is where the kubelet of the pod's node serves the logs opts ask for
+	LogLocation(ctx context.Context, namespace, name string, opts api.PodLogOptions) (*url.URL, error)
+	// ExecLocation is where the kubelet of the pod's node runs the command opts ask for
+	ExecLocation(ctx context.Context, namespace, name string, opts api.PodExecOptions) (*url.URL, error)
+	// AttachLocation is where the kubelet of the pod's node attaches to the container opts name
+	AttachLocation(ctx context.Context, namespace, name string, opts api.PodExecOptions) (*url.URL, error)
+	// PortForwardLocation is where the kubelet of the pod's node forwards connections to the pod's ports
+	PortForwardLocation(ctx context.Context, namespace, name string) (*url.URL, error)
 }
 
+// PodService persists pods through storage.
+// Watchers pick up changes from the store directly so nothing needs notifying here.
 type PodService struct {
-	kvstore      *redis.Client
-	watchService *watch.WatchService
+	store storage.Interface
+	// swapped out in tests
+	now func() time.Time
 }
 
-func NewService(kvstore *redis.Client, watchService *watch.WatchService) *PodService {
+func NewService(store storage.Interface) *PodService {
 	return &PodService{
-		kvstore:      kvstore,
-		watchService: watchService,
+		store: store,
+		now:   time.Now,
 	}
 }
 
-// TODO: Implement update and append when the need arises
+func (s *PodService) ListPods(ctx context.Context, namespace string) (api.PodList, error) {
+	items, rv, err := utils.ListObjects[api.Pod](ctx, s.store, storage.Prefix(resource, namespace))
+	if err != nil {
+		return api.PodList{}, err
+	}
+	return api.PodList{ResourceVersion: rv, Items: items}, nil
+}
 
-func (s *PodService) ListAllNamespacePods(ctx context.Context) ([]api.Pod, error) {
-	// TODO
-	return nil, nil
+func (s *PodService) GetPod(ctx context.Context, namespace, name string) (api.Pod, error) {
+	slog.Info("Getting Pod", "namespace", namespace, "name", name)
+	return utils.GetObject[api.Pod](ctx, s.store, storage.Key(resource, namespace, name))
 }
 
-// write tests for crud (get/set/create/delete) of Pod objects via the apiserver
-// implement redis store once basic pod lifecycle flow is working
-func (s *PodService) GetPodByUid(ctx context.Context, nodename, uid string) (api.Pod, error) {
-	// TODO: Refactor need to get the pod by /resource/nodename/uid
-	slog.Info(fmt.Sprintf("Getting Pod with UID: %s", uid))
-	pod, err := s.kvstore.Get(ctx, fmt.Sprintf("pods/%s/%s", nodename, uid)).Result()
+// NOTE: Return type could be of type CreatePodResponse in the future
+func (s *PodService) CreatePod(ctx context.Context, pod api.Pod) (api.Pod, error) {
+	utils.PrepareObjectMetaForCreate(&pod.ObjectMeta, s.now())
+	setDefaults(&pod)
+	if err := validatePod(pod); err != nil {
+		return api.Pod{}, err
+	}
+	// status belongs to the kubelet, it starts out pending
+	pod.Status = api.PodStatus{Phase: api.PodPending}
+	// create fails if the key is taken so name collisions can't overwrite a pod
+	pod, err := utils.CreateObject(ctx, s.store, storage.Key(resource, pod.Namespace, pod.Name), pod)
 	if err != nil {
-		return api.Pod{}, fmt.Errorf("failed to get pod from store: %v", err)
+		return api.Pod{}, err
 	}
-	var p api.Pod
-	decoder := gob.NewDecoder(bytes.NewReader([]byte(pod)))
-	err = decoder.Decode(&p)
+	slog.Info("Created Pod", "pod", pod)
+	return pod, nil
+}
+
+// UpdatePod replaces the stored pod matching pod's namespace and name.
+// Clearing the last finalizer of a deleted pod removes it from storage.
+// If pod carries a resourceVersion the update is rejected with storage.ErrConflict unless it is still current.
+// Status is left alone, it can only be changed through UpdatePodStatus.
+func (s *PodService) UpdatePod(ctx context.Context, pod api.Pod) (api.Pod, error) {
+	pod, err := s.updatePod(ctx, pod.Namespace, pod.Name, pod.ResourceVersion, func(old api.Pod) (api.Pod, error) {
+		return prepareForUpdate(pod, old)
+	})
 	if err != nil {
-		return api.Pod{}, fmt.Errorf("failed to decode pod: %v", err)
+		return api.Pod{}, err
 	}
-	return p, nil
+	slog.Info("Updated Pod", "pod", pod)
+	return s.finalize(ctx, pod)
 }
 
-// NOTE: Return type could be of type CreatePodResponse in the future
-// TODO: nodename will not be a parameter here, scheduler will decide where pod goes
-func (s *PodService) CreatePod(ctx context.Context, nodename string, spec api.PodSpec) (api.Pod, error) {
-	var buf bytes.Buffer
-	// TODO: make sure uuid is unique
-	pod := api.Pod{
-		Uid:      uuid.New(),
-		Nodename: nodename,
-		Spec:     spec,
-	}
-	encoder := gob.NewEncoder(&buf)
-	err := encoder.Encode(pod)
+// PatchPod applies a merge or strategic merge patch to the stored pod and saves the result.
+// A patch can set metadata.resourceVersion to only apply against that version.
+func (s *PodService) PatchPod(ctx context.Context, namespace, name, patchType string, patch []byte) (api.Pod, error) {
+	if patchType != utils.MergePatchType && patchType != utils.StrategicMergePatchType {
+		return api.Pod{}, fmt.Errorf("unsupported patch type %q", patchType)
+	}
+	pod, err := s.updatePod(ctx, namespace, name, "", func(old api.Pod) (api.Pod, error) {
+		original, err := json.Marshal(old)
+		if err != nil {
+			return api.Pod{}, fmt.Errorf("failed to encode pod: %v", err)
+		}
+		var patched []byte
+		if patchType == utils.MergePatchType {
+			patched, err = utils.MergePatch(original, patch)
+		} else {
+			patched, err = utils.StrategicMergePatch(original, patch, patchMergeKeys)
+		}
+		if err != nil {
+			return api.Pod{}, fmt.Errorf("%w: %v", utils.ErrInvalid, err)
+		}
+		var pod api.Pod
+		if err := json.Unmarshal(patched, &pod); err != nil {
+			return api.Pod{}, fmt.Errorf("%w: patched pod can't be decoded: %v", utils.ErrInvalid, err)
+		}
+		if pod.Name != name || pod.Namespace != namespace {
+			return api.Pod{}, fmt.Errorf("%w: name and namespace can't be patched", utils.ErrInvalid)
+		}
+		if pod.ResourceVersion != old.ResourceVersion {
+			return api.Pod{}, fmt.Errorf("%w: pod %s/%s is at resourceVersion %s, not %s", storage.ErrConflict, namespace, name, old.ResourceVersion, pod.ResourceVersion)
+		}
+		return prepareForUpdate(pod, old)
+	})
 	if err != nil {
-		return api.Pod{}, fmt.Errorf("failed to encode pod: %v", err)
+		return api.Pod{}, err
 	}
-	// flatten key into "resource/nodename/identifier"
-	err = s.kvstore.Set(ctx, fmt.Sprintf("pods/%s/%s", nodename, pod.Uid.String()), buf.Bytes(), 0).Err()
+	slog.Info("Patched Pod", "pod", pod)
+	return s.finalize(ctx, pod)
+}
+
+// UpdatePodStatus replaces only the status of the stored pod, spec and metadata are untouched.
+// pod's resourceVersion is a precondition, same as UpdatePod.
+func (s *PodService) UpdatePodStatus(ctx context.Context, pod api.Pod) (api.Pod, error) {
+	pod, err := s.updatePod(ctx, pod.Namespace, pod.Name, pod.ResourceVersion, func(old api.Pod) (api.Pod, error) {
+		old.Status = pod.Status
+		return old, nil
+	})
 	if err != nil {
-		return api.Pod{}, fmt.Errorf("failed to store pod: %v", err)
+		return api.Pod{}, err
 	}
-	slog.Info("Created Pod", "pod", pod)
+	slog.Debug("Updated Pod status", "pod", pod.Name, "phase", pod.Status.Phase)
+	return pod, nil
+}
 
-	// Notify watch service
-	err = s.watchService.Notify(watch.WatchEvent{
-		EventType: watch.Add,
-		Resource:  "pod",
-		Node:      nodename,
-		Pod:       pod,
+// BindPod assigns a pod to the binding's target node and marks it PodScheduled. A pod is only ever bound once,
+// binding one that already has a node or is being deleted fails with storage.ErrConflict,
+// binding to a node that hasn't registered fails with utils.ErrInvalid.
+func (s *PodService) BindPod(ctx context.Context, binding api.Binding) (api.Pod, error) {
+	if binding.Target == "" {
+		return api.Pod{}, fmt.Errorf("%w: binding needs a target node", utils.ErrInvalid)
+	}
+	// nodes live in the same store, keyed by the node service as "nodes//name"
+	_, err := s.store.Get(ctx, storage.Key("nodes", "", binding.Target))
+	if errors.Is(err, storage.ErrNotFound) {
+		return api.Pod{}, fmt.Errorf("%w: node %s is not registered", utils.ErrInvalid, binding.Target)
+	}
+	if err != nil {
+		return api.Pod{}, fmt.Errorf("failed to get node from store: %w", err)
+	}
+	pod, err := s.updatePod(ctx, binding.Namespace, binding.Name, binding.ResourceVersion, func(old api.Pod) (api.Pod, error) {
+		if binding.Uid != uuid.Nil && binding.Uid != old.Uid {
+			return api.Pod{}, fmt.Errorf("%w: pod %s/%s has uid %s, not %s", storage.ErrConflict, old.Namespace, old.Name, old.Uid, binding.Uid)
+		}
+		if old.Nodename != "" {
+			return api.Pod{}, fmt.Errorf("%w: pod %s/%s is already bound to %s", storage.ErrConflict, old.Namespace, old.Name, old.Nodename)
+		}
+		if old.DeletionTimestamp != nil {
+			return api.Pod{}, fmt.Errorf("%w: pod %s/%s is being deleted", storage.ErrConflict, old.Namespace, old.Name)
+		}
+		old.Nodename = binding.Target
+		old.Status.SetCondition(api.PodCondition{Type: api.PodScheduled, Status: api.ConditionTrue}, s.now().UTC())
+		return old, nil
 	})
 	if err != nil {
-		slog.Warn("failed to notify watcher", "error", err)
+		return api.Pod{}, err
+	}
+	slog.Info("Bound Pod", "namespace", pod.Namespace, "name", pod.Name, "node", pod.Nodename)
+	return pod, nil
+}
+
+// LogLocation points at the logs of the container opts name on the kubelet of the pod's node
+func (s *PodService) LogLocation(ctx context.Context, namespace, name string, opts api.PodLogOptions) (*url.URL, error) {
+	loc, err := s.containerLocation(ctx, namespace, name, opts.Container, "containerLogs")
+	if err != nil {
+		return nil, err
 	}
+	opts.Container = ""
+	loc.RawQuery = opts.Query().Encode()
+	return loc, nil
+}
 
+// ExecLocation points at where the kubelet of the pod's node runs commands in the container opts name
+func (s *PodService) ExecLocation(ctx context.Context, namespace, name string, opts api.PodExecOptions) (*url.URL, error) {
+	loc, err := s.containerLocation(ctx, namespace, name, opts.Container, "exec")
+	if err != nil {
+		return nil, err
+	}
+	opts.Container = ""
+	loc.RawQuery = opts.Query().Encode()
+	return loc, nil
+}
+
+// AttachLocation points at where the kubelet of the pod's node attaches to the container opts name
+func (s *PodService) AttachLocation(ctx context.Context, namespace, name string, opts api.PodExecOptions) (*url.URL, error) {
+	loc, err := s.containerLocation(ctx, namespace, name, opts.Container, "attach")
+	if err != nil {
+		return nil, err
+	}
+	opts.Container, opts.Command = "", nil
+	loc.RawQuery = opts.Query().Encode()
+	return loc, nil
+}
+
+// PortForwardLocation points at where the kubelet of the pod's node forwards connections to the pod's ports
+func (s *PodService) PortForwardLocation(ctx context.Context, namespace, name string) (*url.URL, error) {
+	pod, err := s.GetPod(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.kubeletLocation(ctx, pod, fmt.Sprintf("/portForward/%s/%s", namespace, name))
+}
+
+// containerLocation picks the container, or the pod's only container when none is named,
+// and points at the kubelet endpoint for it on the pod's node
+func (s *PodService) containerLocation(ctx context.Context, namespace, name, container, endpoint string) (*url.URL, error) {
+	pod, err := s.GetPod(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if container == "" {
+		if len(pod.Spec.Containers) != 1 {
+			return nil, fmt.Errorf("%w: pod %s/%s has %d containers, one has to be picked", utils.ErrInvalid, namespace, name, len(pod.Spec.Containers))
+		}
+		container = pod.Spec.Containers[0].Name
+	}
+	all := append(slices.Clone(pod.Spec.InitContainers), pod.Spec.Containers...)
+	if !slices.ContainsFunc(all, func(c api.Container) bool { return c.Name == container }) {
+		return nil, fmt.Errorf("%w: pod %s/%s has no container %s", utils.ErrInvalid, namespace, name, container)
+	}
+	return s.kubeletLocation(ctx, pod, fmt.Sprintf("/%s/%s/%s/%s", endpoint, namespace, name, container))
+}
+
+// kubeletLocation points at path on the kubelet of the pod's node
+func (s *PodService) kubeletLocation(ctx context.Context, pod api.Pod, path string) (*url.URL, error) {
+	if pod.Nodename == "" {
+		return nil, fmt.Errorf("%w: pod %s/%s isn't bound to a node yet", utils.ErrInvalid, pod.Namespace, pod.Name)
+	}
+	node, err := utils.GetObject[api.Node](ctx, s.store, storage.Key("nodes", "", pod.Nodename))
+	if err != nil {
+		return nil, err
+	}
+	host := nodeHost(node)
+	port := node.Status.DaemonEndpoints.KubeletEndpoint.Port
+	if host == "" || port == 0 {
+		return nil, fmt.Errorf("node %s hasn't reported where its kubelet listens", node.Name)
+	}
+	return &url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(host, strconv.Itoa(int(port))),
+		Path:   path,
+	}, nil
+}
+
+// nodeHost is the node's internal IP, or its hostname if it didn't report one
+func nodeHost(node api.Node) string {
+	host := ""
+	for _, a := range node.Status.Addresses {
+		switch {
+		case a.Type == api.NodeInternalIP:
+			return a.Address
+		case a.Type == api.NodeHostName && host == "":
+			host = a.Address
+		}
+	}
+	return host
+}
+
+// DeletePod starts a graceful deletion. The pod gets a deletionTimestamp and grace period
+// and stays in storage until its kubelet confirms the containers are gone, by deleting it again
+// with a grace period of 0, and every finalizer has been cleared.
+// Pods that were never bound to a node have nothing to wait on and go right away.
+// The returned pod is either still terminating or as it was last stored.
+func (s *PodService) DeletePod(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.Pod, error) {
+	if opts.GracePeriodSeconds != nil && *opts.GracePeriodSeconds < 0 {
+		return api.Pod{}, fmt.Errorf("%w: gracePeriodSeconds can't be negative", utils.ErrInvalid)
+	}
+	pod, err := s.updatePod(ctx, namespace, name, opts.ResourceVersion, func(old api.Pod) (api.Pod, error) {
+		if opts.Uid != uuid.Nil && opts.Uid != old.Uid {
+			return api.Pod{}, fmt.Errorf("%w: pod %s/%s has uid %s, not %s", storage.ErrConflict, namespace, name, old.Uid, opts.Uid)
+		}
+		grace := int64(defaultTerminationGracePeriodSeconds)
+		if old.Spec.TerminationGracePeriodSeconds != nil {
+			grace = *old.Spec.TerminationGracePeriodSeconds
+		}
+		if opts.GracePeriodSeconds != nil {
+			grace = *opts.GracePeriodSeconds
+		}
+		if old.Nodename == "" {
+			grace = 0
+		}
+		// deleting again can only shorten the grace period
+		if old.DeletionGracePeriodSeconds != nil && *old.DeletionGracePeriodSeconds <= grace {
+			return old, nil
+		}
+		if old.DeletionTimestamp == nil {
+			now := s.now().UTC()
+			old.DeletionTimestamp = &now
+		}
+		old.DeletionGracePeriodSeconds = &grace
+		return old, nil
+	})
+	if err != nil {
+		return api.Pod{}, err
+	}
+	slog.Info("Deleting Pod", "namespace", namespace, "name", name, "gracePeriodSeconds", *pod.DeletionGracePeriodSeconds)
+	return s.finalize(ctx, pod)
+}
+
+// finalize removes a deleted pod from storage once its grace period is over and no finalizers are left.
+// Otherwise pod is returned as is.
+func (s *PodService) finalize(ctx context.Context, pod api.Pod) (api.Pod, error) {
+	if pod.DeletionTimestamp == nil || len(pod.Finalizers) > 0 ||
+		(pod.DeletionGracePeriodSeconds != nil && *pod.DeletionGracePeriodSeconds > 0) {
+		return pod, nil
+	}
+	p, err := utils.DeleteObject[api.Pod](ctx, s.store, storage.Key(resource, pod.Namespace, pod.Name), api.DeleteOptions{ResourceVersion: pod.ResourceVersion})
+	// whoever wrote the pod since then finalizes it themselves
+	if errors.Is(err, storage.ErrConflict) || errors.Is(err, storage.ErrNotFound) {
+		return pod, nil
+	}
+	if err != nil {
+		return api.Pod{}, err
+	}
+	slog.Info("Deleted Pod", "namespace", pod.Namespace, "name", pod.Name)
+	return p, nil
+}
+
+// updatePod is utils.UpdateObject for the pod at namespace/name
+func (s *PodService) updatePod(ctx context.Context, namespace, name, resourceVersion string, tryUpdate func(old api.Pod) (api.Pod, error)) (api.Pod, error) {
+	return utils.UpdateObject(ctx, s.store, storage.Key(resource, namespace, name), resourceVersion, tryUpdate)
+}
+
+// prepareForUpdate carries status, node and server owned metadata over from old and validates the result.
+// Pods can't move between nodes, the node can only be set through BindPod.
+func prepareForUpdate(pod, old api.Pod) (api.Pod, error) {
+	pod.Status = old.Status
+	pod.Nodename = old.Nodename
+	setDefaults(&pod)
+	utils.PrepareObjectMetaForUpdate(&pod.ObjectMeta, old.ObjectMeta, !reflect.DeepEqual(pod.Spec, old.Spec))
+	if err := validatePod(pod); err != nil {
+		return api.Pod{}, err
+	}
 	return pod, nil
 }
+
+func setDefaults(pod *api.Pod) {
+	SetSpecDefaults(&pod.Spec)
+}
+
+// SetSpecDefaults fills in what a pod spec leaves out: the termination grace period, an Always restart policy,
+// probe timings, thresholds and HTTP path and scheme, and names for unnamed containers after their position.
+// The kubelet relies on names to tell containers apart. Pod templates of the other kinds are defaulted with it too.
+func SetSpecDefaults(spec *api.PodSpec) {
+	if spec.TerminationGracePeriodSeconds == nil {
+		grace := int64(defaultTerminationGracePeriodSeconds)
+		spec.TerminationGracePeriodSeconds = &grace
+	}
+	if spec.RestartPolicy == "" {
+		spec.RestartPolicy = api.RestartPolicyAlways
+	}
+	for i := range spec.InitContainers {
+		if spec.InitContainers[i].Name == "" {
+			spec.InitContainers[i].Name = fmt.Sprintf("init-%d", i)
+		}
+	}
+	for i := range spec.Containers {
+		c := &spec.Containers[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("container-%d", i)
+		}
+		for _, probe := range []*api.Probe{c.LivenessProbe, c.ReadinessProbe, c.StartupProbe} {
+			setProbeDefaults(probe)
+		}
+	}
+}
+
+func setProbeDefaults(probe *api.Probe) {
+	if probe == nil {
+		return
+	}
+	if probe.TimeoutSeconds == 0 {
+		probe.TimeoutSeconds = 1
+	}
+	if probe.PeriodSeconds == 0 {
+		probe.PeriodSeconds = 10
+	}
+	if probe.SuccessThreshold == 0 {
+		probe.SuccessThreshold = 1
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = 3
+	}
+	if h := probe.HTTPGet; h != nil {
+		if h.Path == "" {
+			h.Path = "/"
+		}
+		if h.Scheme == "" {
+			h.Scheme = api.URISchemeHTTP
+		}
+	}
+}
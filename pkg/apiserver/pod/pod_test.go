@@ -1,66 +1,63 @@
 package pod
 
 import (
+	"errors"
 	"os"
 	"testing"
+	"time"
 
-	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 
 	"superminikube/pkg/api"
-	"superminikube/pkg/apiserver/watch"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
 )
 
-var testClient *redis.Client
+var testStore storage.Interface
 
 func TestMain(m *testing.M) {
-	testClient = redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
+	testStore = storage.NewMemoryStore()
 	code := m.Run()
-	testClient.Close()
+	testStore.Close()
 	os.Exit(code)
 }
 
 func TestCreatePod(t *testing.T) {
-	testWatchService := watch.NewService()
-	service := NewService(testClient, testWatchService)
+	service := NewService(testStore)
 	testCases := []struct {
 		name        string
 		nodename    string
 		spec        api.PodSpec
-		client      *redis.Client
 		expectError bool
 	}{
 		{
 			name:     "create pod with basic spec",
 			nodename: "test-node-1",
 			spec: api.PodSpec{
-				Container: api.Container{
+				Containers: []api.Container{{
 					Image: "nginx:latest",
 					Env: map[string]string{
 						"ENV_VAR": "test-value",
 					},
-				},
+				}},
 			},
-			client:      testClient,
 			expectError: false,
 		},
 		{
 			name:     "create pod with empty nodename",
 			nodename: "",
 			spec: api.PodSpec{
-				Container: api.Container{
+				Containers: []api.Container{{
 					Image: "alpine:latest",
-				},
+				}},
 			},
-			client:      testClient,
 			expectError: false,
 		},
 		{
 			name:     "create pod with ports and volumes",
 			nodename: "test-node-2",
 			spec: api.PodSpec{
-				Container: api.Container{
+				Containers: []api.Container{{
 					Image: "redis:latest",
 					Env: map[string]string{
 						"REDIS_PORT": "6379",
@@ -72,17 +69,15 @@ func TestCreatePod(t *testing.T) {
 						},
 					},
 					Volumes: []string{"/data"},
-				},
+				}},
 			},
-			client:      testClient,
 			expectError: false,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// TODO: writing to a non-existent channel at the moment. Check this out...
-			_, err := service.CreatePod(t.Context(), tc.nodename, tc.spec)
+			p, err := service.CreatePod(t.Context(), api.Pod{Nodename: tc.nodename, Spec: tc.spec})
 
 			if tc.expectError {
 				if err == nil {
@@ -95,11 +90,561 @@ func TestCreatePod(t *testing.T) {
 				t.Errorf("unexpected error: %v", err)
 				return
 			}
+			if p.Name == "" || p.Namespace != utils.DefaultNamespace || p.CreationTimestamp.IsZero() || p.Generation != 1 {
+				t.Errorf("server owned metadata not filled in: %+v", p.ObjectMeta)
+			}
+		})
+	}
+}
+
+func TestCreatePodMetadata(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	p, err := service.CreatePod(t.Context(), api.Pod{
+		Nodename: "test-node-1",
+		ObjectMeta: api.ObjectMeta{
+			Name:       "web",
+			Labels:     map[string]string{"app": "web"},
+			Generation: 42,
+			// clients can't pick their own uid or resourceVersion
+			Uid:             uuid.New(),
+			ResourceVersion: "1000",
+		},
+		Spec: api.PodSpec{Containers: []api.Container{{Image: "nginx:1"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	if p.Name != "web" || p.Labels["app"] != "web" {
+		t.Errorf("client metadata not kept: %+v", p.ObjectMeta)
+	}
+	if !p.CreationTimestamp.Equal(now) || p.Generation != 1 || p.ResourceVersion == "1000" {
+		t.Errorf("server owned metadata not reset: %+v", p.ObjectMeta)
+	}
+	if _, err := service.CreatePod(t.Context(), api.Pod{
+		Nodename:   "test-node-1",
+		ObjectMeta: api.ObjectMeta{Name: "web"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Image: "nginx:1"}}},
+	}); !errors.Is(err, storage.ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists creating duplicate name, got %v", err)
+	}
+}
+
+func TestCreatePodDefaults(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	p, err := service.CreatePod(t.Context(), api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web"},
+		Spec: api.PodSpec{Containers: []api.Container{{
+			Image:          "nginx:1",
+			ReadinessProbe: &api.Probe{HTTPGet: &api.HTTPGetAction{Port: 80}},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	if p.Spec.RestartPolicy != api.RestartPolicyAlways || p.Spec.Containers[0].Name != "container-0" {
+		t.Errorf("spec wasn't defaulted: %+v", p.Spec)
+	}
+	probe := p.Spec.Containers[0].ReadinessProbe
+	if probe.PeriodSeconds != 10 || probe.TimeoutSeconds != 1 || probe.SuccessThreshold != 1 || probe.FailureThreshold != 3 {
+		t.Errorf("probe wasn't defaulted: %+v", probe)
+	}
+	if probe.HTTPGet.Path != "/" || probe.HTTPGet.Scheme != api.URISchemeHTTP {
+		t.Errorf("http probe wasn't defaulted: %+v", probe.HTTPGet)
+	}
+}
+
+func TestUpdatePod(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	created, err := service.CreatePod(t.Context(), api.Pod{
+		Nodename:   "test-node-1",
+		ObjectMeta: api.ObjectMeta{Name: "web"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Image: "nginx:1"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+
+	// metadata only changes leave the generation alone
+	p := created
+	p.Labels = map[string]string{"tier": "frontend"}
+	p.Uid = uuid.New()
+	p.Nodename = "other-node"
+	p, err = service.UpdatePod(t.Context(), p)
+	if err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+	if p.Generation != 1 || p.Uid != created.Uid || p.ResourceVersion == created.ResourceVersion || p.Nodename != created.Nodename {
+		t.Errorf("unexpected metadata after label update: %+v", p.ObjectMeta)
+	}
+
+	p.Spec.Containers[0].Image = "nginx:2"
+	p, err = service.UpdatePod(t.Context(), p)
+	if err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+	if p.Generation != 2 || !p.CreationTimestamp.Equal(created.CreationTimestamp) {
+		t.Errorf("unexpected metadata after spec update: %+v", p.ObjectMeta)
+	}
+
+	missing := api.Pod{ObjectMeta: api.ObjectMeta{Name: "missing", Namespace: utils.DefaultNamespace}}
+	if _, err := service.UpdatePod(t.Context(), missing); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound updating missing pod, got %v", err)
+	}
+}
+
+func TestGetPod(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	created, err := service.CreatePod(t.Context(), api.Pod{
+		Nodename: "test-node-1",
+		Spec:     api.PodSpec{Containers: []api.Container{{Image: "nginx:latest"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	testCases := []struct {
+		name      string
+		namespace string
+		podName   string
+		wantErr   error
+	}{
+		{"existing pod", utils.DefaultNamespace, created.Name, nil},
+		{"missing pod", utils.DefaultNamespace, "does-not-exist", storage.ErrNotFound},
+		{"wrong namespace", "other", created.Name, storage.ErrNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := service.GetPod(t.Context(), tc.namespace, tc.podName)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Uid != created.Uid || p.ResourceVersion != created.ResourceVersion || p.Spec.Containers[0].Image != created.Spec.Containers[0].Image {
+				t.Errorf("got pod %+v, expected %+v", p, created)
+			}
+		})
+	}
+}
+
+func TestUpdatePodStatus(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	created, err := service.CreatePod(t.Context(), api.Pod{
+		Nodename:   "test-node-1",
+		ObjectMeta: api.ObjectMeta{Name: "web"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Image: "nginx:1"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	if created.Status.Phase != api.PodPending {
+		t.Errorf("new pod phase = %s, expected Pending", created.Status.Phase)
+	}
+
+	p := created
+	p.Status = api.PodStatus{Phase: api.PodRunning, PodIP: "10.0.0.2"}
+	p, err = service.UpdatePodStatus(t.Context(), p)
+	if err != nil {
+		t.Fatalf("failed to update pod status: %v", err)
+	}
+	if p.Status.Phase != api.PodRunning || p.Generation != created.Generation {
+		t.Errorf("unexpected pod after status update: %+v", p)
+	}
+
+	// regular updates can't touch the status
+	p.Status.Phase = api.PodFailed
+	p, err = service.UpdatePod(t.Context(), p)
+	if err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+	if p.Status.Phase != api.PodRunning {
+		t.Errorf("UpdatePod changed status phase to %s", p.Status.Phase)
+	}
+
+	missing := api.Pod{ObjectMeta: api.ObjectMeta{Name: "missing", Namespace: utils.DefaultNamespace}}
+	if _, err := service.UpdatePodStatus(t.Context(), missing); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCreatePodValidation(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	minute := int64(60)
+	testCases := []struct {
+		name string
+		pod  api.Pod
+	}{
+		{"no containers", api.Pod{}},
+		{"missing image", api.Pod{Spec: api.PodSpec{Containers: []api.Container{{Name: "app"}}}}},
+		{"bad name", api.Pod{
+			ObjectMeta: api.ObjectMeta{Name: "Not_Valid"},
+			Spec:       api.PodSpec{Containers: []api.Container{{Image: "nginx"}}},
+		}},
+		{"duplicate container names", api.Pod{Spec: api.PodSpec{
+			InitContainers: []api.Container{{Name: "app", Image: "busybox"}},
+			Containers:     []api.Container{{Name: "app", Image: "nginx"}},
+		}}},
+		{"port out of range", api.Pod{Spec: api.PodSpec{Containers: []api.Container{{
+			Image: "nginx",
+			Ports: []api.Port{{Containerport: "70000"}},
+		}}}}},
+		{"tolerationSeconds without NoExecute", api.Pod{Spec: api.PodSpec{
+			Containers:  []api.Container{{Image: "nginx"}},
+			Tolerations: []api.Toleration{{Key: "gpu", Operator: api.TolerationOpExists, TolerationSeconds: &minute}},
+		}}},
+		{"relative hostPath", api.Pod{Spec: api.PodSpec{
+			Containers: []api.Container{{Image: "postgres"}},
+			Volumes:    []api.Volume{{Name: "data", HostPath: &api.HostPathVolumeSource{Path: "data"}}},
+		}}},
+		{"mount of an unknown volume", api.Pod{Spec: api.PodSpec{
+			Containers: []api.Container{{Image: "postgres", VolumeMounts: []api.VolumeMount{{Name: "data", MountPath: "/var/lib/postgresql/data"}}}},
+		}}},
+		{"unknown restart policy", api.Pod{Spec: api.PodSpec{
+			Containers:    []api.Container{{Image: "nginx"}},
+			RestartPolicy: "Sometimes",
+		}}},
+		{"probe without a handler", api.Pod{Spec: api.PodSpec{
+			Containers: []api.Container{{Image: "nginx", ReadinessProbe: &api.Probe{PeriodSeconds: 5}}},
+		}}},
+		{"probe with two handlers", api.Pod{Spec: api.PodSpec{
+			Containers: []api.Container{{Image: "nginx", LivenessProbe: &api.Probe{
+				HTTPGet:   &api.HTTPGetAction{Port: 80},
+				TCPSocket: &api.TCPSocketAction{Port: 80},
+			}}},
+		}}},
+		{"liveness probe success threshold", api.Pod{Spec: api.PodSpec{
+			Containers: []api.Container{{Image: "nginx", LivenessProbe: &api.Probe{
+				TCPSocket:        &api.TCPSocketAction{Port: 80},
+				SuccessThreshold: 2,
+			}}},
+		}}},
+		{"probe on an init container", api.Pod{Spec: api.PodSpec{
+			InitContainers: []api.Container{{Image: "busybox", StartupProbe: &api.Probe{Exec: &api.ExecAction{Command: []string{"true"}}}}},
+			Containers:     []api.Container{{Image: "nginx"}},
+		}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := service.CreatePod(t.Context(), tc.pod); !errors.Is(err, utils.ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestListPods(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	for _, ns := range []string{"default", "default", "other"} {
+		_, err := service.CreatePod(t.Context(), api.Pod{
+			Nodename:   "test-node-1",
+			ObjectMeta: api.ObjectMeta{Namespace: ns},
+			Spec:       api.PodSpec{Containers: []api.Container{{Image: "nginx"}}},
+		})
+		if err != nil {
+			t.Fatalf("failed to create pod: %v", err)
+		}
+	}
+	testCases := []struct {
+		namespace string
+		expected  int
+	}{
+		{"", 3},
+		{"default", 2},
+		{"other", 1},
+		{"empty", 0},
+	}
+	for _, tc := range testCases {
+		list, err := service.ListPods(t.Context(), tc.namespace)
+		if err != nil {
+			t.Fatalf("failed to list pods: %v", err)
+		}
+		if len(list.Items) != tc.expected {
+			t.Errorf("namespace %q: got %d pods, expected %d", tc.namespace, len(list.Items), tc.expected)
+		}
+		if list.ResourceVersion != "3" {
+			t.Errorf("list resourceVersion = %q, expected 3", list.ResourceVersion)
+		}
+	}
+}
+
+func TestPatchPod(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	_, err := service.CreatePod(t.Context(), api.Pod{
+		Nodename:   "test-node-1",
+		ObjectMeta: api.ObjectMeta{Name: "web", Labels: map[string]string{"app": "web"}},
+		Spec: api.PodSpec{Containers: []api.Container{
+			{Name: "app", Image: "nginx:1"},
+			{Name: "sidecar", Image: "envoy:1"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+
+	p, err := service.PatchPod(t.Context(), "default", "web", utils.MergePatchType,
+		[]byte(`{"metadata":{"labels":{"tier":"frontend"}}}`))
+	if err != nil {
+		t.Fatalf("failed to merge patch: %v", err)
+	}
+	if p.Labels["app"] != "web" || p.Labels["tier"] != "frontend" || p.Generation != 1 {
+		t.Errorf("unexpected pod after label patch: %+v", p.ObjectMeta)
+	}
+
+	// a merge patch replaces lists wholesale
+	p, err = service.PatchPod(t.Context(), "default", "web", utils.MergePatchType,
+		[]byte(`{"spec":{"containers":[{"name":"app","image":"nginx:2"}]}}`))
+	if err != nil {
+		t.Fatalf("failed to merge patch: %v", err)
+	}
+	if len(p.Spec.Containers) != 1 || p.Spec.Containers[0].Image != "nginx:2" || p.Generation != 2 {
+		t.Errorf("unexpected pod after merge patch: %+v", p)
+	}
+
+	// a strategic merge patch merges containers by name
+	p, err = service.PatchPod(t.Context(), "default", "web", utils.StrategicMergePatchType,
+		[]byte(`{"spec":{"containers":[{"name":"app","image":"nginx:3"},{"name":"sidecar","image":"envoy:2"}]}}`))
+	if err != nil {
+		t.Fatalf("failed to strategic merge patch: %v", err)
+	}
+	if len(p.Spec.Containers) != 2 || p.Spec.Containers[0].Image != "nginx:3" || p.Spec.Containers[1].Image != "envoy:2" {
+		t.Errorf("unexpected containers after strategic merge patch: %+v", p.Spec.Containers)
+	}
+	p, err = service.PatchPod(t.Context(), "default", "web", utils.StrategicMergePatchType,
+		[]byte(`{"spec":{"containers":[{"name":"sidecar","$patch":"delete"}]}}`))
+	if err != nil {
+		t.Fatalf("failed to strategic merge patch: %v", err)
+	}
+	if len(p.Spec.Containers) != 1 || p.Spec.Containers[0].Name != "app" {
+		t.Errorf("sidecar not removed: %+v", p.Spec.Containers)
+	}
+
+	// spec fields the patch leaves out are kept
+	p, err = service.PatchPod(t.Context(), "default", "web", utils.MergePatchType,
+		[]byte(`{"spec":{"restartPolicy":"Never"}}`))
+	if err != nil {
+		t.Fatalf("failed to merge patch: %v", err)
+	}
+	if p.Spec.RestartPolicy != api.RestartPolicyNever || len(p.Spec.Containers) != 1 || p.Spec.Containers[0].Image != "nginx:3" {
+		t.Errorf("unexpected spec after restart policy patch: %+v", p.Spec)
+	}
+
+	testCases := []struct {
+		name    string
+		podName string
+		patch   string
+		wantErr error
+	}{
+		{"missing pod", "missing", `{}`, storage.ErrNotFound},
+		{"rename", "web", `{"metadata":{"name":"other"}}`, utils.ErrInvalid},
+		{"invalid result", "web", `{"spec":{"containers":[]}}`, utils.ErrInvalid},
+		{"malformed patch", "web", `{`, utils.ErrInvalid},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.PatchPod(t.Context(), "default", tc.podName, utils.MergePatchType, []byte(tc.patch))
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
 		})
 	}
 }
 
-// TODO: once mocking is implemented
-func TestGetPodByUid(t *testing.T) {
+func TestDeletePod(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	create := func(name, nodename string, finalizers ...string) api.Pod {
+		t.Helper()
+		p, err := service.CreatePod(t.Context(), api.Pod{
+			Nodename:   nodename,
+			ObjectMeta: api.ObjectMeta{Name: name, Finalizers: finalizers},
+			Spec:       api.PodSpec{Containers: []api.Container{{Image: "nginx:1"}}},
+		})
+		if err != nil {
+			t.Fatalf("failed to create pod: %v", err)
+		}
+		return p
+	}
+	grace := func(s int64) *int64 { return &s }
+	gone := func(name string) bool {
+		_, err := service.GetPod(t.Context(), "default", name)
+		return errors.Is(err, storage.ErrNotFound)
+	}
+
+	t.Run("unscheduled pod is removed right away", func(t *testing.T) {
+		created := create("unscheduled", "")
+		p, err := service.DeletePod(t.Context(), "default", "unscheduled", api.DeleteOptions{})
+		if err != nil {
+			t.Fatalf("failed to delete pod: %v", err)
+		}
+		if p.Uid != created.Uid || !gone("unscheduled") {
+			t.Errorf("pod not removed, got %+v", p.ObjectMeta)
+		}
+		if _, err := service.DeletePod(t.Context(), "default", "unscheduled", api.DeleteOptions{}); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+		}
+	})
 
+	t.Run("bound pod waits for the kubelet", func(t *testing.T) {
+		created := create("bound", "test-node-1")
+		p, err := service.DeletePod(t.Context(), "default", "bound", api.DeleteOptions{})
+		if err != nil {
+			t.Fatalf("failed to delete pod: %v", err)
+		}
+		if p.DeletionTimestamp == nil || !p.DeletionTimestamp.Equal(now) || *p.DeletionGracePeriodSeconds != defaultTerminationGracePeriodSeconds {
+			t.Fatalf("deletion not recorded: %+v", p.ObjectMeta)
+		}
+		if gone("bound") {
+			t.Fatalf("pod removed before its grace period")
+		}
+		// a longer grace period is ignored, a shorter one wins
+		p, _ = service.DeletePod(t.Context(), "default", "bound", api.DeleteOptions{GracePeriodSeconds: grace(60)})
+		if *p.DeletionGracePeriodSeconds != defaultTerminationGracePeriodSeconds {
+			t.Errorf("grace period extended to %d", *p.DeletionGracePeriodSeconds)
+		}
+		p, _ = service.DeletePod(t.Context(), "default", "bound", api.DeleteOptions{GracePeriodSeconds: grace(5)})
+		if *p.DeletionGracePeriodSeconds != 5 {
+			t.Errorf("grace period = %d, expected 5", *p.DeletionGracePeriodSeconds)
+		}
+		if _, err := service.DeletePod(t.Context(), "default", "bound", api.DeleteOptions{GracePeriodSeconds: grace(0), Uid: uuid.New()}); !errors.Is(err, storage.ErrConflict) {
+			t.Errorf("expected ErrConflict confirming with the wrong uid, got %v", err)
+		}
+		if _, err := service.DeletePod(t.Context(), "default", "bound", api.DeleteOptions{GracePeriodSeconds: grace(0), Uid: created.Uid}); err != nil {
+			t.Fatalf("failed to confirm deletion: %v", err)
+		}
+		if !gone("bound") {
+			t.Errorf("pod still stored after the kubelet confirmed")
+		}
+	})
+
+	t.Run("finalizers hold up removal", func(t *testing.T) {
+		create("finalized", "test-node-1", "example.com/cleanup")
+		p, err := service.DeletePod(t.Context(), "default", "finalized", api.DeleteOptions{GracePeriodSeconds: grace(0)})
+		if err != nil {
+			t.Fatalf("failed to delete pod: %v", err)
+		}
+		if gone("finalized") {
+			t.Fatalf("pod removed with a finalizer left")
+		}
+		p.Finalizers = nil
+		if _, err := service.UpdatePod(t.Context(), p); err != nil {
+			t.Fatalf("failed to clear finalizers: %v", err)
+		}
+		if !gone("finalized") {
+			t.Errorf("pod still stored after clearing its finalizers")
+		}
+	})
+
+	if _, err := service.DeletePod(t.Context(), "default", "missing", api.DeleteOptions{GracePeriodSeconds: grace(-1)}); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a negative grace period, got %v", err)
+	}
+}
+
+func TestUpdatePodPreconditions(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	created, err := service.CreatePod(t.Context(), api.Pod{
+		Nodename:   "test-node-1",
+		ObjectMeta: api.ObjectMeta{Name: "web"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Image: "nginx:1"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	// two writers both start from the created pod, the second one has to lose
+	first := created
+	first.Labels = map[string]string{"writer": "first"}
+	updated, err := service.UpdatePod(t.Context(), first)
+	if err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+	second := created
+	second.Labels = map[string]string{"writer": "second"}
+	if _, err := service.UpdatePod(t.Context(), second); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict on stale update, got %v", err)
+	}
+	stale := created
+	stale.Status = api.PodStatus{Phase: api.PodRunning}
+	if _, err := service.UpdatePodStatus(t.Context(), stale); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict on stale status update, got %v", err)
+	}
+	patch := []byte(`{"metadata":{"resourceVersion":"` + created.ResourceVersion + `","labels":{"writer":"patch"}}}`)
+	if _, err := service.PatchPod(t.Context(), "default", "web", utils.MergePatchType, patch); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict on stale patch, got %v", err)
+	}
+	if _, err := service.DeletePod(t.Context(), "default", "web", api.DeleteOptions{ResourceVersion: created.ResourceVersion}); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict on stale delete, got %v", err)
+	}
+	if _, err := service.UpdatePod(t.Context(), api.Pod{ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", ResourceVersion: "abc"}}); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid on malformed resourceVersion, got %v", err)
+	}
+
+	p, err := service.GetPod(t.Context(), "default", "web")
+	if err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	if p.Labels["writer"] != "first" || p.ResourceVersion != updated.ResourceVersion {
+		t.Errorf("stale write went through: %+v", p.ObjectMeta)
+	}
+
+	// no resourceVersion means last write wins
+	p.ResourceVersion = ""
+	p.Labels = map[string]string{"writer": "unconditional"}
+	if _, err := service.UpdatePod(t.Context(), p); err != nil {
+		t.Errorf("unconditional update failed: %v", err)
+	}
+	if _, err := service.DeletePod(t.Context(), "default", "web", api.DeleteOptions{}); err != nil {
+		t.Errorf("unconditional delete failed: %v", err)
+	}
+}
+
+func TestBindPod(t *testing.T) {
+	store := storage.NewMemoryStore()
+	registerNodes(t, store, "node-1", "node-2")
+	service := NewService(store)
+	created, err := service.CreatePod(t.Context(), api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Image: "nginx:1"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	if created.Nodename != "" {
+		t.Fatalf("new pod already bound to %s", created.Nodename)
+	}
+	binding := func(target string) api.Binding {
+		return api.Binding{ObjectMeta: api.ObjectMeta{Namespace: "default", Name: "web"}, Target: target}
+	}
+
+	wrongUid := binding("node-1")
+	wrongUid.Uid = uuid.New()
+	if _, err := service.BindPod(t.Context(), wrongUid); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict binding with the wrong uid, got %v", err)
+	}
+	if _, err := service.BindPod(t.Context(), binding("")); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid binding without a target, got %v", err)
+	}
+	if _, err := service.BindPod(t.Context(), binding("node-9")); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid binding to an unregistered node, got %v", err)
+	}
+	p, err := service.BindPod(t.Context(), binding("node-1"))
+	if err != nil {
+		t.Fatalf("failed to bind pod: %v", err)
+	}
+	if p.Nodename != "node-1" || p.Generation != created.Generation {
+		t.Errorf("unexpected pod after binding: %+v", p)
+	}
+	if c := p.Status.GetCondition(api.PodScheduled); c == nil || c.Status != api.ConditionTrue {
+		t.Errorf("expected PodScheduled=True after binding, got %+v", c)
+	}
+	if _, err := service.BindPod(t.Context(), binding("node-2")); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict binding twice, got %v", err)
+	}
+	if _, err := service.BindPod(t.Context(), api.Binding{ObjectMeta: api.ObjectMeta{Namespace: "default", Name: "missing"}, Target: "node-1"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound binding a missing pod, got %v", err)
+	}
 }
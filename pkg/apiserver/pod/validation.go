@@ -0,0 +1,203 @@
+package pod
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/utils"
+)
+
+// validatePod runs after defaulting, so every container already has a name
+func validatePod(p api.Pod) error {
+	if err := utils.ValidateObjectMeta(p.ObjectMeta); err != nil {
+		return err
+	}
+	return ValidateSpec(p.Spec)
+}
+
+// ValidateSpec checks a defaulted pod spec, it's shared with the workloads that stamp out pods from a template
+func ValidateSpec(spec api.PodSpec) error {
+	if spec.TerminationGracePeriodSeconds != nil && *spec.TerminationGracePeriodSeconds < 0 {
+		return fmt.Errorf("%w: terminationGracePeriodSeconds can't be negative", utils.ErrInvalid)
+	}
+	if len(spec.Containers) == 0 {
+		return fmt.Errorf("%w: pod needs at least one container", utils.ErrInvalid)
+	}
+	switch spec.RestartPolicy {
+	case api.RestartPolicyAlways, api.RestartPolicyOnFailure, api.RestartPolicyNever:
+	default:
+		return fmt.Errorf("%w: unknown restartPolicy %q", utils.ErrInvalid, spec.RestartPolicy)
+	}
+	for _, t := range spec.Tolerations {
+		if err := validateToleration(t); err != nil {
+			return err
+		}
+	}
+	volumes := map[string]bool{}
+	for _, v := range spec.Volumes {
+		if err := validateVolume(v); err != nil {
+			return err
+		}
+		if volumes[v.Name] {
+			return fmt.Errorf("%w: duplicate volume name %q", utils.ErrInvalid, v.Name)
+		}
+		volumes[v.Name] = true
+	}
+	// init containers run to completion, there's nothing for a probe to watch
+	for _, c := range spec.InitContainers {
+		if c.LivenessProbe != nil || c.ReadinessProbe != nil || c.StartupProbe != nil {
+			return fmt.Errorf("%w: init container %q can't have probes", utils.ErrInvalid, c.Name)
+		}
+	}
+	names := map[string]bool{}
+	containers := append(append([]api.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		if err := utils.ValidateLabel("container name", c.Name); err != nil {
+			return err
+		}
+		if names[c.Name] {
+			return fmt.Errorf("%w: duplicate container name %q", utils.ErrInvalid, c.Name)
+		}
+		names[c.Name] = true
+		if c.Image == "" {
+			return fmt.Errorf("%w: container %q needs an image", utils.ErrInvalid, c.Name)
+		}
+		if err := utils.ValidateResourceList(c.Name+" requests", c.Resources.Requests); err != nil {
+			return err
+		}
+		if err := utils.ValidateResourceList(c.Name+" limits", c.Resources.Limits); err != nil {
+			return err
+		}
+		if c.Resources.Requests.Pods != 0 || c.Resources.Limits.Pods != 0 {
+			return fmt.Errorf("%w: container %q can't request pods", utils.ErrInvalid, c.Name)
+		}
+		for _, port := range c.Ports {
+			if err := validatePort(port.Containerport); err != nil {
+				return err
+			}
+			if port.Hostport != "" {
+				if err := validatePort(port.Hostport); err != nil {
+					return err
+				}
+			}
+		}
+		if err := validateProbe(c.Name, "liveness", c.LivenessProbe, true); err != nil {
+			return err
+		}
+		if err := validateProbe(c.Name, "readiness", c.ReadinessProbe, false); err != nil {
+			return err
+		}
+		if err := validateProbe(c.Name, "startup", c.StartupProbe, true); err != nil {
+			return err
+		}
+		mountPaths := map[string]bool{}
+		for _, m := range c.VolumeMounts {
+			if !volumes[m.Name] {
+				return fmt.Errorf("%w: container %q mounts unknown volume %q", utils.ErrInvalid, c.Name, m.Name)
+			}
+			if !path.IsAbs(m.MountPath) {
+				return fmt.Errorf("%w: container %q mountPath %q has to be absolute", utils.ErrInvalid, c.Name, m.MountPath)
+			}
+			if mountPaths[path.Clean(m.MountPath)] {
+				return fmt.Errorf("%w: container %q mounts two volumes at %q", utils.ErrInvalid, c.Name, m.MountPath)
+			}
+			mountPaths[path.Clean(m.MountPath)] = true
+		}
+	}
+	return nil
+}
+
+// validateProbe checks a defaulted probe, liveness and startup probes pass or fail on a single result
+func validateProbe(container, kind string, probe *api.Probe, single bool) error {
+	if probe == nil {
+		return nil
+	}
+	handlers := 0
+	if probe.Exec != nil {
+		handlers++
+		if len(probe.Exec.Command) == 0 {
+			return fmt.Errorf("%w: container %q %s probe needs a command", utils.ErrInvalid, container, kind)
+		}
+	}
+	if h := probe.HTTPGet; h != nil {
+		handlers++
+		if err := validateProbePort(container, kind, h.Port); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(h.Path, "/") {
+			return fmt.Errorf("%w: container %q %s probe path %q has to start with /", utils.ErrInvalid, container, kind, h.Path)
+		}
+		if h.Scheme != api.URISchemeHTTP && h.Scheme != api.URISchemeHTTPS {
+			return fmt.Errorf("%w: container %q %s probe has unknown scheme %q", utils.ErrInvalid, container, kind, h.Scheme)
+		}
+	}
+	if probe.TCPSocket != nil {
+		handlers++
+		if err := validateProbePort(container, kind, probe.TCPSocket.Port); err != nil {
+			return err
+		}
+	}
+	if handlers != 1 {
+		return fmt.Errorf("%w: container %q %s probe needs exactly one of exec, httpGet and tcpSocket", utils.ErrInvalid, container, kind)
+	}
+	if probe.InitialDelaySeconds < 0 {
+		return fmt.Errorf("%w: container %q %s probe initialDelaySeconds can't be negative", utils.ErrInvalid, container, kind)
+	}
+	if probe.TimeoutSeconds < 1 || probe.PeriodSeconds < 1 || probe.SuccessThreshold < 1 || probe.FailureThreshold < 1 {
+		return fmt.Errorf("%w: container %q %s probe timeout, period and thresholds have to be positive", utils.ErrInvalid, container, kind)
+	}
+	if single && probe.SuccessThreshold != 1 {
+		return fmt.Errorf("%w: container %q %s probe successThreshold has to be 1", utils.ErrInvalid, container, kind)
+	}
+	return nil
+}
+
+func validateProbePort(container, kind string, port int32) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%w: container %q %s probe port must be between 1 and 65535", utils.ErrInvalid, container, kind)
+	}
+	return nil
+}
+
+func validateVolume(v api.Volume) error {
+	if err := utils.ValidateLabel("volume name", v.Name); err != nil {
+		return err
+	}
+	if v.HostPath == nil {
+		return fmt.Errorf("%w: volume %q needs a source", utils.ErrInvalid, v.Name)
+	}
+	if !path.IsAbs(v.HostPath.Path) {
+		return fmt.Errorf("%w: volume %q hostPath %q has to be absolute", utils.ErrInvalid, v.Name, v.HostPath.Path)
+	}
+	return nil
+}
+
+func validateToleration(t api.Toleration) error {
+	switch t.Operator {
+	case "", api.TolerationOpEqual:
+		if t.Key == "" {
+			return fmt.Errorf("%w: toleration needs a key unless its operator is Exists", utils.ErrInvalid)
+		}
+	case api.TolerationOpExists:
+		if t.Value != "" {
+			return fmt.Errorf("%w: toleration with operator Exists can't have a value", utils.ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown toleration operator %q", utils.ErrInvalid, t.Operator)
+	}
+	if t.TolerationSeconds != nil && t.Effect != api.TaintEffectNoExecute {
+		return fmt.Errorf("%w: tolerationSeconds only applies to NoExecute tolerations", utils.ErrInvalid)
+	}
+	return utils.ValidateTaintEffect(t.Effect, true)
+}
+
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("%w: port %q must be a number between 1 and 65535", utils.ErrInvalid, port)
+	}
+	return nil
+}
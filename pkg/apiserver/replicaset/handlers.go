@@ -0,0 +1,111 @@
+package replicaset
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func (h *handler) GetReplicaSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	rs, err := h.service.GetReplicaSet(r.Context(), vars["namespace"], vars["name"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, rs)
+}
+
+// ListReplicaSets lists replica sets in the namespace from the url, or every namespace if there is none
+func (h *handler) ListReplicaSets(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListReplicaSets(r.Context(), mux.Vars(r)["namespace"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, list)
+}
+
+func (h *handler) CreateReplicaSet(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	var rs api.ReplicaSet
+	if err := utils.DecodeBody(r, &rs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rs.Namespace != "" && rs.Namespace != namespace {
+		http.Error(w, "replica set namespace does not match url", http.StatusBadRequest)
+		return
+	}
+	rs.Namespace = namespace
+	rs, err := h.service.CreateReplicaSet(r.Context(), rs)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusCreated, rs)
+}
+
+func (h *handler) UpdateReplicaSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var rs api.ReplicaSet
+	if err := utils.DecodeBody(r, &rs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&rs, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rs, err := h.service.UpdateReplicaSet(r.Context(), rs)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, rs)
+}
+
+// UpdateReplicaSetStatus takes the full replica set but only its status is written
+func (h *handler) UpdateReplicaSetStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var rs api.ReplicaSet
+	if err := utils.DecodeBody(r, &rs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&rs, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rs, err := h.service.UpdateReplicaSetStatus(r.Context(), rs)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, rs)
+}
+
+// DeleteReplicaSet takes an optional ?resourceVersion= precondition
+func (h *handler) DeleteReplicaSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	opts := api.DeleteOptions{ResourceVersion: r.URL.Query().Get("resourceVersion")}
+	rs, err := h.service.DeleteReplicaSet(r.Context(), vars["namespace"], vars["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, rs)
+}
+
+func NewHandler(service Service) handler {
+	return handler{
+		service: service,
+	}
+}
+
+type handler struct {
+	service Service
+}
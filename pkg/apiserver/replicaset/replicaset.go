@@ -0,0 +1,118 @@
+package replicaset
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+const resource = "replicasets"
+
+type Service interface {
+	GetReplicaSet(ctx context.Context, namespace, name string) (api.ReplicaSet, error)
+	ListReplicaSets(ctx context.Context, namespace string) (api.ReplicaSetList, error)
+	CreateReplicaSet(ctx context.Context, rs api.ReplicaSet) (api.ReplicaSet, error)
+	UpdateReplicaSet(ctx context.Context, rs api.ReplicaSet) (api.ReplicaSet, error)
+	UpdateReplicaSetStatus(ctx context.Context, rs api.ReplicaSet) (api.ReplicaSet, error)
+	DeleteReplicaSet(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.ReplicaSet, error)
+}
+
+// ReplicaSetService persists replica sets through storage.
+// Pods are left to the replica set controller, nothing here touches them.
+type ReplicaSetService struct {
+	store storage.Interface
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewService(store storage.Interface) *ReplicaSetService {
+	return &ReplicaSetService{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (s *ReplicaSetService) GetReplicaSet(ctx context.Context, namespace, name string) (api.ReplicaSet, error) {
+	return utils.GetObject[api.ReplicaSet](ctx, s.store, storage.Key(resource, namespace, name))
+}
+
+// ListReplicaSets lists replica sets in namespace, or every namespace if it's empty
+func (s *ReplicaSetService) ListReplicaSets(ctx context.Context, namespace string) (api.ReplicaSetList, error) {
+	items, rv, err := utils.ListObjects[api.ReplicaSet](ctx, s.store, storage.Prefix(resource, namespace))
+	if err != nil {
+		return api.ReplicaSetList{}, err
+	}
+	return api.ReplicaSetList{ResourceVersion: rv, Items: items}, nil
+}
+
+func (s *ReplicaSetService) CreateReplicaSet(ctx context.Context, rs api.ReplicaSet) (api.ReplicaSet, error) {
+	utils.PrepareObjectMetaForCreate(&rs.ObjectMeta, s.now())
+	setDefaults(&rs)
+	if err := validateReplicaSet(rs); err != nil {
+		return api.ReplicaSet{}, err
+	}
+	// status belongs to the controller
+	rs.Status = api.ReplicaSetStatus{}
+	rs, err := utils.CreateObject(ctx, s.store, storage.Key(resource, rs.Namespace, rs.Name), rs)
+	if err != nil {
+		return api.ReplicaSet{}, err
+	}
+	slog.Info("Created ReplicaSet", "namespace", rs.Namespace, "name", rs.Name)
+	return rs, nil
+}
+
+// UpdateReplicaSet replaces the stored replica set's spec and metadata, status is left alone.
+// If rs carries a resourceVersion the update is rejected with storage.ErrConflict unless it is still current.
+func (s *ReplicaSetService) UpdateReplicaSet(ctx context.Context, rs api.ReplicaSet) (api.ReplicaSet, error) {
+	rs, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, rs.Namespace, rs.Name), rs.ResourceVersion, func(old api.ReplicaSet) (api.ReplicaSet, error) {
+		updated := rs
+		updated.Status = old.Status
+		setDefaults(&updated)
+		utils.PrepareObjectMetaForUpdate(&updated.ObjectMeta, old.ObjectMeta, !reflect.DeepEqual(updated.Spec, old.Spec))
+		return updated, validateReplicaSet(updated)
+	})
+	if err != nil {
+		return api.ReplicaSet{}, err
+	}
+	slog.Info("Updated ReplicaSet", "namespace", rs.Namespace, "name", rs.Name)
+	return rs, nil
+}
+
+// UpdateReplicaSetStatus replaces only the status of the stored replica set, it's how the controller reports back.
+// rs's resourceVersion is a precondition, same as UpdateReplicaSet.
+func (s *ReplicaSetService) UpdateReplicaSetStatus(ctx context.Context, rs api.ReplicaSet) (api.ReplicaSet, error) {
+	rs, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, rs.Namespace, rs.Name), rs.ResourceVersion, func(old api.ReplicaSet) (api.ReplicaSet, error) {
+		old.Status = rs.Status
+		return old, validateStatus(old.Status)
+	})
+	if err != nil {
+		return api.ReplicaSet{}, err
+	}
+	slog.Debug("Updated ReplicaSet status", "namespace", rs.Namespace, "name", rs.Name)
+	return rs, nil
+}
+
+// DeleteReplicaSet removes the replica set right away.
+// Its pods still point at it through their ownerReferences, the controller cleans those up once it sees it's gone.
+func (s *ReplicaSetService) DeleteReplicaSet(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.ReplicaSet, error) {
+	rs, err := utils.DeleteObject[api.ReplicaSet](ctx, s.store, storage.Key(resource, namespace, name), opts)
+	if err != nil {
+		return api.ReplicaSet{}, err
+	}
+	slog.Info("Deleted ReplicaSet", "namespace", namespace, "name", name)
+	return rs, nil
+}
+
+func setDefaults(rs *api.ReplicaSet) {
+	if rs.Spec.Replicas == nil {
+		one := int32(1)
+		rs.Spec.Replicas = &one
+	}
+	pod.SetSpecDefaults(&rs.Spec.Template.Spec)
+}
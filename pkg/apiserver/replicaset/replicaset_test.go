@@ -0,0 +1,140 @@
+package replicaset
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func TestCreateReplicaSet(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	negative := apitest.NewReplicaSet("negative", -1)
+	noSelector := apitest.NewReplicaSet("no-selector", 1)
+	noSelector.Spec.Selector = api.LabelSelector{}
+	mismatch := apitest.NewReplicaSet("mismatch", 1)
+	mismatch.Spec.Template.Labels = map[string]string{"app": "other"}
+	noContainers := apitest.NewReplicaSet("no-containers", 1)
+	noContainers.Spec.Template.Spec.Containers = nil
+	defaulted := apitest.NewReplicaSet("defaulted", 0)
+	defaulted.Spec.Replicas = nil
+	defaulted.Status.Replicas = 3
+
+	testCases := []struct {
+		name    string
+		rs      api.ReplicaSet
+		wantErr error
+	}{
+		{name: "basic replica set", rs: apitest.NewReplicaSet("web", 3)},
+		{name: "replicas and status defaulted", rs: defaulted},
+		{name: "duplicate name", rs: apitest.NewReplicaSet("web", 1), wantErr: storage.ErrKeyExists},
+		{name: "negative replicas", rs: negative, wantErr: utils.ErrInvalid},
+		{name: "no selector", rs: noSelector, wantErr: utils.ErrInvalid},
+		{name: "selector does not match template", rs: mismatch, wantErr: utils.ErrInvalid},
+		{name: "template without containers", rs: noContainers, wantErr: utils.ErrInvalid},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs, err := service.CreateReplicaSet(t.Context(), tc.rs)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rs.Namespace != utils.DefaultNamespace || rs.ResourceVersion == "" || rs.Spec.Replicas == nil {
+				t.Errorf("unexpected replica set: %+v", rs)
+			}
+			if rs.Status != (api.ReplicaSetStatus{}) {
+				t.Errorf("status %+v should start out empty", rs.Status)
+			}
+			if rs.Spec.Template.Spec.Containers[0].Name == "" {
+				t.Errorf("template containers weren't defaulted")
+			}
+		})
+	}
+}
+
+func TestUpdateReplicaSet(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	created, err := service.CreateReplicaSet(t.Context(), apitest.NewReplicaSet("web", 1))
+	if err != nil {
+		t.Fatalf("failed to create replica set: %v", err)
+	}
+
+	// status updates leave spec and metadata alone
+	rs := created
+	three := int32(3)
+	rs.Spec.Replicas = &three
+	rs.Status = api.ReplicaSetStatus{Replicas: 1, ReadyReplicas: 1, ObservedGeneration: 1}
+	rs, err = service.UpdateReplicaSetStatus(t.Context(), rs)
+	if err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if *rs.Spec.Replicas != 1 || rs.Status.ReadyReplicas != 1 {
+		t.Errorf("unexpected replica set after status update: %+v", rs)
+	}
+	rs.Status.ReadyReplicas = 2
+	if _, err := service.UpdateReplicaSetStatus(t.Context(), rs); !errors.Is(err, utils.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for more ready than existing replicas, got %v", err)
+	}
+
+	// spec updates leave the status alone and bump the generation
+	rs.Spec.Replicas = &three
+	rs.Status = api.ReplicaSetStatus{}
+	rs, err = service.UpdateReplicaSet(t.Context(), rs)
+	if err != nil {
+		t.Fatalf("failed to update replica set: %v", err)
+	}
+	if *rs.Spec.Replicas != 3 || rs.Generation != 2 || rs.Status.Replicas != 1 {
+		t.Errorf("unexpected replica set after update: %+v", rs)
+	}
+	if _, err := service.UpdateReplicaSet(t.Context(), created); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict on stale update, got %v", err)
+	}
+}
+
+func TestListAndDeleteReplicaSets(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	for _, name := range []string{"web", "api"} {
+		if _, err := service.CreateReplicaSet(t.Context(), apitest.NewReplicaSet(name, 1)); err != nil {
+			t.Fatalf("failed to create replica set: %v", err)
+		}
+	}
+	list, err := service.ListReplicaSets(t.Context(), "")
+	if err != nil {
+		t.Fatalf("failed to list replica sets: %v", err)
+	}
+	if len(list.Items) != 2 || list.ResourceVersion == "" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+	if _, err := service.DeleteReplicaSet(t.Context(), utils.DefaultNamespace, "web", api.DeleteOptions{ResourceVersion: "99"}); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict for a stale precondition, got %v", err)
+	}
+	if _, err := service.DeleteReplicaSet(t.Context(), utils.DefaultNamespace, "web", api.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete replica set: %v", err)
+	}
+	if _, err := service.GetReplicaSet(t.Context(), utils.DefaultNamespace, "web"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestGenerateName(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	rs := apitest.NewReplicaSet("", 1)
+	rs.GenerateName = "web-"
+	created, err := service.CreateReplicaSet(t.Context(), rs)
+	if err != nil {
+		t.Fatalf("failed to create replica set: %v", err)
+	}
+	if !strings.HasPrefix(created.Name, "web-") || len(created.Name) != len("web-")+5 {
+		t.Errorf("expected a generated name starting with web-, got %q", created.Name)
+	}
+}
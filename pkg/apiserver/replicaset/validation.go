@@ -0,0 +1,36 @@
+package replicaset
+
+import (
+	"fmt"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func validateReplicaSet(rs api.ReplicaSet) error {
+	if err := utils.ValidateObjectMeta(rs.ObjectMeta); err != nil {
+		return err
+	}
+	if *rs.Spec.Replicas < 0 {
+		return fmt.Errorf("%w: replicas can't be negative", utils.ErrInvalid)
+	}
+	if len(rs.Spec.Selector.MatchLabels) == 0 {
+		return fmt.Errorf("%w: replica set needs a selector", utils.ErrInvalid)
+	}
+	// otherwise the pods it creates aren't counted and it creates them forever
+	if !rs.Spec.Selector.Matches(rs.Spec.Template.Labels) {
+		return fmt.Errorf("%w: selector does not match template labels", utils.ErrInvalid)
+	}
+	return pod.ValidateSpec(rs.Spec.Template.Spec)
+}
+
+func validateStatus(status api.ReplicaSetStatus) error {
+	if status.Replicas < 0 || status.ReadyReplicas < 0 {
+		return fmt.Errorf("%w: replica counts can't be negative", utils.ErrInvalid)
+	}
+	if status.ReadyReplicas > status.Replicas {
+		return fmt.Errorf("%w: readyReplicas can't exceed replicas", utils.ErrInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package statefulset
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func (h *handler) GetStatefulSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ss, err := h.service.GetStatefulSet(r.Context(), vars["namespace"], vars["name"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, ss)
+}
+
+// ListStatefulSets lists stateful sets in the namespace from the url, or every namespace if there is none
+func (h *handler) ListStatefulSets(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListStatefulSets(r.Context(), mux.Vars(r)["namespace"])
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, list)
+}
+
+func (h *handler) CreateStatefulSet(w http.ResponseWriter, r *http.Request) {
+	namespace := mux.Vars(r)["namespace"]
+	var ss api.StatefulSet
+	if err := utils.DecodeBody(r, &ss); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ss.Namespace != "" && ss.Namespace != namespace {
+		http.Error(w, "stateful set namespace does not match url", http.StatusBadRequest)
+		return
+	}
+	ss.Namespace = namespace
+	ss, err := h.service.CreateStatefulSet(r.Context(), ss)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusCreated, ss)
+}
+
+func (h *handler) UpdateStatefulSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var ss api.StatefulSet
+	if err := utils.DecodeBody(r, &ss); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&ss, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ss, err := h.service.UpdateStatefulSet(r.Context(), ss)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, ss)
+}
+
+// UpdateStatefulSetStatus takes the full stateful set but only its status is written
+func (h *handler) UpdateStatefulSetStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	var ss api.StatefulSet
+	if err := utils.DecodeBody(r, &ss); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := utils.MatchURL(&ss, vars["namespace"], vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ss, err := h.service.UpdateStatefulSetStatus(r.Context(), ss)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, ss)
+}
+
+// DeleteStatefulSet takes an optional ?resourceVersion= precondition
+func (h *handler) DeleteStatefulSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	opts := api.DeleteOptions{ResourceVersion: r.URL.Query().Get("resourceVersion")}
+	ss, err := h.service.DeleteStatefulSet(r.Context(), vars["namespace"], vars["name"], opts)
+	if err != nil {
+		utils.WriteError(w, err)
+		return
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, ss)
+}
+
+func NewHandler(service Service) handler {
+	return handler{
+		service: service,
+	}
+}
+
+type handler struct {
+	service Service
+}
@@ -0,0 +1,152 @@
+package statefulset
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+const resource = "statefulsets"
+
+type Service interface {
+	GetStatefulSet(ctx context.Context, namespace, name string) (api.StatefulSet, error)
+	ListStatefulSets(ctx context.Context, namespace string) (api.StatefulSetList, error)
+	CreateStatefulSet(ctx context.Context, ss api.StatefulSet) (api.StatefulSet, error)
+	UpdateStatefulSet(ctx context.Context, ss api.StatefulSet) (api.StatefulSet, error)
+	UpdateStatefulSetStatus(ctx context.Context, ss api.StatefulSet) (api.StatefulSet, error)
+	DeleteStatefulSet(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.StatefulSet, error)
+}
+
+// StatefulSetService persists stateful sets through storage.
+// Pods are left to the stateful set controller, nothing here touches them.
+type StatefulSetService struct {
+	store storage.Interface
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewService(store storage.Interface) *StatefulSetService {
+	return &StatefulSetService{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (s *StatefulSetService) GetStatefulSet(ctx context.Context, namespace, name string) (api.StatefulSet, error) {
+	return utils.GetObject[api.StatefulSet](ctx, s.store, storage.Key(resource, namespace, name))
+}
+
+// ListStatefulSets lists stateful sets in namespace, or every namespace if it's empty
+func (s *StatefulSetService) ListStatefulSets(ctx context.Context, namespace string) (api.StatefulSetList, error) {
+	items, rv, err := utils.ListObjects[api.StatefulSet](ctx, s.store, storage.Prefix(resource, namespace))
+	if err != nil {
+		return api.StatefulSetList{}, err
+	}
+	return api.StatefulSetList{ResourceVersion: rv, Items: items}, nil
+}
+
+func (s *StatefulSetService) CreateStatefulSet(ctx context.Context, ss api.StatefulSet) (api.StatefulSet, error) {
+	utils.PrepareObjectMetaForCreate(&ss.ObjectMeta, s.now())
+	setDefaults(&ss)
+	if err := validateStatefulSet(ss); err != nil {
+		return api.StatefulSet{}, err
+	}
+	// status belongs to the controller
+	ss.Status = api.StatefulSetStatus{}
+	ss, err := utils.CreateObject(ctx, s.store, storage.Key(resource, ss.Namespace, ss.Name), ss)
+	if err != nil {
+		return api.StatefulSet{}, err
+	}
+	slog.Info("Created StatefulSet", "namespace", ss.Namespace, "name", ss.Name)
+	return ss, nil
+}
+
+// UpdateStatefulSet replaces the stored stateful set's spec and metadata, status is left alone.
+// If ss carries a resourceVersion the update is rejected with storage.ErrConflict unless it is still current.
+func (s *StatefulSetService) UpdateStatefulSet(ctx context.Context, ss api.StatefulSet) (api.StatefulSet, error) {
+	ss, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, ss.Namespace, ss.Name), ss.ResourceVersion, func(old api.StatefulSet) (api.StatefulSet, error) {
+		updated := ss
+		updated.Status = old.Status
+		setDefaults(&updated)
+		// pods are matched to the stateful set by it, changing it would orphan them
+		if !reflect.DeepEqual(updated.Spec.Selector, old.Spec.Selector) {
+			return api.StatefulSet{}, fmt.Errorf("%w: selector can't be changed", utils.ErrInvalid)
+		}
+		// pods would lose track of their data
+		if !reflect.DeepEqual(updated.Spec.VolumeClaimTemplates, old.Spec.VolumeClaimTemplates) {
+			return api.StatefulSet{}, fmt.Errorf("%w: volumeClaimTemplates can't be changed", utils.ErrInvalid)
+		}
+		if updated.Spec.PodManagementPolicy != old.Spec.PodManagementPolicy {
+			return api.StatefulSet{}, fmt.Errorf("%w: podManagementPolicy can't be changed", utils.ErrInvalid)
+		}
+		utils.PrepareObjectMetaForUpdate(&updated.ObjectMeta, old.ObjectMeta, !reflect.DeepEqual(updated.Spec, old.Spec))
+		return updated, validateStatefulSet(updated)
+	})
+	if err != nil {
+		return api.StatefulSet{}, err
+	}
+	slog.Info("Updated StatefulSet", "namespace", ss.Namespace, "name", ss.Name)
+	return ss, nil
+}
+
+// UpdateStatefulSetStatus replaces only the status of the stored stateful set, it's how the controller reports back.
+// ss's resourceVersion is a precondition, same as UpdateStatefulSet.
+func (s *StatefulSetService) UpdateStatefulSetStatus(ctx context.Context, ss api.StatefulSet) (api.StatefulSet, error) {
+	ss, err := utils.UpdateObject(ctx, s.store, storage.Key(resource, ss.Namespace, ss.Name), ss.ResourceVersion, func(old api.StatefulSet) (api.StatefulSet, error) {
+		old.Status = ss.Status
+		return old, validateStatus(old.Status)
+	})
+	if err != nil {
+		return api.StatefulSet{}, err
+	}
+	slog.Debug("Updated StatefulSet status", "namespace", ss.Namespace, "name", ss.Name)
+	return ss, nil
+}
+
+// DeleteStatefulSet removes the stateful set right away.
+// Its pods still point at it through their ownerReferences, the controller cleans those up once it sees it's gone.
+// Their volume directories stay on the nodes.
+func (s *StatefulSetService) DeleteStatefulSet(ctx context.Context, namespace, name string, opts api.DeleteOptions) (api.StatefulSet, error) {
+	ss, err := utils.DeleteObject[api.StatefulSet](ctx, s.store, storage.Key(resource, namespace, name), opts)
+	if err != nil {
+		return api.StatefulSet{}, err
+	}
+	slog.Info("Deleted StatefulSet", "namespace", namespace, "name", name)
+	return ss, nil
+}
+
+func setDefaults(ss *api.StatefulSet) {
+	if ss.Spec.Replicas == nil {
+		one := int32(1)
+		ss.Spec.Replicas = &one
+	}
+	for i := range ss.Spec.VolumeClaimTemplates {
+		if ss.Spec.VolumeClaimTemplates[i].Dir == "" {
+			ss.Spec.VolumeClaimTemplates[i].Dir = api.DefaultVolumeClaimDir
+		}
+	}
+	if ss.Spec.PodManagementPolicy == "" {
+		ss.Spec.PodManagementPolicy = api.OrderedReadyPodManagement
+	}
+	strategy := &ss.Spec.UpdateStrategy
+	if strategy.Type == "" {
+		strategy.Type = api.RollingUpdateStatefulSetStrategyType
+	}
+	if strategy.Type == api.RollingUpdateStatefulSetStrategyType {
+		if strategy.RollingUpdate == nil {
+			strategy.RollingUpdate = &api.RollingUpdateStatefulSetStrategy{}
+		}
+		if strategy.RollingUpdate.Partition == nil {
+			zero := int32(0)
+			strategy.RollingUpdate.Partition = &zero
+		}
+	}
+	pod.SetSpecDefaults(&ss.Spec.Template.Spec)
+}
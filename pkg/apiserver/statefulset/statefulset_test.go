@@ -0,0 +1,153 @@
+package statefulset
+
+import (
+	"errors"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func TestCreateStatefulSet(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	negative := apitest.NewStatefulSet("negative", -1)
+	mismatch := apitest.NewStatefulSet("mismatch", 1)
+	mismatch.Spec.Template.Labels = map[string]string{"app": "other"}
+	nameLabel := apitest.NewStatefulSet("name-label", 1)
+	nameLabel.Spec.Template.Labels[api.StatefulSetPodNameLabel] = "db-0"
+	unclaimed := apitest.NewStatefulSet("unclaimed", 1)
+	unclaimed.Spec.VolumeClaimTemplates = nil
+	relativeDir := apitest.NewStatefulSet("relative-dir", 1)
+	relativeDir.Spec.VolumeClaimTemplates[0].Dir = "volumes"
+	unknownPolicy := apitest.NewStatefulSet("unknown-policy", 1)
+	unknownPolicy.Spec.PodManagementPolicy = "Random"
+	negativePartition := apitest.NewStatefulSet("negative-partition", 1)
+	minusOne := int32(-1)
+	negativePartition.Spec.UpdateStrategy.RollingUpdate = &api.RollingUpdateStatefulSetStrategy{Partition: &minusOne}
+	defaulted := apitest.NewStatefulSet("defaulted", 0)
+	defaulted.Spec.Replicas = nil
+	defaulted.Status.Replicas = 3
+
+	testCases := []struct {
+		name    string
+		ss      api.StatefulSet
+		wantErr error
+	}{
+		{name: "basic stateful set", ss: apitest.NewStatefulSet("db", 3)},
+		{name: "defaults and status", ss: defaulted},
+		{name: "duplicate name", ss: apitest.NewStatefulSet("db", 1), wantErr: storage.ErrKeyExists},
+		{name: "negative replicas", ss: negative, wantErr: utils.ErrInvalid},
+		{name: "selector does not match template", ss: mismatch, wantErr: utils.ErrInvalid},
+		{name: "template sets the pod name label", ss: nameLabel, wantErr: utils.ErrInvalid},
+		{name: "mount without a claim template", ss: unclaimed, wantErr: utils.ErrInvalid},
+		{name: "relative claim dir", ss: relativeDir, wantErr: utils.ErrInvalid},
+		{name: "unknown pod management policy", ss: unknownPolicy, wantErr: utils.ErrInvalid},
+		{name: "negative partition", ss: negativePartition, wantErr: utils.ErrInvalid},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ss, err := service.CreateStatefulSet(t.Context(), tc.ss)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ss.Namespace != utils.DefaultNamespace || ss.ResourceVersion == "" || ss.Spec.Replicas == nil {
+				t.Errorf("unexpected stateful set: %+v", ss)
+			}
+			if ss.Spec.PodManagementPolicy != api.OrderedReadyPodManagement || ss.Spec.VolumeClaimTemplates[0].Dir != api.DefaultVolumeClaimDir {
+				t.Errorf("spec wasn't defaulted: %+v", ss.Spec)
+			}
+			strategy := ss.Spec.UpdateStrategy
+			if strategy.Type != api.RollingUpdateStatefulSetStrategyType || strategy.RollingUpdate == nil || *strategy.RollingUpdate.Partition != 0 {
+				t.Errorf("update strategy wasn't defaulted: %+v", strategy)
+			}
+			if ss.Status != (api.StatefulSetStatus{}) {
+				t.Errorf("status %+v should start out empty", ss.Status)
+			}
+		})
+	}
+}
+
+func TestUpdateStatefulSet(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	created, err := service.CreateStatefulSet(t.Context(), apitest.NewStatefulSet("db", 1))
+	if err != nil {
+		t.Fatalf("failed to create stateful set: %v", err)
+	}
+
+	// status updates leave spec and metadata alone
+	ss := created
+	three := int32(3)
+	ss.Spec.Replicas = &three
+	ss.Status = api.StatefulSetStatus{Replicas: 1, ReadyReplicas: 1, ObservedGeneration: 1}
+	ss, err = service.UpdateStatefulSetStatus(t.Context(), ss)
+	if err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if *ss.Spec.Replicas != 1 || ss.Status.ReadyReplicas != 1 {
+		t.Errorf("unexpected stateful set after status update: %+v", ss)
+	}
+
+	// spec updates leave the status alone and bump the generation
+	ss.Spec.Replicas = &three
+	ss.Status = api.StatefulSetStatus{}
+	ss, err = service.UpdateStatefulSet(t.Context(), ss)
+	if err != nil {
+		t.Fatalf("failed to update stateful set: %v", err)
+	}
+	if *ss.Spec.Replicas != 3 || ss.Generation != 2 || ss.Status.Replicas != 1 {
+		t.Errorf("unexpected stateful set after update: %+v", ss)
+	}
+	if _, err := service.UpdateStatefulSet(t.Context(), created); !errors.Is(err, storage.ErrConflict) {
+		t.Errorf("expected ErrConflict on stale update, got %v", err)
+	}
+
+	immutable := map[string]func(*api.StatefulSet){
+		"volumeClaimTemplates": func(ss *api.StatefulSet) {
+			ss.Spec.VolumeClaimTemplates = append(ss.Spec.VolumeClaimTemplates, api.VolumeClaimTemplate{Name: "logs"})
+		},
+		"podManagementPolicy": func(ss *api.StatefulSet) { ss.Spec.PodManagementPolicy = api.ParallelPodManagement },
+		"selector": func(ss *api.StatefulSet) {
+			ss.Spec.Selector = api.LabelSelector{MatchLabels: map[string]string{"tier": "db"}}
+			ss.Spec.Template.Labels = map[string]string{"tier": "db"}
+		},
+	}
+	for field, change := range immutable {
+		changed := ss
+		changed.ResourceVersion = ""
+		changed.Spec.Template.Labels = map[string]string{"app": "db"}
+		change(&changed)
+		if _, err := service.UpdateStatefulSet(t.Context(), changed); !errors.Is(err, utils.ErrInvalid) {
+			t.Errorf("expected ErrInvalid for a changed %s, got %v", field, err)
+		}
+	}
+}
+
+func TestListAndDeleteStatefulSets(t *testing.T) {
+	service := NewService(storage.NewMemoryStore())
+	for _, name := range []string{"db", "cache"} {
+		if _, err := service.CreateStatefulSet(t.Context(), apitest.NewStatefulSet(name, 1)); err != nil {
+			t.Fatalf("failed to create stateful set: %v", err)
+		}
+	}
+	list, err := service.ListStatefulSets(t.Context(), "")
+	if err != nil {
+		t.Fatalf("failed to list stateful sets: %v", err)
+	}
+	if len(list.Items) != 2 || list.ResourceVersion == "" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+	if _, err := service.DeleteStatefulSet(t.Context(), utils.DefaultNamespace, "db", api.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete stateful set: %v", err)
+	}
+	if _, err := service.GetStatefulSet(t.Context(), utils.DefaultNamespace, "db"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package statefulset
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/pod"
+	"superminikube/pkg/apiserver/utils"
+)
+
+func validateStatefulSet(ss api.StatefulSet) error {
+	if err := utils.ValidateObjectMeta(ss.ObjectMeta); err != nil {
+		return err
+	}
+	if *ss.Spec.Replicas < 0 {
+		return fmt.Errorf("%w: replicas can't be negative", utils.ErrInvalid)
+	}
+	if len(ss.Spec.Selector.MatchLabels) == 0 {
+		return fmt.Errorf("%w: stateful set needs a selector", utils.ErrInvalid)
+	}
+	// otherwise the pods it creates aren't counted and it creates them forever
+	if !ss.Spec.Selector.Matches(ss.Spec.Template.Labels) {
+		return fmt.Errorf("%w: selector does not match template labels", utils.ErrInvalid)
+	}
+	// the controller sets them on every pod
+	for _, label := range []string{api.PodTemplateHashLabel, api.StatefulSetPodNameLabel} {
+		if _, ok := ss.Spec.Template.Labels[label]; ok {
+			return fmt.Errorf("%w: template can't set the %s label", utils.ErrInvalid, label)
+		}
+	}
+	switch ss.Spec.PodManagementPolicy {
+	case api.OrderedReadyPodManagement, api.ParallelPodManagement:
+	default:
+		return fmt.Errorf("%w: unknown podManagementPolicy %q", utils.ErrInvalid, ss.Spec.PodManagementPolicy)
+	}
+	if err := validateStrategy(ss.Spec.UpdateStrategy); err != nil {
+		return err
+	}
+	// pods get a volume for every claim template, the template's containers can mount them like its own volumes
+	spec := ss.Spec.Template.Spec
+	spec.Volumes = slices.Clone(spec.Volumes)
+	for _, t := range ss.Spec.VolumeClaimTemplates {
+		if !path.IsAbs(t.Dir) {
+			return fmt.Errorf("%w: volume claim template %q dir %q has to be absolute", utils.ErrInvalid, t.Name, t.Dir)
+		}
+		spec.Volumes = append(spec.Volumes, api.Volume{Name: t.Name, HostPath: &api.HostPathVolumeSource{Path: t.Dir}})
+	}
+	return pod.ValidateSpec(spec)
+}
+
+func validateStrategy(strategy api.StatefulSetUpdateStrategy) error {
+	switch strategy.Type {
+	case api.OnDeleteStatefulSetStrategyType:
+		if strategy.RollingUpdate != nil {
+			return fmt.Errorf("%w: rollingUpdate can't be set with the OnDelete strategy", utils.ErrInvalid)
+		}
+	case api.RollingUpdateStatefulSetStrategyType:
+		if *strategy.RollingUpdate.Partition < 0 {
+			return fmt.Errorf("%w: partition can't be negative", utils.ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown update strategy type %q", utils.ErrInvalid, strategy.Type)
+	}
+	return nil
+}
+
+func validateStatus(status api.StatefulSetStatus) error {
+	if status.Replicas < 0 || status.ReadyReplicas < 0 || status.CurrentReplicas < 0 || status.UpdatedReplicas < 0 {
+		return fmt.Errorf("%w: replica counts can't be negative", utils.ErrInvalid)
+	}
+	if status.ReadyReplicas > status.Replicas {
+		return fmt.Errorf("%w: readyReplicas can't exceed replicas", utils.ErrInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ResourceVersioner is implemented by objects that expose the store revision
+// they were read at as their resourceVersion.
+type ResourceVersioner interface {
+	SetResourceVersion(string)
+}
+
+// Decode unmarshals a stored value into out, stamping the revision as its resourceVersion
+func Decode(kv KeyValue, out any) error {
+	if err := json.Unmarshal(kv.Value, out); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", kv.Key, err)
+	}
+	if v, ok := out.(ResourceVersioner); ok {
+		v.SetResourceVersion(strconv.FormatInt(kv.Revision, 10))
+	}
+	return nil
+}
+
+// ParseResourceVersion converts a resourceVersion back into a store revision.
+// An empty string is revision 0.
+func ParseResourceVersion(rv string) (int64, error) {
+	if rv == "" {
+		return 0, nil
+	}
+	rev, err := strconv.ParseInt(rv, 10, 64)
+	if err != nil || rev < 0 {
+		return 0, fmt.Errorf("invalid resourceVersion %q", rv)
+	}
+	return rev, nil
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdStore implements Interface on top of etcd.
+// Revisions are etcd's mod revisions so resourceVersions line up with etcd history.
+type EtcdStore struct {
+	client *clientv3.Client
+}
+
+func NewEtcdStore(endpoints []string) (*EtcdStore, error) {
+	c, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd client: %v", err)
+	}
+	return NewEtcdStoreFromClient(c), nil
+}
+
+func NewEtcdStoreFromClient(c *clientv3.Client) *EtcdStore {
+	return &EtcdStore{
+		client: c,
+	}
+}
+
+func (s *EtcdStore) Get(ctx context.Context, key string) (KeyValue, error) {
+	resp, err := s.client.Get(ctx, key)
+	if err != nil {
+		return KeyValue{}, fmt.Errorf("failed to get key %s: %v", key, err)
+	}
+	if len(resp.Kvs) == 0 {
+		return KeyValue{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	kv := resp.Kvs[0]
+	return KeyValue{Key: key, Value: kv.Value, Revision: kv.ModRevision}, nil
+}
+
+func (s *EtcdStore) List(ctx context.Context, prefix string) ([]KeyValue, int64, error) {
+	resp, err := s.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list prefix %s: %v", prefix, err)
+	}
+	kvs := make([]KeyValue, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		kvs = append(kvs, KeyValue{Key: string(kv.Key), Value: kv.Value, Revision: kv.ModRevision})
+	}
+	return kvs, resp.Header.Revision, nil
+}
+
+func (s *EtcdStore) Create(ctx context.Context, key string, value []byte) (KeyValue, error) {
+	// only put if the key has never been created
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, string(value))).
+		Commit()
+	if err != nil {
+		return KeyValue{}, fmt.Errorf("failed to create key %s: %v", key, err)
+	}
+	if !resp.Succeeded {
+		return KeyValue{}, fmt.Errorf("%w: %s", ErrKeyExists, key)
+	}
+	return KeyValue{Key: key, Value: value, Revision: resp.Header.Revision}, nil
+}
+
+func (s *EtcdStore) Update(ctx context.Context, key string, value []byte, revision int64) (KeyValue, error) {
+	// the else branch reads the key back so a failed txn can tell missing and modified apart
+	resp, err := s.client.Txn(ctx).
+		If(revisionCompare(key, revision)).
+		Then(clientv3.OpPut(key, string(value))).
+		Else(clientv3.OpGet(key)).
+		Commit()
+	if err != nil {
+		return KeyValue{}, fmt.Errorf("failed to update key %s: %v", key, err)
+	}
+	if !resp.Succeeded {
+		return KeyValue{}, txnFailure(resp, key, revision)
+	}
+	return KeyValue{Key: key, Value: value, Revision: resp.Header.Revision}, nil
+}
+
+func (s *EtcdStore) Delete(ctx context.Context, key string, revision int64) (KeyValue, error) {
+	resp, err := s.client.Txn(ctx).
+		If(revisionCompare(key, revision)).
+		Then(clientv3.OpDelete(key, clientv3.WithPrevKV())).
+		Else(clientv3.OpGet(key)).
+		Commit()
+	if err != nil {
+		return KeyValue{}, fmt.Errorf("failed to delete key %s: %v", key, err)
+	}
+	if !resp.Succeeded {
+		return KeyValue{}, txnFailure(resp, key, revision)
+	}
+	prev := resp.Responses[0].GetResponseDeleteRange().PrevKvs
+	return KeyValue{Key: key, Value: prev[0].Value, Revision: resp.Header.Revision}, nil
+}
+
+// revisionCompare checks key was last modified at revision, or just that it exists for revision 0
+func revisionCompare(key string, revision int64) clientv3.Cmp {
+	if revision > 0 {
+		return clientv3.Compare(clientv3.ModRevision(key), "=", revision)
+	}
+	return clientv3.Compare(clientv3.CreateRevision(key), ">", 0)
+}
+
+// txnFailure explains a failed revisionCompare txn from the key read in its else branch
+func txnFailure(resp *clientv3.TxnResponse, key string, revision int64) error {
+	kvs := resp.Responses[0].GetResponseRange().Kvs
+	if len(kvs) == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return fmt.Errorf("%w: %s is at revision %d, not %d", ErrConflict, key, kvs[0].ModRevision, revision)
+}
+
+func (s *EtcdStore) Watch(ctx context.Context, prefix string, revision int64) (<-chan Event, error) {
+	opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithPrevKV()}
+	if revision > 0 {
+		// etcd only reports compaction once the watch is running, check up front instead
+		_, err := s.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly(), clientv3.WithRev(revision))
+		if errors.Is(err, rpctypes.ErrCompacted) {
+			return nil, fmt.Errorf("%w: %d", ErrCompacted, revision)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to check revision %d: %v", revision, err)
+		}
+		opts = append(opts, clientv3.WithRev(revision+1))
+	}
+	wch := s.client.Watch(clientv3.WithRequireLeader(ctx), prefix, opts...)
+	ch := make(chan Event, watchBufferSize)
+	go func() {
+		defer close(ch)
+		for resp := range wch {
+			if err := resp.Err(); err != nil {
+				if errors.Is(err, rpctypes.ErrCompacted) {
+					slog.Warn("watch revision compacted", "prefix", prefix, "revision", revision)
+				} else {
+					slog.Error("etcd watch failed", "prefix", prefix, "error", err)
+				}
+				return
+			}
+			for _, e := range resp.Events {
+				ev := Event{
+					KeyValue: KeyValue{
+						Key:      string(e.Kv.Key),
+						Value:    e.Kv.Value,
+						Revision: e.Kv.ModRevision,
+					},
+				}
+				switch {
+				case e.Type == clientv3.EventTypeDelete:
+					ev.Type = Deleted
+					if e.PrevKv != nil {
+						ev.Value = e.PrevKv.Value
+					}
+				case e.IsCreate():
+					ev.Type = Added
+				default:
+					ev.Type = Modified
+				}
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (s *EtcdStore) Close() error {
+	return s.client.Close()
+}
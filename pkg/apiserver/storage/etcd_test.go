@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"go.etcd.io/etcd/client/v3/namespace"
+
+	"superminikube/pkg/apiserver/storage/etcdtest"
+)
+
+var testEtcd *etcdtest.Server
+
+func TestMain(m *testing.M) {
+	var err error
+	testEtcd, err = etcdtest.Start()
+	if err != nil {
+		log.Fatalf("failed to start embedded etcd: %v", err)
+	}
+	code := m.Run()
+	testEtcd.Stop()
+	os.Exit(code)
+}
+
+// newTestEtcdStore returns a store scoped to its own key prefix
+// so tests sharing the embedded server start out empty
+func newTestEtcdStore(t *testing.T, scope string) *EtcdStore {
+	c, err := testEtcd.Client()
+	if err != nil {
+		t.Fatalf("failed to create etcd client: %v", err)
+	}
+	prefix := fmt.Sprintf("%s/", scope)
+	c.KV = namespace.NewKV(c.KV, prefix)
+	c.Watcher = namespace.NewWatcher(c.Watcher, prefix)
+	s := NewEtcdStoreFromClient(c)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestEtcdStore(t *testing.T) {
+	testCases := []struct {
+		name string
+		test func(*testing.T, Interface)
+	}{
+		{"crud", testCRUD},
+		{"preconditions", testPreconditions},
+		{"list", testList},
+		{"watch", testWatch},
+		{"watch from revision", testWatchFromRevision},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newTestEtcdStore(t, t.Name()))
+		})
+	}
+}
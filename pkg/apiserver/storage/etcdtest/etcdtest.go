@@ -0,0 +1,86 @@
+// Package etcdtest starts an embedded etcd server for tests.
+package etcdtest
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// Server is an in-process etcd listening on free localhost ports
+type Server struct {
+	etcd *embed.Etcd
+	dir  string
+}
+
+// Start boots an embedded etcd. Call Stop once done with it.
+func Start() (*Server, error) {
+	dir, err := os.MkdirTemp("", "superminikube-etcd")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data dir: %v", err)
+	}
+	cfg := embed.NewConfig()
+	cfg.Dir = dir
+	cfg.LogLevel = "error"
+	// free ports avoid fighting over 2379 when packages test in parallel
+	clientURL, err := freeURL()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	peerURL, err := freeURL()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	cfg.ListenClientUrls = []url.URL{*clientURL}
+	cfg.AdvertiseClientUrls = []url.URL{*clientURL}
+	cfg.ListenPeerUrls = []url.URL{*peerURL}
+	cfg.AdvertisePeerUrls = []url.URL{*peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to start etcd: %v", err)
+	}
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(30 * time.Second):
+		e.Close()
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("etcd took too long to start")
+	}
+	return &Server{etcd: e, dir: dir}, nil
+}
+
+// Endpoints returns the client endpoints of the server
+func (s *Server) Endpoints() []string {
+	return []string{s.etcd.Config().ListenClientUrls[0].String()}
+}
+
+// Client returns a new client connected to the server
+func (s *Server) Client() (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{
+		Endpoints:   s.Endpoints(),
+		DialTimeout: 5 * time.Second,
+	})
+}
+
+func (s *Server) Stop() {
+	s.etcd.Close()
+	os.RemoveAll(s.dir)
+}
+
+func freeURL() (*url.URL, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find a free port: %v", err)
+	}
+	defer l.Close()
+	return &url.URL{Scheme: "http", Host: l.Addr().String()}, nil
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// size of each watcher's buffer, watchers that fall this far behind are dropped
+	watchBufferSize = 100
+	// number of past events kept around for watches that start at an older revision
+	memoryHistorySize = 1000
+)
+
+// MemoryStore is an in-process implementation of Interface.
+// Nothing is persisted, intended for tests and single process setups.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	data     map[string]KeyValue
+	revision int64
+	history  []Event
+	watchers map[int]*memoryWatcher
+	nextID   int
+}
+
+type memoryWatcher struct {
+	prefix string
+	ch     chan Event
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data:     make(map[string]KeyValue),
+		watchers: make(map[int]*memoryWatcher),
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (KeyValue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	kv, ok := s.data[key]
+	if !ok {
+		return KeyValue{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return kv, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, prefix string) ([]KeyValue, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	kvs := make([]KeyValue, 0)
+	for k, kv := range s.data {
+		if strings.HasPrefix(k, prefix) {
+			kvs = append(kvs, kv)
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs, s.revision, nil
+}
+
+func (s *MemoryStore) Create(ctx context.Context, key string, value []byte) (KeyValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; ok {
+		return KeyValue{}, fmt.Errorf("%w: %s", ErrKeyExists, key)
+	}
+	return s.put(Added, key, value), nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, key string, value []byte, revision int64) (KeyValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kv, ok := s.data[key]
+	if !ok {
+		return KeyValue{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if revision > 0 && kv.Revision != revision {
+		return KeyValue{}, fmt.Errorf("%w: %s is at revision %d, not %d", ErrConflict, key, kv.Revision, revision)
+	}
+	return s.put(Modified, key, value), nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string, revision int64) (KeyValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kv, ok := s.data[key]
+	if !ok {
+		return KeyValue{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if revision > 0 && kv.Revision != revision {
+		return KeyValue{}, fmt.Errorf("%w: %s is at revision %d, not %d", ErrConflict, key, kv.Revision, revision)
+	}
+	delete(s.data, key)
+	s.revision++
+	kv.Revision = s.revision
+	s.notify(Event{Type: Deleted, KeyValue: kv})
+	return kv, nil
+}
+
+func (s *MemoryStore) Watch(ctx context.Context, prefix string, revision int64) (<-chan Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// replay whatever happened after revision, holding the lock so nothing slips in between
+	var replay []Event
+	if revision > 0 && revision < s.revision {
+		if len(s.history) == 0 || s.history[0].Revision > revision+1 {
+			return nil, fmt.Errorf("%w: %d", ErrCompacted, revision)
+		}
+		for _, ev := range s.history {
+			if ev.Revision > revision && strings.HasPrefix(ev.Key, prefix) {
+				replay = append(replay, ev)
+			}
+		}
+	}
+	id := s.nextID
+	s.nextID++
+	w := &memoryWatcher{
+		prefix: prefix,
+		ch:     make(chan Event, watchBufferSize+len(replay)),
+	}
+	for _, ev := range replay {
+		w.ch <- ev
+	}
+	s.watchers[id] = w
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.removeWatcher(id)
+	}()
+	return w.ch, nil
+}
+
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range s.watchers {
+		s.removeWatcher(id)
+	}
+	return nil
+}
+
+// put must be called with the lock held
+func (s *MemoryStore) put(t EventType, key string, value []byte) KeyValue {
+	s.revision++
+	kv := KeyValue{
+		Key:      key,
+		Value:    append([]byte(nil), value...),
+		Revision: s.revision,
+	}
+	s.data[key] = kv
+	s.notify(Event{Type: t, KeyValue: kv})
+	return kv
+}
+
+// notify must be called with the lock held
+func (s *MemoryStore) notify(ev Event) {
+	s.history = append(s.history, ev)
+	if len(s.history) > memoryHistorySize {
+		s.history = s.history[len(s.history)-memoryHistorySize:]
+	}
+	for id, w := range s.watchers {
+		if !strings.HasPrefix(ev.Key, w.prefix) {
+			continue
+		}
+		select {
+		case w.ch <- ev:
+		default:
+			slog.Warn("watcher fell behind, dropping it", "prefix", w.prefix)
+			s.removeWatcher(id)
+		}
+	}
+}
+
+// removeWatcher must be called with the lock held
+func (s *MemoryStore) removeWatcher(id int) {
+	w, ok := s.watchers[id]
+	if !ok {
+		return
+	}
+	close(w.ch)
+	delete(s.watchers, id)
+}
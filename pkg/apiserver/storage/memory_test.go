@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryStore(t *testing.T) {
+	testCases := []struct {
+		name string
+		test func(*testing.T, Interface)
+	}{
+		{"crud", testCRUD},
+		{"preconditions", testPreconditions},
+		{"list", testList},
+		{"watch", testWatch},
+		{"watch from revision", testWatchFromRevision},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, NewMemoryStore())
+		})
+	}
+}
+
+func TestMemoryStoreCompacted(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := t.Context()
+	key := Key("pods", "default", "a")
+	s.Create(ctx, key, []byte("v"))
+	for range memoryHistorySize + 1 {
+		s.Update(ctx, key, []byte("v"), 0)
+	}
+	_, err := s.Watch(ctx, Prefix("pods", ""), 1)
+	if !errors.Is(err, ErrCompacted) {
+		t.Errorf("Watch() err = %v, expected ErrCompacted", err)
+	}
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// counter shared by every key, mirrors etcd's store wide revision
+	redisRevisionKey = "_meta/revision"
+	// mutations are published here so watchers across apiservers see them
+	redisEventsChannel = "_meta/events"
+)
+
+// RedisStore implements Interface on top of a redis server.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// what actually gets written to redis
+type redisEntry struct {
+	Value    []byte `json:"value"`
+	Revision int64  `json:"revision"`
+}
+
+type redisEvent struct {
+	Type     EventType `json:"type"`
+	Key      string    `json:"key"`
+	Value    []byte    `json:"value"`
+	Revision int64     `json:"revision"`
+}
+
+func NewRedisStore(addr string) *RedisStore {
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{
+			Addr: addr,
+		}),
+	}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (KeyValue, error) {
+	b, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return KeyValue{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return KeyValue{}, fmt.Errorf("failed to get key %s: %v", key, err)
+	}
+	return decodeRedisEntry(key, b)
+}
+
+func (s *RedisStore) List(ctx context.Context, prefix string) ([]KeyValue, int64, error) {
+	rev, err := s.client.Get(ctx, redisRevisionKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, fmt.Errorf("failed to get revision: %v", err)
+	}
+	kvs := make([]KeyValue, 0)
+	iter := s.client.Scan(ctx, 0, prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		kv, err := s.Get(ctx, iter.Val())
+		if errors.Is(err, ErrNotFound) {
+			// deleted between scan and get
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		kvs = append(kvs, kv)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list prefix %s: %v", prefix, err)
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs, rev, nil
+}
+
+func (s *RedisStore) Create(ctx context.Context, key string, value []byte) (KeyValue, error) {
+	kv, b, err := s.newEntry(ctx, key, value)
+	if err != nil {
+		return KeyValue{}, err
+	}
+	ok, err := s.client.SetNX(ctx, key, b, 0).Result()
+	if err != nil {
+		return KeyValue{}, fmt.Errorf("failed to create key %s: %v", key, err)
+	}
+	if !ok {
+		return KeyValue{}, fmt.Errorf("%w: %s", ErrKeyExists, key)
+	}
+	s.publish(ctx, Event{Type: Added, KeyValue: kv})
+	return kv, nil
+}
+
+func (s *RedisStore) Update(ctx context.Context, key string, value []byte, revision int64) (KeyValue, error) {
+	var kv KeyValue
+	err := s.checkAndSet(ctx, key, revision, func(tx *redis.Tx, _ KeyValue) error {
+		var b []byte
+		var err error
+		kv, b, err = s.newEntry(ctx, key, value)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, b, 0)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return KeyValue{}, err
+	}
+	s.publish(ctx, Event{Type: Modified, KeyValue: kv})
+	return kv, nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, key string, revision int64) (KeyValue, error) {
+	var kv KeyValue
+	err := s.checkAndSet(ctx, key, revision, func(tx *redis.Tx, current KeyValue) error {
+		kv = current
+		rev, err := s.client.Incr(ctx, redisRevisionKey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to bump revision: %v", err)
+		}
+		kv.Revision = rev
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return KeyValue{}, err
+	}
+	s.publish(ctx, Event{Type: Deleted, KeyValue: kv})
+	return kv, nil
+}
+
+// checkAndSet WATCHes key, checks it is at revision and then hands the current entry to write,
+// which has to make its changes inside a MULTI on tx so they're dropped if key changes in the meantime.
+// Without a revision to check against a concurrent change just means trying again.
+func (s *RedisStore) checkAndSet(ctx context.Context, key string, revision int64, write func(tx *redis.Tx, current KeyValue) error) error {
+	for {
+		err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+			b, err := tx.Get(ctx, key).Bytes()
+			if errors.Is(err, redis.Nil) {
+				return fmt.Errorf("%w: %s", ErrNotFound, key)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get key %s: %v", key, err)
+			}
+			current, err := decodeRedisEntry(key, b)
+			if err != nil {
+				return err
+			}
+			if revision > 0 && current.Revision != revision {
+				return fmt.Errorf("%w: %s is at revision %d, not %d", ErrConflict, key, current.Revision, revision)
+			}
+			return write(tx, current)
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+		if revision > 0 {
+			return fmt.Errorf("%w: %s was modified concurrently", ErrConflict, key)
+		}
+	}
+}
+
+// Watch relies on pub/sub which keeps no history,
+// so watching from a past revision is reported as compacted.
+func (s *RedisStore) Watch(ctx context.Context, prefix string, revision int64) (<-chan Event, error) {
+	if revision > 0 {
+		current, err := s.client.Get(ctx, redisRevisionKey).Int64()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("failed to get revision: %v", err)
+		}
+		if revision < current {
+			return nil, fmt.Errorf("%w: %d", ErrCompacted, revision)
+		}
+	}
+	sub := s.client.Subscribe(ctx, redisEventsChannel)
+	// wait for confirmation so no events are missed after returning
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("failed to subscribe to events: %v", err)
+	}
+	ch := make(chan Event, watchBufferSize)
+	go func() {
+		defer close(ch)
+		defer sub.Close()
+		msgs := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var ev redisEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+					slog.Error("failed to decode redis event", "error", err)
+					continue
+				}
+				if !strings.HasPrefix(ev.Key, prefix) {
+					continue
+				}
+				select {
+				case ch <- Event{Type: ev.Type, KeyValue: KeyValue{Key: ev.Key, Value: ev.Value, Revision: ev.Revision}}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *RedisStore) newEntry(ctx context.Context, key string, value []byte) (KeyValue, []byte, error) {
+	rev, err := s.client.Incr(ctx, redisRevisionKey).Result()
+	if err != nil {
+		return KeyValue{}, nil, fmt.Errorf("failed to bump revision: %v", err)
+	}
+	b, err := json.Marshal(redisEntry{Value: value, Revision: rev})
+	if err != nil {
+		return KeyValue{}, nil, fmt.Errorf("failed to encode entry: %v", err)
+	}
+	return KeyValue{Key: key, Value: value, Revision: rev}, b, nil
+}
+
+func (s *RedisStore) publish(ctx context.Context, ev Event) {
+	b, err := json.Marshal(redisEvent{Type: ev.Type, Key: ev.Key, Value: ev.Value, Revision: ev.Revision})
+	if err != nil {
+		slog.Error("failed to encode redis event", "error", err)
+		return
+	}
+	if err := s.client.Publish(ctx, redisEventsChannel, b).Err(); err != nil {
+		slog.Warn("failed to publish event", "key", ev.Key, "error", err)
+	}
+}
+
+func decodeRedisEntry(key string, b []byte) (KeyValue, error) {
+	var e redisEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return KeyValue{}, fmt.Errorf("failed to decode key %s: %v", key, err)
+	}
+	return KeyValue{Key: key, Value: e.Value, Revision: e.Revision}, nil
+}
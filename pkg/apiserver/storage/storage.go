@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotFound  = errors.New("key not found")
+	ErrKeyExists = errors.New("key already exists")
+	// key was modified since the revision a write was conditioned on
+	ErrConflict = errors.New("key has been modified")
+	// requested revision is older than the history the store keeps
+	ErrCompacted = errors.New("revision has been compacted")
+)
+
+// Interface is the key/value store backing the apiserver.
+// Keys are flattened as "resource/namespace/name", see Key.
+// Values are opaque to the store, services decide how objects are encoded.
+type Interface interface {
+	Get(ctx context.Context, key string) (KeyValue, error)
+	// List returns every key/value whose key starts with prefix
+	// along with the store revision the list was read at
+	List(ctx context.Context, prefix string) ([]KeyValue, int64, error)
+	Create(ctx context.Context, key string, value []byte) (KeyValue, error)
+	// Update and Delete only go through if the key was last modified at revision,
+	// otherwise they fail with ErrConflict. A revision of 0 skips the check.
+	Update(ctx context.Context, key string, value []byte, revision int64) (KeyValue, error)
+	Delete(ctx context.Context, key string, revision int64) (KeyValue, error)
+	// Watch streams mutations for keys starting with prefix that happened after revision.
+	// A revision of 0 starts watching from now.
+	// channel is closed once ctx is done
+	Watch(ctx context.Context, prefix string, revision int64) (<-chan Event, error)
+	Close() error
+}
+
+type KeyValue struct {
+	Key   string
+	Value []byte
+	// Revision the key was last modified at
+	Revision int64
+}
+
+type Event struct {
+	Type EventType
+	KeyValue
+}
+
+const (
+	Added EventType = iota
+	Modified
+	Deleted
+)
+
+type EventType int
+
+// Key flattens an object location into "resource/namespace/name"
+func Key(resource, namespace, name string) string {
+	return strings.Join([]string{resource, namespace, name}, "/")
+}
+
+// Prefix returns the key prefix for every object of a resource in namespace.
+// An empty namespace matches all namespaces.
+func Prefix(resource, namespace string) string {
+	if namespace == "" {
+		return resource + "/"
+	}
+	return resource + "/" + namespace + "/"
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// shared tests every Interface implementation has to pass.
+// each is handed a fresh, empty store
+
+func testCRUD(t *testing.T, s Interface) {
+	ctx := t.Context()
+	key := Key("pods", "default", "a")
+
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() on empty store err = %v, expected ErrNotFound", err)
+	}
+	created, err := s.Create(ctx, key, []byte("v1"))
+	if err != nil {
+		t.Fatalf("Create() err = %v", err)
+	}
+	if _, err := s.Create(ctx, key, []byte("v1")); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("Create() on existing key err = %v, expected ErrKeyExists", err)
+	}
+	updated, err := s.Update(ctx, key, []byte("v2"), 0)
+	if err != nil {
+		t.Fatalf("Update() err = %v", err)
+	}
+	if updated.Revision <= created.Revision {
+		t.Errorf("revision did not increase: %d -> %d", created.Revision, updated.Revision)
+	}
+	got, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if string(got.Value) != "v2" {
+		t.Errorf("Get() = %q, expected %q", got.Value, "v2")
+	}
+	if _, err := s.Update(ctx, Key("pods", "default", "missing"), nil, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update() on missing key err = %v, expected ErrNotFound", err)
+	}
+	if _, err := s.Delete(ctx, key, 0); err != nil {
+		t.Fatalf("Delete() err = %v", err)
+	}
+	if _, err := s.Delete(ctx, key, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() on missing key err = %v, expected ErrNotFound", err)
+	}
+}
+
+func testPreconditions(t *testing.T, s Interface) {
+	ctx := t.Context()
+	key := Key("pods", "default", "a")
+	created, err := s.Create(ctx, key, []byte("v1"))
+	if err != nil {
+		t.Fatalf("Create() err = %v", err)
+	}
+	updated, err := s.Update(ctx, key, []byte("v2"), created.Revision)
+	if err != nil {
+		t.Fatalf("Update() at current revision err = %v", err)
+	}
+	if _, err := s.Update(ctx, key, []byte("v3"), created.Revision); !errors.Is(err, ErrConflict) {
+		t.Errorf("Update() at stale revision err = %v, expected ErrConflict", err)
+	}
+	if _, err := s.Delete(ctx, key, created.Revision); !errors.Is(err, ErrConflict) {
+		t.Errorf("Delete() at stale revision err = %v, expected ErrConflict", err)
+	}
+	got, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if string(got.Value) != "v2" {
+		t.Errorf("stale writes went through, Get() = %q, expected %q", got.Value, "v2")
+	}
+	if _, err := s.Update(ctx, Key("pods", "default", "missing"), nil, updated.Revision); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update() on missing key err = %v, expected ErrNotFound", err)
+	}
+	deleted, err := s.Delete(ctx, key, updated.Revision)
+	if err != nil {
+		t.Fatalf("Delete() at current revision err = %v", err)
+	}
+	if string(deleted.Value) != "v2" {
+		t.Errorf("Delete() = %q, expected last value %q", deleted.Value, "v2")
+	}
+}
+
+func testList(t *testing.T, s Interface) {
+	ctx := t.Context()
+	for _, k := range []string{
+		Key("pods", "default", "b"),
+		Key("pods", "default", "a"),
+		Key("pods", "other", "c"),
+		Key("nodes", "", "n1"),
+	} {
+		if _, err := s.Create(ctx, k, []byte(k)); err != nil {
+			t.Fatalf("Create(%q) err = %v", k, err)
+		}
+	}
+	testCases := []struct {
+		prefix   string
+		expected []string
+	}{
+		{Prefix("pods", ""), []string{"pods/default/a", "pods/default/b", "pods/other/c"}},
+		{Prefix("pods", "default"), []string{"pods/default/a", "pods/default/b"}},
+		{Prefix("services", ""), []string{}},
+	}
+	for _, tc := range testCases {
+		kvs, _, err := s.List(ctx, tc.prefix)
+		if err != nil {
+			t.Fatalf("List(%q) err = %v", tc.prefix, err)
+		}
+		if len(kvs) != len(tc.expected) {
+			t.Fatalf("List(%q) returned %d keys, expected %d", tc.prefix, len(kvs), len(tc.expected))
+		}
+		for i, kv := range kvs {
+			if kv.Key != tc.expected[i] {
+				t.Errorf("List(%q)[%d] = %q, expected %q", tc.prefix, i, kv.Key, tc.expected[i])
+			}
+		}
+	}
+}
+
+func testWatch(t *testing.T, s Interface) {
+	ctx, cancel := context.WithCancel(t.Context())
+	ch, err := s.Watch(ctx, Prefix("pods", "default"), 0)
+	if err != nil {
+		t.Fatalf("Watch() err = %v", err)
+	}
+	key := Key("pods", "default", "a")
+	s.Create(ctx, key, []byte("v1"))
+	s.Create(ctx, Key("pods", "other", "b"), []byte("ignored"))
+	s.Update(ctx, key, []byte("v2"), 0)
+	s.Delete(ctx, key, 0)
+
+	expectEvents(t, ch, key, []EventType{Added, Modified, Deleted})
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("expected channel to be closed after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Error("timeout waiting for channel to close")
+	}
+}
+
+func testWatchFromRevision(t *testing.T, s Interface) {
+	ctx := t.Context()
+	key := Key("pods", "default", "a")
+	created, err := s.Create(ctx, key, []byte("v1"))
+	if err != nil {
+		t.Fatalf("Create() err = %v", err)
+	}
+	s.Update(ctx, key, []byte("v2"), 0)
+	deleted, err := s.Delete(ctx, key, 0)
+	if err != nil {
+		t.Fatalf("Delete() err = %v", err)
+	}
+
+	// events after the create are replayed
+	ch, err := s.Watch(ctx, Prefix("pods", ""), created.Revision)
+	if err != nil {
+		t.Fatalf("Watch() err = %v", err)
+	}
+	expectEvents(t, ch, key, []EventType{Modified, Deleted})
+
+	// watching from the latest revision only sees new events
+	ch, err = s.Watch(ctx, Prefix("pods", ""), deleted.Revision)
+	if err != nil {
+		t.Fatalf("Watch() err = %v", err)
+	}
+	s.Create(ctx, key, []byte("v3"))
+	expectEvents(t, ch, key, []EventType{Added})
+}
+
+func expectEvents(t *testing.T, ch <-chan Event, key string, expected []EventType) {
+	t.Helper()
+	for i, et := range expected {
+		select {
+		case ev := <-ch:
+			if ev.Type != et || ev.Key != key {
+				t.Errorf("event %d = %v %q, expected %v %q", i, ev.Type, ev.Key, et, key)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for event %d", i)
+		}
+	}
+}
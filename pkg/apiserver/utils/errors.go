@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"superminikube/pkg/apiserver/storage"
+)
+
+// object failed validation, maps to 422
+var ErrInvalid = errors.New("invalid object")
+
+// WriteError maps service and storage errors onto status codes
+func WriteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, storage.ErrKeyExists), errors.Is(err, storage.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		slog.Error("failed to process request", "error", err)
+		http.Error(w, "Failed to process request", http.StatusInternalServerError)
+	}
+}
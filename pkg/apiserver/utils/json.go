@@ -1,17 +1,63 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+
+	"superminikube/pkg/api"
 )
 
+// DecodeBody strictly decodes a JSON or YAML request body into v, fields v doesn't have are rejected.
+// YAML is picked by the content type, anything else is taken to be JSON.
+// An object's apiVersion and kind are checked against it and dropped, they aren't stored.
+func DecodeBody(r *http.Request, v any) error {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return errors.New("Malformed request")
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return errors.New("Empty request body")
+	}
+	if isYAML(r.Header.Get("Content-Type")) {
+		body, err = api.YAMLToJSON(body)
+		if err != nil {
+			return fmt.Errorf("Malformed request: %v", err)
+		}
+	}
+	if err := api.DecodeStrict(body, v); err != nil {
+		return fmt.Errorf("Malformed request: %v", err)
+	}
+	if obj, ok := v.(api.Object); ok {
+		if err := api.CheckTypeMeta(obj); err != nil {
+			return fmt.Errorf("Malformed request: %v", err)
+		}
+		*obj.GetTypeMeta() = api.TypeMeta{}
+	}
+	return nil
+}
+
+func isYAML(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return true
+	}
+	return false
+}
+
 func WriteJSONResponse(w http.ResponseWriter, statusCode int, data any) {
-	w.Header().Set("content-type:", "application/json")
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	err := json.NewEncoder(w).Encode(data)
 	if err != nil {
 		http.Error(w, "failed to process request", http.StatusInternalServerError)
 		slog.Error("failed to write json response", "error", err)
 	}
-}
\ No newline at end of file
+}
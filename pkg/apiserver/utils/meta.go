@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+)
+
+const DefaultNamespace = "default"
+
+// PrepareObjectMetaForCreate fills in the server owned metadata of a new object.
+// Objects without a name get generateName plus a random suffix, or are named after their uid if that's empty too.
+func PrepareObjectMetaForCreate(m *api.ObjectMeta, now time.Time) {
+	m.Uid = uuid.New()
+	if m.Name == "" && m.GenerateName != "" {
+		m.Name = m.GenerateName + randomSuffix()
+	}
+	if m.Name == "" {
+		m.Name = m.Uid.String()
+	}
+	if m.Namespace == "" {
+		m.Namespace = DefaultNamespace
+	}
+	m.CreationTimestamp = now.UTC()
+	m.DeletionTimestamp = nil
+	m.DeletionGracePeriodSeconds = nil
+	m.Generation = 1
+	m.ResourceVersion = ""
+}
+
+// PrepareObjectMetaForUpdate carries the server owned metadata over from the stored object,
+// bumping the generation when the spec changed.
+func PrepareObjectMetaForUpdate(m *api.ObjectMeta, old api.ObjectMeta, specChanged bool) {
+	m.Name = old.Name
+	m.Namespace = old.Namespace
+	m.Uid = old.Uid
+	m.CreationTimestamp = old.CreationTimestamp
+	m.DeletionTimestamp = old.DeletionTimestamp
+	m.DeletionGracePeriodSeconds = old.DeletionGracePeriodSeconds
+	m.Generation = old.Generation
+	if specChanged {
+		m.Generation++
+	}
+	m.ResourceVersion = old.ResourceVersion
+}
+
+// no vowels so generated names don't spell words
+const suffixAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+
+func randomSuffix() string {
+	b := make([]byte, 5)
+	for i := range b {
+		b[i] = suffixAlphabet[rand.IntN(len(suffixAlphabet))]
+	}
+	return string(b)
+}
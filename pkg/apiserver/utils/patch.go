@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	MergePatchType          = "application/merge-patch+json"
+	StrategicMergePatchType = "application/strategic-merge-patch+json"
+)
+
+// directive a strategic merge patch list element can carry to remove itself
+const patchDirective = "$patch"
+
+// MergePatch applies a JSON merge patch (RFC 7386) to original.
+// Objects are merged recursively, null removes a field and anything else replaces it.
+func MergePatch(original, patch []byte) ([]byte, error) {
+	return applyPatch(original, patch, nil)
+}
+
+// StrategicMergePatch is MergePatch except lists named in mergeKeys are merged element by element,
+// matching elements on the given key instead of replacing the whole list.
+// An element of the form {"<key>": "x", "$patch": "delete"} removes x from the list.
+func StrategicMergePatch(original, patch []byte, mergeKeys map[string]string) ([]byte, error) {
+	return applyPatch(original, patch, mergeKeys)
+}
+
+func applyPatch(original, patch []byte, mergeKeys map[string]string) ([]byte, error) {
+	var o, p any
+	if err := json.Unmarshal(original, &o); err != nil {
+		return nil, fmt.Errorf("failed to decode original: %v", err)
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("failed to decode patch: %v", err)
+	}
+	merged, err := mergeValue(o, p, "", mergeKeys)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(merged)
+}
+
+func mergeValue(original, patch any, field string, mergeKeys map[string]string) (any, error) {
+	switch p := patch.(type) {
+	case map[string]any:
+		o, ok := original.(map[string]any)
+		if !ok {
+			o = map[string]any{}
+		}
+		for k, v := range p {
+			if v == nil {
+				delete(o, k)
+				continue
+			}
+			merged, err := mergeValue(o[k], v, k, mergeKeys)
+			if err != nil {
+				return nil, err
+			}
+			o[k] = merged
+		}
+		return o, nil
+	case []any:
+		key, ok := mergeKeys[field]
+		o, isList := original.([]any)
+		if !ok || !isList {
+			return p, nil
+		}
+		return mergeList(o, p, key, mergeKeys)
+	default:
+		return patch, nil
+	}
+}
+
+func mergeList(original, patch []any, key string, mergeKeys map[string]string) ([]any, error) {
+	merged := append([]any{}, original...)
+	for _, pe := range patch {
+		pm, ok := pe.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("list elements must be objects to merge on %q", key)
+		}
+		id, ok := pm[key]
+		if !ok {
+			return nil, fmt.Errorf("list element is missing merge key %q", key)
+		}
+		idx := -1
+		for i, oe := range merged {
+			if om, ok := oe.(map[string]any); ok && om[key] == id {
+				idx = i
+				break
+			}
+		}
+		if pm[patchDirective] == "delete" {
+			if idx >= 0 {
+				merged = append(merged[:idx], merged[idx+1:]...)
+			}
+			continue
+		}
+		delete(pm, patchDirective)
+		if idx < 0 {
+			merged = append(merged, pm)
+			continue
+		}
+		m, err := mergeValue(merged[idx], pm, "", mergeKeys)
+		if err != nil {
+			return nil, err
+		}
+		merged[idx] = m
+	}
+	return merged, nil
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/storage"
+)
+
+// Stored is a pointer to an object the services keep in storage, T being the object
+type Stored[T any] interface {
+	*T
+	storage.ResourceVersioner
+	GetObjectMeta() *api.ObjectMeta
+}
+
+// GetObject reads the object stored at key
+func GetObject[T any, PT Stored[T]](ctx context.Context, store storage.Interface, key string) (T, error) {
+	var obj T
+	kv, err := store.Get(ctx, key)
+	if err != nil {
+		return obj, fmt.Errorf("failed to get %s from store: %w", key, err)
+	}
+	if err := storage.Decode(kv, PT(&obj)); err != nil {
+		return obj, err
+	}
+	return obj, nil
+}
+
+// ListObjects reads every object stored under prefix, along with the resourceVersion the list was read at
+func ListObjects[T any, PT Stored[T]](ctx context.Context, store storage.Interface, prefix string) ([]T, string, error) {
+	kvs, rev, err := store.List(ctx, prefix)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list %s: %v", prefix, err)
+	}
+	items := make([]T, 0, len(kvs))
+	for _, kv := range kvs {
+		var obj T
+		if err := storage.Decode(kv, PT(&obj)); err != nil {
+			return nil, "", err
+		}
+		items = append(items, obj)
+	}
+	return items, strconv.FormatInt(rev, 10), nil
+}
+
+// CreateObject stores obj at key, failing with storage.ErrKeyExists if the key is taken
+func CreateObject[T any, PT Stored[T]](ctx context.Context, store storage.Interface, key string, obj T) (T, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return obj, fmt.Errorf("failed to encode %s: %v", key, err)
+	}
+	kv, err := store.Create(ctx, key, b)
+	if err != nil {
+		return obj, fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	PT(&obj).SetResourceVersion(strconv.FormatInt(kv.Revision, 10))
+	return obj, nil
+}
+
+// UpdateObject reads the object stored at key, hands it to tryUpdate and writes back the result,
+// but only if the object wasn't written in between. Results that change nothing aren't written.
+// With a resourceVersion the stored object has to be at that version or the update fails with storage.ErrConflict.
+// Without one, losing a race to another writer just means running tryUpdate again on the newer object.
+func UpdateObject[T any, PT Stored[T]](ctx context.Context, store storage.Interface, key, resourceVersion string, tryUpdate func(old T) (T, error)) (T, error) {
+	var zero T
+	precondition, err := storage.ParseResourceVersion(resourceVersion)
+	if err != nil {
+		return zero, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	for {
+		kv, err := store.Get(ctx, key)
+		if err != nil {
+			return zero, fmt.Errorf("failed to get %s from store: %w", key, err)
+		}
+		var old T
+		if err := storage.Decode(kv, PT(&old)); err != nil {
+			return zero, err
+		}
+		if precondition > 0 && precondition != kv.Revision {
+			return zero, fmt.Errorf("%w: %s is at resourceVersion %d, not %d", storage.ErrConflict, key, kv.Revision, precondition)
+		}
+		updated, err := tryUpdate(old)
+		if err != nil {
+			return zero, err
+		}
+		if reflect.DeepEqual(updated, old) {
+			return old, nil
+		}
+		b, err := json.Marshal(updated)
+		if err != nil {
+			return zero, fmt.Errorf("failed to encode %s: %v", key, err)
+		}
+		kv, err = store.Update(ctx, key, b, kv.Revision)
+		if errors.Is(err, storage.ErrConflict) && precondition == 0 {
+			slog.Debug("object changed during update, retrying", "key", key)
+			continue
+		}
+		if err != nil {
+			return zero, fmt.Errorf("failed to update %s: %w", key, err)
+		}
+		PT(&updated).SetResourceVersion(strconv.FormatInt(kv.Revision, 10))
+		return updated, nil
+	}
+}
+
+// DeleteObject removes the object stored at key and returns it as it was.
+// opts.ResourceVersion has to match the stored object, same as UpdateObject, and so does opts.Uid,
+// so an object recreated under the same name isn't deleted in place of the one the caller meant.
+func DeleteObject[T any, PT Stored[T]](ctx context.Context, store storage.Interface, key string, opts api.DeleteOptions) (T, error) {
+	var zero T
+	precondition, err := storage.ParseResourceVersion(opts.ResourceVersion)
+	if err != nil {
+		return zero, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	for {
+		kv, err := store.Get(ctx, key)
+		if err != nil {
+			return zero, fmt.Errorf("failed to get %s from store: %w", key, err)
+		}
+		var old T
+		if err := storage.Decode(kv, PT(&old)); err != nil {
+			return zero, err
+		}
+		if precondition > 0 && precondition != kv.Revision {
+			return zero, fmt.Errorf("%w: %s is at resourceVersion %d, not %d", storage.ErrConflict, key, kv.Revision, precondition)
+		}
+		if uid := PT(&old).GetObjectMeta().Uid; opts.Uid != uuid.Nil && opts.Uid != uid {
+			return zero, fmt.Errorf("%w: %s has uid %s, not %s", storage.ErrConflict, key, uid, opts.Uid)
+		}
+		kv, err = store.Delete(ctx, key, kv.Revision)
+		if errors.Is(err, storage.ErrConflict) && precondition == 0 {
+			slog.Debug("object changed during delete, retrying", "key", key)
+			continue
+		}
+		if err != nil {
+			return zero, fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+		var obj T
+		if err := storage.Decode(kv, PT(&obj)); err != nil {
+			return zero, err
+		}
+		return obj, nil
+	}
+}
+
+// MatchURL fills in obj's namespace and name from the url, rejecting bodies that disagree with it
+func MatchURL(obj api.Object, namespace, name string) error {
+	m := obj.GetObjectMeta()
+	if m.Namespace != "" && m.Namespace != namespace {
+		return fmt.Errorf("%s namespace does not match url", api.KindOf(obj))
+	}
+	if m.Name != "" && m.Name != name {
+		return fmt.Errorf("%s name does not match url", api.KindOf(obj))
+	}
+	m.Namespace = namespace
+	m.Name = name
+	return nil
+}
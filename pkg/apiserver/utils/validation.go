@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+
+	"superminikube/pkg/api"
+)
+
+var (
+	dns1123Label     = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	dns1123Subdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// ValidateName checks name is a DNS-1123 subdomain, the format object names need
+func ValidateName(name string) error {
+	if len(name) > 253 || !dns1123Subdomain.MatchString(name) {
+		return fmt.Errorf("%w: name %q must be a lowercase RFC 1123 subdomain", ErrInvalid, name)
+	}
+	return nil
+}
+
+// ValidateLabel checks s is a DNS-1123 label, used for namespaces and container names
+func ValidateLabel(field, s string) error {
+	if len(s) > 63 || !dns1123Label.MatchString(s) {
+		return fmt.Errorf("%w: %s %q must be a lowercase RFC 1123 label", ErrInvalid, field, s)
+	}
+	return nil
+}
+
+func ValidateObjectMeta(m api.ObjectMeta) error {
+	if err := ValidateName(m.Name); err != nil {
+		return err
+	}
+	return ValidateLabel("namespace", m.Namespace)
+}
+
+// ValidateClusterObjectMeta is ValidateObjectMeta for objects that don't live in a namespace
+func ValidateClusterObjectMeta(m api.ObjectMeta) error {
+	if err := ValidateName(m.Name); err != nil {
+		return err
+	}
+	if m.Namespace != "" {
+		return fmt.Errorf("%w: %s isn't namespaced", ErrInvalid, m.Name)
+	}
+	return nil
+}
+
+func ValidateResourceList(field string, r api.ResourceList) error {
+	if r.MilliCPU < 0 || r.Memory < 0 || r.Pods < 0 {
+		return fmt.Errorf("%w: %s can't be negative", ErrInvalid, field)
+	}
+	return nil
+}
+
+// ValidateTaintEffect checks effect is one of the known taint effects, empty is only allowed if allowEmpty is set
+func ValidateTaintEffect(effect api.TaintEffect, allowEmpty bool) error {
+	switch effect {
+	case api.TaintEffectNoSchedule, api.TaintEffectPreferNoSchedule, api.TaintEffectNoExecute:
+		return nil
+	case "":
+		if allowEmpty {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown taint effect %q", ErrInvalid, effect)
+}
@@ -3,14 +3,16 @@ package watch
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
+
+	"superminikube/pkg/apiserver/storage"
 )
 
 func (ws *WatchService) WatchHandler(w http.ResponseWriter, r *http.Request) {
-	defer ws.Shutdown()
 	// want this to be a stream
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -19,31 +21,46 @@ func (ws *WatchService) WatchHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	flusher, ok := w.(http.Flusher)
 	ctx, cancel := context.WithCancel(r.Context())
-	defer cancel() // Ensure that cancel is called when done
+	defer cancel() // Ensure that cancel is called when done, this also stops the storage watch
 	if !ok {
 		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
 		return
 	}
-	// couple cases
-	// on connect (client hits /watch endpoint)
-	// for now nothing special happens
-	// dispatch (send received event to client side)
-	// on disconnect
-	// aka cancelled req context
-	// on ctx.Done()
-	// cleanly close client connections
+	// ?resource= watches everything of that kind, otherwise the pods on ?nodename=,
+	// an empty nodename watches pods that haven't been scheduled yet
+	resource := r.URL.Query().Get("resource")
 	nodename := r.URL.Query().Get("nodename")
-	// NOTE: in theory an empty value here shouldn't cause a problem
-	if nodename == "" {
-		http.Error(w, "nodename required", http.StatusBadRequest)
+	// clients resume from the last resourceVersion they saw so no events are lost between reconnects
+	revision, err := storage.ParseResourceVersion(r.URL.Query().Get("resourceVersion"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var ch <-chan WatchEvent
+	if resource != "" {
+		ch, err = ws.WatchResource(ctx, resource, revision)
+	} else {
+		ch, err = ws.Watch(ctx, nodename, revision)
+	}
+	if errors.Is(err, storage.ErrCompacted) {
+		http.Error(w, err.Error(), http.StatusGone)
 		return
 	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
 	keepAliveTicker := time.NewTicker(15 * time.Second)
 	defer keepAliveTicker.Stop()
-	ch := ws.Watch(fmt.Sprintf("pod/%s", nodename))
 	for {
 		select {
-		case ev := <-ch:
+		case ev, ok := <-ch:
+			if !ok {
+				slog.Debug("watch channel closed")
+				return
+			}
 			slog.Info(fmt.Sprintf("received event: %v", ev))
 			b, err := json.Marshal(ev)
 			if err != nil {
@@ -63,13 +80,7 @@ func (ws *WatchService) WatchHandler(w http.ResponseWriter, r *http.Request) {
 			flusher.Flush()
 		case <-ctx.Done():
 			slog.Debug("request context done")
-			//TODO: close and delete channel
 			return
-		// case <-parentCtx.Done():
-		// 	slog.Debug("watch service context done")
-		// 	// TODO: send an event so client can disconnect cleanly
-		// 	return
-		// }
 		}
 	}
 }
@@ -2,119 +2,122 @@ package watch
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
-	"sync"
-
-	etcdClient "go.etcd.io/etcd/client/v3"
+	"strconv"
 
 	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/storage"
 )
 
 type Service interface {
-	Watch(string) <-chan WatchEvent
+	Watch(ctx context.Context, nodename string, revision int64) (<-chan WatchEvent, error)
+	WatchResource(ctx context.Context, resource string, revision int64) (<-chan WatchEvent, error)
 }
 
-// should probably make getter and setter private
-func (ws *WatchService) Get(key string) (chan WatchEvent, error) {
-	ws.mu.Lock()
-	defer ws.mu.Unlock()
-	val, ok := ws.watchers[key]
-	if !ok {
-		return nil, fmt.Errorf("key %v doesn't exist", key)
-	}
-	return val, nil
+// WatchService turns storage events into pod events for a node.
+// It holds no state of its own, every watcher gets its own storage watch
+// so the store (etcd or otherwise) is the single source of truth.
+type WatchService struct {
+	store storage.Interface
 }
 
-func (ws *WatchService) Set(key string, value chan WatchEvent) error {
-	ws.mu.Lock()
-	defer ws.mu.Unlock()
-	if key == "" {
-		return fmt.Errorf("key cannot be empty")
-	}
-	ws.watchers[key] = value
-	return nil
+// Watch streams events for pods assigned to nodename that happened after revision,
+// an empty nodename streams pods waiting on the scheduler. A revision of 0 starts from now. The channel is closed once ctx is done.
+func (ws *WatchService) Watch(ctx context.Context, nodename string, revision int64) (<-chan WatchEvent, error) {
+	return ws.watch(ctx, "pods", revision, func(ev WatchEvent) bool {
+		return ev.Pod.Nodename == nodename
+	})
 }
 
-func (ws *WatchService) Shutdown() {
-	// slog.Debug("waiting for goroutines")
-	// ws.wg.Wait()
-	slog.Debug("cleaning up channels")
-	for k := range ws.watchers {
-		// TODO: Create a cleanup method that does this
-		// this way can cleanup on client disconnects or do it in bulk on shutdown
-		close(ws.watchers[k])
-		delete(ws.watchers, k)
-	}
+// WatchResource streams every event for resource, across namespaces, that happened after revision.
+// It's what controllers use, they care about all objects of a kind rather than one node's pods.
+func (ws *WatchService) WatchResource(ctx context.Context, resource string, revision int64) (<-chan WatchEvent, error) {
+	return ws.watch(ctx, resource, revision, func(WatchEvent) bool { return true })
 }
 
-// TODO: Move notify outside of this package, most likely belongs elsewhere
-// will take chan as argument
-// Notify watch service of a mutation event
-func (ws *WatchService) Notify(ev WatchEvent) error {
-	key := fmt.Sprintf("%s/%s", ev.Resource, ev.Node)
-	slog.Debug("notifying watcher", "watcher", ws.watchers[key], "event", ev, "key", key)
-	ws.mu.Lock()
-	defer ws.mu.Unlock()
-	_, ok := ws.watchers[key]
-	if !ok {
-		return fmt.Errorf("failed to notify key: %s not found", key)
+func (ws *WatchService) watch(ctx context.Context, resource string, revision int64, keep func(WatchEvent) bool) (<-chan WatchEvent, error) {
+	events, err := ws.store.Watch(ctx, storage.Prefix(resource, ""), revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", resource, err)
 	}
-	ws.watchers[key] <- ev
-	return nil
+	ch := make(chan WatchEvent)
+	go func() {
+		defer close(ch)
+		for ev := range events {
+			we := WatchEvent{
+				EventType:       toEvent(ev.Type),
+				Resource:        resource,
+				ResourceVersion: strconv.FormatInt(ev.Revision, 10),
+				Object:          ev.Value,
+			}
+			// pods are decoded up front, the kubelet and scheduler only ever look at those
+			if resource == "pods" {
+				if err := storage.Decode(ev.KeyValue, &we.Pod); err != nil {
+					slog.Error("failed to decode watch event", "key", ev.Key, "error", err)
+					continue
+				}
+				we.Node = we.Pod.Nodename
+			}
+			if !keep(we) {
+				continue
+			}
+			select {
+			case ch <- we:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
 }
 
-func (ws *WatchService) Watch(key string) <-chan WatchEvent {
-	// what watch will do now:
-	// create the channel
-	// start a goroutine
-	// etcd Watch
-	// send WatchEvent to channel when etcd Watch notifies
-	// considerations
-	// how do we handle disconnects i.e. client disconnected
-	ev := ws.etcd.Watcher.Watch(context.TODO(), key)
-	// probably should document what these keys look like: 'typically a resource pod/node'
-	// 
-	// make a channel for the key add it to map of channels
-	// TODO: Avoid making duplicate channels
-	// ev := make(chan WatchEvent)
-	// err := ws.Set(key, ev)
-	// if err != nil {
-	// 	// TODO: return error
-	// 	slog.Error("failed to add channel to watchers")
-	// 	return nil
-	// }
-	return ev
+func toEvent(t storage.EventType) Event {
+	switch t {
+	case storage.Added:
+		return Add
+	case storage.Deleted:
+		return Delete
+	default:
+		return Modified
+	}
 }
 
-func NewService(client *etcdClient.Client) *WatchService {
+func NewService(store storage.Interface) *WatchService {
 	return &WatchService{
-		watchers: make(Watchers),
-		etcd: client,
+		store: store,
 	}
 }
 
 type WatchEvent struct {
 	EventType Event
-	// these fields are tentative
+	// storage resource the object belongs to, e.g. "pods"
 	Resource string
 	Node     string
-	// TODO: will need to pass a general 'object' here
-	// will be an interface that every object implements
-	// or will have a field that designates it's type
+	// store revision of the event, watch from here to pick up where this event left off
+	ResourceVersion string
+	// the object as stored, decode it with DecodeObject
+	Object json.RawMessage
+	// filled in for pod events
 	Pod api.Pod
 }
 
-// TODO: Add events type here
+// DecodeObject decodes the event's object into out, stamping it with the event's resourceVersion
+func (e WatchEvent) DecodeObject(out any) error {
+	if err := json.Unmarshal(e.Object, out); err != nil {
+		return fmt.Errorf("failed to decode %s event: %v", e.Resource, err)
+	}
+	if v, ok := out.(storage.ResourceVersioner); ok {
+		v.SetResourceVersion(e.ResourceVersion)
+	}
+	return nil
+}
+
 const (
 	Add Event = iota
 	Delete
+	Modified
 )
 
 type Event int
-type Watchers map[string]chan WatchEvent
-type WatchService struct {
-	watchers Watchers
-	mu       sync.RWMutex
-	etcd     *etcdClient.Client
-}
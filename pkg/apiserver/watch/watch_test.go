@@ -1,218 +1,187 @@
 package watch
 
 import (
-	"fmt"
-	"sync"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/storage"
+	"superminikube/pkg/apiserver/storage/etcdtest"
 )
 
-func TestGet(t *testing.T) {
-	ch := make(chan WatchEvent)
-	ws := WatchService{
-		watchers: Watchers{
-			"test": ch,
+func putPod(t *testing.T, store storage.Interface, nodename string) (api.Pod, storage.KeyValue) {
+	t.Helper()
+	p := api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:      uuid.NewString(),
+			Namespace: "default",
+			Uid:       uuid.New(),
 		},
+		Nodename: nodename,
 	}
-	testCases := []struct {
-		key      string
-		expected chan WatchEvent
-		wantErr  bool
-	}{
-		{"test", ch, false},        // existing key
-		{"nonexistent", nil, true}, // missing key
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("failed to encode pod: %v", err)
 	}
-
-	for _, tc := range testCases {
-		val, err := ws.Get(tc.key)
-		if (err != nil) != tc.wantErr {
-			t.Errorf("Get(%q) error = %v, want %v", tc.key, err, tc.wantErr)
-			continue
-		}
-
-		if val != tc.expected {
-			t.Errorf("Get(%q) = %v, expected %v", tc.key, val, tc.expected)
-		}
+	kv, err := store.Create(t.Context(), storage.Key("pods", p.Namespace, p.Name), b)
+	if err != nil {
+		t.Fatalf("failed to store pod: %v", err)
 	}
+	return p, kv
 }
 
-func TestSet(t *testing.T) {
-	ws := NewService()
-	testCases := []struct {
-		key     string
-		value   chan WatchEvent
-		wantErr bool
-	}{
-		{"test", nil, false},
-		{"", make(chan WatchEvent), true},
-	}
-	for _, tc := range testCases {
-		err := ws.Set(tc.key, tc.value)
-		if (err != nil) != tc.wantErr {
-			t.Errorf("Set(%q) err = %v", tc.key, err)
+func expectEvent(t *testing.T, ch <-chan WatchEvent, eventType Event, uid uuid.UUID) WatchEvent {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		if ev.EventType != eventType || ev.Pod.Uid != uid {
+			t.Errorf("received event %v for %s, expected %v for %s", ev.EventType, ev.Pod.Uid, eventType, uid)
 		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for event")
 	}
-}
-// TODO: check this test out... might end up refactoring all of my tests anyways
-func TestSetGetConcurrently(t *testing.T) {
-	ws := NewService()
-
-	const numGoroutines = 50
-	const numOpsPerGoroutine = 100
-
-	var wg sync.WaitGroup
-
-	for g := 0; g < numGoroutines; g++ {
-		wg.Go(func() {
-			for i := 0; i < numOpsPerGoroutine; i++ {
-				key := fmt.Sprintf("key-%d-%d", g, i)
-				ch := make(chan WatchEvent)
-				if err := ws.Set(key, ch); err != nil {
-					t.Errorf("Set(%q) failed: %v", key, err)
-				}
-
-				got, err := ws.Get(key)
-				if err != nil {
-					t.Errorf("Get(%q) failed: %v", key, err)
-				}
-				if got != ch {
-					t.Errorf("Get(%q) = %v, expected %v", key, got, ch)
-				}
-			}
-		})
-	}
-	wg.Wait()
+	return WatchEvent{}
 }
 
-func TestNotify(t *testing.T) {
-	testCases := []struct {
-		name     string
-		setup    func(*WatchService) string
-		event    WatchEvent
-		wantErr  bool
-		validate func(*testing.T, chan WatchEvent, WatchEvent)
-	}{
-		{
-			name: "successful notification",
-			setup: func(ws *WatchService) string {
-				key := "pod/test-node"
-				ws.Watch(key)
-				return key
-			},
-			event: WatchEvent{
-				Resource: "pod",
-				Node:     "test-node",
-				EventType:     Add,
-			},
-			wantErr: false,
-			validate: func(t *testing.T, ch chan WatchEvent, expected WatchEvent) {
-				select {
-				case received := <-ch:
-					if received.Resource != expected.Resource || received.Node != expected.Node || received.EventType != expected.EventType {
-						t.Errorf("received event %+v, expected %+v", received, expected)
-					}
-				case <-time.After(100 * time.Millisecond):
-					t.Error("timeout waiting for event")
-				}
-			},
-		},
-		{
-			name: "notify non-existent key",
-			setup: func(ws *WatchService) string {
-				return "pod/nonexistent"
-			},
-			event: WatchEvent{
-				Resource: "pod",
-				Node:     "nonexistent",
-				EventType:     Add,
-			},
-			wantErr:  true,
-			validate: nil,
-		},
+func TestWatch(t *testing.T) {
+	store := storage.NewMemoryStore()
+	ws := NewService(store)
+	ch, err := ws.Watch(t.Context(), "node1", 0)
+	if err != nil {
+		t.Fatalf("Watch() err = %v", err)
 	}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			ws := NewService()
-			key := tc.setup(ws)
+	// pods on other nodes are filtered out
+	putPod(t, store, "node2")
+	p, kv := putPod(t, store, "node1")
+	ev := expectEvent(t, ch, Add, p.Uid)
+	if ev.Node != "node1" || ev.Resource != "pods" {
+		t.Errorf("received event %+v, expected pod event for node1", ev)
+	}
+	if ev.Pod.ResourceVersion == "" {
+		t.Error("expected event pod to have a resourceVersion")
+	}
 
-			var err error
-			if tc.validate != nil {
-				go func() {
-					err = ws.Notify(tc.event)
-				}()
+	p.Labels = map[string]string{"app": "nginx"}
+	b, _ := json.Marshal(p)
+	store.Update(t.Context(), kv.Key, b, 0)
+	expectEvent(t, ch, Modified, p.Uid)
 
-				ch, _ := ws.Get(key)
-				tc.validate(t, ch, tc.event)
+	store.Delete(t.Context(), kv.Key, 0)
+	expectEvent(t, ch, Delete, p.Uid)
+}
 
-				if (err != nil) != tc.wantErr {
-					t.Errorf("Notify() error = %v, wantErr %v", err, tc.wantErr)
-				}
-			} else {
-				err = ws.Notify(tc.event)
-				if (err != nil) != tc.wantErr {
-					t.Errorf("Notify() error = %v, wantErr %v", err, tc.wantErr)
-				}
-			}
-		})
+func TestWatchResource(t *testing.T) {
+	store := storage.NewMemoryStore()
+	ws := NewService(store)
+	ch, err := ws.WatchResource(t.Context(), "replicasets", 0)
+	if err != nil {
+		t.Fatalf("WatchResource() err = %v", err)
 	}
-}
 
-func TestShutdown(t *testing.T) {
-	testCases := []struct {
-		name         string
-		watcherKeys  []string
-		expectClosed bool
-		expectEmpty  bool
-	}{
-		{
-			name:         "shutdown with multiple watchers",
-			watcherKeys:  []string{"pod/node1", "pod/node2", "pod/node3"},
-			expectClosed: true,
-			expectEmpty:  true,
-		},
-		{
-			name:         "shutdown with single watcher",
-			watcherKeys:  []string{"pod/node1"},
-			expectClosed: true,
-			expectEmpty:  true,
-		},
-		{
-			name:         "shutdown with no watchers",
-			watcherKeys:  []string{},
-			expectClosed: false,
-			expectEmpty:  true,
-		},
+	// other resources are filtered out
+	putPod(t, store, "node1")
+	rs := api.ReplicaSet{ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New()}}
+	b, _ := json.Marshal(rs)
+	kv, err := store.Create(t.Context(), storage.Key("replicasets", rs.Namespace, rs.Name), b)
+	if err != nil {
+		t.Fatalf("failed to store replica set: %v", err)
+	}
+	select {
+	case ev := <-ch:
+		if ev.EventType != Add || ev.Resource != "replicasets" {
+			t.Errorf("received event %+v, expected a replica set add", ev)
+		}
+		var got api.ReplicaSet
+		if err := ev.DecodeObject(&got); err != nil {
+			t.Fatalf("DecodeObject() err = %v", err)
+		}
+		if got.Uid != rs.Uid || got.ResourceVersion != strconv.FormatInt(kv.Revision, 10) {
+			t.Errorf("decoded %+v, expected %s at revision %d", got.ObjectMeta, rs.Uid, kv.Revision)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for event")
 	}
+}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			ws := NewService()
+func TestWatchFromResourceVersion(t *testing.T) {
+	store := storage.NewMemoryStore()
+	ws := NewService(store)
+	_, first := putPod(t, store, "node1")
+	second, _ := putPod(t, store, "node1")
 
-			channels := make([]<-chan WatchEvent, 0, len(tc.watcherKeys))
-			for _, key := range tc.watcherKeys {
-				ch := ws.Watch(key)
-				channels = append(channels, ch)
-			}
+	// resuming after the first pod only replays the second
+	ch, err := ws.Watch(t.Context(), "node1", first.Revision)
+	if err != nil {
+		t.Fatalf("Watch() err = %v", err)
+	}
+	expectEvent(t, ch, Add, second.Uid)
+	select {
+	case ev := <-ch:
+		t.Errorf("unexpected event %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
 
-			if len(ws.watchers) != len(tc.watcherKeys) {
-				t.Errorf("expected %d watchers before shutdown, got %d", len(tc.watcherKeys), len(ws.watchers))
-			}
+func TestWatchClosesOnCancel(t *testing.T) {
+	ws := NewService(storage.NewMemoryStore())
+	ctx, cancel := context.WithCancel(t.Context())
+	ch, err := ws.Watch(ctx, "node1", 0)
+	if err != nil {
+		t.Fatalf("Watch() err = %v", err)
+	}
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("channel should be closed but is still open")
+		}
+	case <-time.After(time.Second):
+		t.Error("timeout waiting for channel to close")
+	}
+}
 
-			ws.Shutdown()
+func TestWatchCompacted(t *testing.T) {
+	store := storage.NewMemoryStore()
+	ws := NewService(store)
+	_, kv := putPod(t, store, "node1")
+	for range 1001 {
+		store.Update(t.Context(), kv.Key, kv.Value, 0)
+	}
+	_, err := ws.Watch(t.Context(), "node1", kv.Revision)
+	if !errors.Is(err, storage.ErrCompacted) {
+		t.Errorf("Watch() err = %v, expected ErrCompacted", err)
+	}
+}
 
-			if tc.expectClosed {
-				for i, ch := range channels {
-					_, ok := <-ch
-					if ok {
-						t.Errorf("channel %d should be closed but is still open", i)
-					}
-				}
-			}
+func TestWatchEtcd(t *testing.T) {
+	server, err := etcdtest.Start()
+	if err != nil {
+		t.Fatalf("failed to start embedded etcd: %v", err)
+	}
+	defer server.Stop()
+	store, err := storage.NewEtcdStore(server.Endpoints())
+	if err != nil {
+		t.Fatalf("failed to create etcd store: %v", err)
+	}
+	defer store.Close()
 
-			if tc.expectEmpty && len(ws.watchers) != 0 {
-				t.Errorf("expected watchers map to be empty, got %d entries", len(ws.watchers))
-			}
-		})
+	ws := NewService(store)
+	_, first := putPod(t, store, "node1")
+	ch, err := ws.Watch(t.Context(), "node1", first.Revision)
+	if err != nil {
+		t.Fatalf("Watch() err = %v", err)
+	}
+	second, kv := putPod(t, store, "node1")
+	ev := expectEvent(t, ch, Add, second.Uid)
+	if ev.Pod.ResourceVersion != strconv.FormatInt(kv.Revision, 10) {
+		t.Errorf("event resourceVersion = %s, expected %d", ev.Pod.ResourceVersion, kv.Revision)
 	}
 }
@@ -2,12 +2,21 @@ package client
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 
+	"superminikube/pkg/api"
 	"superminikube/pkg/apiserver/watch"
 )
 
+var (
+	// the apiserver doesn't have the object
+	ErrNotFound = errors.New("not found")
+	// the object already exists, or changed since it was read
+	ErrConflict = errors.New("conflict")
+)
+
 // Client provides an interface for interacting with the API server
 type Client interface {
 	// Resource operations
@@ -15,8 +24,73 @@ type Client interface {
 	List(ctx context.Context, resource string) ([]byte, error)
 	Update(ctx context.Context, resource string, id uuid.UUID, data []byte) error
 
-	// Watch for events from the control plane
-	Watch(ctx context.Context) (<-chan watch.WatchEvent, error)
+	// Pods in every namespace
+	ListPods(ctx context.Context) (api.PodList, error)
+	// Pods bound to nodeName, or waiting on the scheduler if it's empty
+	ListNodePods(ctx context.Context, nodeName string) (api.PodList, error)
+	// Fails with ErrNotFound if there's no such pod
+	GetPod(ctx context.Context, namespace, name string) (api.Pod, error)
+	// Create a pod in pod.Namespace, fails with ErrConflict if the name is taken
+	CreatePod(ctx context.Context, pod api.Pod) error
+	// Replace a pod's spec and metadata, a resourceVersion makes it fail with ErrConflict if the pod changed since
+	UpdatePod(ctx context.Context, pod api.Pod) error
+	// Report the status of a pod, only pod.Status is written.
+	// A resourceVersion makes it fail with ErrConflict if the pod changed since.
+	UpdatePodStatus(ctx context.Context, pod api.Pod) error
+	// Assign an unscheduled pod to a node
+	BindPod(ctx context.Context, pod api.Pod, nodeName string) error
+	DeletePod(ctx context.Context, pod api.Pod, opts api.DeleteOptions) error
+
+	ListNodes(ctx context.Context) (api.NodeList, error)
+	// Fails with ErrNotFound if the node isn't registered
+	GetNode(ctx context.Context, name string) (api.Node, error)
+	// Register a node, fails with ErrConflict if it's already registered
+	CreateNode(ctx context.Context, node api.Node) error
+	// Replace a node's spec and metadata, a resourceVersion makes it fail with ErrConflict if the node changed since
+	UpdateNode(ctx context.Context, node api.Node) error
+	// Report the status of a node, only node.Status is written
+	UpdateNodeStatus(ctx context.Context, node api.Node) error
+
+	// Replica sets in every namespace
+	ListReplicaSets(ctx context.Context) (api.ReplicaSetList, error)
+	CreateReplicaSet(ctx context.Context, rs api.ReplicaSet) error
+	// Replace a replica set's spec and metadata, a resourceVersion makes it fail with ErrConflict if it changed since
+	UpdateReplicaSet(ctx context.Context, rs api.ReplicaSet) error
+	// Report the status of a replica set, only rs.Status is written
+	UpdateReplicaSetStatus(ctx context.Context, rs api.ReplicaSet) error
+	DeleteReplicaSet(ctx context.Context, rs api.ReplicaSet, opts api.DeleteOptions) error
+
+	// Deployments in every namespace
+	ListDeployments(ctx context.Context) (api.DeploymentList, error)
+	// Report the status of a deployment, only d.Status is written
+	UpdateDeploymentStatus(ctx context.Context, d api.Deployment) error
+
+	// Jobs in every namespace
+	ListJobs(ctx context.Context) (api.JobList, error)
+	CreateJob(ctx context.Context, j api.Job) error
+	// Report the status of a job, only j.Status is written
+	UpdateJobStatus(ctx context.Context, j api.Job) error
+	DeleteJob(ctx context.Context, j api.Job, opts api.DeleteOptions) error
+
+	// Cron jobs in every namespace
+	ListCronJobs(ctx context.Context) (api.CronJobList, error)
+	// Report the status of a cron job, only cj.Status is written
+	UpdateCronJobStatus(ctx context.Context, cj api.CronJob) error
+
+	// Daemon sets in every namespace
+	ListDaemonSets(ctx context.Context) (api.DaemonSetList, error)
+	// Report the status of a daemon set, only ds.Status is written
+	UpdateDaemonSetStatus(ctx context.Context, ds api.DaemonSet) error
+
+	// Stateful sets in every namespace
+	ListStatefulSets(ctx context.Context) (api.StatefulSetList, error)
+	// Report the status of a stateful set, only ss.Status is written
+	UpdateStatefulSetStatus(ctx context.Context, ss api.StatefulSet) error
+
+	// Watch for events from the control plane, starting after resourceVersion or from now if it's empty
+	Watch(ctx context.Context, resourceVersion string) (<-chan watch.WatchEvent, error)
+	// Watch every object of resource, e.g. "replicasets", same resourceVersion semantics as Watch
+	WatchResource(ctx context.Context, resource, resourceVersion string) (<-chan watch.WatchEvent, error)
 
 	// Health check
 	Ping(ctx context.Context) error
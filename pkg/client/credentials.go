@@ -0,0 +1,45 @@
+package client
+
+import (
+	"encoding/base64"
+	"net/http"
+)
+
+// Credentials authenticate requests to the apiserver. The apiserver doesn't check them itself,
+// they're for one behind a proxy that does.
+type Credentials struct {
+	// sent as a bearer token, takes precedence over Username and Password
+	Token    string
+	Username string
+	Password string
+}
+
+// header is the Authorization header for c, empty when there's nothing to send
+func (c Credentials) header() http.Header {
+	h := http.Header{}
+	switch {
+	case c.Token != "":
+		h.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "":
+		h.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.Password)))
+	}
+	return h
+}
+
+// headerTransport adds header to every request it sends
+type headerTransport struct {
+	header http.Header
+	next   http.RoundTripper
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.header) == 0 {
+		return t.next.RoundTrip(req)
+	}
+	// a transport mustn't modify the request it's handed
+	req = req.Clone(req.Context())
+	for k, v := range t.header {
+		req.Header[k] = v
+	}
+	return t.next.RoundTrip(req)
+}
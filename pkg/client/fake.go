@@ -0,0 +1,408 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/watch"
+)
+
+// FakeClient keeps objects in memory, for testing components that talk to the apiserver.
+// Writes behave like the apiserver's but resourceVersion preconditions aren't checked.
+type FakeClient struct {
+	mu           sync.Mutex
+	Pods         []api.Pod
+	Nodes        []api.Node
+	ReplicaSets  []api.ReplicaSet
+	Deployments  []api.Deployment
+	Jobs         []api.Job
+	CronJobs     []api.CronJob
+	DaemonSets   []api.DaemonSet
+	StatefulSets []api.StatefulSet
+	// every pod status reported, in order
+	PodStatuses []api.Pod
+	DeletedPods []api.Pod
+	// handed out by Watch, nil means a watch that never sends anything
+	Events chan watch.WatchEvent
+	// counts pods created with a generateName, so generated names are predictable
+	generated int
+}
+
+func (c *FakeClient) Get(ctx context.Context, resource string, id uuid.UUID) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *FakeClient) List(ctx context.Context, resource string) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *FakeClient) Update(ctx context.Context, resource string, id uuid.UUID, data []byte) error {
+	return nil
+}
+
+func (c *FakeClient) ListPods(ctx context.Context) (api.PodList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return api.PodList{Items: slices.Clone(c.Pods)}, nil
+}
+
+func (c *FakeClient) ListNodePods(ctx context.Context, nodeName string) (api.PodList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var list api.PodList
+	for _, p := range c.Pods {
+		if p.Nodename == nodeName {
+			list.Items = append(list.Items, p)
+		}
+	}
+	return list, nil
+}
+
+func (c *FakeClient) GetPod(ctx context.Context, namespace, name string) (api.Pod, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.podIndex(api.Pod{ObjectMeta: api.ObjectMeta{Namespace: namespace, Name: name}})
+	if i < 0 {
+		return api.Pod{}, fmt.Errorf("%w: pod %s/%s", ErrNotFound, namespace, name)
+	}
+	return c.Pods[i], nil
+}
+
+// CreatePod fills in what the apiserver would, generated names get a counter rather than a random suffix
+func (c *FakeClient) CreatePod(ctx context.Context, pod api.Pod) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if pod.Name == "" {
+		c.generated++
+		pod.Name = fmt.Sprintf("%s%d", pod.GenerateName, c.generated)
+	}
+	if pod.Namespace == "" {
+		pod.Namespace = "default"
+	}
+	if c.podIndex(pod) >= 0 {
+		return fmt.Errorf("%w: pod %s/%s", ErrConflict, pod.Namespace, pod.Name)
+	}
+	pod.Uid = uuid.New()
+	pod.CreationTimestamp = time.Now()
+	pod.Status = api.PodStatus{Phase: api.PodPending}
+	c.Pods = append(c.Pods, pod)
+	return nil
+}
+
+func (c *FakeClient) UpdatePod(ctx context.Context, pod api.Pod) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.podIndex(pod)
+	if i < 0 {
+		return fmt.Errorf("%w: pod %s/%s", ErrNotFound, pod.Namespace, pod.Name)
+	}
+	pod.Nodename = c.Pods[i].Nodename
+	pod.Status = c.Pods[i].Status
+	c.Pods[i] = pod
+	return nil
+}
+
+func (c *FakeClient) UpdatePodStatus(ctx context.Context, pod api.Pod) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.podIndex(pod)
+	if i < 0 {
+		return fmt.Errorf("%w: pod %s/%s", ErrNotFound, pod.Namespace, pod.Name)
+	}
+	c.Pods[i].Status = pod.Status
+	c.PodStatuses = append(c.PodStatuses, pod)
+	return nil
+}
+
+func (c *FakeClient) BindPod(ctx context.Context, pod api.Pod, nodeName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.podIndex(pod)
+	if i < 0 {
+		return fmt.Errorf("%w: pod %s/%s", ErrNotFound, pod.Namespace, pod.Name)
+	}
+	if c.Pods[i].Nodename != "" {
+		return fmt.Errorf("%w: pod %s/%s is already bound", ErrConflict, pod.Namespace, pod.Name)
+	}
+	c.Pods[i].Nodename = nodeName
+	return nil
+}
+
+// DeletePod removes the pod right away, there's no kubelet to wait on
+func (c *FakeClient) DeletePod(ctx context.Context, pod api.Pod, opts api.DeleteOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.podIndex(pod)
+	if i < 0 {
+		return nil
+	}
+	if opts.Uid != uuid.Nil && opts.Uid != c.Pods[i].Uid {
+		return fmt.Errorf("%w: pod %s/%s has a different uid", ErrConflict, pod.Namespace, pod.Name)
+	}
+	c.DeletedPods = append(c.DeletedPods, c.Pods[i])
+	c.Pods = slices.Delete(c.Pods, i, i+1)
+	return nil
+}
+
+func (c *FakeClient) ListNodes(ctx context.Context) (api.NodeList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return api.NodeList{Items: slices.Clone(c.Nodes)}, nil
+}
+
+func (c *FakeClient) GetNode(ctx context.Context, name string) (api.Node, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.nodeIndex(name)
+	if i < 0 {
+		return api.Node{}, fmt.Errorf("%w: node %s", ErrNotFound, name)
+	}
+	return c.Nodes[i], nil
+}
+
+func (c *FakeClient) CreateNode(ctx context.Context, node api.Node) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nodeIndex(node.Name) >= 0 {
+		return fmt.Errorf("%w: node %s", ErrConflict, node.Name)
+	}
+	c.Nodes = append(c.Nodes, node)
+	return nil
+}
+
+func (c *FakeClient) UpdateNode(ctx context.Context, node api.Node) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.nodeIndex(node.Name)
+	if i < 0 {
+		return fmt.Errorf("%w: node %s", ErrNotFound, node.Name)
+	}
+	node.Status = c.Nodes[i].Status
+	c.Nodes[i] = node
+	return nil
+}
+
+func (c *FakeClient) UpdateNodeStatus(ctx context.Context, node api.Node) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.nodeIndex(node.Name)
+	if i < 0 {
+		return fmt.Errorf("%w: node %s", ErrNotFound, node.Name)
+	}
+	c.Nodes[i].Status = node.Status
+	return nil
+}
+
+func (c *FakeClient) ListReplicaSets(ctx context.Context) (api.ReplicaSetList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return api.ReplicaSetList{Items: slices.Clone(c.ReplicaSets)}, nil
+}
+
+func (c *FakeClient) CreateReplicaSet(ctx context.Context, rs api.ReplicaSet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.replicaSetIndex(rs) >= 0 {
+		return fmt.Errorf("%w: replica set %s/%s", ErrConflict, rs.Namespace, rs.Name)
+	}
+	rs.Uid = uuid.New()
+	rs.CreationTimestamp = time.Now()
+	rs.Status = api.ReplicaSetStatus{}
+	c.ReplicaSets = append(c.ReplicaSets, rs)
+	return nil
+}
+
+func (c *FakeClient) UpdateReplicaSet(ctx context.Context, rs api.ReplicaSet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.replicaSetIndex(rs)
+	if i < 0 {
+		return fmt.Errorf("%w: replica set %s/%s", ErrNotFound, rs.Namespace, rs.Name)
+	}
+	rs.Status = c.ReplicaSets[i].Status
+	c.ReplicaSets[i] = rs
+	return nil
+}
+
+func (c *FakeClient) UpdateReplicaSetStatus(ctx context.Context, rs api.ReplicaSet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.replicaSetIndex(rs)
+	if i < 0 {
+		return fmt.Errorf("%w: replica set %s/%s", ErrNotFound, rs.Namespace, rs.Name)
+	}
+	c.ReplicaSets[i].Status = rs.Status
+	return nil
+}
+
+func (c *FakeClient) DeleteReplicaSet(ctx context.Context, rs api.ReplicaSet, opts api.DeleteOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.replicaSetIndex(rs)
+	if i < 0 {
+		return fmt.Errorf("%w: replica set %s/%s", ErrNotFound, rs.Namespace, rs.Name)
+	}
+	c.ReplicaSets = slices.Delete(c.ReplicaSets, i, i+1)
+	return nil
+}
+
+func (c *FakeClient) ListDeployments(ctx context.Context) (api.DeploymentList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return api.DeploymentList{Items: slices.Clone(c.Deployments)}, nil
+}
+
+func (c *FakeClient) UpdateDeploymentStatus(ctx context.Context, d api.Deployment) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.IndexFunc(c.Deployments, func(o api.Deployment) bool {
+		return o.Namespace == d.Namespace && o.Name == d.Name
+	})
+	if i < 0 {
+		return fmt.Errorf("%w: deployment %s/%s", ErrNotFound, d.Namespace, d.Name)
+	}
+	c.Deployments[i].Status = d.Status
+	return nil
+}
+
+func (c *FakeClient) ListJobs(ctx context.Context) (api.JobList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return api.JobList{Items: slices.Clone(c.Jobs)}, nil
+}
+
+func (c *FakeClient) CreateJob(ctx context.Context, j api.Job) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.jobIndex(j) >= 0 {
+		return fmt.Errorf("%w: job %s/%s", ErrConflict, j.Namespace, j.Name)
+	}
+	j.Uid = uuid.New()
+	j.CreationTimestamp = time.Now()
+	j.Status = api.JobStatus{}
+	c.Jobs = append(c.Jobs, j)
+	return nil
+}
+
+func (c *FakeClient) UpdateJobStatus(ctx context.Context, j api.Job) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.jobIndex(j)
+	if i < 0 {
+		return fmt.Errorf("%w: job %s/%s", ErrNotFound, j.Namespace, j.Name)
+	}
+	c.Jobs[i].Status = j.Status
+	return nil
+}
+
+func (c *FakeClient) DeleteJob(ctx context.Context, j api.Job, opts api.DeleteOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := c.jobIndex(j)
+	if i < 0 {
+		return fmt.Errorf("%w: job %s/%s", ErrNotFound, j.Namespace, j.Name)
+	}
+	c.Jobs = slices.Delete(c.Jobs, i, i+1)
+	return nil
+}
+
+func (c *FakeClient) ListCronJobs(ctx context.Context) (api.CronJobList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return api.CronJobList{Items: slices.Clone(c.CronJobs)}, nil
+}
+
+func (c *FakeClient) UpdateCronJobStatus(ctx context.Context, cj api.CronJob) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.IndexFunc(c.CronJobs, func(o api.CronJob) bool {
+		return o.Namespace == cj.Namespace && o.Name == cj.Name
+	})
+	if i < 0 {
+		return fmt.Errorf("%w: cron job %s/%s", ErrNotFound, cj.Namespace, cj.Name)
+	}
+	c.CronJobs[i].Status = cj.Status
+	return nil
+}
+
+func (c *FakeClient) ListDaemonSets(ctx context.Context) (api.DaemonSetList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return api.DaemonSetList{Items: slices.Clone(c.DaemonSets)}, nil
+}
+
+func (c *FakeClient) UpdateDaemonSetStatus(ctx context.Context, ds api.DaemonSet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.IndexFunc(c.DaemonSets, func(o api.DaemonSet) bool {
+		return o.Namespace == ds.Namespace && o.Name == ds.Name
+	})
+	if i < 0 {
+		return fmt.Errorf("%w: daemon set %s/%s", ErrNotFound, ds.Namespace, ds.Name)
+	}
+	c.DaemonSets[i].Status = ds.Status
+	return nil
+}
+
+func (c *FakeClient) ListStatefulSets(ctx context.Context) (api.StatefulSetList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return api.StatefulSetList{Items: slices.Clone(c.StatefulSets)}, nil
+}
+
+func (c *FakeClient) UpdateStatefulSetStatus(ctx context.Context, ss api.StatefulSet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.IndexFunc(c.StatefulSets, func(o api.StatefulSet) bool {
+		return o.Namespace == ss.Namespace && o.Name == ss.Name
+	})
+	if i < 0 {
+		return fmt.Errorf("%w: stateful set %s/%s", ErrNotFound, ss.Namespace, ss.Name)
+	}
+	c.StatefulSets[i].Status = ss.Status
+	return nil
+}
+
+func (c *FakeClient) Watch(ctx context.Context, resourceVersion string) (<-chan watch.WatchEvent, error) {
+	if c.Events != nil {
+		return c.Events, nil
+	}
+	return make(chan watch.WatchEvent), nil
+}
+
+// WatchResource never sends anything, tests drive controllers by calling their sync directly
+func (c *FakeClient) WatchResource(ctx context.Context, resource, resourceVersion string) (<-chan watch.WatchEvent, error) {
+	return make(chan watch.WatchEvent), nil
+}
+
+func (c *FakeClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (c *FakeClient) podIndex(pod api.Pod) int {
+	return slices.IndexFunc(c.Pods, func(p api.Pod) bool {
+		return p.Namespace == pod.Namespace && p.Name == pod.Name
+	})
+}
+
+func (c *FakeClient) replicaSetIndex(rs api.ReplicaSet) int {
+	return slices.IndexFunc(c.ReplicaSets, func(r api.ReplicaSet) bool {
+		return r.Namespace == rs.Namespace && r.Name == rs.Name
+	})
+}
+
+func (c *FakeClient) jobIndex(j api.Job) int {
+	return slices.IndexFunc(c.Jobs, func(o api.Job) bool {
+		return o.Namespace == j.Namespace && o.Name == j.Name
+	})
+}
+
+func (c *FakeClient) nodeIndex(name string) int {
+	return slices.IndexFunc(c.Nodes, func(n api.Node) bool { return n.Name == name })
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,26 +10,44 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	"superminikube/pkg/api"
 	"superminikube/pkg/apiserver/watch"
+	"superminikube/pkg/portforward"
+	"superminikube/pkg/remotecommand"
 )
 
 type HTTPClient struct {
 	baseURL    string
 	httpClient *http.Client
+	// for requests that stay open for as long as they like, watches and followed logs
+	streamClient *http.Client
+	// sent with every request, WebSocket dials included
+	header http.Header
 	// This is components node identifier
 	nodeName string
 }
 
 func NewHTTPClient(baseURL, nodeName string) *HTTPClient {
+	return NewHTTPClientWithCredentials(baseURL, nodeName, Credentials{})
+}
+
+// NewHTTPClientWithCredentials authenticates every request with creds
+func NewHTTPClientWithCredentials(baseURL, nodeName string, creds Credentials) *HTTPClient {
+	header := creds.header()
+	transport := headerTransport{header: header, next: http.DefaultTransport}
 	return &HTTPClient{
-		baseURL:    baseURL,
-		httpClient: &http.Client{Timeout: 10 * time.Second},
-		nodeName:   nodeName,
+		baseURL:      baseURL,
+		httpClient:   &http.Client{Timeout: 10 * time.Second, Transport: transport},
+		streamClient: &http.Client{Transport: transport},
+		header:       header,
+		nodeName:     nodeName,
 	}
 }
 
@@ -104,47 +123,525 @@ func (c *HTTPClient) Update(ctx context.Context, resource string, id uuid.UUID,
 	return nil
 }
 
-func (c *HTTPClient) Watch(ctx context.Context) (<-chan watch.WatchEvent, error) {
+func (c *HTTPClient) ListPods(ctx context.Context) (api.PodList, error) {
+	var list api.PodList
+	if err := c.getJSON(ctx, "pods", &list); err != nil {
+		return api.PodList{}, err
+	}
+	return list, nil
+}
+
+func (c *HTTPClient) GetPod(ctx context.Context, namespace, name string) (api.Pod, error) {
+	b, err := c.Do(ctx, http.MethodGet, "pods/"+namespace+"/"+name, nil)
+	if err != nil {
+		return api.Pod{}, err
+	}
+	var pod api.Pod
+	if err := json.Unmarshal(b, &pod); err != nil {
+		return api.Pod{}, fmt.Errorf("failed to decode pod %s/%s: %v", namespace, name, err)
+	}
+	return pod, nil
+}
+
+func (c *HTTPClient) ListNodePods(ctx context.Context, nodeName string) (api.PodList, error) {
+	var list api.PodList
+	if err := c.getJSON(ctx, "pods?"+url.Values{"nodename": {nodeName}}.Encode(), &list); err != nil {
+		return api.PodList{}, err
+	}
+	return list, nil
+}
+
+func (c *HTTPClient) CreatePod(ctx context.Context, pod api.Pod) error {
+	return c.sendJSON(ctx, http.MethodPost, "pods/"+pod.Namespace, pod, http.StatusCreated)
+}
+
+func (c *HTTPClient) ListNodes(ctx context.Context) (api.NodeList, error) {
+	var list api.NodeList
+	if err := c.getJSON(ctx, "nodes", &list); err != nil {
+		return api.NodeList{}, err
+	}
+	return list, nil
+}
+
+func (c *HTTPClient) GetNode(ctx context.Context, name string) (api.Node, error) {
+	b, err := c.Do(ctx, http.MethodGet, "nodes/"+name, nil)
+	if err != nil {
+		return api.Node{}, err
+	}
+	var node api.Node
+	if err := json.Unmarshal(b, &node); err != nil {
+		return api.Node{}, fmt.Errorf("failed to decode node %s: %v", name, err)
+	}
+	return node, nil
+}
+
+func (c *HTTPClient) CreateNode(ctx context.Context, node api.Node) error {
+	return c.sendJSON(ctx, http.MethodPost, "nodes", node, http.StatusCreated)
+}
+
+func (c *HTTPClient) UpdateNode(ctx context.Context, node api.Node) error {
+	return c.sendJSON(ctx, http.MethodPut, "nodes/"+node.Name, node, http.StatusOK)
+}
+
+func (c *HTTPClient) UpdateNodeStatus(ctx context.Context, node api.Node) error {
+	return c.sendJSON(ctx, http.MethodPut, fmt.Sprintf("nodes/%s/status", node.Name), node, http.StatusOK)
+}
+
+func (c *HTTPClient) ListReplicaSets(ctx context.Context) (api.ReplicaSetList, error) {
+	var list api.ReplicaSetList
+	if err := c.getJSON(ctx, "replicasets", &list); err != nil {
+		return api.ReplicaSetList{}, err
+	}
+	return list, nil
+}
+
+func (c *HTTPClient) UpdateReplicaSetStatus(ctx context.Context, rs api.ReplicaSet) error {
+	return c.sendJSON(ctx, http.MethodPut, fmt.Sprintf("replicasets/%s/%s/status", rs.Namespace, rs.Name), rs, http.StatusOK)
+}
+
+func (c *HTTPClient) CreateReplicaSet(ctx context.Context, rs api.ReplicaSet) error {
+	return c.sendJSON(ctx, http.MethodPost, "replicasets/"+rs.Namespace, rs, http.StatusCreated)
+}
+
+func (c *HTTPClient) UpdateReplicaSet(ctx context.Context, rs api.ReplicaSet) error {
+	return c.sendJSON(ctx, http.MethodPut, fmt.Sprintf("replicasets/%s/%s", rs.Namespace, rs.Name), rs, http.StatusOK)
+}
+
+func (c *HTTPClient) DeleteReplicaSet(ctx context.Context, rs api.ReplicaSet, opts api.DeleteOptions) error {
+	path := fmt.Sprintf("replicasets/%s/%s", rs.Namespace, rs.Name)
+	if opts.ResourceVersion != "" {
+		path += "?" + url.Values{"resourceVersion": {opts.ResourceVersion}}.Encode()
+	}
+	return c.sendJSON(ctx, http.MethodDelete, path, nil, http.StatusOK)
+}
+
+func (c *HTTPClient) ListDeployments(ctx context.Context) (api.DeploymentList, error) {
+	var list api.DeploymentList
+	if err := c.getJSON(ctx, "deployments", &list); err != nil {
+		return api.DeploymentList{}, err
+	}
+	return list, nil
+}
+
+func (c *HTTPClient) UpdateDeploymentStatus(ctx context.Context, d api.Deployment) error {
+	return c.sendJSON(ctx, http.MethodPut, fmt.Sprintf("deployments/%s/%s/status", d.Namespace, d.Name), d, http.StatusOK)
+}
+
+func (c *HTTPClient) ListJobs(ctx context.Context) (api.JobList, error) {
+	var list api.JobList
+	if err := c.getJSON(ctx, "jobs", &list); err != nil {
+		return api.JobList{}, err
+	}
+	return list, nil
+}
+
+func (c *HTTPClient) CreateJob(ctx context.Context, j api.Job) error {
+	return c.sendJSON(ctx, http.MethodPost, "jobs/"+j.Namespace, j, http.StatusCreated)
+}
+
+func (c *HTTPClient) UpdateJobStatus(ctx context.Context, j api.Job) error {
+	return c.sendJSON(ctx, http.MethodPut, fmt.Sprintf("jobs/%s/%s/status", j.Namespace, j.Name), j, http.StatusOK)
+}
+
+func (c *HTTPClient) DeleteJob(ctx context.Context, j api.Job, opts api.DeleteOptions) error {
+	path := fmt.Sprintf("jobs/%s/%s", j.Namespace, j.Name)
+	if opts.ResourceVersion != "" {
+		path += "?" + url.Values{"resourceVersion": {opts.ResourceVersion}}.Encode()
+	}
+	return c.sendJSON(ctx, http.MethodDelete, path, nil, http.StatusOK)
+}
+
+func (c *HTTPClient) ListCronJobs(ctx context.Context) (api.CronJobList, error) {
+	var list api.CronJobList
+	if err := c.getJSON(ctx, "cronjobs", &list); err != nil {
+		return api.CronJobList{}, err
+	}
+	return list, nil
+}
+
+func (c *HTTPClient) UpdateCronJobStatus(ctx context.Context, cj api.CronJob) error {
+	return c.sendJSON(ctx, http.MethodPut, fmt.Sprintf("cronjobs/%s/%s/status", cj.Namespace, cj.Name), cj, http.StatusOK)
+}
+
+func (c *HTTPClient) ListDaemonSets(ctx context.Context) (api.DaemonSetList, error) {
+	var list api.DaemonSetList
+	if err := c.getJSON(ctx, "daemonsets", &list); err != nil {
+		return api.DaemonSetList{}, err
+	}
+	return list, nil
+}
+
+func (c *HTTPClient) UpdateDaemonSetStatus(ctx context.Context, ds api.DaemonSet) error {
+	return c.sendJSON(ctx, http.MethodPut, fmt.Sprintf("daemonsets/%s/%s/status", ds.Namespace, ds.Name), ds, http.StatusOK)
+}
+
+func (c *HTTPClient) ListStatefulSets(ctx context.Context) (api.StatefulSetList, error) {
+	var list api.StatefulSetList
+	if err := c.getJSON(ctx, "statefulsets", &list); err != nil {
+		return api.StatefulSetList{}, err
+	}
+	return list, nil
+}
+
+func (c *HTTPClient) UpdateStatefulSetStatus(ctx context.Context, ss api.StatefulSet) error {
+	return c.sendJSON(ctx, http.MethodPut, fmt.Sprintf("statefulsets/%s/%s/status", ss.Namespace, ss.Name), ss, http.StatusOK)
+}
+
+// sendJSON sends v as the body of a request to /api/v1/path, 404 and 409 are reported as ErrNotFound and ErrConflict
+func (c *HTTPClient) sendJSON(ctx context.Context, method, path string, v any, expected int) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", path, err)
+	}
+	url := fmt.Sprintf("%s/api/v1/%s", c.baseURL, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case expected:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %s", ErrConflict, path)
+	default:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+// Do sends body, if there is one, to /api/v1/path and returns the response body.
+// 404 and 409 are reported as ErrNotFound and ErrConflict, every failure carries the apiserver's message.
+func (c *HTTPClient) Do(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	url := fmt.Sprintf("%s/api/v1/%s", c.baseURL, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return b, nil
+	}
+	msg := strings.TrimSpace(string(b))
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, msg)
+	case http.StatusConflict:
+		return nil, fmt.Errorf("%w: %s", ErrConflict, msg)
+	default:
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, msg)
+	}
+}
+
+// getJSON decodes the response to a GET of /api/v1/path into v
+func (c *HTTPClient) getJSON(ctx context.Context, path string, v any) error {
+	body, err := c.List(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	return nil
+}
+
+func (c *HTTPClient) UpdatePod(ctx context.Context, pod api.Pod) error {
+	return c.sendJSON(ctx, http.MethodPut, fmt.Sprintf("pods/%s/%s", pod.Namespace, pod.Name), pod, http.StatusOK)
+}
+
+func (c *HTTPClient) UpdatePodStatus(ctx context.Context, pod api.Pod) error {
+	return c.sendJSON(ctx, http.MethodPut, fmt.Sprintf("pods/%s/%s/status", pod.Namespace, pod.Name), pod, http.StatusOK)
+}
+
+// BindPod only binds the pod with pod's uid, so a pod recreated under the same name isn't bound by mistake
+func (c *HTTPClient) BindPod(ctx context.Context, pod api.Pod, nodeName string) error {
+	url := fmt.Sprintf("%s/api/v1/pods/%s/%s/binding", c.baseURL, pod.Namespace, pod.Name)
+	body, err := json.Marshal(api.Binding{
+		ObjectMeta: api.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name, Uid: pod.Uid},
+		Target:     nodeName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode binding: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to bind pod: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// DeletePod treats a pod that's already gone as deleted
+func (c *HTTPClient) DeletePod(ctx context.Context, pod api.Pod, opts api.DeleteOptions) error {
+	q := url.Values{}
+	if opts.GracePeriodSeconds != nil {
+		q.Set("gracePeriodSeconds", strconv.FormatInt(*opts.GracePeriodSeconds, 10))
+	}
+	if opts.ResourceVersion != "" {
+		q.Set("resourceVersion", opts.ResourceVersion)
+	}
+	if opts.Uid != uuid.Nil {
+		q.Set("uid", opts.Uid.String())
+	}
+	u := fmt.Sprintf("%s/api/v1/pods/%s/%s?%s", c.baseURL, pod.Namespace, pod.Name, q.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete pod: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// PodLogs streams the logs of a container of the pod, the caller closes the stream.
+// A followed stream stays open until ctx is done or the container stops.
+func (c *HTTPClient) PodLogs(ctx context.Context, namespace, name string, opts api.PodLogOptions) (io.ReadCloser, error) {
+	u := fmt.Sprintf("%s/api/v1/pods/%s/%s/log?%s", c.baseURL, namespace, name, opts.Query().Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	// followed logs can go on for as long as they like
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod logs: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, strings.TrimSpace(string(msg)))
+		}
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp.Body, nil
+}
+
+// PodExec runs a command in a container of the pod with streams attached and returns its exit code once it's done.
+// opts pick the container and the command, which streams are attached is up to streams.
+func (c *HTTPClient) PodExec(ctx context.Context, namespace, name string, opts api.PodExecOptions, streams remotecommand.Streams) (int, error) {
+	return c.stream(ctx, namespace, name, "exec", opts, streams)
+}
+
+// PodAttach attaches streams to the process of a container of the pod until it exits or ctx is done
+func (c *HTTPClient) PodAttach(ctx context.Context, namespace, name string, opts api.PodExecOptions, streams remotecommand.Streams) error {
+	_, err := c.stream(ctx, namespace, name, "attach", opts, streams)
+	return err
+}
+
+// PortForward listens on localhost for every port and tunnels the connections it accepts to the pod's ports
+// until ctx is done, the returned forwarder has the local ports it picked
+func (c *HTTPClient) PortForward(ctx context.Context, namespace, name string, ports []portforward.Port) (*portforward.Forwarder, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/api/v1/pods/%s/%s/portforward", c.baseURL, namespace, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	return portforward.Forward(ctx, u, c.header, ports)
+}
+
+func (c *HTTPClient) stream(ctx context.Context, namespace, name, subresource string, opts api.PodExecOptions, streams remotecommand.Streams) (int, error) {
+	// stderr comes out of stdout on a TTY
+	opts.Stdin, opts.Stdout, opts.Stderr, opts.TTY = streams.Stdin != nil, streams.Stdout != nil, streams.Stderr != nil && !streams.TTY, streams.TTY
+	u, err := url.Parse(fmt.Sprintf("%s/api/v1/pods/%s/%s/%s?%s", c.baseURL, namespace, name, subresource, opts.Query().Encode()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	return remotecommand.Stream(ctx, u, c.header, streams)
+}
+
+const (
+	// how long a watch waits before reconnecting, doubling every time it fails in a row
+	watchMinDelay = time.Second
+	watchMaxDelay = 30 * time.Second
+	// longest event line a watch reads, bufio.Scanner stops at 64KB otherwise
+	maxWatchEventSize = 16 << 20
+)
+
+// the apiserver no longer has the history a watch asked to resume from
+var errWatchExpired = errors.New("resourceVersion expired")
+
+func (c *HTTPClient) Watch(ctx context.Context, resourceVersion string) (<-chan watch.WatchEvent, error) {
+	return c.watch(ctx, url.Values{"nodename": {c.nodeName}}, resourceVersion)
+}
+
+func (c *HTTPClient) WatchResource(ctx context.Context, resource, resourceVersion string) (<-chan watch.WatchEvent, error) {
+	return c.watch(ctx, url.Values{"resource": {resource}}, resourceVersion)
+}
+
+// watch streams /api/v1/watch?query, reconnecting from the last resourceVersion seen so no events are lost.
+// A watch that has nothing to resume from, its resourceVersion expired or it never saw an event, relists first:
+// what's there now is sent as Modified events, objects that went away in the gap as Delete events,
+// and the watch carries on from the list's resourceVersion.
+// It keeps at it until ctx is done, only then is the channel closed.
+func (c *HTTPClient) watch(ctx context.Context, query url.Values, resourceVersion string) (<-chan watch.WatchEvent, error) {
 	eventChan := make(chan watch.WatchEvent)
-	defaultDelay := 1
-	const maxAttempts = 3
 	go func() {
 		defer close(eventChan)
-
-		for {
-			select {
-			case <-ctx.Done():
+		w := &watcher{query: query, eventChan: eventChan, resourceVersion: resourceVersion, known: map[uuid.UUID]watch.WatchEvent{}}
+		delay := watchMinDelay
+		for attempt := 0; ; attempt++ {
+			var err error
+			if attempt > 0 && w.resourceVersion == "" {
+				err = c.relist(ctx, w)
+			}
+			if err == nil {
+				var connected bool
+				connected, err = c.watchStream(ctx, w)
+				if connected {
+					delay = watchMinDelay
+				}
+			}
+			if ctx.Err() != nil {
 				slog.Debug("cancelled watch context")
 				return
+			}
+			switch {
+			case errors.Is(err, errWatchExpired):
+				slog.Warn("watch resourceVersion too old, relisting", "query", query.Encode())
+				continue
+			case err != nil:
+				slog.Error("watch stream error, reconnecting", "error", err, "delay", delay)
 			default:
-				err := WithRetry(ctx, RetryOptions{
-					Attempts: maxAttempts,
-					Delay:    time.Duration(defaultDelay) * time.Second,
-					Backoff: func(attempt int) time.Duration {
-						newDelay := defaultDelay * 2
-						defaultDelay = newDelay
-						slog.Debug("new delay", "timer", newDelay)
-						return time.Second * time.Duration(newDelay)
-					},
-					ShouldRetry: func(err error) bool {
-						return errors.Is(err, context.DeadlineExceeded) ||
-							strings.Contains(err.Error(), "connection refused")
-					},
-				},
-					func(ctx context.Context) error {
-						return c.watchStream(ctx, eventChan)
-					})
-				if err != nil {
-					slog.Error("watch stream error", "error", err)
-					return
-				}
+				slog.Debug("watch stream ended, reconnecting", "resourceVersion", w.resourceVersion)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
 			}
+			delay = min(delay*2, watchMaxDelay)
 		}
 	}()
-
 	return eventChan, nil
 }
 
+// watcher is the state one watch carries across reconnects
+type watcher struct {
+	query     url.Values
+	eventChan chan<- watch.WatchEvent
+	// last resourceVersion delivered, empty when the watch has to relist
+	resourceVersion string
+	// the last event for every object the receiver has been told about and not yet seen deleted, by uid
+	known map[uuid.UUID]watch.WatchEvent
+}
+
+// send delivers ev and keeps track of which objects the receiver knows about
+func (w *watcher) send(ctx context.Context, ev watch.WatchEvent) bool {
+	var meta struct {
+		api.ObjectMeta `json:"metadata"`
+	}
+	if err := json.Unmarshal(ev.Object, &meta); err != nil {
+		slog.Debug("watch event without metadata", "resource", ev.Resource, "error", err)
+	}
+	select {
+	case w.eventChan <- ev:
+	case <-ctx.Done():
+		return false
+	}
+	if ev.EventType == watch.Delete {
+		delete(w.known, meta.Uid)
+	} else {
+		w.known[meta.Uid] = ev
+	}
+	return true
+}
+
+// relist sends everything the watch covers as Modified events, and a Delete event for every object
+// the receiver knew about that isn't there anymore, then sets resourceVersion to where the list was read
+func (c *HTTPClient) relist(ctx context.Context, w *watcher) error {
+	resource := w.query.Get("resource")
+	path := resource
+	if resource == "" {
+		resource = "pods"
+		path = "pods?" + url.Values{"nodename": {w.query.Get("nodename")}}.Encode()
+	}
+	var list struct {
+		ResourceVersion string            `json:"resourceVersion"`
+		Items           []json.RawMessage `json:"items"`
+	}
+	if err := c.getJSON(ctx, path, &list); err != nil {
+		return fmt.Errorf("failed to relist %s: %v", resource, err)
+	}
+	listed := map[uuid.UUID]bool{}
+	for _, item := range list.Items {
+		var meta struct {
+			api.ObjectMeta `json:"metadata"`
+		}
+		if err := json.Unmarshal(item, &meta); err != nil {
+			return fmt.Errorf("failed to decode relisted %s: %v", resource, err)
+		}
+		// the object's own resourceVersion, DecodeObject stamps it with the event's
+		ev := watch.WatchEvent{EventType: watch.Modified, Resource: resource, ResourceVersion: meta.ResourceVersion, Object: item}
+		if resource == "pods" {
+			if err := ev.DecodeObject(&ev.Pod); err != nil {
+				return err
+			}
+			ev.Node = ev.Pod.Nodename
+		}
+		listed[meta.Uid] = true
+		if !w.send(ctx, ev) {
+			return ctx.Err()
+		}
+	}
+	for uid, last := range w.known {
+		if listed[uid] {
+			continue
+		}
+		// deleted while the watch was down, the last state the receiver saw is all there is
+		last.EventType = watch.Delete
+		if !w.send(ctx, last) {
+			return ctx.Err()
+		}
+	}
+	w.resourceVersion = list.ResourceVersion
+	return nil
+}
+
 func parseStream(line string) (watch.WatchEvent, error) {
 	// Ignore comments/keepalives
 	if strings.HasPrefix(line, ":") {
@@ -168,12 +665,15 @@ func parseStream(line string) (watch.WatchEvent, error) {
 	return watch.WatchEvent{}, fmt.Errorf("unknown line format")
 }
 
-func (c *HTTPClient) watchStream(ctx context.Context, eventChan chan<- watch.WatchEvent) error {
-	url := fmt.Sprintf("%s/api/v1/watch?nodename=%s", c.baseURL, c.nodeName)
-	slog.Debug(fmt.Sprintf("making request to %s", url))
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// watchStream reads one watch connection, keeping resourceVersion at the last event it delivered.
+// connected is whether the apiserver accepted the watch, a clean end of the stream is no error.
+func (c *HTTPClient) watchStream(ctx context.Context, w *watcher) (connected bool, err error) {
+	w.query.Set("resourceVersion", w.resourceVersion)
+	u := fmt.Sprintf("%s/api/v1/watch?%s", c.baseURL, w.query.Encode())
+	slog.Debug(fmt.Sprintf("making request to %s", u))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+		return false, fmt.Errorf("failed to create request: %v", err)
 	}
 
 	req.Header.Set("Accept", "text/event-stream")
@@ -181,35 +681,38 @@ func (c *HTTPClient) watchStream(ctx context.Context, eventChan chan<- watch.Wat
 	req.Header.Set("Connection", "keep-alive")
 
 	// Use a client with no timeout for SSE long-lived connection
-	watchClient := &http.Client{Timeout: 0}
-	resp, err := watchClient.Do(req)
+	resp, err := c.streamClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to connect to watch stream: %v", err)
+		return false, fmt.Errorf("failed to connect to watch stream: %v", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusGone {
+		// history we wanted to resume from is gone, start over from a fresh list
+		w.resourceVersion = ""
+		return false, errWatchExpired
+	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxWatchEventSize)
 	for scanner.Scan() {
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-			line := scanner.Text()
-			slog.Debug("received SSE event", "line", line)
-			parsedEvent, err := parseStream(line)
-			if err != nil {
-				slog.Debug("nothing to do.")
-				continue
-			}
-			eventChan <- parsedEvent
+		line := scanner.Text()
+		slog.Debug("received SSE event", "line", line)
+		parsedEvent, err := parseStream(line)
+		if err != nil {
+			slog.Debug("nothing to do.")
+			continue
+		}
+		if !w.send(ctx, parsedEvent) {
+			return true, nil
 		}
+		w.resourceVersion = parsedEvent.ResourceVersion
 	}
 
-	return scanner.Err()
+	return true, scanner.Err()
 }
 
 func (c *HTTPClient) Ping(ctx context.Context) error {
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"superminikube/pkg/apiserver/watch"
+)
+
+func TestWatchRecovers(t *testing.T) {
+	uids := map[string]string{"kept": "8a1f0c6e-5a44-4a36-9d6c-0f4b8f7c2a01", "gone": "8a1f0c6e-5a44-4a36-9d6c-0f4b8f7c2a02"}
+	object := func(name, rv string) string {
+		return fmt.Sprintf(`{"metadata":{"name":%q,"uid":%q,"resourceVersion":%q}}`, name, uids[name], rv)
+	}
+	big := fmt.Sprintf(`{"metadata":{"name":"big"},"data":%q}`, strings.Repeat("x", 100*1024))
+	send := func(w http.ResponseWriter, rv string, object string) {
+		b, _ := json.Marshal(watch.WatchEvent{EventType: watch.Add, Resource: "replicasets", ResourceVersion: rv, Object: json.RawMessage(object)})
+		fmt.Fprintf(w, "data: %s\n\n", b)
+		w.(http.Flusher).Flush()
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/replicasets", func(w http.ResponseWriter, r *http.Request) {
+		// kept is still there, gone was deleted while the watch was down
+		fmt.Fprintf(w, `{"resourceVersion":"10","items":[%s]}`, object("kept", "8"))
+	})
+	mux.HandleFunc("/api/v1/watch", func(w http.ResponseWriter, r *http.Request) {
+		switch rv := r.URL.Query().Get("resourceVersion"); rv {
+		case "":
+			// a clean end of the stream, e.g. the apiserver restarting
+			send(w, "3", object("kept", "3"))
+			send(w, "4", object("gone", "4"))
+		case "4":
+			// compacted away
+			http.Error(w, "gone", http.StatusGone)
+		case "10":
+			send(w, "11", big)
+			<-r.Context().Done()
+		default:
+			t.Errorf("unexpected watch from resourceVersion %q", rv)
+			http.Error(w, "unexpected", http.StatusBadRequest)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	events, err := NewHTTPClient(srv.URL, "").WatchResource(ctx, "replicasets", "")
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	expected := []string{"Add 3", "Add 4", "Modified 8", "Delete 4", "Add 11"}
+	for _, want := range expected {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatalf("watch closed, expected %s", want)
+			}
+			kind := map[watch.Event]string{watch.Add: "Add", watch.Modified: "Modified", watch.Delete: "Delete"}[ev.EventType]
+			if got := kind + " " + ev.ResourceVersion; got != want {
+				t.Errorf("got event %s, expected %s", got, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+	cancel()
+	for range events {
+	}
+}
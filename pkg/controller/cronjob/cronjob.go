@@ -0,0 +1,287 @@
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+	"superminikube/pkg/cron"
+)
+
+const (
+	// schedules go down to the minute, checking a few times a minute starts runs close to on time
+	DefaultResyncPeriod = 10 * time.Second
+	// Kind set on the ownerReferences of jobs a cron job creates
+	Kind = "CronJob"
+	// more missed runs than this and something's off, like a clock jump or the controller being down for long
+	maxMissedRuns = 100
+)
+
+type Opts struct {
+	// how often every cron job is synced regardless of events, runs start at most this late
+	ResyncPeriod time.Duration
+}
+
+// Controller starts a job for every run of every cron job's schedule, and deletes old finished ones.
+// Jobs are named after the cron job and their scheduled time, so a run is never started twice.
+type Controller struct {
+	client client.Client
+	opts   Opts
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewController(c client.Client, opts Opts) *Controller {
+	if opts.ResyncPeriod == 0 {
+		opts.ResyncPeriod = DefaultResyncPeriod
+	}
+	return &Controller{
+		client: c,
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// Start syncs cron jobs as they or their jobs change, and all of them every ResyncPeriod, until ctx is done
+func (c *Controller) Start(ctx context.Context) error {
+	if err := c.client.Ping(ctx); err != nil {
+		return fmt.Errorf("cron job controller failed to start: %v", err)
+	}
+	cronJobEvents, err := c.client.WatchResource(ctx, "cronjobs", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch cron jobs: %v", err)
+	}
+	jobEvents, err := c.client.WatchResource(ctx, "jobs", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch jobs: %v", err)
+	}
+	c.sync(ctx, "", "")
+	ticker := time.NewTicker(c.opts.ResyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("cron job controller stopped due to context cancellation")
+			return nil
+		case ev, ok := <-cronJobEvents:
+			if !ok {
+				return errors.New("cron job watch channel closed")
+			}
+			var cj api.CronJob
+			if err := ev.DecodeObject(&cj); err != nil {
+				slog.Error("failed to decode cron job event", "error", err)
+				continue
+			}
+			c.sync(ctx, cj.Namespace, cj.Name)
+		case ev, ok := <-jobEvents:
+			if !ok {
+				return errors.New("job watch channel closed")
+			}
+			var j api.Job
+			if err := ev.DecodeObject(&j); err != nil {
+				slog.Error("failed to decode job event", "error", err)
+				continue
+			}
+			if ref := j.ControllerRef(); ref != nil && ref.Kind == Kind {
+				c.sync(ctx, j.Namespace, ref.Name)
+			}
+		case <-ticker.C:
+			c.sync(ctx, "", "")
+		}
+	}
+}
+
+// sync syncs the cron job namespace/name, or every cron job if name is empty.
+// Jobs left behind by a deleted cron job are deleted here too, which in turn takes their pods.
+func (c *Controller) sync(ctx context.Context, namespace, name string) {
+	cronJobs, err := c.client.ListCronJobs(ctx)
+	if err != nil {
+		slog.Error("failed to list cron jobs", "error", err)
+		return
+	}
+	jobs, err := c.client.ListJobs(ctx)
+	if err != nil {
+		slog.Error("failed to list jobs", "error", err)
+		return
+	}
+	selected := func(ns, n string) bool {
+		return name == "" || (ns == namespace && n == name)
+	}
+	for _, cj := range cronJobs.Items {
+		if !selected(cj.Namespace, cj.Name) {
+			continue
+		}
+		var owned []api.Job
+		for _, j := range jobs.Items {
+			if j.Namespace == cj.Namespace && j.IsControlledBy(cj.Uid) {
+				owned = append(owned, j)
+			}
+		}
+		c.syncCronJob(ctx, cj, owned)
+	}
+	for _, j := range jobs.Items {
+		ref := j.ControllerRef()
+		if ref == nil || ref.Kind != Kind || !selected(j.Namespace, ref.Name) {
+			continue
+		}
+		owned := slices.ContainsFunc(cronJobs.Items, func(cj api.CronJob) bool {
+			return cj.Namespace == j.Namespace && cj.Uid == ref.Uid
+		})
+		if !owned {
+			slog.Info("deleting job of a deleted cron job", "namespace", j.Namespace, "job", j.Name, "cronjob", ref.Name)
+			c.deleteJob(ctx, j)
+		}
+	}
+}
+
+// syncCronJob starts a job if a run is due, trims the history of finished jobs and reports what's running
+func (c *Controller) syncCronJob(ctx context.Context, cj api.CronJob, jobs []api.Job) {
+	now := c.now().UTC()
+	status := cj.Status
+	var running, succeeded, failed []api.Job
+	for _, j := range jobs {
+		finished := j.Status.Finished()
+		switch {
+		case finished == nil:
+			running = append(running, j)
+		case finished.Type == api.JobComplete:
+			succeeded = append(succeeded, j)
+			if t := j.Status.CompletionTime; t != nil && (status.LastSuccessfulTime == nil || t.After(*status.LastSuccessfulTime)) {
+				status.LastSuccessfulTime = t
+			}
+		default:
+			failed = append(failed, j)
+		}
+	}
+	c.cleanupHistory(ctx, succeeded, int(*cj.Spec.SuccessfulJobsHistoryLimit))
+	c.cleanupHistory(ctx, failed, int(*cj.Spec.FailedJobsHistoryLimit))
+
+	if scheduled, ok := c.dueRun(cj, now); ok && !cj.Spec.Suspend {
+		switch {
+		case cj.Spec.ConcurrencyPolicy == api.ForbidConcurrent && len(running) > 0:
+			// the run stays due, it starts late once the running job is done if the starting deadline allows
+			slog.Info("skipping cron job run while an earlier job is running", "namespace", cj.Namespace, "cronjob", cj.Name, "scheduled", scheduled)
+		default:
+			if cj.Spec.ConcurrencyPolicy == api.ReplaceConcurrent {
+				for _, j := range running {
+					slog.Info("replacing running job", "namespace", cj.Namespace, "cronjob", cj.Name, "job", j.Name)
+					c.deleteJob(ctx, j)
+				}
+				running = nil
+			}
+			j := newJob(cj, scheduled)
+			err := c.client.CreateJob(ctx, j)
+			switch {
+			case err == nil:
+				slog.Info("started job for cron job", "namespace", cj.Namespace, "cronjob", cj.Name, "job", j.Name, "scheduled", scheduled)
+				running = append(running, j)
+				status.LastScheduleTime = &scheduled
+			case errors.Is(err, client.ErrConflict):
+				// an earlier sync started it but didn't get to record it
+				status.LastScheduleTime = &scheduled
+			default:
+				slog.Error("failed to create job", "namespace", cj.Namespace, "cronjob", cj.Name, "error", err)
+			}
+		}
+	}
+
+	status.Active = nil
+	for _, j := range running {
+		status.Active = append(status.Active, j.Name)
+	}
+	slices.Sort(status.Active)
+	if reflect.DeepEqual(status, cj.Status) {
+		return
+	}
+	cj.Status = status
+	cj.ResourceVersion = ""
+	if err := c.client.UpdateCronJobStatus(ctx, cj); err != nil {
+		slog.Error("failed to update cron job status", "cronjob", cj.Name, "error", err)
+	}
+}
+
+// dueRun returns the most recent scheduled time since the last run that's at or before now.
+// Runs missed in between are skipped, and so is the due one if it's later than the starting deadline allows.
+func (c *Controller) dueRun(cj api.CronJob, now time.Time) (time.Time, bool) {
+	schedule, err := cron.Parse(cj.Spec.Schedule)
+	if err != nil {
+		// validated by the apiserver
+		slog.Error("failed to parse cron job schedule", "namespace", cj.Namespace, "cronjob", cj.Name, "error", err)
+		return time.Time{}, false
+	}
+	since := cj.CreationTimestamp.UTC()
+	if cj.Status.LastScheduleTime != nil {
+		since = cj.Status.LastScheduleTime.UTC()
+	}
+	deadline := cj.Spec.StartingDeadlineSeconds
+	if deadline != nil {
+		// nothing from before the deadline could be started anyway
+		since = later(since, now.Add(-time.Duration(*deadline)*time.Second-time.Minute))
+	}
+	var due time.Time
+	missed := 0
+	for t := schedule.Next(since); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		due = t
+		missed++
+	}
+	if due.IsZero() {
+		return time.Time{}, false
+	}
+	if missed > maxMissedRuns {
+		slog.Warn("cron job missed many runs, only the latest is started", "namespace", cj.Namespace, "cronjob", cj.Name, "missed", missed)
+	}
+	if deadline != nil && now.Sub(due) > time.Duration(*deadline)*time.Second {
+		slog.Info("cron job run missed its starting deadline", "namespace", cj.Namespace, "cronjob", cj.Name, "scheduled", due)
+		return time.Time{}, false
+	}
+	return due, true
+}
+
+// cleanupHistory deletes the oldest of the finished jobs beyond limit
+func (c *Controller) cleanupHistory(ctx context.Context, finished []api.Job, limit int) {
+	slices.SortFunc(finished, func(a, b api.Job) int { return a.CreationTimestamp.Compare(b.CreationTimestamp) })
+	for i := 0; i < len(finished)-limit; i++ {
+		slog.Info("deleting old job", "namespace", finished[i].Namespace, "job", finished[i].Name)
+		c.deleteJob(ctx, finished[i])
+	}
+}
+
+func (c *Controller) deleteJob(ctx context.Context, j api.Job) {
+	err := c.client.DeleteJob(ctx, j, api.DeleteOptions{ResourceVersion: j.ResourceVersion})
+	if err != nil && !errors.Is(err, client.ErrNotFound) && !errors.Is(err, client.ErrConflict) {
+		slog.Error("failed to delete job", "namespace", j.Namespace, "job", j.Name, "error", err)
+	}
+}
+
+// newJob stamps out the job for the run scheduled at t, named after the minute it's for
+func newJob(cj api.CronJob, t time.Time) api.Job {
+	return api.Job{
+		ObjectMeta: api.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%d", cj.Name, t.Unix()/60),
+			Namespace:   cj.Namespace,
+			Labels:      maps.Clone(cj.Spec.JobTemplate.Labels),
+			Annotations: maps.Clone(cj.Spec.JobTemplate.Annotations),
+			OwnerReferences: []api.OwnerReference{{
+				Kind:       Kind,
+				Name:       cj.Name,
+				Uid:        cj.Uid,
+				Controller: true,
+			}},
+		},
+		Spec: cj.Spec.JobTemplate.Spec,
+	}
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,208 @@
+package cronjob
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/client"
+)
+
+var created = time.Date(2025, 1, 1, 10, 0, 30, 0, time.UTC)
+
+func newCronJob(name, schedule string, policy api.ConcurrencyPolicy) api.CronJob {
+	cj := apitest.NewCronJob(name, schedule)
+	cj.CreationTimestamp = created
+	cj.Spec.ConcurrencyPolicy = policy
+	cj.Spec.SuccessfulJobsHistoryLimit = apitest.Ptr(int32(3))
+	cj.Spec.FailedJobsHistoryLimit = apitest.Ptr(int32(1))
+	return cj
+}
+
+func newController(c *client.FakeClient, now *time.Time) *Controller {
+	controller := NewController(c, Opts{})
+	controller.now = func() time.Time { return *now }
+	return controller
+}
+
+func jobName(cj string, t time.Time) string {
+	return fmt.Sprintf("%s-%d", cj, t.Unix()/60)
+}
+
+func complete(j *api.Job, at time.Time) {
+	j.Status.CompletionTime = &at
+	j.Status.Conditions = []api.JobCondition{{Type: api.JobComplete, Status: api.ConditionTrue, LastTransitionTime: at}}
+}
+
+func jobNames(jobs []api.Job) []string {
+	var names []string
+	for _, j := range jobs {
+		names = append(names, j.Name)
+	}
+	return names
+}
+
+func TestCronJobStartsJobsOnSchedule(t *testing.T) {
+	cj := newCronJob("report", "*/5 * * * *", api.AllowConcurrent)
+	c := &client.FakeClient{CronJobs: []api.CronJob{cj}}
+	now := created.Add(2 * time.Minute)
+	controller := newController(c, &now)
+
+	controller.sync(t.Context(), "", "")
+	if len(c.Jobs) != 0 {
+		t.Fatalf("started a job before the first run was due: %v", jobNames(c.Jobs))
+	}
+
+	first := time.Date(2025, 1, 1, 10, 5, 0, 0, time.UTC)
+	now = first.Add(10 * time.Second)
+	controller.sync(t.Context(), "", "")
+	controller.sync(t.Context(), "", "")
+	if len(c.Jobs) != 1 || c.Jobs[0].Name != jobName("report", first) {
+		t.Fatalf("expected one job for the 10:05 run, got %v", jobNames(c.Jobs))
+	}
+	j := c.Jobs[0]
+	if ref := j.ControllerRef(); ref == nil || ref.Kind != Kind || ref.Uid != cj.Uid {
+		t.Errorf("job has controller ref %+v", ref)
+	}
+	if j.Labels["app"] != "report" {
+		t.Errorf("job has labels %v", j.Labels)
+	}
+	status := c.CronJobs[0].Status
+	if status.LastScheduleTime == nil || !status.LastScheduleTime.Equal(first) {
+		t.Errorf("last schedule time is %v, expected %v", status.LastScheduleTime, first)
+	}
+	if !slices.Equal(status.Active, []string{j.Name}) {
+		t.Errorf("active jobs are %v", status.Active)
+	}
+
+	// Allow lets the next run overlap the first
+	now = first.Add(5 * time.Minute)
+	controller.sync(t.Context(), "", "")
+	if len(c.Jobs) != 2 || len(c.CronJobs[0].Status.Active) != 2 {
+		t.Fatalf("expected two jobs running, got %v", jobNames(c.Jobs))
+	}
+}
+
+func TestCronJobMissedRuns(t *testing.T) {
+	cj := newCronJob("report", "* * * * *", api.AllowConcurrent)
+	c := &client.FakeClient{CronJobs: []api.CronJob{cj}}
+	now := created.Add(time.Hour)
+	controller := newController(c, &now)
+
+	// an hour of missed runs only starts the latest
+	controller.sync(t.Context(), "", "")
+	if len(c.Jobs) != 1 || c.Jobs[0].Name != jobName("report", created.Add(time.Hour).Truncate(time.Minute)) {
+		t.Fatalf("expected one job for the latest run, got %v", jobNames(c.Jobs))
+	}
+
+	// past the starting deadline nothing is started
+	deadline := int64(30)
+	c.CronJobs[0].Spec.StartingDeadlineSeconds = &deadline
+	now = now.Add(3 * time.Minute).Truncate(time.Minute).Add(45 * time.Second)
+	controller.sync(t.Context(), "", "")
+	if len(c.Jobs) != 1 {
+		t.Fatalf("started a job past its starting deadline: %v", jobNames(c.Jobs))
+	}
+	now = now.Add(30 * time.Second)
+	controller.sync(t.Context(), "", "")
+	if len(c.Jobs) != 2 {
+		t.Fatalf("expected a job for the run within its starting deadline, got %v", jobNames(c.Jobs))
+	}
+}
+
+func TestCronJobConcurrencyPolicy(t *testing.T) {
+	first := time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	t.Run("Forbid", func(t *testing.T) {
+		c := &client.FakeClient{CronJobs: []api.CronJob{newCronJob("backup", "@hourly", api.ForbidConcurrent)}}
+		now := first
+		controller := newController(c, &now)
+		controller.sync(t.Context(), "", "")
+
+		now = second
+		controller.sync(t.Context(), "", "")
+		if len(c.Jobs) != 1 {
+			t.Fatalf("started a job while the previous is running: %v", jobNames(c.Jobs))
+		}
+		// the skipped run starts once the previous job is done
+		complete(&c.Jobs[0], second.Add(time.Minute))
+		now = second.Add(2 * time.Minute)
+		controller.sync(t.Context(), "", "")
+		if len(c.Jobs) != 2 || c.Jobs[1].Name != jobName("backup", second) {
+			t.Fatalf("expected the skipped run to start, got %v", jobNames(c.Jobs))
+		}
+		if got := c.CronJobs[0].Status.LastSuccessfulTime; got == nil || !got.Equal(second.Add(time.Minute)) {
+			t.Errorf("last successful time is %v", got)
+		}
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		c := &client.FakeClient{CronJobs: []api.CronJob{newCronJob("backup", "@hourly", api.ReplaceConcurrent)}}
+		now := first
+		controller := newController(c, &now)
+		controller.sync(t.Context(), "", "")
+
+		now = second
+		controller.sync(t.Context(), "", "")
+		if len(c.Jobs) != 1 || c.Jobs[0].Name != jobName("backup", second) {
+			t.Fatalf("expected the running job to be replaced, got %v", jobNames(c.Jobs))
+		}
+		if active := c.CronJobs[0].Status.Active; !slices.Equal(active, []string{jobName("backup", second)}) {
+			t.Errorf("active jobs are %v", active)
+		}
+	})
+}
+
+func TestCronJobSuspend(t *testing.T) {
+	cj := newCronJob("report", "* * * * *", api.AllowConcurrent)
+	cj.Spec.Suspend = true
+	c := &client.FakeClient{CronJobs: []api.CronJob{cj}}
+	now := created.Add(5 * time.Minute)
+	controller := newController(c, &now)
+
+	controller.sync(t.Context(), "", "")
+	if len(c.Jobs) != 0 {
+		t.Fatalf("suspended cron job started %v", jobNames(c.Jobs))
+	}
+	c.CronJobs[0].Spec.Suspend = false
+	controller.sync(t.Context(), "", "")
+	if len(c.Jobs) != 1 {
+		t.Fatalf("expected a job once resumed, got %v", jobNames(c.Jobs))
+	}
+}
+
+func TestCronJobHistoryLimits(t *testing.T) {
+	cj := newCronJob("report", "@daily", api.AllowConcurrent)
+	owner := []api.OwnerReference{{Kind: Kind, Name: cj.Name, Uid: cj.Uid, Controller: true}}
+	c := &client.FakeClient{CronJobs: []api.CronJob{cj}}
+	for i := range 5 {
+		at := created.Add(time.Duration(i) * time.Minute)
+		j := api.Job{ObjectMeta: api.ObjectMeta{Name: fmt.Sprintf("ok-%d", i), Namespace: "default", CreationTimestamp: at, OwnerReferences: owner}}
+		complete(&j, at)
+		c.Jobs = append(c.Jobs, j)
+	}
+	for i := range 2 {
+		j := api.Job{ObjectMeta: api.ObjectMeta{Name: fmt.Sprintf("failed-%d", i), Namespace: "default", CreationTimestamp: created.Add(time.Duration(i) * time.Minute), OwnerReferences: owner}}
+		j.Status.Conditions = []api.JobCondition{{Type: api.JobFailed, Status: api.ConditionTrue}}
+		c.Jobs = append(c.Jobs, j)
+	}
+	orphan := []api.OwnerReference{{Kind: Kind, Name: "gone", Uid: uuid.New(), Controller: true}}
+	c.Jobs = append(c.Jobs, api.Job{ObjectMeta: api.ObjectMeta{Name: "orphan", Namespace: "default", OwnerReferences: orphan}})
+	now := created.Add(time.Hour)
+	controller := newController(c, &now)
+
+	controller.sync(t.Context(), "", "")
+	want := []string{"ok-2", "ok-3", "ok-4", "failed-1"}
+	if got := jobNames(c.Jobs); !slices.Equal(got, want) {
+		t.Errorf("jobs left are %v, expected %v", got, want)
+	}
+	if got := c.CronJobs[0].Status.LastSuccessfulTime; got == nil || !got.Equal(created.Add(4*time.Minute)) {
+		t.Errorf("last successful time is %v", got)
+	}
+}
@@ -0,0 +1,335 @@
+package daemonset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+	"superminikube/pkg/scheduler"
+)
+
+const (
+	DefaultResyncPeriod = 30 * time.Second
+	// Kind set on the ownerReferences of pods a daemon set creates
+	Kind = "DaemonSet"
+)
+
+type Opts struct {
+	// how often every daemon set is synced regardless of events, catches anything a watch missed
+	ResyncPeriod time.Duration
+}
+
+// Controller keeps one pod of every daemon set on each node the daemon set's template fits.
+// Pods are created with their node already set so the scheduler leaves them alone,
+// and like the replica set controller it keeps no cache, a sync reads daemon sets, nodes and pods fresh.
+type Controller struct {
+	client client.Client
+	opts   Opts
+}
+
+func NewController(c client.Client, opts Opts) *Controller {
+	if opts.ResyncPeriod == 0 {
+		opts.ResyncPeriod = DefaultResyncPeriod
+	}
+	return &Controller{
+		client: c,
+		opts:   opts,
+	}
+}
+
+// Start syncs daemon sets as they, their pods or any node change, and all of them every ResyncPeriod, until ctx is done
+func (c *Controller) Start(ctx context.Context) error {
+	if err := c.client.Ping(ctx); err != nil {
+		return fmt.Errorf("daemon set controller failed to start: %v", err)
+	}
+	dsEvents, err := c.client.WatchResource(ctx, "daemonsets", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch daemon sets: %v", err)
+	}
+	podEvents, err := c.client.WatchResource(ctx, "pods", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch pods: %v", err)
+	}
+	nodeEvents, err := c.client.WatchResource(ctx, "nodes", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch nodes: %v", err)
+	}
+	c.sync(ctx, "", "")
+	ticker := time.NewTicker(c.opts.ResyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("daemon set controller stopped due to context cancellation")
+			return nil
+		case ev, ok := <-dsEvents:
+			if !ok {
+				return errors.New("daemon set watch channel closed")
+			}
+			var ds api.DaemonSet
+			if err := ev.DecodeObject(&ds); err != nil {
+				slog.Error("failed to decode daemon set event", "error", err)
+				continue
+			}
+			c.sync(ctx, ds.Namespace, ds.Name)
+		case ev, ok := <-podEvents:
+			if !ok {
+				return errors.New("pod watch channel closed")
+			}
+			if ref := ev.Pod.ControllerRef(); ref != nil && ref.Kind == Kind {
+				c.sync(ctx, ev.Pod.Namespace, ref.Name)
+			}
+		case _, ok := <-nodeEvents:
+			if !ok {
+				return errors.New("node watch channel closed")
+			}
+			// a node joining, leaving or changing its labels or taints can matter to any daemon set
+			c.sync(ctx, "", "")
+		case <-ticker.C:
+			c.sync(ctx, "", "")
+		}
+	}
+}
+
+// sync syncs the daemon set namespace/name, or every daemon set if name is empty.
+// Pods left behind by a deleted daemon set are cleaned up here too.
+func (c *Controller) sync(ctx context.Context, namespace, name string) {
+	sets, err := c.client.ListDaemonSets(ctx)
+	if err != nil {
+		slog.Error("failed to list daemon sets", "error", err)
+		return
+	}
+	nodes, err := c.client.ListNodes(ctx)
+	if err != nil {
+		slog.Error("failed to list nodes", "error", err)
+		return
+	}
+	pods, err := c.client.ListPods(ctx)
+	if err != nil {
+		slog.Error("failed to list pods", "error", err)
+		return
+	}
+	selected := func(ns, n string) bool {
+		return name == "" || (ns == namespace && n == name)
+	}
+	for _, ds := range sets.Items {
+		if !selected(ds.Namespace, ds.Name) {
+			continue
+		}
+		var owned []api.Pod
+		for _, p := range pods.Items {
+			if p.Namespace == ds.Namespace && p.IsControlledBy(ds.Uid) {
+				owned = append(owned, p)
+			}
+		}
+		c.syncDaemonSet(ctx, ds, nodes.Items, owned)
+	}
+	for _, p := range pods.Items {
+		ref := p.ControllerRef()
+		if ref == nil || ref.Kind != Kind || !selected(p.Namespace, ref.Name) || p.DeletionTimestamp != nil {
+			continue
+		}
+		owned := slices.ContainsFunc(sets.Items, func(ds api.DaemonSet) bool {
+			return ds.Namespace == p.Namespace && ds.Uid == ref.Uid
+		})
+		if !owned {
+			slog.Info("deleting pod of a deleted daemon set", "namespace", p.Namespace, "pod", p.Name, "daemonset", ref.Name)
+			c.deletePod(ctx, p)
+		}
+	}
+}
+
+// syncDaemonSet walks the nodes, starting the daemon pod where it's missing and deleting it where it doesn't belong,
+// then moves a rolling update along and reports what it found
+func (c *Controller) syncDaemonSet(ctx context.Context, ds api.DaemonSet, nodes []api.Node, pods []api.Pod) {
+	hash := templateHash(ds.Spec.Template)
+	byNode := map[string][]api.Pod{}
+	for _, p := range pods {
+		byNode[p.Nodename] = append(byNode[p.Nodename], p)
+	}
+
+	status := api.DaemonSetStatus{ObservedGeneration: ds.Generation}
+	var old []api.Pod
+	for _, node := range nodes {
+		nodePods := byNode[node.Name]
+		delete(byNode, node.Name)
+		if !shouldRun(ds, node) {
+			if len(nodePods) > 0 {
+				status.NumberMisscheduled++
+				slog.Info("deleting daemon pods from node they don't fit", "namespace", ds.Namespace, "daemonset", ds.Name, "node", node.Name)
+			}
+			for _, p := range nodePods {
+				c.deletePod(ctx, p)
+			}
+			continue
+		}
+		status.DesiredNumberScheduled++
+
+		var active []api.Pod
+		terminating := false
+		for _, p := range nodePods {
+			switch {
+			case p.DeletionTimestamp != nil:
+				terminating = true
+			case p.Status.Phase == api.PodSucceeded || p.Status.Phase == api.PodFailed:
+				// a daemon is meant to keep running, a finished one is replaced
+				slog.Info("deleting finished daemon pod", "namespace", ds.Namespace, "pod", p.Name, "node", node.Name, "phase", p.Status.Phase)
+				c.deletePod(ctx, p)
+				terminating = true
+			default:
+				active = append(active, p)
+			}
+		}
+		if len(active) == 0 {
+			status.NumberUnavailable++
+			// the replacement waits for the old pod to be gone, it likely wants the same host ports
+			if terminating {
+				continue
+			}
+			slog.Info("creating daemon pod", "namespace", ds.Namespace, "daemonset", ds.Name, "node", node.Name)
+			if err := c.client.CreatePod(ctx, newPod(ds, node.Name, hash)); err != nil {
+				slog.Error("failed to create pod", "daemonset", ds.Name, "node", node.Name, "error", err)
+			}
+			continue
+		}
+		// more than one is left over from an earlier sync racing this one, the oldest stays
+		slices.SortFunc(active, func(a, b api.Pod) int { return a.CreationTimestamp.Compare(b.CreationTimestamp) })
+		for _, p := range active[1:] {
+			c.deletePod(ctx, p)
+		}
+		p := active[0]
+		status.CurrentNumberScheduled++
+		if isReady(p) {
+			status.NumberReady++
+		} else {
+			status.NumberUnavailable++
+		}
+		if p.Labels[api.PodTemplateHashLabel] == hash {
+			status.UpdatedNumberScheduled++
+		} else {
+			old = append(old, p)
+		}
+	}
+	// whatever is left sits on nodes that are gone
+	for nodeName, nodePods := range byNode {
+		for _, p := range nodePods {
+			if p.DeletionTimestamp == nil {
+				slog.Info("deleting daemon pod of a removed node", "namespace", ds.Namespace, "pod", p.Name, "node", nodeName)
+				c.deletePod(ctx, p)
+			}
+		}
+	}
+
+	if ds.Spec.UpdateStrategy.Type == api.RollingUpdateDaemonSetStrategyType {
+		c.rollingUpdate(ctx, ds, old, status)
+	}
+
+	if status == ds.Status {
+		return
+	}
+	ds.Status = status
+	ds.ResourceVersion = ""
+	if err := c.client.UpdateDaemonSetStatus(ctx, ds); err != nil {
+		slog.Error("failed to update daemon set status", "daemonset", ds.Name, "error", err)
+	}
+}
+
+// rollingUpdate deletes pods of an old template so the next sync replaces them, as many at a time as maxUnavailable allows.
+// Old pods that aren't ready make nothing less available and go first, without counting against it.
+func (c *Controller) rollingUpdate(ctx context.Context, ds api.DaemonSet, old []api.Pod, status api.DaemonSetStatus) {
+	if len(old) == 0 {
+		return
+	}
+	// validated by the apiserver
+	maxUnavailable, _ := ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable.ScaledValue(int(status.DesiredNumberScheduled), true)
+	budget := maxUnavailable - int(status.NumberUnavailable)
+	slices.SortStableFunc(old, func(a, b api.Pod) int {
+		switch ra, rb := isReady(a), isReady(b); {
+		case ra == rb:
+			return 0
+		case rb:
+			return -1
+		default:
+			return 1
+		}
+	})
+	for _, p := range old {
+		if isReady(p) {
+			if budget <= 0 {
+				break
+			}
+			budget--
+		}
+		slog.Info("replacing daemon pod of an old template", "namespace", ds.Namespace, "daemonset", ds.Name, "pod", p.Name, "node", p.Nodename)
+		c.deletePod(ctx, p)
+	}
+}
+
+func (c *Controller) deletePod(ctx context.Context, p api.Pod) {
+	// the uid keeps a pod recreated under the same name from being deleted by mistake
+	if err := c.client.DeletePod(ctx, p, api.DeleteOptions{Uid: p.Uid}); err != nil && !errors.Is(err, client.ErrConflict) {
+		slog.Error("failed to delete pod", "namespace", p.Namespace, "pod", p.Name, "error", err)
+	}
+}
+
+// shouldRun checks the node carries the template's nodeSelector labels and that its pods tolerate the node's taints.
+// Cordoned nodes still get daemon pods, same as the kubelet's own system pods would.
+func shouldRun(ds api.DaemonSet, node api.Node) bool {
+	pod := newPod(ds, node.Name, "")
+	info := scheduler.NodeInfo{Node: node}
+	return scheduler.MatchNodeSelector(pod, info) == nil && scheduler.PodToleratesNodeTaints(pod, info) == nil
+}
+
+// newPod stamps out the daemon set's pod for node, bound to it from the start.
+// It tolerates the node going unreachable, the daemon belongs on the node however long it's away.
+func newPod(ds api.DaemonSet, nodeName, hash string) api.Pod {
+	labels := maps.Clone(ds.Spec.Template.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[api.PodTemplateHashLabel] = hash
+	spec := ds.Spec.Template.Spec
+	spec.Tolerations = append(slices.Clone(spec.Tolerations), api.Toleration{
+		Key:      api.TaintNodeUnreachable,
+		Operator: api.TolerationOpExists,
+		Effect:   api.TaintEffectNoExecute,
+	})
+	return api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			GenerateName: ds.Name + "-",
+			Namespace:    ds.Namespace,
+			Labels:       labels,
+			Annotations:  maps.Clone(ds.Spec.Template.Annotations),
+			OwnerReferences: []api.OwnerReference{{
+				Kind:       Kind,
+				Name:       ds.Name,
+				Uid:        ds.Uid,
+				Controller: true,
+			}},
+		},
+		Nodename: nodeName,
+		Spec:     spec,
+	}
+}
+
+func isReady(p api.Pod) bool {
+	ready := p.Status.GetCondition(api.PodReady)
+	return ready != nil && ready.Status == api.ConditionTrue
+}
+
+// templateHash tells pods of the current template from those of an old one.
+// The template is encoded as json, which sorts map keys, so equal templates always hash the same.
+func templateHash(template api.PodTemplateSpec) string {
+	b, _ := json.Marshal(template)
+	h := fnv.New32a()
+	h.Write(b)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
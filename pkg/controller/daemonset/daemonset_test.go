@@ -0,0 +1,247 @@
+package daemonset
+
+import (
+	"slices"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/client"
+)
+
+func newDaemonSet(name string) api.DaemonSet {
+	ds := apitest.NewDaemonSet(name)
+	ds.Spec.Template.Spec.NodeSelector = map[string]string{"role": "worker"}
+	ds.Spec.UpdateStrategy = api.DaemonSetUpdateStrategy{
+		Type:          api.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: &api.RollingUpdateDaemonSet{MaxUnavailable: apitest.Ptr(api.FromInt(1))},
+	}
+	return ds
+}
+
+func newNode(name string, labels map[string]string) api.Node {
+	return api.Node{ObjectMeta: api.ObjectMeta{Name: name, Labels: labels}}
+}
+
+var worker = map[string]string{"role": "worker"}
+
+// readyAll marks every pod running and ready, as if the kubelets started them
+func readyAll(c *client.FakeClient) {
+	for i := range c.Pods {
+		c.Pods[i].Status.Phase = api.PodRunning
+		c.Pods[i].Status.Conditions = []api.PodCondition{{Type: api.PodReady, Status: api.ConditionTrue}}
+	}
+}
+
+// podNodes lists the nodes the pods are on, sorted
+func podNodes(pods []api.Pod) []string {
+	var nodes []string
+	for _, p := range pods {
+		nodes = append(nodes, p.Nodename)
+	}
+	slices.Sort(nodes)
+	return nodes
+}
+
+func TestDaemonSetRunsOnMatchingNodes(t *testing.T) {
+	ds := newDaemonSet("logs")
+	tainted := newNode("node-3", worker)
+	tainted.Spec.Taints = []api.Taint{{Key: "dedicated", Value: "db", Effect: api.TaintEffectNoSchedule}}
+	cordoned := newNode("node-4", worker)
+	cordoned.Spec.Unschedulable = true
+	c := &client.FakeClient{
+		DaemonSets: []api.DaemonSet{ds},
+		Nodes:      []api.Node{newNode("node-1", worker), newNode("node-2", nil), tainted, cordoned},
+	}
+	controller := NewController(c, Opts{})
+
+	controller.sync(t.Context(), "", "")
+	if got := podNodes(c.Pods); !slices.Equal(got, []string{"node-1", "node-4"}) {
+		t.Fatalf("expected pods on node-1 and node-4, got %v", got)
+	}
+	p := c.Pods[0]
+	if ref := p.ControllerRef(); ref == nil || ref.Kind != Kind || ref.Uid != ds.Uid {
+		t.Errorf("pod has controller ref %+v", ref)
+	}
+	if p.Labels["app"] != "logs" || p.Labels[api.PodTemplateHashLabel] == "" {
+		t.Errorf("pod has labels %v", p.Labels)
+	}
+	unreachable := api.Taint{Key: api.TaintNodeUnreachable, Effect: api.TaintEffectNoExecute}
+	if !slices.ContainsFunc(p.Spec.Tolerations, func(t api.Toleration) bool { return t.Tolerates(unreachable) }) {
+		t.Errorf("pod doesn't tolerate its node going unreachable: %+v", p.Spec.Tolerations)
+	}
+	if len(c.DaemonSets[0].Spec.Template.Spec.Tolerations) != 0 {
+		t.Error("pod tolerations alias the template's")
+	}
+	status := c.DaemonSets[0].Status
+	if status.DesiredNumberScheduled != 2 || status.NumberUnavailable != 2 || status.ObservedGeneration != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	readyAll(c)
+	controller.sync(t.Context(), "", "")
+	status = c.DaemonSets[0].Status
+	if status.CurrentNumberScheduled != 2 || status.NumberReady != 2 || status.UpdatedNumberScheduled != 2 || status.NumberUnavailable != 0 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// tolerating the taint lets the pod onto the tainted node
+	c.DaemonSets[0].Spec.UpdateStrategy.Type = api.OnDeleteDaemonSetStrategyType
+	c.DaemonSets[0].Spec.Template.Spec.Tolerations = []api.Toleration{{Key: "dedicated", Operator: api.TolerationOpExists}}
+	controller.sync(t.Context(), "", "")
+	if got := podNodes(c.Pods); !slices.Equal(got, []string{"node-1", "node-3", "node-4"}) {
+		t.Fatalf("expected pods on node-1, node-3 and node-4, got %v", got)
+	}
+}
+
+func TestDaemonSetFollowsNodes(t *testing.T) {
+	c := &client.FakeClient{
+		DaemonSets: []api.DaemonSet{newDaemonSet("logs")},
+		Nodes:      []api.Node{newNode("node-1", worker)},
+	}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+
+	// a node joining gets a pod
+	c.Nodes = append(c.Nodes, newNode("node-2", worker))
+	controller.sync(t.Context(), "", "")
+	if got := podNodes(c.Pods); !slices.Equal(got, []string{"node-1", "node-2"}) {
+		t.Fatalf("expected pods on node-1 and node-2, got %v", got)
+	}
+
+	// a node losing its label loses its pod
+	c.Nodes[0].Labels = nil
+	controller.sync(t.Context(), "", "")
+	if got := podNodes(c.Pods); !slices.Equal(got, []string{"node-2"}) {
+		t.Fatalf("expected a pod on node-2 only, got %v", got)
+	}
+
+	// so does a node leaving
+	c.Nodes = c.Nodes[:1]
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 0 {
+		t.Fatalf("expected no pods left, got them on %v", podNodes(c.Pods))
+	}
+	if status := c.DaemonSets[0].Status; status.DesiredNumberScheduled != 0 || status.CurrentNumberScheduled != 0 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestDaemonSetReplacesPods(t *testing.T) {
+	c := &client.FakeClient{
+		DaemonSets: []api.DaemonSet{newDaemonSet("logs")},
+		Nodes:      []api.Node{newNode("node-1", worker)},
+	}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+	first := c.Pods[0]
+
+	// a duplicate from a racing sync is deleted, the older pod stays
+	dup := newPod(c.DaemonSets[0], "node-1", templateHash(c.DaemonSets[0].Spec.Template))
+	if err := c.CreatePod(t.Context(), dup); err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 1 || c.Pods[0].Uid != first.Uid {
+		t.Fatalf("expected only the first pod to be left, got %+v", c.Pods)
+	}
+
+	// a failed pod is deleted, and replaced once it's gone
+	c.Pods[0].Status.Phase = api.PodFailed
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 0 {
+		t.Fatalf("expected the failed pod to be deleted, have %d pods", len(c.Pods))
+	}
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 1 || c.Pods[0].Uid == first.Uid {
+		t.Fatalf("expected a replacement pod, got %+v", c.Pods)
+	}
+}
+
+func TestDaemonSetRollingUpdate(t *testing.T) {
+	c := &client.FakeClient{
+		DaemonSets: []api.DaemonSet{newDaemonSet("logs")},
+		Nodes:      []api.Node{newNode("node-1", worker), newNode("node-2", worker), newNode("node-3", worker)},
+	}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+	readyAll(c)
+	oldHash := c.Pods[0].Labels[api.PodTemplateHashLabel]
+
+	c.DaemonSets[0].Spec.Template.Spec.Containers = []api.Container{{Name: "agent", Image: "fluentd:2"}}
+	c.DaemonSets[0].Generation = 2
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 2 {
+		t.Fatalf("expected maxUnavailable pods to be replaced at once, have %d pods left", len(c.Pods))
+	}
+	// the replacement isn't ready, so no other old pod goes
+	for range 2 {
+		controller.sync(t.Context(), "", "")
+	}
+	if len(c.Pods) != 3 {
+		t.Fatalf("expected the replacement to be created and nothing more deleted, have %d pods", len(c.Pods))
+	}
+	status := c.DaemonSets[0].Status
+	if status.UpdatedNumberScheduled != 1 || status.NumberReady != 2 || status.NumberUnavailable != 1 || status.ObservedGeneration != 2 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// every node gets a new pod once each replacement is ready
+	for range 4 {
+		readyAll(c)
+		controller.sync(t.Context(), "", "")
+	}
+	readyAll(c)
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 3 {
+		t.Fatalf("expected 3 pods, got %d", len(c.Pods))
+	}
+	for _, p := range c.Pods {
+		if p.Labels[api.PodTemplateHashLabel] == oldHash || p.Spec.Containers[0].Image != "fluentd:2" {
+			t.Errorf("pod %s on %s is still on the old template", p.Name, p.Nodename)
+		}
+	}
+	if status := c.DaemonSets[0].Status; status.UpdatedNumberScheduled != 3 || status.NumberReady != 3 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestDaemonSetOnDelete(t *testing.T) {
+	ds := newDaemonSet("logs")
+	ds.Spec.UpdateStrategy = api.DaemonSetUpdateStrategy{Type: api.OnDeleteDaemonSetStrategyType}
+	c := &client.FakeClient{DaemonSets: []api.DaemonSet{ds}, Nodes: []api.Node{newNode("node-1", worker)}}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+	readyAll(c)
+
+	c.DaemonSets[0].Spec.Template.Spec.Containers = []api.Container{{Name: "agent", Image: "fluentd:2"}}
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 1 || c.Pods[0].Spec.Containers[0].Image != "fluentd:1" {
+		t.Fatalf("expected the old pod to be left alone, got %+v", c.Pods)
+	}
+	if c.DaemonSets[0].Status.UpdatedNumberScheduled != 0 {
+		t.Errorf("unexpected status %+v", c.DaemonSets[0].Status)
+	}
+
+	// deleting it by hand brings in the new template
+	c.Pods = nil
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 1 || c.Pods[0].Spec.Containers[0].Image != "fluentd:2" {
+		t.Fatalf("expected a pod of the new template, got %+v", c.Pods)
+	}
+}
+
+func TestDaemonSetDeletesPodsOfDeletedDaemonSet(t *testing.T) {
+	c := &client.FakeClient{
+		DaemonSets: []api.DaemonSet{newDaemonSet("logs")},
+		Nodes:      []api.Node{newNode("node-1", worker)},
+	}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+
+	c.DaemonSets = nil
+	controller.sync(t.Context(), "default", "logs")
+	if len(c.Pods) != 0 {
+		t.Errorf("expected the pods of the deleted daemon set to be deleted, %d left", len(c.Pods))
+	}
+}
@@ -0,0 +1,286 @@
+package deployment
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/watch"
+	"superminikube/pkg/client"
+)
+
+const (
+	DefaultResyncPeriod = 30 * time.Second
+	// Kind set on the ownerReferences of replica sets a deployment creates
+	Kind = "Deployment"
+)
+
+type Opts struct {
+	// how often every deployment is synced regardless of events, it's also how often progress deadlines are checked
+	ResyncPeriod time.Duration
+}
+
+// Controller rolls deployments out through replica sets, one per pod template.
+// A template change gets a new replica set, named after the template's hash, that's scaled up as the old ones are scaled down.
+// Like the replica set controller it keeps no cache, every sync reads deployments and replica sets fresh.
+type Controller struct {
+	client client.Client
+	opts   Opts
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewController(c client.Client, opts Opts) *Controller {
+	if opts.ResyncPeriod == 0 {
+		opts.ResyncPeriod = DefaultResyncPeriod
+	}
+	return &Controller{
+		client: c,
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// Start syncs deployments as they or their replica sets change, and all of them every ResyncPeriod, until ctx is done
+func (c *Controller) Start(ctx context.Context) error {
+	if err := c.client.Ping(ctx); err != nil {
+		return fmt.Errorf("deployment controller failed to start: %v", err)
+	}
+	deploymentEvents, err := c.client.WatchResource(ctx, "deployments", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch deployments: %v", err)
+	}
+	rsEvents, err := c.client.WatchResource(ctx, "replicasets", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch replica sets: %v", err)
+	}
+	// Recreate waits on old pods being gone, which the replica sets' status doesn't say
+	podEvents, err := c.client.WatchResource(ctx, "pods", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch pods: %v", err)
+	}
+	c.sync(ctx, "", "")
+	ticker := time.NewTicker(c.opts.ResyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("deployment controller stopped due to context cancellation")
+			return nil
+		case ev, ok := <-deploymentEvents:
+			if !ok {
+				return errors.New("deployment watch channel closed")
+			}
+			var d api.Deployment
+			if err := ev.DecodeObject(&d); err != nil {
+				slog.Error("failed to decode deployment event", "error", err)
+				continue
+			}
+			c.sync(ctx, d.Namespace, d.Name)
+		case ev, ok := <-rsEvents:
+			if !ok {
+				return errors.New("replica set watch channel closed")
+			}
+			var rs api.ReplicaSet
+			if err := ev.DecodeObject(&rs); err != nil {
+				slog.Error("failed to decode replica set event", "error", err)
+				continue
+			}
+			if ref := rs.ControllerRef(); ref != nil && ref.Kind == Kind {
+				c.sync(ctx, rs.Namespace, ref.Name)
+			}
+		case ev, ok := <-podEvents:
+			if !ok {
+				return errors.New("pod watch channel closed")
+			}
+			if ev.EventType == watch.Delete {
+				c.sync(ctx, "", "")
+			}
+		case <-ticker.C:
+			c.sync(ctx, "", "")
+		}
+	}
+}
+
+// sync syncs the deployment namespace/name, or every deployment if name is empty.
+// Replica sets left behind by a deleted deployment are deleted here too, which in turn takes their pods.
+func (c *Controller) sync(ctx context.Context, namespace, name string) {
+	deployments, err := c.client.ListDeployments(ctx)
+	if err != nil {
+		slog.Error("failed to list deployments", "error", err)
+		return
+	}
+	sets, err := c.client.ListReplicaSets(ctx)
+	if err != nil {
+		slog.Error("failed to list replica sets", "error", err)
+		return
+	}
+	pods, err := c.client.ListPods(ctx)
+	if err != nil {
+		slog.Error("failed to list pods", "error", err)
+		return
+	}
+	selected := func(ns, n string) bool {
+		return name == "" || (ns == namespace && n == name)
+	}
+	for _, d := range deployments.Items {
+		if !selected(d.Namespace, d.Name) {
+			continue
+		}
+		var owned []api.ReplicaSet
+		for _, rs := range sets.Items {
+			if rs.Namespace == d.Namespace && rs.IsControlledBy(d.Uid) {
+				owned = append(owned, rs)
+			}
+		}
+		c.syncDeployment(ctx, d, owned, pods.Items)
+	}
+	for _, rs := range sets.Items {
+		ref := rs.ControllerRef()
+		if ref == nil || ref.Kind != Kind || !selected(rs.Namespace, ref.Name) {
+			continue
+		}
+		owned := slices.ContainsFunc(deployments.Items, func(d api.Deployment) bool {
+			return d.Namespace == rs.Namespace && d.Uid == ref.Uid
+		})
+		if !owned {
+			slog.Info("deleting replica set of a deleted deployment", "namespace", rs.Namespace, "replicaset", rs.Name, "deployment", ref.Name)
+			c.deleteReplicaSet(ctx, rs)
+		}
+	}
+}
+
+// syncDeployment takes one step of the rollout, then reports where the deployment is at.
+// A paused deployment only has its status reported.
+func (c *Controller) syncDeployment(ctx context.Context, d api.Deployment, sets []api.ReplicaSet, pods []api.Pod) {
+	hash := templateHash(d.Spec.Template)
+	var newRS *api.ReplicaSet
+	var old []api.ReplicaSet
+	for i := range sets {
+		if sets[i].Labels[api.PodTemplateHashLabel] == hash {
+			newRS = &sets[i]
+		} else {
+			old = append(old, sets[i])
+		}
+	}
+	slices.SortFunc(old, func(a, b api.ReplicaSet) int { return cmp.Compare(revision(a), revision(b)) })
+
+	var progress string
+	if !d.Spec.Paused {
+		var err error
+		switch d.Spec.Strategy.Type {
+		case api.RecreateDeploymentStrategyType:
+			progress, err = c.recreate(ctx, d, newRS, old, pods, hash)
+		default:
+			progress, err = c.rollingUpdate(ctx, d, newRS, old, hash)
+		}
+		if err != nil {
+			slog.Error("failed to roll out deployment", "namespace", d.Namespace, "deployment", d.Name, "error", err)
+		}
+		c.cleanupHistory(ctx, d, old)
+	}
+	c.syncStatus(ctx, d, newRS, old, hash, progress)
+}
+
+// syncStatus reports replica counts and the Available and Progressing conditions.
+// progress is the reason for the Progressing condition if the rollout moved along this sync, empty if it didn't.
+func (c *Controller) syncStatus(ctx context.Context, d api.Deployment, newRS *api.ReplicaSet, old []api.ReplicaSet, hash, progress string) {
+	desired := *d.Spec.Replicas
+	status := api.DeploymentStatus{
+		ObservedGeneration: d.Generation,
+		Conditions:         slices.Clone(d.Status.Conditions),
+	}
+	oldActive := false
+	for _, rs := range old {
+		status.Replicas += rs.Status.Replicas
+		status.ReadyReplicas += rs.Status.ReadyReplicas
+		oldActive = oldActive || *rs.Spec.Replicas > 0 || rs.Status.Replicas > 0
+	}
+	if newRS != nil {
+		status.Replicas += newRS.Status.Replicas
+		status.ReadyReplicas += newRS.Status.ReadyReplicas
+		status.UpdatedReplicas = newRS.Status.Replicas
+	}
+	status.UnavailableReplicas = max(0, desired-status.ReadyReplicas)
+
+	now := c.now().UTC()
+	_, maxUnavailable := fenceposts(d)
+	if status.ReadyReplicas >= desired-maxUnavailable {
+		status.SetCondition(api.DeploymentCondition{
+			Type:    api.DeploymentAvailable,
+			Status:  api.ConditionTrue,
+			Reason:  "MinimumReplicasAvailable",
+			Message: "Deployment has minimum availability.",
+		}, now)
+	} else {
+		status.SetCondition(api.DeploymentCondition{
+			Type:    api.DeploymentAvailable,
+			Status:  api.ConditionFalse,
+			Reason:  "MinimumReplicasUnavailable",
+			Message: "Deployment does not have minimum availability.",
+		}, now)
+	}
+
+	rsName := d.Name + "-" + hash
+	prev := status.GetCondition(api.DeploymentProgressing)
+	complete := newRS != nil && !oldActive && *newRS.Spec.Replicas == desired &&
+		status.UpdatedReplicas == desired && status.ReadyReplicas == desired
+	countsChanged := status.Replicas != d.Status.Replicas || status.UpdatedReplicas != d.Status.UpdatedReplicas ||
+		status.ReadyReplicas != d.Status.ReadyReplicas
+	switch {
+	case d.Spec.Paused:
+		status.SetCondition(api.DeploymentCondition{
+			Type:    api.DeploymentProgressing,
+			Status:  api.ConditionUnknown,
+			Reason:  "DeploymentPaused",
+			Message: "Deployment is paused.",
+		}, now)
+	case complete:
+		status.SetCondition(api.DeploymentCondition{
+			Type:    api.DeploymentProgressing,
+			Status:  api.ConditionTrue,
+			Reason:  "NewReplicaSetAvailable",
+			Message: fmt.Sprintf("ReplicaSet %q has successfully progressed.", rsName),
+		}, now)
+	case progress != "" || countsChanged || prev == nil || prev.Reason == "DeploymentPaused" || prev.Reason == "NewReplicaSetAvailable":
+		if progress == "" {
+			progress = "ReplicaSetUpdated"
+		}
+		if prev != nil && prev.Reason == "DeploymentPaused" {
+			progress = "DeploymentResumed"
+		}
+		status.SetCondition(api.DeploymentCondition{
+			Type:    api.DeploymentProgressing,
+			Status:  api.ConditionTrue,
+			Reason:  progress,
+			Message: fmt.Sprintf("ReplicaSet %q is progressing.", rsName),
+		}, now)
+		// progress was made, the deadline starts over
+		status.GetCondition(api.DeploymentProgressing).LastUpdateTime = now
+	case prev.Status == api.ConditionTrue:
+		deadline := time.Duration(*d.Spec.ProgressDeadlineSeconds) * time.Second
+		if now.Sub(prev.LastUpdateTime) > deadline {
+			status.SetCondition(api.DeploymentCondition{
+				Type:    api.DeploymentProgressing,
+				Status:  api.ConditionFalse,
+				Reason:  "ProgressDeadlineExceeded",
+				Message: fmt.Sprintf("ReplicaSet %q has timed out progressing.", rsName),
+			}, now)
+		}
+	}
+
+	if reflect.DeepEqual(status, d.Status) {
+		return
+	}
+	d.Status = status
+	d.ResourceVersion = ""
+	if err := c.client.UpdateDeploymentStatus(ctx, d); err != nil {
+		slog.Error("failed to update deployment status", "deployment", d.Name, "error", err)
+	}
+}
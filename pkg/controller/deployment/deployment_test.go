@@ -0,0 +1,242 @@
+package deployment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/client"
+)
+
+func newDeployment(name string, replicas int32, strategy api.DeploymentStrategy) api.Deployment {
+	d := apitest.NewDeployment(name, replicas)
+	d.Spec.Strategy = strategy
+	d.Spec.RevisionHistoryLimit = apitest.Ptr(int32(10))
+	d.Spec.ProgressDeadlineSeconds = apitest.Ptr(int32(60))
+	return d
+}
+
+func rollingUpdate(surge, unavailable int32) api.DeploymentStrategy {
+	s, u := api.FromInt(surge), api.FromInt(unavailable)
+	return api.DeploymentStrategy{
+		Type:          api.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &api.RollingUpdateDeployment{MaxSurge: &s, MaxUnavailable: &u},
+	}
+}
+
+// settle does what the replica set controller would, with every pod starting and becoming ready straight away
+func settle(c *client.FakeClient) {
+	for i, rs := range c.ReplicaSets {
+		c.ReplicaSets[i].Status = api.ReplicaSetStatus{Replicas: *rs.Spec.Replicas, ReadyReplicas: *rs.Spec.Replicas}
+	}
+}
+
+func replicaSetFor(c *client.FakeClient, d api.Deployment) *api.ReplicaSet {
+	hash := templateHash(d.Spec.Template)
+	for i := range c.ReplicaSets {
+		if c.ReplicaSets[i].Labels[api.PodTemplateHashLabel] == hash {
+			return &c.ReplicaSets[i]
+		}
+	}
+	return nil
+}
+
+func TestRollingUpdate(t *testing.T) {
+	d := newDeployment("web", 4, rollingUpdate(1, 1))
+	c := &client.FakeClient{Deployments: []api.Deployment{d}}
+	controller := NewController(c, Opts{})
+
+	controller.sync(t.Context(), "", "")
+	first := replicaSetFor(c, d)
+	if first == nil || *first.Spec.Replicas != 4 || revision(*first) != 1 {
+		t.Fatalf("expected a first replica set with 4 replicas at revision 1, got %+v", first)
+	}
+	if !first.IsControlledBy(d.Uid) || first.Spec.Template.Labels[api.PodTemplateHashLabel] == "" {
+		t.Errorf("replica set isn't tied to the deployment: %+v", first.ObjectMeta)
+	}
+	settle(c)
+	controller.sync(t.Context(), "", "")
+	if cond := c.Deployments[0].Status.GetCondition(api.DeploymentProgressing); cond == nil || cond.Reason != "NewReplicaSetAvailable" {
+		t.Fatalf("expected rollout to be complete, got %+v", cond)
+	}
+
+	d.Spec.Template.Spec.Containers = []api.Container{{Name: "app", Image: "nginx:2.0"}}
+	d.Generation = 2
+	c.Deployments[0] = d
+	for step := 0; ; step++ {
+		if step == 20 {
+			t.Fatalf("rollout didn't finish in %d steps", step)
+		}
+		controller.sync(t.Context(), "", "")
+		total, ready := int32(0), int32(0)
+		for _, rs := range c.ReplicaSets {
+			total += *rs.Spec.Replicas
+			ready += min(*rs.Spec.Replicas, rs.Status.ReadyReplicas)
+		}
+		if total > 5 {
+			t.Fatalf("step %d: %d pods wanted, more than maxSurge allows", step, total)
+		}
+		if ready < 3 {
+			t.Fatalf("step %d: only %d pods ready, more unavailable than maxUnavailable allows", step, ready)
+		}
+		settle(c)
+		if rs := replicaSetFor(c, d); rs != nil && *rs.Spec.Replicas == 4 && *c.ReplicaSets[0].Spec.Replicas == 0 {
+			break
+		}
+	}
+	if rs := replicaSetFor(c, d); revision(*rs) != 2 {
+		t.Errorf("new replica set at revision %d, expected 2", revision(*rs))
+	}
+	controller.sync(t.Context(), "", "")
+	status := c.Deployments[0].Status
+	if status.UpdatedReplicas != 4 || status.ReadyReplicas != 4 || status.ObservedGeneration != 2 {
+		t.Errorf("unexpected status after rollout: %+v", status)
+	}
+	if cond := status.GetCondition(api.DeploymentProgressing); cond.Reason != "NewReplicaSetAvailable" {
+		t.Errorf("expected rollout to be complete, got %+v", cond)
+	}
+	if cond := status.GetCondition(api.DeploymentAvailable); cond.Status != api.ConditionTrue {
+		t.Errorf("expected deployment to be available, got %+v", cond)
+	}
+}
+
+func TestRecreate(t *testing.T) {
+	d := newDeployment("web", 2, api.DeploymentStrategy{Type: api.RecreateDeploymentStrategyType})
+	c := &client.FakeClient{Deployments: []api.Deployment{d}}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+	settle(c)
+	old := c.ReplicaSets[0]
+	pod := api.Pod{ObjectMeta: api.ObjectMeta{
+		Name: "web-old", Namespace: "default", Uid: uuid.New(),
+		OwnerReferences: []api.OwnerReference{{Kind: "ReplicaSet", Name: old.Name, Uid: old.Uid, Controller: true}},
+	}}
+	c.Pods = []api.Pod{pod}
+
+	d.Spec.Template.Spec.Containers = []api.Container{{Name: "app", Image: "nginx:2.0"}}
+	c.Deployments[0] = d
+	controller.sync(t.Context(), "", "")
+	if *c.ReplicaSets[0].Spec.Replicas != 0 || len(c.ReplicaSets) != 1 {
+		t.Fatalf("expected the old replica set scaled to 0 and nothing new, got %+v", c.ReplicaSets)
+	}
+	settle(c)
+	// old pods are still shutting down
+	controller.sync(t.Context(), "", "")
+	if len(c.ReplicaSets) != 1 {
+		t.Fatalf("new replica set created before old pods were gone")
+	}
+	c.Pods = nil
+	controller.sync(t.Context(), "", "")
+	if rs := replicaSetFor(c, d); rs == nil || *rs.Spec.Replicas != 2 {
+		t.Fatalf("expected new replica set with 2 replicas, got %+v", rs)
+	}
+}
+
+func TestRollbackBumpsRevision(t *testing.T) {
+	d := newDeployment("web", 1, rollingUpdate(1, 0))
+	c := &client.FakeClient{Deployments: []api.Deployment{d}}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+	settle(c)
+	original := d.Spec.Template
+
+	d.Spec.Template.Spec.Containers = []api.Container{{Name: "app", Image: "nginx:2.0"}}
+	c.Deployments[0] = d
+	for range 5 {
+		controller.sync(t.Context(), "", "")
+		settle(c)
+	}
+	// what the apiserver's rollback does, the old template goes back into the spec
+	d.Spec.Template = original
+	c.Deployments[0] = d
+	controller.sync(t.Context(), "", "")
+	if len(c.ReplicaSets) != 2 {
+		t.Fatalf("expected the old replica set to be reused, got %d replica sets", len(c.ReplicaSets))
+	}
+	if rs := replicaSetFor(c, d); revision(*rs) != 3 {
+		t.Errorf("rolled back replica set at revision %d, expected 3", revision(*rs))
+	}
+}
+
+func TestPausedAndProgressDeadline(t *testing.T) {
+	d := newDeployment("web", 1, rollingUpdate(1, 0))
+	d.Spec.Paused = true
+	c := &client.FakeClient{Deployments: []api.Deployment{d}}
+	controller := NewController(c, Opts{})
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	controller.now = func() time.Time { return now }
+
+	controller.sync(t.Context(), "", "")
+	if len(c.ReplicaSets) != 0 {
+		t.Fatalf("paused deployment rolled out")
+	}
+	if cond := c.Deployments[0].Status.GetCondition(api.DeploymentProgressing); cond.Status != api.ConditionUnknown || cond.Reason != "DeploymentPaused" {
+		t.Errorf("expected paused condition, got %+v", cond)
+	}
+
+	d.Spec.Paused = false
+	d.Status = c.Deployments[0].Status
+	c.Deployments[0] = d
+	controller.sync(t.Context(), "", "")
+	if len(c.ReplicaSets) != 1 {
+		t.Fatalf("resumed deployment didn't roll out")
+	}
+	if cond := c.Deployments[0].Status.GetCondition(api.DeploymentProgressing); cond.Status != api.ConditionTrue || cond.Reason != "DeploymentResumed" {
+		t.Errorf("expected resumed condition, got %+v", cond)
+	}
+
+	// the pod never becomes ready, nothing moves
+	c.ReplicaSets[0].Status = api.ReplicaSetStatus{Replicas: 1}
+	now = start.Add(30 * time.Second)
+	controller.sync(t.Context(), "", "")
+	controller.sync(t.Context(), "", "")
+	if cond := c.Deployments[0].Status.GetCondition(api.DeploymentProgressing); cond.Status != api.ConditionTrue {
+		t.Errorf("deadline exceeded early: %+v", cond)
+	}
+	now = start.Add(2 * time.Minute)
+	controller.sync(t.Context(), "", "")
+	cond := c.Deployments[0].Status.GetCondition(api.DeploymentProgressing)
+	if cond.Status != api.ConditionFalse || cond.Reason != "ProgressDeadlineExceeded" {
+		t.Errorf("expected progress deadline to be exceeded, got %+v", cond)
+	}
+	if cond := c.Deployments[0].Status.GetCondition(api.DeploymentAvailable); cond.Status != api.ConditionFalse {
+		t.Errorf("expected deployment to be unavailable, got %+v", cond)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	d := newDeployment("web", 1, rollingUpdate(1, 0))
+	limit := int32(1)
+	d.Spec.RevisionHistoryLimit = &limit
+	zero := int32(0)
+	old := func(name, rev string) api.ReplicaSet {
+		return api.ReplicaSet{
+			ObjectMeta: api.ObjectMeta{
+				Name: name, Namespace: "default", Uid: uuid.New(),
+				Labels:          map[string]string{api.PodTemplateHashLabel: name},
+				Annotations:     map[string]string{api.DeploymentRevisionAnnotation: rev},
+				OwnerReferences: []api.OwnerReference{{Kind: Kind, Name: d.Name, Uid: d.Uid, Controller: true}},
+			},
+			Spec: api.ReplicaSetSpec{Replicas: &zero},
+		}
+	}
+	orphan := old("orphan", "1")
+	orphan.OwnerReferences[0].Uid = uuid.New()
+	c := &client.FakeClient{
+		Deployments: []api.Deployment{d},
+		ReplicaSets: []api.ReplicaSet{old("rev-2", "2"), old("rev-1", "1"), old("rev-3", "3"), orphan},
+	}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+	names := map[string]bool{}
+	for _, rs := range c.ReplicaSets {
+		names[rs.Name] = true
+	}
+	if len(names) != 2 || !names["rev-3"] || replicaSetFor(c, d) == nil {
+		t.Errorf("expected only rev-3 and the new replica set to be left, got %v", names)
+	}
+}
@@ -0,0 +1,267 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"maps"
+	"strconv"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+)
+
+// rollingUpdate scales the new replica set up as far as maxSurge allows, or if it can't,
+// scales old replica sets down as far as maxUnavailable allows.
+// Scaling down waits until every replica set has reported on its latest spec, otherwise ready counts could be stale.
+// It returns the reason to report on the Progressing condition if it changed anything.
+func (c *Controller) rollingUpdate(ctx context.Context, d api.Deployment, newRS *api.ReplicaSet, old []api.ReplicaSet, hash string) (string, error) {
+	desired := int(*d.Spec.Replicas)
+	maxSurge, maxUnavailable := fenceposts(d)
+	total := 0
+	for _, rs := range old {
+		total += int(*rs.Spec.Replicas)
+	}
+	if newRS == nil {
+		replicas := min(desired, max(0, desired+int(maxSurge)-total))
+		return "NewReplicaSetCreated", c.createReplicaSet(ctx, d, hash, int32(replicas), old)
+	}
+	if bumped, err := c.bumpRevision(ctx, *newRS, old); bumped || err != nil {
+		return "ReplicaSetUpdated", err
+	}
+	current := int(*newRS.Spec.Replicas)
+	total += current
+	switch {
+	case current > desired:
+		return "ReplicaSetUpdated", c.scale(ctx, *newRS, int32(desired))
+	case current < desired && total < desired+int(maxSurge):
+		return "ReplicaSetUpdated", c.scale(ctx, *newRS, int32(min(desired, current+desired+int(maxSurge)-total)))
+	}
+
+	if !observed(*newRS) || !allObserved(old) {
+		return "", nil
+	}
+	minAvailable := desired - int(maxUnavailable)
+	newUnavailable := current - int(newRS.Status.ReadyReplicas)
+	// scaling down further would leave fewer than minAvailable pods once the new ones that aren't ready yet are counted
+	budget := total - minAvailable - newUnavailable
+	if budget <= 0 {
+		return "", nil
+	}
+	// old pods that aren't ready don't count towards availability, they go first
+	scaled := map[string]bool{}
+	for _, rs := range old {
+		unhealthy := min(int(*rs.Spec.Replicas-rs.Status.ReadyReplicas), budget)
+		if unhealthy <= 0 {
+			continue
+		}
+		if err := c.scale(ctx, rs, *rs.Spec.Replicas-int32(unhealthy)); err != nil {
+			return "", err
+		}
+		budget -= unhealthy
+		scaled[rs.Name] = true
+	}
+	// then ready ones, as long as enough stay ready. A replica set already scaled this sync waits for the next,
+	// its resourceVersion has moved on
+	ready := int(newRS.Status.ReadyReplicas)
+	for _, rs := range old {
+		ready += int(rs.Status.ReadyReplicas)
+	}
+	budget = min(budget, ready-minAvailable)
+	for _, rs := range old {
+		if budget <= 0 {
+			break
+		}
+		n := min(int(*rs.Spec.Replicas), budget)
+		if n == 0 || scaled[rs.Name] {
+			continue
+		}
+		if err := c.scale(ctx, rs, *rs.Spec.Replicas-int32(n)); err != nil {
+			return "", err
+		}
+		budget -= n
+		scaled[rs.Name] = true
+	}
+	if len(scaled) > 0 {
+		return "ReplicaSetUpdated", nil
+	}
+	return "", nil
+}
+
+// recreate scales every old replica set to 0 and waits for their pods to be gone before bringing up the new one
+func (c *Controller) recreate(ctx context.Context, d api.Deployment, newRS *api.ReplicaSet, old []api.ReplicaSet, pods []api.Pod, hash string) (string, error) {
+	scaled := false
+	for _, rs := range old {
+		if *rs.Spec.Replicas == 0 {
+			continue
+		}
+		if err := c.scale(ctx, rs, 0); err != nil {
+			return "", err
+		}
+		scaled = true
+	}
+	if scaled {
+		return "ReplicaSetUpdated", nil
+	}
+	for _, p := range pods {
+		for _, rs := range old {
+			if p.Namespace == rs.Namespace && p.IsControlledBy(rs.Uid) {
+				// terminating pods count too, they could still be holding on to ports or volumes
+				return "", nil
+			}
+		}
+	}
+	if newRS == nil {
+		return "NewReplicaSetCreated", c.createReplicaSet(ctx, d, hash, *d.Spec.Replicas, old)
+	}
+	if bumped, err := c.bumpRevision(ctx, *newRS, old); bumped || err != nil {
+		return "ReplicaSetUpdated", err
+	}
+	if *newRS.Spec.Replicas != *d.Spec.Replicas {
+		return "ReplicaSetUpdated", c.scale(ctx, *newRS, *d.Spec.Replicas)
+	}
+	return "", nil
+}
+
+// cleanupHistory deletes the oldest replica sets that have been scaled all the way down,
+// leaving revisionHistoryLimit of them to roll back to
+func (c *Controller) cleanupHistory(ctx context.Context, d api.Deployment, old []api.ReplicaSet) {
+	var idle []api.ReplicaSet
+	for _, rs := range old {
+		if *rs.Spec.Replicas == 0 && rs.Status.Replicas == 0 && observed(rs) {
+			idle = append(idle, rs)
+		}
+	}
+	// old is sorted by revision, the oldest come first
+	for i := 0; i < len(idle)-int(*d.Spec.RevisionHistoryLimit); i++ {
+		slog.Info("deleting old replica set", "namespace", d.Namespace, "deployment", d.Name, "replicaset", idle[i].Name)
+		c.deleteReplicaSet(ctx, idle[i])
+	}
+}
+
+// createReplicaSet creates the replica set for d's current template, one revision past the newest of old
+func (c *Controller) createReplicaSet(ctx context.Context, d api.Deployment, hash string, replicas int32, old []api.ReplicaSet) error {
+	labels := maps.Clone(d.Spec.Template.Labels)
+	labels[api.PodTemplateHashLabel] = hash
+	selector := maps.Clone(d.Spec.Selector.MatchLabels)
+	selector[api.PodTemplateHashLabel] = hash
+	rs := api.ReplicaSet{
+		ObjectMeta: api.ObjectMeta{
+			Name:        d.Name + "-" + hash,
+			Namespace:   d.Namespace,
+			Labels:      labels,
+			Annotations: map[string]string{api.DeploymentRevisionAnnotation: strconv.FormatInt(maxRevision(old)+1, 10)},
+			OwnerReferences: []api.OwnerReference{{
+				Kind:       Kind,
+				Name:       d.Name,
+				Uid:        d.Uid,
+				Controller: true,
+			}},
+		},
+		Spec: api.ReplicaSetSpec{
+			Replicas: &replicas,
+			Selector: api.LabelSelector{MatchLabels: selector},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: labels, Annotations: maps.Clone(d.Spec.Template.Annotations)},
+				Spec:       d.Spec.Template.Spec,
+			},
+		},
+	}
+	slog.Info("creating replica set", "namespace", d.Namespace, "deployment", d.Name, "replicaset", rs.Name, "replicas", replicas)
+	if err := c.client.CreateReplicaSet(ctx, rs); err != nil {
+		return fmt.Errorf("failed to create replica set %s: %w", rs.Name, err)
+	}
+	return nil
+}
+
+// bumpRevision makes a replica set that's current again, after a rollback, the newest revision
+func (c *Controller) bumpRevision(ctx context.Context, rs api.ReplicaSet, old []api.ReplicaSet) (bool, error) {
+	latest := maxRevision(old)
+	if revision(rs) > latest {
+		return false, nil
+	}
+	rs.Annotations = maps.Clone(rs.Annotations)
+	if rs.Annotations == nil {
+		rs.Annotations = map[string]string{}
+	}
+	rs.Annotations[api.DeploymentRevisionAnnotation] = strconv.FormatInt(latest+1, 10)
+	if err := c.client.UpdateReplicaSet(ctx, rs); err != nil {
+		return true, fmt.Errorf("failed to update revision of replica set %s: %w", rs.Name, err)
+	}
+	return true, nil
+}
+
+// scale writes against the replica set's resourceVersion, losing a race just means the next sync tries again
+func (c *Controller) scale(ctx context.Context, rs api.ReplicaSet, replicas int32) error {
+	slog.Info("scaling replica set", "namespace", rs.Namespace, "replicaset", rs.Name, "from", *rs.Spec.Replicas, "to", replicas)
+	rs.Spec.Replicas = &replicas
+	if err := c.client.UpdateReplicaSet(ctx, rs); err != nil {
+		return fmt.Errorf("failed to scale replica set %s: %w", rs.Name, err)
+	}
+	return nil
+}
+
+func (c *Controller) deleteReplicaSet(ctx context.Context, rs api.ReplicaSet) {
+	err := c.client.DeleteReplicaSet(ctx, rs, api.DeleteOptions{ResourceVersion: rs.ResourceVersion})
+	if err != nil && !errors.Is(err, client.ErrNotFound) && !errors.Is(err, client.ErrConflict) {
+		slog.Error("failed to delete replica set", "namespace", rs.Namespace, "replicaset", rs.Name, "error", err)
+	}
+}
+
+// fenceposts resolves maxSurge and maxUnavailable against the replica count.
+// Recreate has no surge and no pod can be missing for it to be available.
+// If both round down to 0 one pod is allowed to be unavailable so the rollout can move at all.
+func fenceposts(d api.Deployment) (surge, unavailable int32) {
+	ru := d.Spec.Strategy.RollingUpdate
+	if d.Spec.Strategy.Type != api.RollingUpdateDeploymentStrategyType || ru == nil {
+		return 0, 0
+	}
+	desired := int(*d.Spec.Replicas)
+	// validated by the apiserver
+	s, _ := ru.MaxSurge.ScaledValue(desired, true)
+	u, _ := ru.MaxUnavailable.ScaledValue(desired, false)
+	u = min(u, desired)
+	if s == 0 && u == 0 {
+		u = 1
+	}
+	return int32(s), int32(u)
+}
+
+// templateHash names the replica set a template is rolled out through.
+// The template is encoded as json, which sorts map keys, so equal templates always hash the same.
+func templateHash(template api.PodTemplateSpec) string {
+	b, _ := json.Marshal(template)
+	h := fnv.New32a()
+	h.Write(b)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+func revision(rs api.ReplicaSet) int64 {
+	rev, _ := strconv.ParseInt(rs.Annotations[api.DeploymentRevisionAnnotation], 10, 64)
+	return rev
+}
+
+func maxRevision(sets []api.ReplicaSet) int64 {
+	var latest int64
+	for _, rs := range sets {
+		latest = max(latest, revision(rs))
+	}
+	return latest
+}
+
+// observed reports whether the replica set's status is for its latest spec
+func observed(rs api.ReplicaSet) bool {
+	return rs.Status.ObservedGeneration >= rs.Generation
+}
+
+func allObserved(sets []api.ReplicaSet) bool {
+	for _, rs := range sets {
+		if !observed(rs) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,310 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+)
+
+const (
+	DefaultResyncPeriod = 30 * time.Second
+	// Kind set on the ownerReferences of pods a job creates
+	Kind = "Job"
+	// a failed pod is replaced after this long, doubling with every failure up to maxBackoff
+	backoffBase = 10 * time.Second
+	maxBackoff  = 6 * time.Minute
+)
+
+type Opts struct {
+	// how often every job is synced regardless of events, it's also how often deadlines, TTLs and backoffs are checked
+	ResyncPeriod time.Duration
+}
+
+// Controller runs the pods of every job until enough of them succeed, or too many fail.
+// Finished pods are what a job's counts are made of, so they're left alone until the job itself is deleted.
+type Controller struct {
+	client client.Client
+	opts   Opts
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewController(c client.Client, opts Opts) *Controller {
+	if opts.ResyncPeriod == 0 {
+		opts.ResyncPeriod = DefaultResyncPeriod
+	}
+	return &Controller{
+		client: c,
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// Start syncs jobs as they or their pods change, and all of them every ResyncPeriod, until ctx is done
+func (c *Controller) Start(ctx context.Context) error {
+	if err := c.client.Ping(ctx); err != nil {
+		return fmt.Errorf("job controller failed to start: %v", err)
+	}
+	jobEvents, err := c.client.WatchResource(ctx, "jobs", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch jobs: %v", err)
+	}
+	podEvents, err := c.client.WatchResource(ctx, "pods", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch pods: %v", err)
+	}
+	c.sync(ctx, "", "")
+	ticker := time.NewTicker(c.opts.ResyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("job controller stopped due to context cancellation")
+			return nil
+		case ev, ok := <-jobEvents:
+			if !ok {
+				return errors.New("job watch channel closed")
+			}
+			var j api.Job
+			if err := ev.DecodeObject(&j); err != nil {
+				slog.Error("failed to decode job event", "error", err)
+				continue
+			}
+			c.sync(ctx, j.Namespace, j.Name)
+		case ev, ok := <-podEvents:
+			if !ok {
+				return errors.New("pod watch channel closed")
+			}
+			if ref := ev.Pod.ControllerRef(); ref != nil && ref.Kind == Kind {
+				c.sync(ctx, ev.Pod.Namespace, ref.Name)
+			}
+		case <-ticker.C:
+			c.sync(ctx, "", "")
+		}
+	}
+}
+
+// sync syncs the job namespace/name, or every job if name is empty.
+// Pods left behind by a deleted job are deleted here too, finished or not.
+func (c *Controller) sync(ctx context.Context, namespace, name string) {
+	jobs, err := c.client.ListJobs(ctx)
+	if err != nil {
+		slog.Error("failed to list jobs", "error", err)
+		return
+	}
+	pods, err := c.client.ListPods(ctx)
+	if err != nil {
+		slog.Error("failed to list pods", "error", err)
+		return
+	}
+	selected := func(ns, n string) bool {
+		return name == "" || (ns == namespace && n == name)
+	}
+	for _, j := range jobs.Items {
+		if !selected(j.Namespace, j.Name) {
+			continue
+		}
+		var owned []api.Pod
+		for _, p := range pods.Items {
+			if p.Namespace == j.Namespace && p.IsControlledBy(j.Uid) {
+				owned = append(owned, p)
+			}
+		}
+		c.syncJob(ctx, j, owned)
+	}
+	for _, p := range pods.Items {
+		ref := p.ControllerRef()
+		if ref == nil || ref.Kind != Kind || !selected(p.Namespace, ref.Name) || p.DeletionTimestamp != nil {
+			continue
+		}
+		owned := slices.ContainsFunc(jobs.Items, func(j api.Job) bool {
+			return j.Namespace == p.Namespace && j.Uid == ref.Uid
+		})
+		if !owned {
+			slog.Info("deleting pod of a deleted job", "namespace", p.Namespace, "pod", p.Name, "job", ref.Name)
+			c.deletePod(ctx, p)
+		}
+	}
+}
+
+// syncJob moves the job along by one step: it's failed, completed, or gets pods created or deleted to match its parallelism.
+// A finished job only has its leftover pods stopped and waits out its TTL.
+func (c *Controller) syncJob(ctx context.Context, j api.Job, pods []api.Pod) {
+	now := c.now().UTC()
+	var active, succeeded, failed []api.Pod
+	for _, p := range pods {
+		switch {
+		case p.Status.Phase == api.PodSucceeded:
+			succeeded = append(succeeded, p)
+		case p.Status.Phase == api.PodFailed:
+			failed = append(failed, p)
+		case p.DeletionTimestamp == nil:
+			active = append(active, p)
+		}
+	}
+
+	if finished := j.Status.Finished(); finished != nil {
+		for _, p := range active {
+			c.deletePod(ctx, p)
+		}
+		ttl := j.Spec.TTLSecondsAfterFinished
+		if ttl != nil && !now.Before(finished.LastTransitionTime.Add(time.Duration(*ttl)*time.Second)) {
+			slog.Info("deleting finished job past its ttl", "namespace", j.Namespace, "job", j.Name)
+			err := c.client.DeleteJob(ctx, j, api.DeleteOptions{ResourceVersion: j.ResourceVersion})
+			if err != nil && !errors.Is(err, client.ErrNotFound) && !errors.Is(err, client.ErrConflict) {
+				slog.Error("failed to delete job", "namespace", j.Namespace, "job", j.Name, "error", err)
+			}
+		}
+		return
+	}
+
+	status := j.Status
+	status.Conditions = slices.Clone(j.Status.Conditions)
+	if status.StartTime == nil {
+		status.StartTime = &now
+	}
+	status.Succeeded = int32(len(succeeded))
+	status.Failed = int32(len(failed))
+	completions := j.Spec.Completions
+	deadline := j.Spec.ActiveDeadlineSeconds
+	// with OnFailure the kubelet restarts failed containers in place, every restart counts as a failure
+	var restarts int32
+	for _, p := range active {
+		for _, cs := range p.Status.ContainerStatuses {
+			restarts += cs.RestartCount
+		}
+	}
+
+	switch {
+	case status.Failed+restarts > *j.Spec.BackoffLimit:
+		c.finish(ctx, &status, active, api.JobFailed, "BackoffLimitExceeded", "Job has reached the specified backoff limit", now)
+	case deadline != nil && !now.Before(status.StartTime.Add(time.Duration(*deadline)*time.Second)):
+		c.finish(ctx, &status, active, api.JobFailed, "DeadlineExceeded", "Job was active longer than specified deadline", now)
+	case completions != nil && status.Succeeded >= *completions,
+		completions == nil && status.Succeeded > 0 && len(active) == 0:
+		c.finish(ctx, &status, active, api.JobComplete, "Completed", "", now)
+		status.CompletionTime = &now
+	default:
+		want := int(*j.Spec.Parallelism)
+		if completions != nil {
+			want = min(want, int(*completions-status.Succeeded))
+		} else if status.Succeeded > 0 {
+			// one pod succeeding means the work is done, the rest are left to finish up
+			want = len(active)
+		}
+		switch diff := want - len(active); {
+		case diff > 0:
+			if wait := backoffRemaining(failed, now); wait > 0 {
+				slog.Debug("job backing off before replacing failed pods", "namespace", j.Namespace, "job", j.Name, "wait", wait)
+				break
+			}
+			slog.Info("creating pods for job", "namespace", j.Namespace, "job", j.Name, "count", diff)
+			for range diff {
+				if err := c.client.CreatePod(ctx, newPod(j)); err != nil {
+					slog.Error("failed to create pod", "job", j.Name, "error", err)
+				}
+			}
+		case diff < 0:
+			slog.Info("deleting pods of job", "namespace", j.Namespace, "job", j.Name, "count", -diff)
+			// the newest have done the least work
+			slices.SortFunc(active, func(a, b api.Pod) int { return b.CreationTimestamp.Compare(a.CreationTimestamp) })
+			for _, p := range active[:-diff] {
+				c.deletePod(ctx, p)
+			}
+			active = active[-diff:]
+		}
+		status.Active = int32(len(active))
+	}
+
+	if reflect.DeepEqual(status, j.Status) {
+		return
+	}
+	j.Status = status
+	j.ResourceVersion = ""
+	if err := c.client.UpdateJobStatus(ctx, j); err != nil {
+		slog.Error("failed to update job status", "job", j.Name, "error", err)
+	}
+}
+
+// finish marks the job with a Complete or Failed condition and stops the pods it still has running
+func (c *Controller) finish(ctx context.Context, status *api.JobStatus, active []api.Pod, t api.JobConditionType, reason, message string, now time.Time) {
+	for _, p := range active {
+		c.deletePod(ctx, p)
+	}
+	status.Active = 0
+	status.Conditions = append(status.Conditions, api.JobCondition{
+		Type:               t,
+		Status:             api.ConditionTrue,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func (c *Controller) deletePod(ctx context.Context, p api.Pod) {
+	if err := c.client.DeletePod(ctx, p, api.DeleteOptions{Uid: p.Uid}); err != nil && !errors.Is(err, client.ErrConflict) {
+		slog.Error("failed to delete pod", "namespace", p.Namespace, "pod", p.Name, "error", err)
+	}
+}
+
+// newPod stamps out a pod from the job's template, labelled with the job's name
+func newPod(j api.Job) api.Pod {
+	labels := maps.Clone(j.Spec.Template.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[api.JobNameLabel] = j.Name
+	return api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			GenerateName: j.Name + "-",
+			Namespace:    j.Namespace,
+			Labels:       labels,
+			Annotations:  maps.Clone(j.Spec.Template.Annotations),
+			OwnerReferences: []api.OwnerReference{{
+				Kind:       Kind,
+				Name:       j.Name,
+				Uid:        j.Uid,
+				Controller: true,
+			}},
+		},
+		Spec: j.Spec.Template.Spec,
+	}
+}
+
+// backoffRemaining is how much longer to wait before replacing failed pods,
+// counted from the most recent failure and doubling with each one
+func backoffRemaining(failed []api.Pod, now time.Time) time.Duration {
+	if len(failed) == 0 {
+		return 0
+	}
+	var last time.Time
+	for _, p := range failed {
+		if t := finishedAt(p); t.After(last) {
+			last = t
+		}
+	}
+	backoff := maxBackoff
+	if shift := len(failed) - 1; shift < 16 {
+		backoff = min(backoffBase<<shift, maxBackoff)
+	}
+	return last.Add(backoff).Sub(now)
+}
+
+// finishedAt is when the pod's last container exited, or its creation if no container got as far as running
+func finishedAt(p api.Pod) time.Time {
+	t := p.CreationTimestamp
+	for _, cs := range append(slices.Clone(p.Status.InitContainerStatuses), p.Status.ContainerStatuses...) {
+		if term := cs.State.Terminated; term != nil && term.FinishedAt.After(t) {
+			t = term.FinishedAt
+		}
+	}
+	return t
+}
@@ -0,0 +1,222 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/client"
+)
+
+func newJob(name string, completions *int32, parallelism int32) api.Job {
+	j := apitest.NewJob(name)
+	j.Spec.Completions = completions
+	j.Spec.Parallelism = &parallelism
+	j.Spec.BackoffLimit = apitest.Ptr(int32(6))
+	return j
+}
+
+// finish moves n of the pods that are still pending to phase, as if they ran and exited
+func finish(c *client.FakeClient, phase api.PodPhase, n int) {
+	for i := range c.Pods {
+		if n == 0 {
+			return
+		}
+		if c.Pods[i].Status.Phase == api.PodPending {
+			c.Pods[i].Status.Phase = phase
+			n--
+		}
+	}
+}
+
+func newController(c *client.FakeClient, now *time.Time) *Controller {
+	controller := NewController(c, Opts{})
+	controller.now = func() time.Time { return *now }
+	return controller
+}
+
+func TestJobRunsToCompletion(t *testing.T) {
+	c := &client.FakeClient{Jobs: []api.Job{newJob("batch", apitest.Ptr(int32(3)), 2)}}
+	now := time.Now()
+	controller := newController(c, &now)
+
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 2 {
+		t.Fatalf("expected parallelism many pods, got %d", len(c.Pods))
+	}
+	p := c.Pods[0]
+	if p.Labels[api.JobNameLabel] != "batch" || p.Labels["app"] != "batch" {
+		t.Errorf("pod has labels %v", p.Labels)
+	}
+	if ref := p.ControllerRef(); ref == nil || ref.Kind != Kind || ref.Uid != c.Jobs[0].Uid {
+		t.Errorf("pod has controller ref %+v", ref)
+	}
+	if c.Jobs[0].Status.StartTime == nil {
+		t.Error("start time wasn't set")
+	}
+
+	// only one more is needed to reach completions
+	finish(c, api.PodSucceeded, 2)
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 3 {
+		t.Fatalf("expected 3 pods, got %d", len(c.Pods))
+	}
+	if got := c.Jobs[0].Status; got.Succeeded != 2 || got.Active != 0 {
+		t.Errorf("unexpected status %+v", got)
+	}
+
+	finish(c, api.PodSucceeded, 1)
+	controller.sync(t.Context(), "", "")
+	got := c.Jobs[0].Status
+	if got.Succeeded != 3 || got.CompletionTime == nil {
+		t.Errorf("unexpected status %+v", got)
+	}
+	if f := got.Finished(); f == nil || f.Type != api.JobComplete {
+		t.Errorf("job isn't complete: %+v", got.Conditions)
+	}
+	// finished pods are kept for their logs
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 3 || len(c.DeletedPods) != 0 {
+		t.Errorf("expected the finished pods to be kept, have %d and deleted %d", len(c.Pods), len(c.DeletedPods))
+	}
+}
+
+func TestJobWithoutCompletions(t *testing.T) {
+	c := &client.FakeClient{Jobs: []api.Job{newJob("queue", nil, 2)}}
+	now := time.Now()
+	controller := newController(c, &now)
+
+	controller.sync(t.Context(), "", "")
+	finish(c, api.PodSucceeded, 1)
+	// the work is done once any pod succeeds, the other is left to wrap up rather than replaced or deleted
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 2 || len(c.DeletedPods) != 0 {
+		t.Fatalf("expected the 2 pods to be left alone, have %d and deleted %d", len(c.Pods), len(c.DeletedPods))
+	}
+	if f := c.Jobs[0].Status.Finished(); f != nil {
+		t.Fatalf("job finished with a pod still running: %+v", f)
+	}
+
+	finish(c, api.PodSucceeded, 1)
+	controller.sync(t.Context(), "", "")
+	if f := c.Jobs[0].Status.Finished(); f == nil || f.Type != api.JobComplete {
+		t.Errorf("job isn't complete: %+v", c.Jobs[0].Status)
+	}
+}
+
+func TestJobBackoffLimit(t *testing.T) {
+	j := newJob("flaky", apitest.Ptr(int32(1)), 1)
+	j.Spec.BackoffLimit = apitest.Ptr(int32(1))
+	c := &client.FakeClient{Jobs: []api.Job{j}}
+	now := time.Now()
+	controller := newController(c, &now)
+
+	controller.sync(t.Context(), "", "")
+	finish(c, api.PodFailed, 1)
+	// a failed pod isn't replaced straight away
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 1 {
+		t.Fatalf("expected the failed pod to be replaced after a backoff, got %d pods", len(c.Pods))
+	}
+	if got := c.Jobs[0].Status; got.Failed != 1 || got.Active != 0 {
+		t.Errorf("unexpected status %+v", got)
+	}
+
+	now = now.Add(backoffBase + time.Second)
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 2 {
+		t.Fatalf("expected the failed pod to be replaced, got %d pods", len(c.Pods))
+	}
+
+	finish(c, api.PodFailed, 1)
+	controller.sync(t.Context(), "", "")
+	f := c.Jobs[0].Status.Finished()
+	if f == nil || f.Type != api.JobFailed || f.Reason != "BackoffLimitExceeded" {
+		t.Errorf("expected job to fail on its backoff limit, got %+v", c.Jobs[0].Status)
+	}
+	if c.Jobs[0].Status.CompletionTime != nil {
+		t.Error("failed job got a completion time")
+	}
+}
+
+func TestJobBackoffLimitCountsRestarts(t *testing.T) {
+	j := newJob("flaky", apitest.Ptr(int32(1)), 1)
+	j.Spec.BackoffLimit = apitest.Ptr(int32(2))
+	j.Spec.Template.Spec.RestartPolicy = api.RestartPolicyOnFailure
+	c := &client.FakeClient{Jobs: []api.Job{j}}
+	now := time.Now()
+	controller := newController(c, &now)
+
+	controller.sync(t.Context(), "", "")
+	c.Pods[0].Status.Phase = api.PodRunning
+	c.Pods[0].Status.ContainerStatuses = []api.ContainerStatus{{Name: "task", RestartCount: 2}}
+	controller.sync(t.Context(), "", "")
+	if c.Jobs[0].Status.Finished() != nil {
+		t.Fatalf("job finished before running out of restarts: %+v", c.Jobs[0].Status)
+	}
+
+	c.Pods[0].Status.ContainerStatuses[0].RestartCount = 3
+	controller.sync(t.Context(), "", "")
+	f := c.Jobs[0].Status.Finished()
+	if f == nil || f.Type != api.JobFailed || f.Reason != "BackoffLimitExceeded" {
+		t.Errorf("expected job to fail on its backoff limit, got %+v", c.Jobs[0].Status)
+	}
+	if len(c.Pods) != 0 {
+		t.Errorf("expected the restarting pod to be stopped, got %d pods", len(c.Pods))
+	}
+}
+
+func TestJobActiveDeadline(t *testing.T) {
+	j := newJob("slow", apitest.Ptr(int32(1)), 1)
+	deadline := int64(60)
+	j.Spec.ActiveDeadlineSeconds = &deadline
+	c := &client.FakeClient{Jobs: []api.Job{j}}
+	now := time.Now()
+	controller := newController(c, &now)
+
+	controller.sync(t.Context(), "", "")
+	now = now.Add(30 * time.Second)
+	controller.sync(t.Context(), "", "")
+	if f := c.Jobs[0].Status.Finished(); f != nil {
+		t.Fatalf("job finished before its deadline: %+v", f)
+	}
+
+	now = now.Add(31 * time.Second)
+	controller.sync(t.Context(), "", "")
+	f := c.Jobs[0].Status.Finished()
+	if f == nil || f.Type != api.JobFailed || f.Reason != "DeadlineExceeded" {
+		t.Errorf("expected job to fail on its deadline, got %+v", c.Jobs[0].Status)
+	}
+	if len(c.Pods) != 0 || c.Jobs[0].Status.Active != 0 {
+		t.Errorf("expected the running pod to be stopped, have %d pods and status %+v", len(c.Pods), c.Jobs[0].Status)
+	}
+}
+
+func TestJobTTLAfterFinished(t *testing.T) {
+	j := newJob("done", apitest.Ptr(int32(1)), 1)
+	j.Spec.TTLSecondsAfterFinished = apitest.Ptr(int32(30))
+	c := &client.FakeClient{Jobs: []api.Job{j}}
+	now := time.Now()
+	controller := newController(c, &now)
+
+	controller.sync(t.Context(), "", "")
+	finish(c, api.PodSucceeded, 1)
+	controller.sync(t.Context(), "", "")
+	now = now.Add(10 * time.Second)
+	controller.sync(t.Context(), "", "")
+	if len(c.Jobs) != 1 {
+		t.Fatal("job deleted before its ttl ran out")
+	}
+
+	now = now.Add(30 * time.Second)
+	controller.sync(t.Context(), "", "")
+	if len(c.Jobs) != 0 {
+		t.Fatal("job wasn't deleted after its ttl")
+	}
+	// the next sync sees the job is gone and takes its pods with it
+	controller.sync(t.Context(), "default", "done")
+	if len(c.Pods) != 0 {
+		t.Errorf("expected the pods of the deleted job to be deleted, %d left", len(c.Pods))
+	}
+}
@@ -0,0 +1,192 @@
+package nodelifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+)
+
+const (
+	DefaultMonitorPeriod      = 5 * time.Second
+	DefaultGracePeriod        = 40 * time.Second
+	DefaultPodEvictionTimeout = 5 * time.Minute
+)
+
+type Opts struct {
+	// how often node heartbeats are checked
+	MonitorPeriod time.Duration
+	// how long a node can go without a heartbeat before it's NotReady
+	GracePeriod time.Duration
+	// how long pods stay on an unreachable node, unless they tolerate the unreachable taint themselves
+	PodEvictionTimeout time.Duration
+}
+
+// Controller keeps an eye on node heartbeats. A node whose kubelet goes quiet for longer than the grace period
+// is marked NotReady and tainted unreachable, so nothing new is scheduled there,
+// and its pods are deleted once they've tolerated the taint long enough so their controllers can replace them.
+type Controller struct {
+	client client.Client
+	opts   Opts
+	// swapped out in tests
+	now func() time.Time
+}
+
+func NewController(c client.Client, opts Opts) *Controller {
+	if opts.MonitorPeriod == 0 {
+		opts.MonitorPeriod = DefaultMonitorPeriod
+	}
+	if opts.GracePeriod == 0 {
+		opts.GracePeriod = DefaultGracePeriod
+	}
+	if opts.PodEvictionTimeout == 0 {
+		opts.PodEvictionTimeout = DefaultPodEvictionTimeout
+	}
+	return &Controller{
+		client: c,
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// Start checks on every node each MonitorPeriod until ctx is done
+func (c *Controller) Start(ctx context.Context) error {
+	if err := c.client.Ping(ctx); err != nil {
+		return fmt.Errorf("node lifecycle controller failed to start: %v", err)
+	}
+	ticker := time.NewTicker(c.opts.MonitorPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("node lifecycle controller stopped due to context cancellation")
+			return nil
+		case <-ticker.C:
+			c.monitorNodes(ctx)
+		}
+	}
+}
+
+func (c *Controller) monitorNodes(ctx context.Context) {
+	nodes, err := c.client.ListNodes(ctx)
+	if err != nil {
+		slog.Error("failed to list nodes", "error", err)
+		return
+	}
+	pods, err := c.client.ListPods(ctx)
+	if err != nil {
+		slog.Error("failed to list pods", "error", err)
+		return
+	}
+	byNode := map[string][]api.Pod{}
+	for _, p := range pods.Items {
+		if p.Nodename != "" {
+			byNode[p.Nodename] = append(byNode[p.Nodename], p)
+		}
+	}
+	now := c.now().UTC()
+	for _, n := range nodes.Items {
+		c.monitorNode(ctx, n, byNode[n.Name], now)
+	}
+}
+
+// monitorNode moves the node one step along, NotReady, then tainted, then evicting, or back once it heartbeats again.
+// Each step is a single write made against the node's resourceVersion,
+// if the kubelet reports in at the same time the write fails and the next pass sees the fresh status.
+func (c *Controller) monitorNode(ctx context.Context, node api.Node, pods []api.Pod, now time.Time) {
+	ready := node.Status.GetCondition(api.NodeReady)
+	// a node that never reported in gets the grace period from when it was created
+	lastHeartbeat := node.CreationTimestamp
+	if ready != nil {
+		lastHeartbeat = ready.LastHeartbeatTime
+	}
+	stale := now.Sub(lastHeartbeat) > c.opts.GracePeriod
+	if stale && (ready == nil || ready.Status != api.ConditionUnknown) {
+		c.markNotReady(ctx, node, lastHeartbeat, now)
+		return
+	}
+	unreachable := ready != nil && ready.Status == api.ConditionUnknown
+	i := slices.IndexFunc(node.Spec.Taints, func(t api.Taint) bool { return t.Key == api.TaintNodeUnreachable })
+	switch {
+	case unreachable && i < 0:
+		node.Spec.Taints = append(slices.Clone(node.Spec.Taints), api.Taint{
+			Key:       api.TaintNodeUnreachable,
+			Effect:    api.TaintEffectNoExecute,
+			TimeAdded: &now,
+		})
+		c.updateNode(ctx, node, "tainted node unreachable")
+	case !unreachable && i >= 0:
+		node.Spec.Taints = slices.Delete(slices.Clone(node.Spec.Taints), i, i+1)
+		c.updateNode(ctx, node, "node is reachable again, removed unreachable taint")
+	case i >= 0:
+		c.evictPods(ctx, node.Spec.Taints[i], pods, now)
+	}
+}
+
+func (c *Controller) markNotReady(ctx context.Context, node api.Node, lastHeartbeat, now time.Time) {
+	node.Status.Conditions = slices.DeleteFunc(slices.Clone(node.Status.Conditions), func(cond api.NodeCondition) bool {
+		return cond.Type == api.NodeReady
+	})
+	node.Status.Conditions = append(node.Status.Conditions, api.NodeCondition{
+		Type:               api.NodeReady,
+		Status:             api.ConditionUnknown,
+		LastHeartbeatTime:  lastHeartbeat,
+		LastTransitionTime: now,
+		Reason:             "NodeStatusUnknown",
+		Message:            "kubelet stopped posting node status",
+	})
+	if err := c.client.UpdateNodeStatus(ctx, node); err != nil {
+		slog.Error("failed to mark node NotReady", "node", node.Name, "error", err)
+		return
+	}
+	slog.Warn("node stopped heartbeating, marked NotReady", "node", node.Name, "lastHeartbeat", lastHeartbeat)
+}
+
+func (c *Controller) updateNode(ctx context.Context, node api.Node, msg string) {
+	if err := c.client.UpdateNode(ctx, node); err != nil {
+		slog.Error("failed to update node taints", "node", node.Name, "error", err)
+		return
+	}
+	slog.Info(msg, "node", node.Name)
+}
+
+// evictPods deletes every pod on the node whose toleration of taint has run out.
+// The kubelet is gone and can't confirm the containers stopped, so pods are deleted without a grace period.
+func (c *Controller) evictPods(ctx context.Context, taint api.Taint, pods []api.Pod, now time.Time) {
+	for _, p := range pods {
+		at, ok := c.evictionTime(p, taint)
+		if !ok || now.Before(at) {
+			continue
+		}
+		zero := int64(0)
+		if err := c.client.DeletePod(ctx, p, api.DeleteOptions{GracePeriodSeconds: &zero, Uid: p.Uid}); err != nil {
+			slog.Error("failed to evict pod", "namespace", p.Namespace, "pod", p.Name, "error", err)
+			continue
+		}
+		slog.Info("evicted pod from unreachable node", "namespace", p.Namespace, "pod", p.Name, "node", p.Nodename)
+	}
+}
+
+// evictionTime is when pod has to leave a node tainted with taint, false if it tolerates the taint forever.
+// Pods without a toleration of their own get PodEvictionTimeout,
+// a taint without TimeAdded counts as added long ago.
+func (c *Controller) evictionTime(pod api.Pod, taint api.Taint) (time.Time, bool) {
+	var added time.Time
+	if taint.TimeAdded != nil {
+		added = *taint.TimeAdded
+	}
+	for _, t := range pod.Spec.Tolerations {
+		if !t.Tolerates(taint) {
+			continue
+		}
+		if t.TolerationSeconds == nil {
+			return time.Time{}, false
+		}
+		return added.Add(time.Duration(*t.TolerationSeconds) * time.Second), true
+	}
+	return added.Add(c.opts.PodEvictionTimeout), true
+}
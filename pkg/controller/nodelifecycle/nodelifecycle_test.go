@@ -0,0 +1,117 @@
+package nodelifecycle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+)
+
+func TestNodeLifecycle(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	node := api.Node{
+		ObjectMeta: api.ObjectMeta{Name: "node-1", CreationTimestamp: start},
+		Status: api.NodeStatus{Conditions: []api.NodeCondition{{
+			Type:              api.NodeReady,
+			Status:            api.ConditionTrue,
+			LastHeartbeatTime: start,
+		}}},
+	}
+	seconds := func(s int64) *int64 { return &s }
+	pod := func(name string, tolerationSeconds *int64, tolerate bool) api.Pod {
+		p := api.Pod{ObjectMeta: api.ObjectMeta{Namespace: "default", Name: name, Uid: uuid.New()}, Nodename: "node-1"}
+		if tolerate {
+			p.Spec.Tolerations = []api.Toleration{{
+				Key:               api.TaintNodeUnreachable,
+				Operator:          api.TolerationOpExists,
+				Effect:            api.TaintEffectNoExecute,
+				TolerationSeconds: tolerationSeconds,
+			}}
+		}
+		return p
+	}
+	c := &client.FakeClient{
+		Nodes: []api.Node{node},
+		Pods: []api.Pod{
+			pod("default-timeout", nil, false),
+			pod("short", seconds(30), true),
+			pod("forever", nil, true),
+		},
+	}
+	controller := NewController(c, Opts{})
+	now := start
+	controller.now = func() time.Time { return now }
+	pass := func(at time.Duration) api.Node {
+		now = start.Add(at)
+		controller.monitorNodes(t.Context())
+		return c.Nodes[0]
+	}
+	remaining := func() []string {
+		names := []string{}
+		for _, p := range c.Pods {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	// heartbeat within the grace period, nothing happens
+	n := pass(30 * time.Second)
+	if n.Status.Conditions[0].Status != api.ConditionTrue || len(n.Spec.Taints) != 0 {
+		t.Fatalf("healthy node was touched: %+v", n)
+	}
+
+	n = pass(time.Minute)
+	ready := n.Status.GetCondition(api.NodeReady)
+	if ready.Status != api.ConditionUnknown || !ready.LastHeartbeatTime.Equal(start) || !ready.LastTransitionTime.Equal(now) {
+		t.Fatalf("expected node to be NotReady, got %+v", ready)
+	}
+	if len(n.Spec.Taints) != 0 {
+		t.Fatalf("node tainted before being marked NotReady")
+	}
+
+	n = pass(time.Minute + 5*time.Second)
+	if len(n.Spec.Taints) != 1 || n.Spec.Taints[0].Key != api.TaintNodeUnreachable || !n.Spec.Taints[0].TimeAdded.Equal(now) {
+		t.Fatalf("expected unreachable taint, got %+v", n.Spec.Taints)
+	}
+	tainted := now
+
+	pass(tainted.Sub(start) + 10*time.Second)
+	if len(c.DeletedPods) != 0 {
+		t.Fatalf("pods evicted before their toleration ran out: %v", remaining())
+	}
+	pass(tainted.Sub(start) + 31*time.Second)
+	if got := remaining(); len(got) != 2 || got[0] != "default-timeout" {
+		t.Fatalf("expected only the short toleration pod to be evicted, left with %v", got)
+	}
+	pass(tainted.Sub(start) + DefaultPodEvictionTimeout + time.Second)
+	if got := remaining(); len(got) != 1 || got[0] != "forever" {
+		t.Fatalf("expected only the pod tolerating forever to be left, got %v", got)
+	}
+
+	// the kubelet comes back and reports Ready again
+	heartbeat := start.Add(time.Hour)
+	c.Nodes[0].Status.Conditions = []api.NodeCondition{{Type: api.NodeReady, Status: api.ConditionTrue, LastHeartbeatTime: heartbeat}}
+	n = pass(time.Hour + time.Second)
+	if len(n.Spec.Taints) != 0 {
+		t.Errorf("expected unreachable taint to be removed, got %+v", n.Spec.Taints)
+	}
+}
+
+func TestNodeNeverReported(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &client.FakeClient{Nodes: []api.Node{{ObjectMeta: api.ObjectMeta{Name: "node-1", CreationTimestamp: start}}}}
+	controller := NewController(c, Opts{GracePeriod: time.Minute})
+	controller.now = func() time.Time { return start.Add(30 * time.Second) }
+	controller.monitorNodes(t.Context())
+	if len(c.Nodes[0].Status.Conditions) != 0 {
+		t.Fatalf("new node marked NotReady inside the grace period")
+	}
+	controller.now = func() time.Time { return start.Add(2 * time.Minute) }
+	controller.monitorNodes(t.Context())
+	if ready := c.Nodes[0].Status.GetCondition(api.NodeReady); ready == nil || ready.Status != api.ConditionUnknown {
+		t.Errorf("expected silent node to be NotReady, got %+v", ready)
+	}
+}
@@ -0,0 +1,251 @@
+package replicaset
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+)
+
+const (
+	DefaultResyncPeriod = 30 * time.Second
+	// Kind set on the ownerReferences of pods a replica set creates
+	Kind = "ReplicaSet"
+)
+
+type Opts struct {
+	// how often every replica set is synced regardless of events, catches anything a watch missed
+	ResyncPeriod time.Duration
+}
+
+// Controller keeps the pods of every replica set at its replica count.
+// It keeps no cache, events only say which replica set to look at and a sync reads everything fresh,
+// pods it creates or adopts point back at their replica set through a controller ownerReference.
+type Controller struct {
+	client client.Client
+	opts   Opts
+}
+
+func NewController(c client.Client, opts Opts) *Controller {
+	if opts.ResyncPeriod == 0 {
+		opts.ResyncPeriod = DefaultResyncPeriod
+	}
+	return &Controller{
+		client: c,
+		opts:   opts,
+	}
+}
+
+// Start syncs replica sets as they or their pods change, and all of them every ResyncPeriod, until ctx is done
+func (c *Controller) Start(ctx context.Context) error {
+	if err := c.client.Ping(ctx); err != nil {
+		return fmt.Errorf("replica set controller failed to start: %v", err)
+	}
+	rsEvents, err := c.client.WatchResource(ctx, "replicasets", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch replica sets: %v", err)
+	}
+	podEvents, err := c.client.WatchResource(ctx, "pods", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch pods: %v", err)
+	}
+	// watches start before the first sync, so nothing changed in between goes unnoticed
+	c.sync(ctx, "", "")
+	ticker := time.NewTicker(c.opts.ResyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("replica set controller stopped due to context cancellation")
+			return nil
+		case ev, ok := <-rsEvents:
+			if !ok {
+				return errors.New("replica set watch channel closed")
+			}
+			var rs api.ReplicaSet
+			if err := ev.DecodeObject(&rs); err != nil {
+				slog.Error("failed to decode replica set event", "error", err)
+				continue
+			}
+			c.sync(ctx, rs.Namespace, rs.Name)
+		case ev, ok := <-podEvents:
+			if !ok {
+				return errors.New("pod watch channel closed")
+			}
+			if ref := ev.Pod.ControllerRef(); ref != nil && ref.Kind == Kind {
+				c.sync(ctx, ev.Pod.Namespace, ref.Name)
+			}
+		case <-ticker.C:
+			c.sync(ctx, "", "")
+		}
+	}
+}
+
+// sync syncs the replica set namespace/name, or every replica set if name is empty.
+// Pods left behind by a deleted replica set are cleaned up here too.
+func (c *Controller) sync(ctx context.Context, namespace, name string) {
+	sets, err := c.client.ListReplicaSets(ctx)
+	if err != nil {
+		slog.Error("failed to list replica sets", "error", err)
+		return
+	}
+	pods, err := c.client.ListPods(ctx)
+	if err != nil {
+		slog.Error("failed to list pods", "error", err)
+		return
+	}
+	selected := func(ns, n string) bool {
+		return name == "" || (ns == namespace && n == name)
+	}
+	for _, rs := range sets.Items {
+		if selected(rs.Namespace, rs.Name) {
+			c.syncReplicaSet(ctx, rs, pods.Items)
+		}
+	}
+	for _, p := range pods.Items {
+		ref := p.ControllerRef()
+		if ref == nil || ref.Kind != Kind || !selected(p.Namespace, ref.Name) || p.DeletionTimestamp != nil {
+			continue
+		}
+		owned := slices.ContainsFunc(sets.Items, func(rs api.ReplicaSet) bool {
+			return rs.Namespace == p.Namespace && rs.Uid == ref.Uid
+		})
+		if !owned {
+			slog.Info("deleting pod of a deleted replica set", "namespace", p.Namespace, "pod", p.Name, "replicaset", ref.Name)
+			c.deletePod(ctx, p)
+		}
+	}
+}
+
+// syncReplicaSet creates or deletes pods until the replica set has as many as it asks for, then reports what it found.
+// Pods its selector matches that have no controller are adopted first and count towards the replica count.
+func (c *Controller) syncReplicaSet(ctx context.Context, rs api.ReplicaSet, pods []api.Pod) {
+	var active []api.Pod
+	for _, p := range pods {
+		if p.Namespace != rs.Namespace || !isActive(p) {
+			continue
+		}
+		if p.IsControlledBy(rs.Uid) || (p.ControllerRef() == nil && rs.Spec.Selector.Matches(p.Labels) && c.adoptPod(ctx, rs, p)) {
+			active = append(active, p)
+		}
+	}
+	want := int(*rs.Spec.Replicas)
+	switch diff := want - len(active); {
+	case diff > 0:
+		slog.Info("creating pods for replica set", "namespace", rs.Namespace, "replicaset", rs.Name, "count", diff)
+		for range diff {
+			if err := c.client.CreatePod(ctx, newPod(rs)); err != nil {
+				slog.Error("failed to create pod", "replicaset", rs.Name, "error", err)
+			}
+		}
+	case diff < 0:
+		slog.Info("deleting pods of replica set", "namespace", rs.Namespace, "replicaset", rs.Name, "count", -diff)
+		slices.SortStableFunc(active, deletionOrder)
+		for _, p := range active[:-diff] {
+			c.deletePod(ctx, p)
+		}
+		// the deleted pods are on their way out, reporting them would overstate what's ready
+		active = active[-diff:]
+	}
+
+	status := api.ReplicaSetStatus{
+		Replicas:           int32(len(active)),
+		ObservedGeneration: rs.Generation,
+	}
+	for _, p := range active {
+		if isReady(p) {
+			status.ReadyReplicas++
+		}
+	}
+	if status == rs.Status {
+		return
+	}
+	rs.Status = status
+	// an update from the spec racing this one just means the next sync reports again
+	rs.ResourceVersion = ""
+	if err := c.client.UpdateReplicaSetStatus(ctx, rs); err != nil {
+		slog.Error("failed to update replica set status", "replicaset", rs.Name, "error", err)
+	}
+}
+
+// adoptPod makes rs the controller of the orphaned pod p.
+// The write is held to p's resourceVersion, so a pod adopted or changed in the meantime is left alone.
+func (c *Controller) adoptPod(ctx context.Context, rs api.ReplicaSet, p api.Pod) bool {
+	p.OwnerReferences = append(slices.Clone(p.OwnerReferences), controllerRef(rs))
+	if err := c.client.UpdatePod(ctx, p); err != nil {
+		if !errors.Is(err, client.ErrConflict) {
+			slog.Error("failed to adopt pod", "namespace", p.Namespace, "pod", p.Name, "replicaset", rs.Name, "error", err)
+		}
+		return false
+	}
+	slog.Info("adopted pod", "namespace", p.Namespace, "pod", p.Name, "replicaset", rs.Name)
+	return true
+}
+
+func (c *Controller) deletePod(ctx context.Context, p api.Pod) {
+	// the uid keeps a pod recreated under the same name from being deleted by mistake
+	if err := c.client.DeletePod(ctx, p, api.DeleteOptions{Uid: p.Uid}); err != nil && !errors.Is(err, client.ErrConflict) {
+		slog.Error("failed to delete pod", "namespace", p.Namespace, "pod", p.Name, "error", err)
+	}
+}
+
+// newPod stamps out a pod from the replica set's template, named after the replica set
+func newPod(rs api.ReplicaSet) api.Pod {
+	return api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			GenerateName:    rs.Name + "-",
+			Namespace:       rs.Namespace,
+			Labels:          maps.Clone(rs.Spec.Template.Labels),
+			Annotations:     maps.Clone(rs.Spec.Template.Annotations),
+			OwnerReferences: []api.OwnerReference{controllerRef(rs)},
+		},
+		Spec: rs.Spec.Template.Spec,
+	}
+}
+
+func controllerRef(rs api.ReplicaSet) api.OwnerReference {
+	return api.OwnerReference{
+		Kind:       Kind,
+		Name:       rs.Name,
+		Uid:        rs.Uid,
+		Controller: true,
+	}
+}
+
+// isActive leaves out pods on their way out, they no longer count towards the replica count
+func isActive(p api.Pod) bool {
+	return p.DeletionTimestamp == nil && p.Status.Phase != api.PodSucceeded && p.Status.Phase != api.PodFailed
+}
+
+func isReady(p api.Pod) bool {
+	ready := p.Status.GetCondition(api.PodReady)
+	return ready != nil && ready.Status == api.ConditionTrue
+}
+
+// deletionOrder sorts the pods that are cheapest to lose first:
+// unscheduled before scheduled, pending before running, not ready before ready, then newest first
+func deletionOrder(a, b api.Pod) int {
+	rank := func(p api.Pod) int {
+		switch {
+		case p.Nodename == "":
+			return 0
+		case p.Status.Phase == api.PodPending:
+			return 1
+		case !isReady(p):
+			return 2
+		default:
+			return 3
+		}
+	}
+	if r := cmp.Compare(rank(a), rank(b)); r != 0 {
+		return r
+	}
+	return b.CreationTimestamp.Compare(a.CreationTimestamp)
+}
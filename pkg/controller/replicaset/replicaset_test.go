@@ -0,0 +1,174 @@
+package replicaset
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/client"
+)
+
+func ready(p api.Pod) api.Pod {
+	p.Nodename = "node-1"
+	p.Status.Phase = api.PodRunning
+	p.Status.Conditions = []api.PodCondition{{Type: api.PodReady, Status: api.ConditionTrue}}
+	return p
+}
+
+func TestSyncCreatesPods(t *testing.T) {
+	rs := apitest.NewReplicaSet("web", 3)
+	c := &client.FakeClient{ReplicaSets: []api.ReplicaSet{rs}}
+	controller := NewController(c, Opts{})
+
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 3 {
+		t.Fatalf("expected 3 pods, got %d", len(c.Pods))
+	}
+	for _, p := range c.Pods {
+		if !strings.HasPrefix(p.Name, "web-") || p.Labels["app"] != "web" {
+			t.Errorf("pod %s wasn't stamped out of the template: %+v", p.Name, p.ObjectMeta)
+		}
+		ref := p.ControllerRef()
+		if ref == nil || ref.Kind != Kind || ref.Name != "web" || ref.Uid != rs.Uid {
+			t.Errorf("pod %s has controller ref %+v", p.Name, ref)
+		}
+	}
+	// the template's labels aren't shared with the pods
+	c.Pods[0].Labels["app"] = "changed"
+	if c.ReplicaSets[0].Spec.Template.Labels["app"] != "web" {
+		t.Error("pod labels alias the template's")
+	}
+	c.Pods[0].Labels["app"] = "web"
+
+	// nothing is ready yet
+	if got := c.ReplicaSets[0].Status; got.Replicas != 0 || got.ObservedGeneration != 1 {
+		t.Errorf("unexpected status %+v", got)
+	}
+
+	// syncing again sees the pods it created and doesn't create more
+	c.Pods[0] = ready(c.Pods[0])
+	controller.sync(t.Context(), "default", "web")
+	if len(c.Pods) != 3 {
+		t.Fatalf("expected 3 pods, got %d", len(c.Pods))
+	}
+	if got := c.ReplicaSets[0].Status; got.Replicas != 3 || got.ReadyReplicas != 1 {
+		t.Errorf("unexpected status %+v", got)
+	}
+}
+
+func TestSyncIgnoresOtherPods(t *testing.T) {
+	rs := apitest.NewReplicaSet("web", 1)
+	other := apitest.NewReplicaSet("other", 1)
+	// matches the selector but belongs to someone else, it isn't counted
+	stranger := api.Pod{ObjectMeta: api.ObjectMeta{
+		Name: "stranger", Namespace: "default", Uid: uuid.New(), Labels: map[string]string{"app": "web"},
+		OwnerReferences: []api.OwnerReference{{Kind: Kind, Name: "other", Uid: other.Uid, Controller: true}},
+	}}
+	terminating := newPod(rs)
+	terminating.Name, terminating.Uid = "terminating", uuid.New()
+	now := time.Now()
+	terminating.DeletionTimestamp = &now
+	c := &client.FakeClient{
+		ReplicaSets: []api.ReplicaSet{rs, other},
+		Pods:        []api.Pod{stranger, terminating},
+	}
+	controller := NewController(c, Opts{})
+
+	controller.sync(t.Context(), "default", "web")
+	owned := 0
+	for _, p := range c.Pods {
+		if p.IsControlledBy(rs.Uid) && p.DeletionTimestamp == nil {
+			owned++
+		}
+	}
+	if owned != 1 || len(c.DeletedPods) != 0 {
+		t.Errorf("expected one new pod for web and nothing deleted, got %d owned and %d deleted", owned, len(c.DeletedPods))
+	}
+}
+
+func TestSyncAdoptsOrphans(t *testing.T) {
+	rs := apitest.NewReplicaSet("web", 2)
+	orphan := api.Pod{ObjectMeta: api.ObjectMeta{
+		Name: "orphan", Namespace: "default", Uid: uuid.New(), Labels: map[string]string{"app": "web"},
+	}}
+	unmatched := api.Pod{ObjectMeta: api.ObjectMeta{
+		Name: "unmatched", Namespace: "default", Uid: uuid.New(), Labels: map[string]string{"app": "other"},
+	}}
+	elsewhere := orphan
+	elsewhere.Name, elsewhere.Namespace, elsewhere.Uid = "elsewhere", "staging", uuid.New()
+	c := &client.FakeClient{
+		ReplicaSets: []api.ReplicaSet{rs},
+		Pods:        []api.Pod{ready(orphan), unmatched, elsewhere},
+	}
+	controller := NewController(c, Opts{})
+
+	controller.sync(t.Context(), "default", "web")
+	if ref := c.Pods[0].ControllerRef(); ref == nil || ref.Uid != rs.Uid {
+		t.Errorf("expected the orphan to be adopted, got controller ref %+v", ref)
+	}
+	if c.Pods[1].ControllerRef() != nil || c.Pods[2].ControllerRef() != nil {
+		t.Errorf("pods the selector doesn't cover were adopted: %+v, %+v", c.Pods[1].ObjectMeta, c.Pods[2].ObjectMeta)
+	}
+	if len(c.Pods) != 4 {
+		t.Fatalf("expected the orphan to count towards the replicas and one pod to be created, got %d pods", len(c.Pods))
+	}
+	// the pod just created isn't seen until the next sync
+	if got := c.ReplicaSets[0].Status; got.Replicas != 1 || got.ReadyReplicas != 1 {
+		t.Errorf("unexpected status %+v", got)
+	}
+}
+
+func TestSyncDeletesSurplusPods(t *testing.T) {
+	rs := apitest.NewReplicaSet("web", 1)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	pod := func(name string, age time.Duration) api.Pod {
+		p := newPod(rs)
+		p.Name, p.Uid = name, uuid.New()
+		p.CreationTimestamp = start.Add(-age)
+		return p
+	}
+	unscheduled := pod("unscheduled", time.Hour)
+	pending := pod("pending", time.Hour)
+	pending.Nodename = "node-1"
+	pending.Status.Phase = api.PodPending
+	older := ready(pod("older", time.Hour))
+	newer := ready(pod("newer", time.Minute))
+	c := &client.FakeClient{
+		ReplicaSets: []api.ReplicaSet{rs},
+		Pods:        []api.Pod{older, newer, pending, unscheduled},
+	}
+	controller := NewController(c, Opts{})
+
+	controller.sync(t.Context(), "", "")
+	deleted := []string{}
+	for _, p := range c.DeletedPods {
+		deleted = append(deleted, p.Name)
+	}
+	if strings.Join(deleted, ",") != "unscheduled,pending,newer" {
+		t.Errorf("deleted %v, expected unscheduled, pending, then newer", deleted)
+	}
+	if len(c.Pods) != 1 || c.Pods[0].Name != "older" {
+		t.Errorf("expected only the oldest ready pod to be left, got %+v", c.Pods)
+	}
+	if got := c.ReplicaSets[0].Status; got.Replicas != 1 || got.ReadyReplicas != 1 {
+		t.Errorf("status %+v counts the deleted pods", got)
+	}
+}
+
+func TestSyncDeletesPodsOfDeletedReplicaSet(t *testing.T) {
+	rs := apitest.NewReplicaSet("web", 2)
+	c := &client.FakeClient{ReplicaSets: []api.ReplicaSet{rs}}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+
+	// recreated under the same name, the old pods belong to the old uid
+	c.ReplicaSets = []api.ReplicaSet{apitest.NewReplicaSet("web", 0)}
+	controller.sync(t.Context(), "default", "web")
+	if len(c.Pods) != 0 || len(c.DeletedPods) != 2 {
+		t.Errorf("expected the old pods to be deleted, %d left and %d deleted", len(c.Pods), len(c.DeletedPods))
+	}
+}
@@ -0,0 +1,369 @@
+package statefulset
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"maps"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+)
+
+const (
+	DefaultResyncPeriod = 30 * time.Second
+	// Kind set on the ownerReferences of pods a stateful set creates
+	Kind = "StatefulSet"
+)
+
+type Opts struct {
+	// how often every stateful set is synced regardless of events, catches anything a watch missed
+	ResyncPeriod time.Duration
+}
+
+// Controller keeps pods <name>-0 to <name>-(replicas-1) of every stateful set running.
+// A pod is only ever replaced under the same name once the old one is gone, so two pods never share an identity or volume directory.
+// Like the replica set controller it keeps no cache, a sync reads stateful sets and pods fresh.
+type Controller struct {
+	client client.Client
+	opts   Opts
+}
+
+func NewController(c client.Client, opts Opts) *Controller {
+	if opts.ResyncPeriod == 0 {
+		opts.ResyncPeriod = DefaultResyncPeriod
+	}
+	return &Controller{
+		client: c,
+		opts:   opts,
+	}
+}
+
+// Start syncs stateful sets as they or their pods change, and all of them every ResyncPeriod, until ctx is done
+func (c *Controller) Start(ctx context.Context) error {
+	if err := c.client.Ping(ctx); err != nil {
+		return fmt.Errorf("stateful set controller failed to start: %v", err)
+	}
+	ssEvents, err := c.client.WatchResource(ctx, "statefulsets", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch stateful sets: %v", err)
+	}
+	podEvents, err := c.client.WatchResource(ctx, "pods", "")
+	if err != nil {
+		return fmt.Errorf("failed to watch pods: %v", err)
+	}
+	c.sync(ctx, "", "")
+	ticker := time.NewTicker(c.opts.ResyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("stateful set controller stopped due to context cancellation")
+			return nil
+		case ev, ok := <-ssEvents:
+			if !ok {
+				return errors.New("stateful set watch channel closed")
+			}
+			var ss api.StatefulSet
+			if err := ev.DecodeObject(&ss); err != nil {
+				slog.Error("failed to decode stateful set event", "error", err)
+				continue
+			}
+			c.sync(ctx, ss.Namespace, ss.Name)
+		case ev, ok := <-podEvents:
+			if !ok {
+				return errors.New("pod watch channel closed")
+			}
+			if ref := ev.Pod.ControllerRef(); ref != nil && ref.Kind == Kind {
+				c.sync(ctx, ev.Pod.Namespace, ref.Name)
+			}
+		case <-ticker.C:
+			c.sync(ctx, "", "")
+		}
+	}
+}
+
+// sync syncs the stateful set namespace/name, or every stateful set if name is empty.
+// Pods left behind by a deleted stateful set are cleaned up here too, all at once whatever the set's policy was.
+func (c *Controller) sync(ctx context.Context, namespace, name string) {
+	sets, err := c.client.ListStatefulSets(ctx)
+	if err != nil {
+		slog.Error("failed to list stateful sets", "error", err)
+		return
+	}
+	pods, err := c.client.ListPods(ctx)
+	if err != nil {
+		slog.Error("failed to list pods", "error", err)
+		return
+	}
+	selected := func(ns, n string) bool {
+		return name == "" || (ns == namespace && n == name)
+	}
+	for _, ss := range sets.Items {
+		if !selected(ss.Namespace, ss.Name) {
+			continue
+		}
+		var owned []api.Pod
+		for _, p := range pods.Items {
+			if p.Namespace == ss.Namespace && p.IsControlledBy(ss.Uid) {
+				owned = append(owned, p)
+			}
+		}
+		c.syncStatefulSet(ctx, ss, owned)
+	}
+	for _, p := range pods.Items {
+		ref := p.ControllerRef()
+		if ref == nil || ref.Kind != Kind || !selected(p.Namespace, ref.Name) || p.DeletionTimestamp != nil {
+			continue
+		}
+		owned := slices.ContainsFunc(sets.Items, func(ss api.StatefulSet) bool {
+			return ss.Namespace == p.Namespace && ss.Uid == ref.Uid
+		})
+		if !owned {
+			slog.Info("deleting pod of a deleted stateful set", "namespace", p.Namespace, "pod", p.Name, "statefulset", ref.Name)
+			c.deletePod(ctx, p)
+		}
+	}
+}
+
+// syncStatefulSet takes one step towards the set's replica count and template, then reports what it found.
+// With OrderedReady a step waits on every pod before it, with Parallel it does everything it can at once.
+func (c *Controller) syncStatefulSet(ctx context.Context, ss api.StatefulSet, pods []api.Pod) {
+	replicas := int(*ss.Spec.Replicas)
+	updateRevision := templateHash(ss.Spec.Template)
+	currentRevision := ss.Status.CurrentRevision
+	if currentRevision == "" {
+		currentRevision = updateRevision
+	}
+
+	byOrdinal := map[int]api.Pod{}
+	var condemned []api.Pod
+	for _, p := range pods {
+		if ord, ok := ordinal(ss, p); ok && ord < replicas {
+			byOrdinal[ord] = p
+		} else {
+			condemned = append(condemned, p)
+		}
+	}
+
+	status := api.StatefulSetStatus{
+		ObservedGeneration: ss.Generation,
+		CurrentRevision:    currentRevision,
+		UpdateRevision:     updateRevision,
+	}
+	for _, p := range pods {
+		if p.DeletionTimestamp != nil {
+			continue
+		}
+		status.Replicas++
+		if isReady(p) {
+			status.ReadyReplicas++
+		}
+		revision := p.Labels[api.PodTemplateHashLabel]
+		if revision == currentRevision {
+			status.CurrentReplicas++
+		}
+		if revision == updateRevision {
+			status.UpdatedReplicas++
+		}
+	}
+	// every pod made it onto the new template, it's current from here on
+	if status.UpdatedReplicas == int32(replicas) && status.Replicas == int32(replicas) {
+		status.CurrentRevision = updateRevision
+		status.CurrentReplicas = status.UpdatedReplicas
+	}
+
+	settled := c.scale(ctx, ss, byOrdinal, condemned, pods, currentRevision, updateRevision)
+	if settled && ss.Spec.UpdateStrategy.Type == api.RollingUpdateStatefulSetStrategyType {
+		c.rollingUpdate(ctx, ss, byOrdinal, updateRevision)
+	}
+
+	if status == ss.Status {
+		return
+	}
+	ss.Status = status
+	// an update from the spec racing this one just means the next sync reports again
+	ss.ResourceVersion = ""
+	if err := c.client.UpdateStatefulSetStatus(ctx, ss); err != nil {
+		slog.Error("failed to update stateful set status", "statefulset", ss.Name, "error", err)
+	}
+}
+
+// scale creates the missing ordinals lowest first and deletes the ones past the replica count highest first.
+// It reports whether every ordinal has a ready pod and nothing is left to delete, only then can a rolling update move.
+func (c *Controller) scale(ctx context.Context, ss api.StatefulSet, byOrdinal map[int]api.Pod, condemned, pods []api.Pod, currentRevision, updateRevision string) bool {
+	parallel := ss.Spec.PodManagementPolicy == api.ParallelPodManagement
+	settled := true
+	for ord := range int(*ss.Spec.Replicas) {
+		p, ok := byOrdinal[ord]
+		switch {
+		case !ok:
+			template, revision := c.revisionFor(ss, ord, pods, currentRevision, updateRevision)
+			pod := newPod(ss, ord, template, revision)
+			slog.Info("creating stateful pod", "namespace", ss.Namespace, "statefulset", ss.Name, "pod", pod.Name, "revision", revision)
+			if err := c.client.CreatePod(ctx, pod); err != nil {
+				slog.Error("failed to create pod", "statefulset", ss.Name, "pod", pod.Name, "error", err)
+			}
+		case p.DeletionTimestamp != nil:
+			// the replacement takes the same name, it has to wait for the old pod to be gone
+		case p.Status.Phase == api.PodSucceeded || p.Status.Phase == api.PodFailed:
+			slog.Info("deleting finished stateful pod", "namespace", ss.Namespace, "pod", p.Name, "phase", p.Status.Phase)
+			c.deletePod(ctx, p)
+		case isReady(p):
+			continue
+		}
+		settled = false
+		if !parallel {
+			return false
+		}
+	}
+
+	// the highest ordinal goes first, pods that don't have one aren't stateful pods of this set and go before that
+	deletionOrder := func(p api.Pod) int {
+		if ord, ok := ordinal(ss, p); ok {
+			return ord
+		}
+		return math.MaxInt
+	}
+	slices.SortFunc(condemned, func(a, b api.Pod) int { return cmp.Compare(deletionOrder(b), deletionOrder(a)) })
+	for _, p := range condemned {
+		settled = false
+		if p.DeletionTimestamp == nil {
+			slog.Info("deleting stateful pod past the replica count", "namespace", ss.Namespace, "statefulset", ss.Name, "pod", p.Name)
+			c.deletePod(ctx, p)
+		}
+		if !parallel {
+			break
+		}
+	}
+	return settled
+}
+
+// rollingUpdate deletes the highest ordinal at or above the partition that's still on an old revision, scale recreates it on the new one.
+// It's only called once every pod is ready, so pods are replaced one at a time whatever the pod management policy.
+func (c *Controller) rollingUpdate(ctx context.Context, ss api.StatefulSet, byOrdinal map[int]api.Pod, updateRevision string) {
+	partition := int(*ss.Spec.UpdateStrategy.RollingUpdate.Partition)
+	for ord := int(*ss.Spec.Replicas) - 1; ord >= partition; ord-- {
+		p := byOrdinal[ord]
+		if p.Labels[api.PodTemplateHashLabel] != updateRevision {
+			slog.Info("replacing stateful pod of an old revision", "namespace", ss.Namespace, "statefulset", ss.Name, "pod", p.Name)
+			c.deletePod(ctx, p)
+			return
+		}
+	}
+}
+
+// revisionFor picks the template a missing ordinal is created from.
+// Below the partition of a rolling update that's the current revision, which is taken from a pod still running it,
+// there's no history of old templates to go back to otherwise.
+func (c *Controller) revisionFor(ss api.StatefulSet, ord int, pods []api.Pod, currentRevision, updateRevision string) (api.PodTemplateSpec, string) {
+	ru := ss.Spec.UpdateStrategy.RollingUpdate
+	if ss.Spec.UpdateStrategy.Type != api.RollingUpdateStatefulSetStrategyType || ord >= int(*ru.Partition) || currentRevision == updateRevision {
+		return ss.Spec.Template, updateRevision
+	}
+	for _, p := range pods {
+		if p.Labels[api.PodTemplateHashLabel] == currentRevision {
+			return templateFromPod(ss, p), currentRevision
+		}
+	}
+	slog.Warn("no pod left on the current revision, recreating on the update revision", "namespace", ss.Namespace, "statefulset", ss.Name, "ordinal", ord)
+	return ss.Spec.Template, updateRevision
+}
+
+func (c *Controller) deletePod(ctx context.Context, p api.Pod) {
+	// the uid keeps the pod's replacement, which has the same name, from being deleted by mistake
+	if err := c.client.DeletePod(ctx, p, api.DeleteOptions{Uid: p.Uid}); err != nil && !errors.Is(err, client.ErrConflict) {
+		slog.Error("failed to delete pod", "namespace", p.Namespace, "pod", p.Name, "error", err)
+	}
+}
+
+// newPod stamps out ordinal ord of the stateful set from template, with a volume for each of the set's claim templates
+func newPod(ss api.StatefulSet, ord int, template api.PodTemplateSpec, revision string) api.Pod {
+	name := fmt.Sprintf("%s-%d", ss.Name, ord)
+	labels := maps.Clone(template.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[api.PodTemplateHashLabel] = revision
+	labels[api.StatefulSetPodNameLabel] = name
+	spec := template.Spec
+	spec.Volumes = slices.Clone(spec.Volumes)
+	for _, t := range ss.Spec.VolumeClaimTemplates {
+		spec.Volumes = append(spec.Volumes, api.Volume{
+			Name:     t.Name,
+			HostPath: &api.HostPathVolumeSource{Path: claimDir(ss, t, ord)},
+		})
+	}
+	return api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:        name,
+			Namespace:   ss.Namespace,
+			Labels:      labels,
+			Annotations: maps.Clone(template.Annotations),
+			OwnerReferences: []api.OwnerReference{{
+				Kind:       Kind,
+				Name:       ss.Name,
+				Uid:        ss.Uid,
+				Controller: true,
+			}},
+		},
+		Spec: spec,
+	}
+}
+
+// templateFromPod undoes newPod, giving back the template p was stamped out of
+func templateFromPod(ss api.StatefulSet, p api.Pod) api.PodTemplateSpec {
+	labels := maps.Clone(p.Labels)
+	delete(labels, api.PodTemplateHashLabel)
+	delete(labels, api.StatefulSetPodNameLabel)
+	spec := p.Spec
+	spec.Volumes = slices.DeleteFunc(slices.Clone(spec.Volumes), func(v api.Volume) bool {
+		return slices.ContainsFunc(ss.Spec.VolumeClaimTemplates, func(t api.VolumeClaimTemplate) bool { return t.Name == v.Name })
+	})
+	return api.PodTemplateSpec{
+		ObjectMeta: api.ObjectMeta{Labels: labels, Annotations: maps.Clone(p.Annotations)},
+		Spec:       spec,
+	}
+}
+
+// claimDir is the directory on the node ordinal ord of the stateful set keeps for claim template t
+func claimDir(ss api.StatefulSet, t api.VolumeClaimTemplate, ord int) string {
+	return path.Join(t.Dir, ss.Namespace, fmt.Sprintf("%s-%s-%d", t.Name, ss.Name, ord))
+}
+
+// ordinal parses the ordinal out of a pod named <set>-<ordinal>, false for pods named anything else
+func ordinal(ss api.StatefulSet, p api.Pod) (int, bool) {
+	suffix, ok := strings.CutPrefix(p.Name, ss.Name+"-")
+	if !ok {
+		return -1, false
+	}
+	ord, err := strconv.Atoi(suffix)
+	if err != nil || ord < 0 || strconv.Itoa(ord) != suffix {
+		return -1, false
+	}
+	return ord, true
+}
+
+func isReady(p api.Pod) bool {
+	ready := p.Status.GetCondition(api.PodReady)
+	return ready != nil && ready.Status == api.ConditionTrue
+}
+
+// templateHash names the revision a template is rolled out as.
+// The template is encoded as json, which sorts map keys, so equal templates always hash the same.
+func templateHash(template api.PodTemplateSpec) string {
+	b, _ := json.Marshal(template)
+	h := fnv.New32a()
+	h.Write(b)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
@@ -0,0 +1,237 @@
+package statefulset
+
+import (
+	"slices"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/api/apitest"
+	"superminikube/pkg/client"
+)
+
+func newStatefulSet(name string, replicas int32, policy api.PodManagementPolicyType) api.StatefulSet {
+	ss := apitest.NewStatefulSet(name, replicas)
+	ss.Spec.VolumeClaimTemplates[0].Dir = "/volumes"
+	ss.Spec.PodManagementPolicy = policy
+	ss.Spec.UpdateStrategy = api.StatefulSetUpdateStrategy{
+		Type:          api.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &api.RollingUpdateStatefulSetStrategy{Partition: apitest.Ptr(int32(0))},
+	}
+	return ss
+}
+
+// readyAll marks every pod running and ready, as if the kubelet started them
+func readyAll(c *client.FakeClient) {
+	for i := range c.Pods {
+		c.Pods[i].Status.Phase = api.PodRunning
+		c.Pods[i].Status.Conditions = []api.PodCondition{{Type: api.PodReady, Status: api.ConditionTrue}}
+	}
+}
+
+func podNames(pods []api.Pod) []string {
+	var names []string
+	for _, p := range pods {
+		names = append(names, p.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func image(c *client.FakeClient, name string) string {
+	i := slices.IndexFunc(c.Pods, func(p api.Pod) bool { return p.Name == name })
+	if i < 0 {
+		return ""
+	}
+	return c.Pods[i].Spec.Containers[0].Image
+}
+
+func TestStatefulSetOrderedReady(t *testing.T) {
+	ss := newStatefulSet("db", 3, api.OrderedReadyPodManagement)
+	c := &client.FakeClient{StatefulSets: []api.StatefulSet{ss}}
+	controller := NewController(c, Opts{})
+
+	// db-1 waits for db-0 to be ready
+	controller.sync(t.Context(), "", "")
+	controller.sync(t.Context(), "", "")
+	if got := podNames(c.Pods); !slices.Equal(got, []string{"db-0"}) {
+		t.Fatalf("expected only db-0, got %v", got)
+	}
+	p := c.Pods[0]
+	if ref := p.ControllerRef(); ref == nil || ref.Kind != Kind || ref.Uid != ss.Uid {
+		t.Errorf("pod has controller ref %+v", ref)
+	}
+	if p.Labels["app"] != "db" || p.Labels[api.StatefulSetPodNameLabel] != "db-0" || p.Labels[api.PodTemplateHashLabel] == "" {
+		t.Errorf("pod has labels %v", p.Labels)
+	}
+	want := []api.Volume{{Name: "data", HostPath: &api.HostPathVolumeSource{Path: "/volumes/default/data-db-0"}}}
+	if len(p.Spec.Volumes) != 1 || p.Spec.Volumes[0].Name != "data" || *p.Spec.Volumes[0].HostPath != *want[0].HostPath {
+		t.Errorf("pod has volumes %+v, expected %+v", p.Spec.Volumes, want)
+	}
+	if len(c.StatefulSets[0].Spec.Template.Spec.Volumes) != 0 {
+		t.Error("pod volumes alias the template's")
+	}
+
+	for range 2 {
+		readyAll(c)
+		controller.sync(t.Context(), "", "")
+	}
+	readyAll(c)
+	controller.sync(t.Context(), "", "")
+	if got := podNames(c.Pods); !slices.Equal(got, []string{"db-0", "db-1", "db-2"}) {
+		t.Fatalf("expected db-0 to db-2, got %v", got)
+	}
+	status := c.StatefulSets[0].Status
+	if status.Replicas != 3 || status.ReadyReplicas != 3 || status.CurrentReplicas != 3 || status.CurrentRevision != status.UpdateRevision {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// a deleted pod comes back under its name and with its directory
+	c.Pods = slices.DeleteFunc(c.Pods, func(p api.Pod) bool { return p.Name == "db-1" })
+	controller.sync(t.Context(), "", "")
+	i := slices.IndexFunc(c.Pods, func(p api.Pod) bool { return p.Name == "db-1" })
+	if i < 0 || c.Pods[i].Spec.Volumes[0].HostPath.Path != "/volumes/default/data-db-1" {
+		t.Fatalf("expected db-1 to be recreated with its volume, got %v", podNames(c.Pods))
+	}
+	readyAll(c)
+
+	// scaling down goes from the highest ordinal, one at a time
+	one := int32(1)
+	c.StatefulSets[0].Spec.Replicas = &one
+	controller.sync(t.Context(), "", "")
+	if got := podNames(c.Pods); !slices.Equal(got, []string{"db-0", "db-1"}) {
+		t.Fatalf("expected db-2 to go first, got %v", got)
+	}
+	controller.sync(t.Context(), "", "")
+	if got := podNames(c.Pods); !slices.Equal(got, []string{"db-0"}) {
+		t.Fatalf("expected db-1 to go next, got %v", got)
+	}
+}
+
+func TestStatefulSetParallel(t *testing.T) {
+	c := &client.FakeClient{StatefulSets: []api.StatefulSet{newStatefulSet("cache", 3, api.ParallelPodManagement)}}
+	controller := NewController(c, Opts{})
+
+	controller.sync(t.Context(), "", "")
+	if got := podNames(c.Pods); !slices.Equal(got, []string{"cache-0", "cache-1", "cache-2"}) {
+		t.Fatalf("expected every pod at once, got %v", got)
+	}
+
+	zero := int32(0)
+	c.StatefulSets[0].Spec.Replicas = &zero
+	controller.sync(t.Context(), "", "")
+	if len(c.Pods) != 0 {
+		t.Fatalf("expected every pod to be deleted at once, got %v", podNames(c.Pods))
+	}
+}
+
+func TestStatefulSetPartitionedRollingUpdate(t *testing.T) {
+	c := &client.FakeClient{StatefulSets: []api.StatefulSet{newStatefulSet("db", 3, api.OrderedReadyPodManagement)}}
+	controller := NewController(c, Opts{})
+	for range 3 {
+		controller.sync(t.Context(), "", "")
+		readyAll(c)
+	}
+	controller.sync(t.Context(), "", "")
+	oldRevision := c.StatefulSets[0].Status.CurrentRevision
+
+	partition := int32(1)
+	c.StatefulSets[0].Spec.UpdateStrategy.RollingUpdate.Partition = &partition
+	c.StatefulSets[0].Spec.Template.Spec.Containers = []api.Container{{
+		Name:         "postgres",
+		Image:        "postgres:17",
+		VolumeMounts: []api.VolumeMount{{Name: "data", MountPath: "/var/lib/postgresql/data"}},
+	}}
+	c.StatefulSets[0].Generation = 2
+
+	// the highest ordinal is replaced first, and the next waits for it to be ready
+	controller.sync(t.Context(), "", "")
+	if got := podNames(c.Pods); !slices.Equal(got, []string{"db-0", "db-1"}) {
+		t.Fatalf("expected db-2 to be replaced first, got %v", got)
+	}
+	controller.sync(t.Context(), "", "")
+	controller.sync(t.Context(), "", "")
+	if image(c, "db-2") != "postgres:17" || image(c, "db-1") != "postgres:16" {
+		t.Fatalf("expected only db-2 on the new image, db-1 has %q and db-2 %q", image(c, "db-1"), image(c, "db-2"))
+	}
+
+	// below the partition a deleted pod comes back on the old template
+	partition = 2
+	readyAll(c)
+	c.Pods = slices.DeleteFunc(c.Pods, func(p api.Pod) bool { return p.Name == "db-0" })
+	controller.sync(t.Context(), "", "")
+	if image(c, "db-0") != "postgres:16" {
+		t.Fatalf("expected db-0 to be recreated on the old image, got %q", image(c, "db-0"))
+	}
+	i := slices.IndexFunc(c.Pods, func(p api.Pod) bool { return p.Name == "db-0" })
+	if v := c.Pods[i].Spec.Volumes; len(v) != 1 || v[0].HostPath.Path != "/volumes/default/data-db-0" {
+		t.Errorf("recreated db-0 has volumes %+v", v)
+	}
+	for range 3 {
+		readyAll(c)
+		controller.sync(t.Context(), "", "")
+	}
+	if image(c, "db-1") != "postgres:16" || image(c, "db-0") != "postgres:16" {
+		t.Fatalf("expected the update to stop at the partition, db-0 has %q and db-1 %q", image(c, "db-0"), image(c, "db-1"))
+	}
+	status := c.StatefulSets[0].Status
+	if status.UpdatedReplicas != 1 || status.CurrentReplicas != 2 || status.CurrentRevision != oldRevision || status.ObservedGeneration != 2 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// lowering the partition finishes the rollout
+	partition = 0
+	for range 5 {
+		readyAll(c)
+		controller.sync(t.Context(), "", "")
+	}
+	if image(c, "db-0") != "postgres:17" || image(c, "db-1") != "postgres:17" {
+		t.Fatalf("expected every pod on the new image, db-0 has %q and db-1 %q", image(c, "db-0"), image(c, "db-1"))
+	}
+	status = c.StatefulSets[0].Status
+	if status.CurrentRevision != status.UpdateRevision || status.CurrentRevision == oldRevision || status.CurrentReplicas != 3 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestStatefulSetDeletesPodsOfDeletedStatefulSet(t *testing.T) {
+	ss := newStatefulSet("db", 2, api.ParallelPodManagement)
+	c := &client.FakeClient{StatefulSets: []api.StatefulSet{ss}}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+
+	// a pod named like an ordinal but owned by another set isn't touched
+	other := newStatefulSet("db", 1, api.ParallelPodManagement)
+	c.Pods[0].OwnerReferences = []api.OwnerReference{{Kind: Kind, Name: "db", Uid: other.Uid, Controller: true}}
+	c.StatefulSets = []api.StatefulSet{other}
+	controller.sync(t.Context(), "default", "db")
+	if got := podNames(c.Pods); !slices.Equal(got, []string{"db-0"}) || !c.Pods[0].IsControlledBy(other.Uid) {
+		t.Errorf("expected only the other set's pod to be left, got %v", got)
+	}
+}
+
+func TestStatefulSetOnDelete(t *testing.T) {
+	ss := newStatefulSet("db", 2, api.ParallelPodManagement)
+	ss.Spec.UpdateStrategy = api.StatefulSetUpdateStrategy{Type: api.OnDeleteStatefulSetStrategyType}
+	c := &client.FakeClient{StatefulSets: []api.StatefulSet{ss}}
+	controller := NewController(c, Opts{})
+	controller.sync(t.Context(), "", "")
+	readyAll(c)
+
+	c.StatefulSets[0].Spec.Template.Spec.Containers = []api.Container{{Name: "postgres", Image: "postgres:17"}}
+	controller.sync(t.Context(), "", "")
+	controller.sync(t.Context(), "", "")
+	if image(c, "db-0") != "postgres:16" || image(c, "db-1") != "postgres:16" {
+		t.Fatalf("expected pods to be left on the old image, db-0 has %q and db-1 %q", image(c, "db-0"), image(c, "db-1"))
+	}
+
+	// only a deleted pod picks up the new template
+	c.Pods = slices.DeleteFunc(c.Pods, func(p api.Pod) bool { return p.Name == "db-1" })
+	controller.sync(t.Context(), "", "")
+	if image(c, "db-1") != "postgres:17" || image(c, "db-0") != "postgres:16" {
+		t.Fatalf("expected only db-1 on the new image, db-0 has %q and db-1 %q", image(c, "db-0"), image(c, "db-1"))
+	}
+	controller.sync(t.Context(), "", "")
+	if status := c.StatefulSets[0].Status; status.UpdatedReplicas != 1 || status.CurrentReplicas != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
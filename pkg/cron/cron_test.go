@@ -0,0 +1,62 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2025, 1, 1, 10, 30, 45, 0, time.UTC)
+	testCases := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "* * * * *", want: time.Date(2025, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", want: time.Date(2025, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{spec: "0 * * * *", want: time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", want: time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{spec: "@daily", want: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{spec: "30 9 * * 1-5", want: time.Date(2025, 1, 2, 9, 30, 0, 0, time.UTC)},
+		{spec: "0 0 * * sun", want: time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", want: time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 12 15 mar *", want: time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{spec: "5,10 8-9 * * *", want: time.Date(2025, 1, 2, 8, 5, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// both day fields restricted, either one matching is enough
+		{spec: "0 0 10 * fri", want: time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", want: time.Time{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if got := s.Next(from); !got.Equal(tc.want) {
+				t.Errorf("next run at %v, expected %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"@sometimes",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package kubelet
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/kubelet/runtime"
+)
+
+// adoptPods takes over the containers an earlier run of the kubelet left behind for pods still bound to the node,
+// so they carry on running rather than being created again.
+// Containers of any other pod are left for the first resync to remove.
+func (k *Kubelet) adoptPods(ctx context.Context) error {
+	running, err := k.containerruntime.ListPods(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list pods in the runtime: %v", err)
+	}
+	if len(running) == 0 {
+		return nil
+	}
+	inRuntime := map[uuid.UUID]bool{}
+	for _, rp := range running {
+		inRuntime[rp.Uid] = true
+	}
+	list, err := k.client.ListNodePods(ctx, k.nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %v", err)
+	}
+	for _, p := range list.Items {
+		if inRuntime[p.Uid] {
+			k.adoptPod(ctx, p)
+		}
+	}
+	return nil
+}
+
+// adoptPod tracks p with the containers the runtime already has for it.
+// Its status carries on from what the apiserver has, restart counts included.
+func (k *Kubelet) adoptPod(ctx context.Context, p api.Pod) {
+	slog.Info("adopting pod", "pod", p.Name, "node", k.nodeName)
+	k.AddPod(p)
+	rs, err := k.containerruntime.GetPodStatus(ctx, p)
+	if err != nil {
+		slog.Error("failed to get pod status from runtime", "pod", p.Name, "error", err)
+		return
+	}
+	res := runtime.CreatePodResponse{ContainerIds: map[string]string{}}
+	for name, cs := range rs.Containers {
+		res.ContainerIds[name] = cs.Id
+	}
+	k.setContainerIds(p.Uid, res)
+	k.syncPodStatus(ctx, p.Uid)
+	k.startProbes(ctx, p)
+}
@@ -0,0 +1,64 @@
+package kubelet
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+	"superminikube/pkg/kubelet/runtime"
+)
+
+func TestAdoptPods(t *testing.T) {
+	web := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New()},
+		Nodename:   "test-node",
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}},
+	}
+	orphan := web
+	orphan.Name, orphan.Uid = "orphan", uuid.New()
+	// what an earlier run of the kubelet left behind
+	rt := &runtime.FakeRuntime{}
+	rt.CreatePod(t.Context(), web)
+	rt.CreatePod(t.Context(), orphan)
+	web.Status = api.PodStatus{
+		Phase:             api.PodRunning,
+		ContainerStatuses: []api.ContainerStatus{{Name: "app", RestartCount: 2}},
+	}
+	c := &client.FakeClient{Pods: []api.Pod{web}}
+	k := NewKubeletWithRuntime(KubeletOpts{NodeName: "test-node"}, rt)
+	k.client = c
+
+	if err := k.adoptPods(t.Context()); err != nil {
+		t.Fatalf("failed to adopt pods: %v", err)
+	}
+	p, err := k.GetPod(web.Uid)
+	if err != nil {
+		t.Fatalf("running pod wasn't adopted: %v", err)
+	}
+	if p.Spec.Containers[0].ContainerId == "" {
+		t.Errorf("adopted pod has no container id")
+	}
+	if cs := p.Status.ContainerStatuses; len(cs) != 1 || cs[0].RestartCount != 2 || cs[0].State.Running == nil {
+		t.Errorf("adopted pod has container statuses %+v, expected app running with 2 restarts", cs)
+	}
+	if _, err := k.GetPod(orphan.Uid); err == nil {
+		t.Errorf("pod the apiserver doesn't have was adopted")
+	}
+
+	// the first resync leaves the adopted pod alone and removes the rest
+	if err := k.resync(t.Context()); err != nil {
+		t.Fatalf("resync failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	pods, _ := rt.ListPods(t.Context())
+	if len(pods) != 1 || pods[0].Uid != web.Uid {
+		t.Errorf("expected only web to be left in the runtime, got %+v", pods)
+	}
+	if len(rt.CreatedPods) != 2 || slices.ContainsFunc(rt.DeletedPods, func(p api.Pod) bool { return p.Uid == web.Uid }) {
+		t.Errorf("adopted pod was recreated")
+	}
+}
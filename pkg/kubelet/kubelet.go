@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -14,6 +16,8 @@ import (
 )
 
 func (k *Kubelet) ListPods() []api.Pod {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
 	pods := make([]api.Pod, 0)
 	for _, v := range k.pods {
 		pods = append(pods, v)
@@ -22,6 +26,8 @@ func (k *Kubelet) ListPods() []api.Pod {
 }
 
 func (k *Kubelet) GetPod(uid uuid.UUID) (api.Pod, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
 	p, ok := k.pods[uid]
 	if !ok {
 		return api.Pod{}, fmt.Errorf("pod not found: %s", uid)
@@ -30,29 +36,71 @@ func (k *Kubelet) GetPod(uid uuid.UUID) (api.Pod, error) {
 }
 
 func (k *Kubelet) AddPod(p api.Pod) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
 	k.pods[p.Uid] = p
 	slog.Debug("added pod to internal map", "pods", k.pods, "added", p)
 }
 
+// handlePodEvent hands the pod in the event to its worker, the worker works out what has to be done about it
 func (k *Kubelet) handlePodEvent(ctx context.Context, event watch.WatchEvent) {
 	switch event.EventType {
-	case watch.Add:
-		slog.Info("creating pod with spec... on node...")
-		res, err := k.containerruntime.CreatePod(ctx, event.Pod.Spec)
-		if err != nil {
-			slog.Error("failed to create pod", "err", err)
-			return
-		}
-		p := event.Pod
-		p.Spec.Container.ContainerId = res.ContainerId
-		k.AddPod(p)
+	case watch.Add, watch.Modified:
+		k.updatePod(ctx, podUpdate{pod: event.Pod})
 	case watch.Delete:
-		break
+		// already gone from the apiserver, e.g. force deleted, so there's nobody to confirm to
+		k.updatePod(ctx, podUpdate{pod: event.Pod, removed: true})
 	default:
 		slog.Error("Unknown event type")
 	}
 }
 
+func (k *Kubelet) createPod(ctx context.Context, p api.Pod) {
+	slog.Info("creating pod", "pod", p.Name, "node", k.nodeName)
+	k.AddPod(p)
+	res, err := k.containerruntime.CreatePod(ctx, p)
+	if err != nil {
+		slog.Error("failed to create pod", "err", err)
+		k.failPod(ctx, p.Uid, "CreatePodError", err.Error())
+		return
+	}
+	k.setContainerIds(p.Uid, res)
+	k.syncPodStatus(ctx, p.Uid)
+	k.startProbes(ctx, p)
+}
+
+func (k *Kubelet) setContainerIds(uid uuid.UUID, res runtime.CreatePodResponse) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	p, ok := k.pods[uid]
+	if !ok {
+		return
+	}
+	for i, c := range p.Spec.InitContainers {
+		p.Spec.InitContainers[i].ContainerId = res.ContainerIds[c.Name]
+	}
+	for i, c := range p.Spec.Containers {
+		p.Spec.Containers[i].ContainerId = res.ContainerIds[c.Name]
+	}
+	k.pods[uid] = p
+}
+
+// failPod reports a pod as Failed when its containers couldn't be started
+func (k *Kubelet) failPod(ctx context.Context, uid uuid.UUID, reason, message string) {
+	p, err := k.GetPod(uid)
+	if err != nil {
+		return
+	}
+	status := p.Status
+	if rs, err := k.containerruntime.GetPodStatus(ctx, p); err == nil {
+		status = generatePodStatus(p, rs, nil, k.now())
+	}
+	status.Phase = api.PodFailed
+	status.Reason = reason
+	status.Message = message
+	k.setPodStatus(ctx, uid, status)
+}
+
 // TODO: move this to PodManager service
 // Pod lifecycle sync loop
 func (k *Kubelet) syncLoop(ctx context.Context, events <-chan watch.WatchEvent) {
@@ -63,9 +111,9 @@ func (k *Kubelet) syncLoop(ctx context.Context, events <-chan watch.WatchEvent)
 			return
 		case event, ok := <-events:
 			if !ok {
-				slog.Info("event channel closed")
-				return // works for now ->
-				// i guess best behavior would be to restart kubelet once user gets cluster working again
+				// resyncLoop keeps pods converging, just without the quick reaction to changes
+				slog.Warn("event channel closed, relying on periodic resyncs")
+				return
 			}
 			slog.Debug("Got event", "event", event)
 			k.handlePodEvent(ctx, event)
@@ -73,8 +121,61 @@ func (k *Kubelet) syncLoop(ctx context.Context, events <-chan watch.WatchEvent)
 	}
 }
 
-func (k *Kubelet) handlePodDelete(param any) {
-	panic("unimplemented")
+// handlePodDelete terminates p, blocking its worker for the grace period.
+// confirm tells the apiserver once the containers are gone so it can remove the pod.
+func (k *Kubelet) handlePodDelete(ctx context.Context, p api.Pod, confirm bool) {
+	k.mu.Lock()
+	_, tracked := k.pods[p.Uid]
+	// an untracked pod was either never started here or already cleaned up,
+	// unless the apiserver is still waiting to hear back about it
+	waiting := confirm && p.DeletionGracePeriodSeconds != nil && *p.DeletionGracePeriodSeconds > 0
+	if k.terminating[p.Uid] || (!tracked && !waiting) {
+		k.mu.Unlock()
+		return
+	}
+	k.terminating[p.Uid] = true
+	k.mu.Unlock()
+	// a liveness probe failing while the containers shut down mustn't kill them again
+	k.prober.remove(p.Uid)
+
+	var gracePeriod time.Duration
+	if confirm && p.DeletionGracePeriodSeconds != nil {
+		gracePeriod = time.Duration(*p.DeletionGracePeriodSeconds) * time.Second
+	}
+	k.terminatePod(ctx, p, gracePeriod, confirm)
+}
+
+// terminatePod stops p's containers, giving them gracePeriod to exit, then removes them
+func (k *Kubelet) terminatePod(ctx context.Context, p api.Pod, gracePeriod time.Duration, confirm bool) {
+	defer func() {
+		k.mu.Lock()
+		delete(k.terminating, p.Uid)
+		k.mu.Unlock()
+	}()
+	slog.Info("stopping pod", "pod", p.Name, "gracePeriod", gracePeriod)
+	// removing the containers kills anything still running, so carry on even if stopping failed
+	if err := k.containerruntime.StopPod(ctx, p, gracePeriod); err != nil {
+		slog.Error("failed to stop pod", "pod", p.Name, "error", err)
+	}
+	if err := k.DeletePod(ctx, p); err != nil {
+		slog.Error("failed to delete pod", "pod", p.Name, "error", err)
+		return
+	}
+	k.mu.Lock()
+	delete(k.pods, p.Uid)
+	k.forgetBackoffs(p.Uid)
+	k.mu.Unlock()
+	if !confirm {
+		return
+	}
+	// a zero grace period tells the apiserver nothing is left running,
+	// the uid makes sure a new pod with the same name isn't deleted instead
+	zero := int64(0)
+	if err := k.client.DeletePod(ctx, p, api.DeleteOptions{GracePeriodSeconds: &zero, Uid: p.Uid}); err != nil {
+		slog.Error("failed to confirm pod deletion", "pod", p.Name, "error", err)
+		return
+	}
+	slog.Info("pod terminated", "pod", p.Name)
 }
 
 // Creates container then returns container id
@@ -123,21 +224,22 @@ func (k *Kubelet) handlePodDelete(param any) {
 // }
 
 func (k *Kubelet) Shutdown(ctx context.Context) {
-	removedContainers := make([]string, 0, len(k.pods))
+	pods := k.ListPods()
+	removedPods := make([]string, 0, len(pods))
 	errs := make([]error, 0)
-	for _, p := range k.pods {
+	for _, p := range pods {
 		err := k.DeletePod(ctx, p)
 		if err != nil {
-			err = fmt.Errorf("id: %s\terr: %v", p.Spec.Container.ContainerId, err)
+			err = fmt.Errorf("pod: %s\terr: %v", p.Uid, err)
 			errs = append(errs, err)
 			continue
 		}
-		removedContainers = append(removedContainers, p.Spec.Container.ContainerId)
+		removedPods = append(removedPods, p.Uid.String())
 	}
 	if len(errs) > 0 {
-		slog.Error("failed to remove containers", "containers", errs)
+		slog.Error("failed to remove pods", "pods", errs)
 	}
-	slog.Debug("containers removed", "containers", removedContainers)
+	slog.Debug("pods removed", "pods", removedPods)
 }
 
 // Cleanup Kubelet if process killed/stopped
@@ -152,37 +254,85 @@ func (k *Kubelet) DeletePod(ctx context.Context, p api.Pod) error {
 }
 
 func (k *Kubelet) Start(ctx context.Context) error {
-	defer k.Shutdown(ctx)
+	if !k.keepPodsOnShutdown {
+		defer k.Shutdown(ctx)
+	}
 	err := k.containerruntime.Ping(ctx)
 	if err != nil {
 		return fmt.Errorf("Kubelet failed to start: %v", err)
 	}
 	slog.Info("Successfully pinged Docker")
-	events, err := k.client.Watch(ctx)
+	if err := k.registerNode(ctx); err != nil {
+		return fmt.Errorf("Kubelet failed to start: %v", err)
+	}
+	// before any pod is synced, so pods that are still running aren't started a second time
+	if err := k.adoptPods(ctx); err != nil {
+		return fmt.Errorf("Kubelet failed to start: %v", err)
+	}
+	events, err := k.client.Watch(ctx, "")
 	// _ = events
 	if err != nil {
 		return fmt.Errorf("failed to watch events: %v", err)
 	}
+	exits, err := k.containerruntime.WatchContainerExits(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to watch container exits: %v", err)
+	}
+	go k.serve(ctx)
 	go k.syncLoop(ctx, events)
+	go k.resyncLoop(ctx)
+	go k.exitLoop(ctx, exits)
+	go k.statusLoop(ctx)
+	go k.nodeStatusLoop(ctx)
 	<-ctx.Done()
 	return nil
 }
 
-func NewKubelet(apiServerURL, nodeName string) (*Kubelet, error) {
+type KubeletOpts struct {
+	APIServerURL string
+	NodeName     string
+	// added to the labels the kubelet sets on its node itself
+	NodeLabels map[string]string
+	// most pods the node takes, defaults to 110
+	MaxPods int64
+	// leaves the containers running when the kubelet stops, the next start adopts them
+	KeepPodsOnShutdown bool
+	// the kubelet's HTTP server listens here, defaults to 10250
+	Port int32
+}
+
+func NewKubelet(opts KubeletOpts) (*Kubelet, error) {
 	rt, err := runtime.NewDockerRuntime()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kubelet: %v", err)
 	}
-	return NewKubeletWithRuntime(apiServerURL, nodeName, rt), nil
+	return NewKubeletWithRuntime(opts, rt), nil
 }
 
-func NewKubeletWithRuntime(apiServerURL, nodeName string, rt runtime.ContainerRuntime) *Kubelet {
-	c := client.NewHTTPClient(apiServerURL, nodeName)
+func NewKubeletWithRuntime(opts KubeletOpts, rt runtime.ContainerRuntime) *Kubelet {
+	c := client.NewHTTPClient(opts.APIServerURL, opts.NodeName)
+	maxPods := opts.MaxPods
+	if maxPods == 0 {
+		maxPods = defaultMaxPods
+	}
+	port := opts.Port
+	if port == 0 {
+		port = defaultPort
+	}
 	return &Kubelet{
-		client:           c,
-		containerruntime: rt,
-		pods:             map[uuid.UUID]api.Pod{},
-		nodeName:         nodeName,
+		client:             c,
+		containerruntime:   rt,
+		pods:               map[uuid.UUID]api.Pod{},
+		terminating:        map[uuid.UUID]bool{},
+		backoffs:           map[containerKey]containerBackoff{},
+		podUpdates:         map[uuid.UUID]chan podUpdate{},
+		prober:             newProberManager(),
+		now:                time.Now,
+		nodeName:           opts.NodeName,
+		nodeLabels:         opts.NodeLabels,
+		maxPods:            maxPods,
+		keepPodsOnShutdown: opts.KeepPodsOnShutdown,
+		port:               port,
 	}
 }
 
@@ -190,6 +340,23 @@ type Kubelet struct {
 	client client.Client
 	// containerruntime *mobyclient.Client
 	containerruntime runtime.ContainerRuntime
-	pods             map[uuid.UUID]api.Pod
-	nodeName         string
+	// guards pods, terminating and backoffs, pod workers, statusLoop and exitLoop all touch them
+	mu   sync.RWMutex
+	pods map[uuid.UUID]api.Pod
+	// pods whose containers are being stopped right now
+	terminating map[uuid.UUID]bool
+	// restart backoff of every app container that has exited before
+	backoffs map[containerKey]containerBackoff
+	prober   *proberManager
+	// guards podUpdates, taken before mu when both are needed
+	workersMu sync.Mutex
+	// pending update of every pod that has a worker, see updatePod
+	podUpdates map[uuid.UUID]chan podUpdate
+	// swapped out in tests
+	now                func() time.Time
+	nodeName           string
+	nodeLabels         map[string]string
+	maxPods            int64
+	keepPodsOnShutdown bool
+	port               int32
 }
@@ -1,12 +1,17 @@
 package kubelet
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
 	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/watch"
 	"superminikube/pkg/kubelet/runtime"
 )
 
@@ -14,7 +19,7 @@ var testKubelet *Kubelet
 
 func TestMain(m *testing.M) {
 	rt := &runtime.FakeRuntime{}
-	testKubelet = NewKubeletWithRuntime("http://localhost:8080", "test-node", rt)
+	testKubelet = NewKubeletWithRuntime(KubeletOpts{APIServerURL: "http://localhost:8080", NodeName: "test-node"}, rt)
 
 	code := m.Run()
 
@@ -22,15 +27,33 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+// waitFor polls cond until it holds, pods are synced on their workers' goroutines
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// hasPhase reports whether the kubelet tracks the pod in the given phase
+func hasPhase(k *Kubelet, uid uuid.UUID, phase api.PodPhase) bool {
+	p, err := k.GetPod(uid)
+	return err == nil && p.Status.Phase == phase
+}
+
 func TestPodCreate(t *testing.T) {
 	testPods := []api.PodSpec{
 		{
-			Container: api.Container{
+			Containers: []api.Container{{
 				Image: "alpine",
-			},
+			}},
 		},
 		{
-			Container: api.Container{
+			Containers: []api.Container{{
 				Image: "nginx",
 				Ports: []api.Port{
 					{
@@ -38,12 +61,12 @@ func TestPodCreate(t *testing.T) {
 						Containerport: "80",
 					},
 				},
-			},
+			}},
 		},
 	}
 	t.Run("test pod create", func(t *testing.T) {
 		for _, p := range testPods {
-			_, err := testKubelet.containerruntime.CreatePod(t.Context(), p)
+			_, err := testKubelet.containerruntime.CreatePod(t.Context(), api.Pod{Spec: p})
 			if err != nil {
 				t.Errorf("failed to create pod: %v", err)
 			}
@@ -61,14 +84,14 @@ func TestListPods(t *testing.T) {
 	}
 
 	testKubelet.AddPod(api.Pod{
-		Uid:      uuid.New(),
-		Nodename: "test-node",
-		Spec:     api.PodSpec{Container: api.Container{Image: "alpine"}},
+		ObjectMeta: api.ObjectMeta{Uid: uuid.New()},
+		Nodename:   "test-node",
+		Spec:       api.PodSpec{Containers: []api.Container{{Image: "alpine"}}},
 	})
 	testKubelet.AddPod(api.Pod{
-		Uid:      uuid.New(),
-		Nodename: "test-node",
-		Spec:     api.PodSpec{Container: api.Container{Image: "nginx"}},
+		ObjectMeta: api.ObjectMeta{Uid: uuid.New()},
+		Nodename:   "test-node",
+		Spec:       api.PodSpec{Containers: []api.Container{{Image: "nginx"}}},
 	})
 
 	pods = testKubelet.ListPods()
@@ -76,3 +99,163 @@ func TestListPods(t *testing.T) {
 		t.Errorf("expected 2 pods, got %d", len(pods))
 	}
 }
+
+func TestPodCreateInitContainers(t *testing.T) {
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Uid: uuid.New()},
+		Spec: api.PodSpec{
+			InitContainers: []api.Container{
+				{Name: "migrate", Image: "alpine"},
+				{Name: "seed", Image: "alpine"},
+			},
+			Containers: []api.Container{
+				{Name: "app", Image: "nginx"},
+				{Name: "sidecar", Image: "alpine"},
+			},
+		},
+	}
+	testCases := []struct {
+		name        string
+		failInit    map[string]bool
+		expectedRun []string
+		expectPhase api.PodPhase
+	}{
+		{
+			name:        "init containers run before app containers",
+			expectedRun: []string{"migrate", "seed", "app", "sidecar"},
+			expectPhase: api.PodRunning,
+		},
+		{
+			name:        "failed init container stops the pod",
+			failInit:    map[string]bool{"seed": true},
+			expectedRun: []string{"migrate"},
+			expectPhase: api.PodFailed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rt := &runtime.FakeRuntime{FailInitContainers: tc.failInit}
+			k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: "http://localhost:8080", NodeName: "test-node"}, rt)
+			k.handlePodEvent(t.Context(), watch.WatchEvent{EventType: watch.Add, Pod: pod})
+			waitFor(t, "the pod to be "+string(tc.expectPhase), func() bool { return hasPhase(k, pod.Uid, tc.expectPhase) })
+
+			if !slices.Equal(rt.StartedContainers, tc.expectedRun) {
+				t.Errorf("started containers %v, expected %v", rt.StartedContainers, tc.expectedRun)
+			}
+			p, err := k.GetPod(pod.Uid)
+			if err != nil {
+				t.Fatalf("failed to get pod: %v", err)
+			}
+			if p.Status.Phase != tc.expectPhase {
+				t.Errorf("pod phase %s, expected %s", p.Status.Phase, tc.expectPhase)
+			}
+			if tc.expectPhase != api.PodRunning {
+				return
+			}
+			for _, c := range p.Spec.Containers {
+				if c.ContainerId == "" {
+					t.Errorf("container %s has no container id", c.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestPodBoundByScheduler(t *testing.T) {
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Uid: uuid.New()},
+		Nodename:   "test-node",
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}},
+	}
+	rt := &runtime.FakeRuntime{}
+	k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: "http://localhost:8080", NodeName: "test-node"}, rt)
+	// binding shows up as a modification of a pod the kubelet has never seen
+	k.handlePodEvent(t.Context(), watch.WatchEvent{EventType: watch.Modified, Pod: pod})
+	waitFor(t, "the bound pod to run", func() bool { return hasPhase(k, pod.Uid, api.PodRunning) })
+	k.handlePodEvent(t.Context(), watch.WatchEvent{EventType: watch.Modified, Pod: pod})
+	time.Sleep(50 * time.Millisecond)
+
+	if !slices.Equal(rt.StartedContainers, []string{"app"}) {
+		t.Errorf("started containers %v, expected [app]", rt.StartedContainers)
+	}
+}
+
+func TestPodDelete(t *testing.T) {
+	confirmed := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			confirmed <- r
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	newPod := func() api.Pod {
+		return api.Pod{
+			ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New()},
+			Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}},
+		}
+	}
+	deleting := func(p api.Pod, grace int64) api.Pod {
+		now := time.Now()
+		p.DeletionTimestamp = &now
+		p.DeletionGracePeriodSeconds = &grace
+		return p
+	}
+
+	t.Run("graceful delete stops containers and confirms", func(t *testing.T) {
+		rt := &runtime.FakeRuntime{}
+		k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: srv.URL, NodeName: "test-node"}, rt)
+		pod := newPod()
+		k.handlePodEvent(t.Context(), watch.WatchEvent{EventType: watch.Add, Pod: pod})
+		waitFor(t, "the pod to run", func() bool { return hasPhase(k, pod.Uid, api.PodRunning) })
+		k.handlePodEvent(t.Context(), watch.WatchEvent{EventType: watch.Modified, Pod: deleting(pod, 5)})
+
+		select {
+		case r := <-confirmed:
+			q := r.URL.Query()
+			if r.URL.Path != "/api/v1/pods/default/web" || q.Get("gracePeriodSeconds") != "0" || q.Get("uid") != pod.Uid.String() {
+				t.Errorf("unexpected confirmation %s", r.URL)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("pod deletion never confirmed")
+		}
+		if len(rt.StoppedPods) != 1 || rt.StoppedPods[0].GracePeriod != 5*time.Second {
+			t.Errorf("stopped pods %+v, expected one with a 5s grace period", rt.StoppedPods)
+		}
+		if len(rt.DeletedPods) != 1 {
+			t.Errorf("expected containers to be removed, got %d deleted pods", len(rt.DeletedPods))
+		}
+		if _, err := k.GetPod(pod.Uid); err == nil {
+			t.Errorf("pod still tracked after termination")
+		}
+
+		// the final delete event for a pod that's already cleaned up is a no-op
+		k.handlePodEvent(t.Context(), watch.WatchEvent{EventType: watch.Delete, Pod: pod})
+		time.Sleep(50 * time.Millisecond)
+		if len(rt.StoppedPods) != 1 {
+			t.Errorf("pod stopped again on delete event")
+		}
+	})
+
+	t.Run("force delete stops containers right away", func(t *testing.T) {
+		rt := &runtime.FakeRuntime{}
+		k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: srv.URL, NodeName: "test-node"}, rt)
+		pod := newPod()
+		k.handlePodEvent(t.Context(), watch.WatchEvent{EventType: watch.Add, Pod: pod})
+		waitFor(t, "the pod to run", func() bool { return hasPhase(k, pod.Uid, api.PodRunning) })
+		k.handlePodEvent(t.Context(), watch.WatchEvent{EventType: watch.Delete, Pod: pod})
+		waitFor(t, "the pod to terminate", func() bool {
+			_, err := k.GetPod(pod.Uid)
+			return err != nil
+		})
+		if len(rt.StoppedPods) != 1 || rt.StoppedPods[0].GracePeriod != 0 {
+			t.Errorf("stopped pods %+v, expected one with no grace period", rt.StoppedPods)
+		}
+		select {
+		case r := <-confirmed:
+			t.Errorf("force deleted pod shouldn't be confirmed, got %s", r.URL)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package kubelet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+)
+
+const (
+	// pods a node takes unless configured otherwise
+	defaultMaxPods = 110
+	// how often the kubelet heartbeats, a node that misses enough of these is considered gone
+	nodeStatusUpdateFrequency = 10 * time.Second
+)
+
+// registerNode creates the kubelet's node in the apiserver.
+// A node that's already registered, say because the kubelet restarted, gets the kubelet's labels merged in
+// and its status refreshed. Labels set by anyone else are left alone.
+func (k *Kubelet) registerNode(ctx context.Context) error {
+	status, err := k.nodeStatus(ctx, api.NodeStatus{})
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{
+		api.LabelHostname: k.nodeName,
+		api.LabelOS:       status.NodeInfo.OperatingSystem,
+		api.LabelArch:     status.NodeInfo.Architecture,
+	}
+	for key, v := range k.nodeLabels {
+		labels[key] = v
+	}
+	node := api.Node{
+		ObjectMeta: api.ObjectMeta{Name: k.nodeName, Labels: labels},
+		Status:     status,
+	}
+	err = k.client.CreateNode(ctx, node)
+	if errors.Is(err, client.ErrConflict) {
+		slog.Info("node already registered, updating it", "node", k.nodeName)
+		err = k.updateRegisteredNode(ctx, labels)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to register node %s: %v", k.nodeName, err)
+	}
+	slog.Info("registered node", "node", k.nodeName, "capacity", status.Capacity)
+	return nil
+}
+
+func (k *Kubelet) updateRegisteredNode(ctx context.Context, labels map[string]string) error {
+	node, err := k.client.GetNode(ctx, k.nodeName)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for key, v := range labels {
+		if node.Labels[key] != v {
+			if node.Labels == nil {
+				node.Labels = map[string]string{}
+			}
+			node.Labels[key] = v
+			changed = true
+		}
+	}
+	if changed {
+		// the node's resourceVersion makes this fail rather than overwrite someone else's change
+		if err := k.client.UpdateNode(ctx, node); err != nil {
+			return err
+		}
+	}
+	status, err := k.nodeStatus(ctx, node.Status)
+	if err != nil {
+		return err
+	}
+	return k.client.UpdateNodeStatus(ctx, api.Node{ObjectMeta: api.ObjectMeta{Name: k.nodeName}, Status: status})
+}
+
+// nodeStatusLoop heartbeats by reporting the node's status every nodeStatusUpdateFrequency
+func (k *Kubelet) nodeStatusLoop(ctx context.Context) {
+	ticker := time.NewTicker(nodeStatusUpdateFrequency)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("nodeStatusLoop stopped due to context cancellation")
+			return
+		case <-ticker.C:
+			k.updateNodeStatus(ctx)
+		}
+	}
+}
+
+// updateNodeStatus reads the node back first, the node lifecycle controller may have changed its conditions since the last heartbeat
+func (k *Kubelet) updateNodeStatus(ctx context.Context) {
+	node, err := k.client.GetNode(ctx, k.nodeName)
+	if err == nil {
+		var status api.NodeStatus
+		status, err = k.nodeStatus(ctx, node.Status)
+		if err != nil {
+			slog.Error("failed to get node status", "node", k.nodeName, "error", err)
+			return
+		}
+		err = k.client.UpdateNodeStatus(ctx, api.Node{ObjectMeta: api.ObjectMeta{Name: k.nodeName}, Status: status})
+	}
+	if errors.Is(err, client.ErrNotFound) {
+		// someone deleted the node, put it back
+		slog.Warn("node is gone from the apiserver, registering again", "node", k.nodeName)
+		if err := k.registerNode(ctx); err != nil {
+			slog.Error("failed to register node", "node", k.nodeName, "error", err)
+		}
+		return
+	}
+	if err != nil {
+		slog.Error("failed to update node status", "node", k.nodeName, "error", err)
+	}
+}
+
+// nodeStatus describes the node as it is now, the Ready condition doubles as the heartbeat.
+// stored is the status the apiserver has, Ready's transition time only moves when its status differs from that one.
+func (k *Kubelet) nodeStatus(ctx context.Context, stored api.NodeStatus) (api.NodeStatus, error) {
+	info, err := k.containerruntime.Info(ctx)
+	if err != nil {
+		return api.NodeStatus{}, err
+	}
+	now := k.now().UTC()
+	ready := api.NodeCondition{
+		Type:              api.NodeReady,
+		Status:            api.ConditionTrue,
+		LastHeartbeatTime: now,
+		Reason:            "KubeletReady",
+		Message:           "kubelet is posting ready status",
+	}
+	if err := k.containerruntime.Ping(ctx); err != nil {
+		ready.Status = api.ConditionFalse
+		ready.Reason = "RuntimeUnreachable"
+		ready.Message = err.Error()
+	}
+	ready.LastTransitionTime = now
+	if prev := stored.GetCondition(api.NodeReady); prev != nil && prev.Status == ready.Status {
+		ready.LastTransitionTime = prev.LastTransitionTime
+	}
+	return api.NodeStatus{
+		Capacity: api.ResourceList{
+			MilliCPU: int64(info.NumCPU) * 1000,
+			Memory:   info.MemoryBytes,
+			Pods:     k.maxPods,
+		},
+		Conditions: []api.NodeCondition{ready},
+		Addresses:  nodeAddresses(k.nodeName),
+		DaemonEndpoints: api.NodeDaemonEndpoints{
+			KubeletEndpoint: api.DaemonEndpoint{Port: k.port},
+		},
+		NodeInfo: api.NodeSystemInfo{
+			OperatingSystem:         info.OperatingSystem,
+			Architecture:            info.Architecture,
+			KernelVersion:           info.KernelVersion,
+			ContainerRuntimeVersion: info.Version,
+		},
+	}, nil
+}
+
+// nodeAddresses reports the machine's hostname and first non loopback IPv4 address
+func nodeAddresses(nodeName string) []api.NodeAddress {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = nodeName
+	}
+	addrs := []api.NodeAddress{{Type: api.NodeHostName, Address: hostname}}
+	ifaddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		slog.Warn("failed to list interface addresses", "error", err)
+		return addrs
+	}
+	for _, a := range ifaddrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.To4() == nil {
+			continue
+		}
+		return append(addrs, api.NodeAddress{Type: api.NodeInternalIP, Address: ipnet.IP.String()})
+	}
+	return addrs
+}
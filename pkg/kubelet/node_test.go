@@ -0,0 +1,146 @@
+package kubelet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/kubelet/runtime"
+)
+
+// fakeNodeServer answers the node endpoints the kubelet uses the way the apiserver does
+type fakeNodeServer struct {
+	mu       sync.Mutex
+	nodes    map[string]api.Node
+	creates  int
+	updates  int
+	statuses int
+}
+
+func (s *fakeNodeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n api.Node
+	json.NewDecoder(r.Body).Decode(&n)
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/nodes/"):
+		old, ok := s.nodes[strings.TrimPrefix(r.URL.Path, "/api/v1/nodes/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(old)
+	case r.Method == http.MethodPut && r.URL.Path == "/api/v1/nodes/"+n.Name:
+		s.updates++
+		old, ok := s.nodes[n.Name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n.Status = old.Status
+		s.nodes[n.Name] = n
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/nodes":
+		s.creates++
+		if _, ok := s.nodes[n.Name]; ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.nodes[n.Name] = n
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && r.URL.Path == "/api/v1/nodes/"+n.Name+"/status":
+		s.statuses++
+		old, ok := s.nodes[n.Name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		old.Status = n.Status
+		s.nodes[n.Name] = old
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestRegisterNode(t *testing.T) {
+	fake := &fakeNodeServer{nodes: map[string]api.Node{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	opts := KubeletOpts{APIServerURL: srv.URL, NodeName: "test-node", NodeLabels: map[string]string{"disk": "ssd"}}
+	k := NewKubeletWithRuntime(opts, &runtime.FakeRuntime{})
+
+	if err := k.registerNode(t.Context()); err != nil {
+		t.Fatalf("failed to register node: %v", err)
+	}
+	n := fake.nodes["test-node"]
+	if n.Labels["disk"] != "ssd" || n.Labels[api.LabelHostname] != "test-node" || n.Labels[api.LabelArch] != "amd64" {
+		t.Errorf("unexpected node labels %v", n.Labels)
+	}
+	want := api.ResourceList{MilliCPU: 4000, Memory: 8 << 30, Pods: defaultMaxPods}
+	if n.Status.Capacity != want {
+		t.Errorf("capacity %+v, expected %+v", n.Status.Capacity, want)
+	}
+	if n.Status.NodeInfo.ContainerRuntimeVersion != "fake://0.0.0" || len(n.Status.Addresses) == 0 {
+		t.Errorf("unexpected node status %+v", n.Status)
+	}
+	ready := n.Status.GetCondition(api.NodeReady)
+	if ready == nil || ready.Status != api.ConditionTrue {
+		t.Fatalf("expected node to be Ready, got %+v", ready)
+	}
+
+	// a restarted kubelet finds its node already there and refreshes the status
+	k = NewKubeletWithRuntime(opts, &runtime.FakeRuntime{})
+	if err := k.registerNode(t.Context()); err != nil {
+		t.Fatalf("failed to register node again: %v", err)
+	}
+	if fake.creates != 2 || fake.updates != 0 || fake.statuses != 1 {
+		t.Errorf("expected 2 creates, no update and 1 status update, got %d, %d and %d", fake.creates, fake.updates, fake.statuses)
+	}
+
+	// changed labels are merged into the registered node, ones set by someone else stay
+	n = fake.nodes["test-node"]
+	n.Labels["team"] = "infra"
+	fake.nodes["test-node"] = n
+	opts.NodeLabels = map[string]string{"disk": "nvme"}
+	k = NewKubeletWithRuntime(opts, &runtime.FakeRuntime{})
+	if err := k.registerNode(t.Context()); err != nil {
+		t.Fatalf("failed to register node with new labels: %v", err)
+	}
+	if labels := fake.nodes["test-node"].Labels; fake.updates != 1 || labels["disk"] != "nvme" || labels["team"] != "infra" || labels[api.LabelHostname] != "test-node" {
+		t.Errorf("got labels %v after %d updates, expected disk=nvme merged in", labels, fake.updates)
+	}
+
+	// heartbeats keep the transition time but move the heartbeat time along
+	registered := fake.nodes["test-node"].Status.Conditions[0]
+	now := registered.LastHeartbeatTime.Add(time.Minute)
+	k.now = func() time.Time { return now }
+	k.updateNodeStatus(t.Context())
+	heartbeat := fake.nodes["test-node"].Status.Conditions[0]
+	if !heartbeat.LastTransitionTime.Equal(registered.LastTransitionTime) || !heartbeat.LastHeartbeatTime.Equal(now) {
+		t.Errorf("unexpected heartbeat %+v after %+v", heartbeat, registered)
+	}
+
+	// the node lifecycle controller marking the node Unknown makes the next heartbeat a transition
+	n = fake.nodes["test-node"]
+	n.Status.Conditions[0].Status = api.ConditionUnknown
+	fake.nodes["test-node"] = n
+	now = now.Add(time.Minute)
+	k.updateNodeStatus(t.Context())
+	recovered := fake.nodes["test-node"].Status.Conditions[0]
+	if recovered.Status != api.ConditionTrue || !recovered.LastTransitionTime.Equal(now) {
+		t.Errorf("unexpected condition %+v after being Unknown, expected a new transition time", recovered)
+	}
+
+	// a node deleted out from under the kubelet is registered again on the next heartbeat
+	delete(fake.nodes, "test-node")
+	k.updateNodeStatus(t.Context())
+	if _, ok := fake.nodes["test-node"]; !ok {
+		t.Errorf("node wasn't registered again after being deleted")
+	}
+}
@@ -0,0 +1,293 @@
+package kubelet
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+)
+
+type probeType string
+
+const (
+	liveness  probeType = "liveness"
+	readiness probeType = "readiness"
+	startup   probeType = "startup"
+)
+
+type probeKey struct {
+	uid       uuid.UUID
+	container string
+	probeType probeType
+}
+
+// proberManager keeps track of a worker per probe of every pod on the node and of the probes' latest results.
+// Workers run on their own goroutines, results are read by status syncs.
+type proberManager struct {
+	mu      sync.Mutex
+	workers map[probeKey]*probeWorker
+	results map[probeKey]bool
+	// probes go straight to the pod's IP, certificates of HTTPS probes aren't checked
+	client *http.Client
+}
+
+func newProberManager() *proberManager {
+	return &proberManager{
+		workers: map[probeKey]*probeWorker{},
+		results: map[probeKey]bool{},
+		client: &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		}},
+	}
+}
+
+// probeWorker runs one probe of one container every period
+type probeWorker struct {
+	key   probeKey
+	probe api.Probe
+	stop  chan struct{}
+	// results in a row, only touched by the worker's own goroutine
+	successes, failures int32
+	// the run of the container the counts are for, they start over when it's restarted
+	restartCount int32
+	startedAt    time.Time
+}
+
+// result is the probe's latest result. Until a probe has one, liveness passes and readiness and startup don't.
+func (m *proberManager) result(key probeKey) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.results[key]
+	if !ok {
+		return key.probeType == liveness
+	}
+	return r
+}
+
+// setResult records a probe result, reporting whether it changed.
+// Results of workers that were removed in the meantime are dropped.
+func (m *proberManager) setResult(key probeKey, r bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.workers[key] == nil {
+		return false
+	}
+	old, ok := m.results[key]
+	if !ok {
+		old = key.probeType == liveness
+	}
+	m.results[key] = r
+	return old != r
+}
+
+// readiness reports which of p's containers with a readiness or startup probe pass them, keyed by container name.
+// Containers without either are left out, they're ready as soon as they run.
+func (m *proberManager) readiness(p api.Pod) map[string]bool {
+	ready := map[string]bool{}
+	for _, c := range p.Spec.Containers {
+		if c.ReadinessProbe == nil && c.StartupProbe == nil {
+			continue
+		}
+		ready[c.Name] = (c.StartupProbe == nil || m.result(probeKey{p.Uid, c.Name, startup})) &&
+			(c.ReadinessProbe == nil || m.result(probeKey{p.Uid, c.Name, readiness}))
+	}
+	return ready
+}
+
+// remove stops the workers of a pod and forgets their results
+func (m *proberManager) remove(uid uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, w := range m.workers {
+		if key.uid == uid {
+			close(w.stop)
+			delete(m.workers, key)
+		}
+	}
+	for key := range m.results {
+		if key.uid == uid {
+			delete(m.results, key)
+		}
+	}
+}
+
+// startProbes starts a worker for every probe of p's containers
+func (k *Kubelet) startProbes(ctx context.Context, p api.Pod) {
+	k.prober.mu.Lock()
+	defer k.prober.mu.Unlock()
+	for _, c := range p.Spec.Containers {
+		probes := map[probeType]*api.Probe{liveness: c.LivenessProbe, readiness: c.ReadinessProbe, startup: c.StartupProbe}
+		for t, probe := range probes {
+			key := probeKey{uid: p.Uid, container: c.Name, probeType: t}
+			if probe == nil || k.prober.workers[key] != nil {
+				continue
+			}
+			w := &probeWorker{key: key, probe: *probe, stop: make(chan struct{}), restartCount: -1}
+			k.prober.workers[key] = w
+			go k.runProbeWorker(ctx, w)
+		}
+	}
+}
+
+func (k *Kubelet) runProbeWorker(ctx context.Context, w *probeWorker) {
+	ticker := time.NewTicker(time.Duration(w.probe.PeriodSeconds) * time.Second)
+	defer ticker.Stop()
+	for k.doProbe(ctx, w) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// doProbe runs the worker's probe once if the container is in a state to be probed and acts on the result:
+// a changed readiness or startup result gets the pod's status synced, failed liveness and startup probes get the container killed.
+// It reports whether the worker should carry on.
+func (k *Kubelet) doProbe(ctx context.Context, w *probeWorker) bool {
+	p, err := k.GetPod(w.key.uid)
+	if err != nil {
+		return false
+	}
+	var c api.Container
+	for _, spec := range p.Spec.Containers {
+		if spec.Name == w.key.container {
+			c = spec
+		}
+	}
+	var cs *api.ContainerStatus
+	for i := range p.Status.ContainerStatuses {
+		if p.Status.ContainerStatuses[i].Name == c.Name {
+			cs = &p.Status.ContainerStatuses[i]
+		}
+	}
+	if cs == nil || cs.State.Running == nil {
+		// nothing to probe until the container runs again
+		w.successes, w.failures = 0, 0
+		k.setProbeResult(ctx, w.key, w.key.probeType == liveness)
+		return true
+	}
+	if cs.RestartCount != w.restartCount || !cs.State.Running.StartedAt.Equal(w.startedAt) {
+		w.restartCount, w.startedAt = cs.RestartCount, cs.State.Running.StartedAt
+		w.successes, w.failures = 0, 0
+		k.setProbeResult(ctx, w.key, w.key.probeType == liveness)
+	}
+	switch {
+	case w.key.probeType == startup && k.prober.result(w.key):
+		// a startup probe is done once it passed
+		return true
+	case w.key.probeType != startup && c.StartupProbe != nil && !k.prober.result(probeKey{p.Uid, c.Name, startup}):
+		return true
+	case k.now().Sub(cs.State.Running.StartedAt) < time.Duration(w.probe.InitialDelaySeconds)*time.Second:
+		return true
+	}
+
+	ok, msg := k.runProbe(ctx, p, w.probe, c.Name)
+	if ok {
+		w.successes, w.failures = w.successes+1, 0
+	} else {
+		w.successes, w.failures = 0, w.failures+1
+		slog.Debug("probe failed", "pod", p.Name, "container", c.Name, "probe", w.key.probeType, "message", msg)
+	}
+	switch {
+	case ok && w.successes >= w.probe.SuccessThreshold:
+		k.setProbeResult(ctx, w.key, true)
+	case !ok && w.failures >= w.probe.FailureThreshold:
+		k.setProbeResult(ctx, w.key, false)
+		if w.key.probeType == readiness {
+			break
+		}
+		slog.Info("container failed its probe, killing it", "pod", p.Name, "container", c.Name, "probe", w.key.probeType, "message", msg)
+		w.failures = 0
+		k.killContainer(ctx, p, c.Name)
+	}
+	return true
+}
+
+// setProbeResult records a result, syncing the pod's status when its readiness may have changed
+func (k *Kubelet) setProbeResult(ctx context.Context, key probeKey, r bool) {
+	if k.prober.setResult(key, r) && key.probeType != liveness {
+		k.syncPodStatus(ctx, key.uid)
+	}
+}
+
+// killContainer stops a container with the pod's grace period, the restart policy decides whether it's started again
+func (k *Kubelet) killContainer(ctx context.Context, p api.Pod, name string) {
+	var gracePeriod time.Duration
+	if p.Spec.TerminationGracePeriodSeconds != nil {
+		gracePeriod = time.Duration(*p.Spec.TerminationGracePeriodSeconds) * time.Second
+	}
+	if err := k.containerruntime.StopContainer(ctx, p, name, gracePeriod); err != nil {
+		slog.Error("failed to kill container", "pod", p.Name, "container", name, "error", err)
+		return
+	}
+	k.syncPodStatus(ctx, p.Uid)
+}
+
+// runProbe runs probe against p once, returning whether it passed and why not
+func (k *Kubelet) runProbe(ctx context.Context, p api.Pod, probe api.Probe, container string) (bool, string) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(probe.TimeoutSeconds)*time.Second)
+	defer cancel()
+	switch {
+	case probe.Exec != nil:
+		code, err := k.containerruntime.ExecSync(ctx, p, container, probe.Exec.Command)
+		if err != nil {
+			return false, err.Error()
+		}
+		return code == 0, fmt.Sprintf("command exited with %d", code)
+	case probe.HTTPGet != nil:
+		h := probe.HTTPGet
+		host, err := probeHost(h.Host, p)
+		if err != nil {
+			return false, err.Error()
+		}
+		url := fmt.Sprintf("%s://%s%s", strings.ToLower(string(h.Scheme)), net.JoinHostPort(host, strconv.Itoa(int(h.Port))), h.Path)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false, err.Error()
+		}
+		resp, err := k.prober.client.Do(req)
+		if err != nil {
+			return false, err.Error()
+		}
+		resp.Body.Close()
+		return resp.StatusCode >= 200 && resp.StatusCode < 400, fmt.Sprintf("GET %s returned %d", url, resp.StatusCode)
+	case probe.TCPSocket != nil:
+		host, err := probeHost(probe.TCPSocket.Host, p)
+		if err != nil {
+			return false, err.Error()
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(int(probe.TCPSocket.Port))))
+		if err != nil {
+			return false, err.Error()
+		}
+		conn.Close()
+		return true, ""
+	}
+	return false, "probe has no handler"
+}
+
+// probeHost is where a network probe goes, the pod's IP unless the probe names a host
+func probeHost(host string, p api.Pod) (string, error) {
+	if host != "" {
+		return host, nil
+	}
+	if p.Status.PodIP == "" {
+		return "", fmt.Errorf("pod has no IP yet")
+	}
+	return p.Status.PodIP, nil
+}
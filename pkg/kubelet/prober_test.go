@@ -0,0 +1,178 @@
+package kubelet
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/kubelet/runtime"
+)
+
+// newProbeKubelet tracks a pod with a single running container named app, statuses are reported to a server that accepts anything
+func newProbeKubelet(t *testing.T, rt *runtime.FakeRuntime, c api.Container) (*Kubelet, api.Pod) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	rt.StartedContainers = []string{"app"}
+	k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: srv.URL, NodeName: "test-node"}, rt)
+	c.Name = "app"
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New()},
+		Spec:       api.PodSpec{Containers: []api.Container{c}, RestartPolicy: api.RestartPolicyAlways},
+	}
+	k.AddPod(pod)
+	k.syncPodStatus(t.Context(), pod.Uid)
+	return k, pod
+}
+
+// newTestWorker registers a worker without starting it, tests run its probes by hand
+func newTestWorker(k *Kubelet, p api.Pod, t probeType, probe *api.Probe) *probeWorker {
+	w := &probeWorker{key: probeKey{uid: p.Uid, container: "app", probeType: t}, probe: *probe, stop: make(chan struct{}), restartCount: -1}
+	k.prober.mu.Lock()
+	k.prober.workers[w.key] = w
+	k.prober.mu.Unlock()
+	return w
+}
+
+func hostPort(t *testing.T, addr string) (string, int32) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("bad address %q: %v", addr, err)
+	}
+	n, _ := strconv.Atoi(port)
+	return host, int32(n)
+}
+
+func isPodReady(t *testing.T, k *Kubelet, uid uuid.UUID) bool {
+	p, err := k.GetPod(uid)
+	if err != nil {
+		t.Fatalf("pod not tracked: %v", err)
+	}
+	ready := p.Status.GetCondition(api.PodReady)
+	return ready != nil && ready.Status == api.ConditionTrue
+}
+
+func TestReadinessProbe(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			t.Errorf("probe went to %s", r.URL.Path)
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer target.Close()
+	u, _ := url.Parse(target.URL)
+	host, port := hostPort(t, u.Host)
+	probe := &api.Probe{
+		HTTPGet:          &api.HTTPGetAction{Host: host, Port: port, Path: "/healthz", Scheme: api.URISchemeHTTP},
+		TimeoutSeconds:   1,
+		SuccessThreshold: 1,
+		FailureThreshold: 2,
+	}
+	k, pod := newProbeKubelet(t, &runtime.FakeRuntime{}, api.Container{ReadinessProbe: probe})
+	if isPodReady(t, k, pod.Uid) {
+		t.Fatal("pod ready before its readiness probe passed")
+	}
+
+	w := newTestWorker(k, pod, readiness, probe)
+	k.doProbe(t.Context(), w)
+	if !isPodReady(t, k, pod.Uid) {
+		t.Fatal("pod not ready after its readiness probe passed")
+	}
+
+	// it takes failureThreshold failures in a row to turn unready
+	status.Store(http.StatusServiceUnavailable)
+	k.doProbe(t.Context(), w)
+	if !isPodReady(t, k, pod.Uid) {
+		t.Fatal("pod turned unready on the first failure")
+	}
+	k.doProbe(t.Context(), w)
+	if isPodReady(t, k, pod.Uid) {
+		t.Fatal("pod still ready after failing its readiness probe")
+	}
+}
+
+func TestLivenessProbe(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	host, port := hostPort(t, lis.Addr().String())
+	probe := &api.Probe{
+		TCPSocket:        &api.TCPSocketAction{Host: host, Port: port},
+		TimeoutSeconds:   1,
+		SuccessThreshold: 1,
+		FailureThreshold: 1,
+	}
+	rt := &runtime.FakeRuntime{}
+	k, pod := newProbeKubelet(t, rt, api.Container{LivenessProbe: probe})
+	w := newTestWorker(k, pod, liveness, probe)
+
+	k.doProbe(t.Context(), w)
+	if len(rt.StoppedContainers) != 0 {
+		t.Fatalf("container killed while passing its liveness probe")
+	}
+
+	// a failed liveness probe kills the container and the restart policy brings it back
+	lis.Close()
+	k.doProbe(t.Context(), w)
+	if !slices.Equal(rt.StoppedContainers, []string{"app"}) || !slices.Equal(rt.RestartedContainers, []string{"app"}) {
+		t.Fatalf("expected app to be killed and restarted, stopped %v and restarted %v", rt.StoppedContainers, rt.RestartedContainers)
+	}
+	p, _ := k.GetPod(pod.Uid)
+	if cs := p.Status.ContainerStatuses[0]; cs.RestartCount != 1 || cs.State.Running == nil {
+		t.Errorf("expected the container running after one restart, got %+v", cs)
+	}
+	if !isPodReady(t, k, pod.Uid) {
+		t.Error("liveness probe shouldn't affect readiness")
+	}
+}
+
+func TestStartupProbe(t *testing.T) {
+	startupProbe := &api.Probe{Exec: &api.ExecAction{Command: []string{"pg_isready"}}, TimeoutSeconds: 1, SuccessThreshold: 1, FailureThreshold: 3}
+	readinessProbe := &api.Probe{TCPSocket: &api.TCPSocketAction{Host: "127.0.0.1", Port: 1}, TimeoutSeconds: 1, SuccessThreshold: 1, FailureThreshold: 1}
+	rt := &runtime.FakeRuntime{ExecExitCodes: map[string]int{"app": 1}}
+	k, pod := newProbeKubelet(t, rt, api.Container{StartupProbe: startupProbe, ReadinessProbe: readinessProbe})
+	startupWorker := newTestWorker(k, pod, startup, startupProbe)
+	readinessWorker := newTestWorker(k, pod, readiness, readinessProbe)
+
+	// readiness waits for the startup probe, even though it would fail
+	k.doProbe(t.Context(), readinessWorker)
+	if readinessWorker.failures != 0 {
+		t.Fatal("readiness probe ran before the startup probe passed")
+	}
+	k.doProbe(t.Context(), startupWorker)
+	if startupWorker.failures != 1 || len(rt.StoppedContainers) != 0 {
+		t.Fatalf("expected one startup failure and no kill, got %d failures and stopped %v", startupWorker.failures, rt.StoppedContainers)
+	}
+
+	rt.ExecExitCodes["app"] = 0
+	k.doProbe(t.Context(), startupWorker)
+	if !k.prober.result(startupWorker.key) {
+		t.Fatal("startup probe didn't pass")
+	}
+	k.doProbe(t.Context(), readinessWorker)
+	if readinessWorker.failures != 1 || isPodReady(t, k, pod.Uid) {
+		t.Errorf("expected the readiness probe to run and fail once startup passed")
+	}
+
+	// a startup probe that keeps failing gets the container killed
+	k2, pod2 := newProbeKubelet(t, &runtime.FakeRuntime{ExecExitCodes: map[string]int{"app": 1}}, api.Container{StartupProbe: startupProbe})
+	w := newTestWorker(k2, pod2, startup, startupProbe)
+	for range 3 {
+		k2.doProbe(t.Context(), w)
+	}
+	if rt2 := k2.containerruntime.(*runtime.FakeRuntime); !slices.Equal(rt2.StoppedContainers, []string{"app"}) {
+		t.Errorf("expected app to be killed after failing its startup probe, stopped %v", rt2.StoppedContainers)
+	}
+}
@@ -0,0 +1,140 @@
+package kubelet
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/kubelet/runtime"
+)
+
+const (
+	// an exited container is restarted straight away the first time, after that it waits this long, doubling up to maxRestartBackoff
+	initialRestartBackoff = 10 * time.Second
+	maxRestartBackoff     = 5 * time.Minute
+	// a container that ran this long before it exited starts over with no backoff
+	backoffResetAfter = 2 * maxRestartBackoff
+	// waiting reason of containers the kubelet is backing off from restarting
+	crashLoopBackOff = "CrashLoopBackOff"
+)
+
+type containerKey struct {
+	uid  uuid.UUID
+	name string
+}
+
+type containerBackoff struct {
+	// how long the last restart made the next one wait
+	delay time.Duration
+	// the container isn't restarted again before this
+	next time.Time
+	// a sync is already set up for when next comes around
+	retryScheduled bool
+}
+
+// exitLoop syncs a pod as soon as one of its containers exits, rather than on the next statusLoop tick
+func (k *Kubelet) exitLoop(ctx context.Context, exits <-chan runtime.ContainerExit) {
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("exitLoop stopped due to context cancellation")
+			return
+		case exit, ok := <-exits:
+			if !ok {
+				return
+			}
+			slog.Info("container exited", "pod", exit.PodUid, "container", exit.Name, "exitCode", exit.ExitCode)
+			k.syncPodStatus(ctx, exit.PodUid)
+		}
+	}
+}
+
+// restartContainers starts exited app containers of p again when its restart policy asks for it and their backoff is up.
+// It reports whether it restarted any, rs is stale if it did.
+func (k *Kubelet) restartContainers(ctx context.Context, p api.Pod, rs runtime.PodStatus) bool {
+	restarted := false
+	for _, c := range p.Spec.Containers {
+		s, ok := rs.Containers[c.Name]
+		if !ok || s.State.Terminated == nil || !p.Spec.RestartPolicy.ShouldRestart(s.State.Terminated.ExitCode) {
+			continue
+		}
+		if !k.takeRestart(ctx, p.Uid, c.Name, *s.State.Terminated) {
+			continue
+		}
+		slog.Info("restarting container", "pod", p.Name, "container", c.Name, "exitCode", s.State.Terminated.ExitCode)
+		if err := k.containerruntime.StartContainer(ctx, p, c.Name); err != nil {
+			slog.Error("failed to restart container", "pod", p.Name, "container", c.Name, "error", err)
+			continue
+		}
+		k.recordRestart(p.Uid, c.Name, s.State)
+		restarted = true
+	}
+	return restarted
+}
+
+// takeRestart reports whether the container can be restarted now, pushing its backoff out if so.
+// Otherwise the pod is synced again once the backoff is up. Containers of pods being stopped aren't restarted.
+func (k *Kubelet) takeRestart(ctx context.Context, uid uuid.UUID, name string, exited api.ContainerStateTerminated) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.pods[uid]; !ok || k.terminating[uid] {
+		return false
+	}
+	now := k.now()
+	key := containerKey{uid: uid, name: name}
+	b, ok := k.backoffs[key]
+	if ok && exited.FinishedAt.Sub(exited.StartedAt) >= backoffResetAfter {
+		ok = false
+	}
+	if ok && now.Before(b.next) {
+		if !b.retryScheduled {
+			b.retryScheduled = true
+			k.backoffs[key] = b
+			time.AfterFunc(b.next.Sub(now), func() {
+				if ctx.Err() == nil {
+					k.syncPodStatus(ctx, uid)
+				}
+			})
+		}
+		return false
+	}
+	delay := initialRestartBackoff
+	if ok {
+		delay = min(2*b.delay, maxRestartBackoff)
+	}
+	k.backoffs[key] = containerBackoff{delay: delay, next: now.Add(delay)}
+	return true
+}
+
+// recordRestart counts the restart on the tracked pod's status, generatePodStatus carries it over from there
+func (k *Kubelet) recordRestart(uid uuid.UUID, name string, exited api.ContainerState) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	p, ok := k.pods[uid]
+	if !ok {
+		return
+	}
+	statuses := slices.Clone(p.Status.ContainerStatuses)
+	i := slices.IndexFunc(statuses, func(cs api.ContainerStatus) bool { return cs.Name == name })
+	if i < 0 {
+		statuses = append(statuses, api.ContainerStatus{Name: name})
+		i = len(statuses) - 1
+	}
+	statuses[i].RestartCount++
+	statuses[i].LastTerminationState = exited
+	p.Status.ContainerStatuses = statuses
+	k.pods[uid] = p
+}
+
+// forgetBackoffs drops the restart backoffs of a pod that's gone, k.mu has to be held
+func (k *Kubelet) forgetBackoffs(uid uuid.UUID) {
+	for key := range k.backoffs {
+		if key.uid == uid {
+			delete(k.backoffs, key)
+		}
+	}
+}
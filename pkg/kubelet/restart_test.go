@@ -0,0 +1,121 @@
+package kubelet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/kubelet/runtime"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	rt := &runtime.FakeRuntime{StartedContainers: []string{"app"}}
+	k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: srv.URL, NodeName: "test-node"}, rt)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	k.now = func() time.Time { return now }
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New()},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app"}}, RestartPolicy: api.RestartPolicyAlways},
+	}
+	k.AddPod(pod)
+	status := func() api.ContainerStatus {
+		p, err := k.GetPod(pod.Uid)
+		if err != nil {
+			t.Fatalf("pod not tracked: %v", err)
+		}
+		return p.Status.ContainerStatuses[0]
+	}
+	crash := func() {
+		rt.ContainerStates = map[string]api.ContainerState{"app": exited(1)}
+		k.syncPodStatus(t.Context(), pod.Uid)
+	}
+
+	// the first crash is restarted straight away
+	crash()
+	if cs := status(); len(rt.RestartedContainers) != 1 || cs.RestartCount != 1 || cs.State.Running == nil {
+		t.Fatalf("expected one restart and the container running, got %d restarts and %+v", len(rt.RestartedContainers), cs)
+	}
+	if cs := status(); cs.LastTerminationState.Terminated == nil || cs.LastTerminationState.Terminated.ExitCode != 1 {
+		t.Errorf("expected the crash as last termination state, got %+v", cs.LastTerminationState)
+	}
+
+	// after that it waits 10s, then 20s
+	for _, wait := range []time.Duration{10 * time.Second, 20 * time.Second} {
+		crash()
+		restarts := len(rt.RestartedContainers)
+		if cs := status(); cs.State.Waiting == nil || cs.State.Waiting.Reason != crashLoopBackOff {
+			t.Fatalf("expected the container in CrashLoopBackOff, got %+v", cs.State)
+		}
+		now = now.Add(wait - time.Second)
+		k.syncPodStatus(t.Context(), pod.Uid)
+		if len(rt.RestartedContainers) != restarts {
+			t.Fatalf("restarted %s into a %s back-off", wait-time.Second, wait)
+		}
+		now = now.Add(time.Second)
+		k.syncPodStatus(t.Context(), pod.Uid)
+		if len(rt.RestartedContainers) != restarts+1 {
+			t.Fatalf("not restarted after a %s back-off", wait)
+		}
+	}
+	if cs := status(); cs.RestartCount != 3 {
+		t.Errorf("expected 3 restarts, got %d", cs.RestartCount)
+	}
+
+	// containers of a pod being stopped stay down
+	now = now.Add(maxRestartBackoff)
+	k.mu.Lock()
+	k.terminating[pod.Uid] = true
+	k.mu.Unlock()
+	crash()
+	if len(rt.RestartedContainers) != 3 {
+		t.Errorf("restarted a container of a terminating pod")
+	}
+}
+
+func TestRestartOnContainerExit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	exits := make(chan runtime.ContainerExit)
+	rt := &runtime.FakeRuntime{
+		StartedContainers: []string{"app", "sidecar"},
+		ContainerStates:   map[string]api.ContainerState{"app": exited(1), "sidecar": exited(0)},
+		Exits:             exits,
+	}
+	k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: srv.URL, NodeName: "test-node"}, rt)
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New()},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app"}, {Name: "sidecar"}}, RestartPolicy: api.RestartPolicyOnFailure},
+	}
+	k.AddPod(pod)
+	go k.exitLoop(t.Context(), exits)
+
+	exits <- runtime.ContainerExit{PodUid: pod.Uid, Name: "app", ExitCode: 1}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p, _ := k.GetPod(pod.Uid)
+		if p.Status.Phase == api.PodRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pod status never synced after the exit")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// only the failed container is restarted under OnFailure
+	if !slices.Equal(rt.RestartedContainers, []string{"app"}) {
+		t.Errorf("restarted %v, expected [app]", rt.RestartedContainers)
+	}
+}
@@ -0,0 +1,79 @@
+package kubelet
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+)
+
+// how often the pods on the node are compared against the apiserver and the runtime, whatever the watch delivered
+const resyncPeriod = 30 * time.Second
+
+// resyncLoop resyncs straight away, then every resyncPeriod.
+// Watch events only make the kubelet react sooner, missed ones are made up for by the next resync.
+func (k *Kubelet) resyncLoop(ctx context.Context) {
+	ticker := time.NewTicker(resyncPeriod)
+	defer ticker.Stop()
+	for {
+		if err := k.resync(ctx); err != nil {
+			slog.Error("failed to resync pods", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			slog.Info("resyncLoop stopped due to context cancellation")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// resync lists the pods bound to this node and the pods the runtime has containers of and diffs them with the tracked pods:
+// every bound pod gets an update, tracked pods the apiserver no longer has are terminated
+// and containers of pods nobody knows about are removed.
+func (k *Kubelet) resync(ctx context.Context) error {
+	// a pod that shows up after the list was made isn't in it, it mustn't be mistaken for a deleted one
+	tracked := map[uuid.UUID]api.Pod{}
+	for _, p := range k.ListPods() {
+		tracked[p.Uid] = p
+	}
+	list, err := k.client.ListNodePods(ctx, k.nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %v", err)
+	}
+	desired := map[uuid.UUID]bool{}
+	for _, p := range list.Items {
+		desired[p.Uid] = true
+		k.updatePod(ctx, podUpdate{pod: p})
+	}
+	for uid, p := range tracked {
+		if !desired[uid] {
+			slog.Info("pod is gone from the apiserver, terminating it", "pod", p.Name)
+			k.updatePod(ctx, podUpdate{pod: p, removed: true})
+		}
+	}
+
+	running, err := k.containerruntime.ListPods(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list pods in the runtime: %v", err)
+	}
+	for _, rp := range running {
+		// pods are tracked before their containers are created, so anything untracked by now isn't being started
+		if _, err := k.GetPod(rp.Uid); err == nil || desired[rp.Uid] {
+			continue
+		}
+		orphan := api.Pod{ObjectMeta: api.ObjectMeta{Name: rp.Name, Namespace: rp.Namespace, Uid: rp.Uid}}
+		for _, name := range rp.Containers {
+			orphan.Spec.Containers = append(orphan.Spec.Containers, api.Container{Name: name})
+		}
+		slog.Info("removing containers of unknown pod", "pod", rp.Name, "uid", rp.Uid, "containers", rp.Containers)
+		if err := k.DeletePod(ctx, orphan); err != nil {
+			slog.Error("failed to remove unknown pod", "pod", rp.Uid, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package kubelet
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+	"superminikube/pkg/kubelet/runtime"
+)
+
+func TestResync(t *testing.T) {
+	newPod := func(name, node string) api.Pod {
+		return api.Pod{
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: "default", Uid: uuid.New()},
+			Nodename:   node,
+			Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}},
+		}
+	}
+	web, db, other := newPod("web", "test-node"), newPod("db", "test-node"), newPod("other", "other-node")
+	c := &client.FakeClient{Pods: []api.Pod{web, db, other}}
+	rt := &runtime.FakeRuntime{}
+	k := NewKubeletWithRuntime(KubeletOpts{NodeName: "test-node"}, rt)
+	k.client = c
+	resync := func() {
+		t.Helper()
+		if err := k.resync(t.Context()); err != nil {
+			t.Fatalf("resync failed: %v", err)
+		}
+	}
+	created := func(uid uuid.UUID) int {
+		n := 0
+		for _, p := range rt.CreatedPods {
+			if p.Uid == uid {
+				n++
+			}
+		}
+		return n
+	}
+
+	// pods bound to the node start without any watch event
+	resync()
+	waitFor(t, "the node's pods to run", func() bool {
+		return hasPhase(k, web.Uid, api.PodRunning) && hasPhase(k, db.Uid, api.PodRunning)
+	})
+	if created(other.Uid) != 0 {
+		t.Errorf("pod of another node was started")
+	}
+
+	// nothing changed, nothing happens
+	resync()
+	time.Sleep(50 * time.Millisecond)
+	if created(web.Uid) != 1 || created(db.Uid) != 1 {
+		t.Errorf("pods created again, web %d and db %d times", created(web.Uid), created(db.Uid))
+	}
+
+	// containers that vanished from the runtime are created again
+	rt.DeletePod(t.Context(), web)
+	resync()
+	waitFor(t, "web to be recreated", func() bool { return created(web.Uid) == 2 })
+
+	// containers nobody knows about are removed
+	orphan := newPod("orphan", "test-node")
+	rt.CreatePod(t.Context(), orphan)
+	resync()
+	if pods, _ := rt.ListPods(t.Context()); slices.ContainsFunc(pods, func(rp runtime.RunningPod) bool { return rp.Uid == orphan.Uid }) {
+		t.Errorf("orphaned containers weren't removed")
+	}
+
+	// a missed force delete terminates the pod, a missed graceful one is confirmed too
+	now := time.Now()
+	grace := int64(1)
+	c.Pods[0].DeletionTimestamp, c.Pods[0].DeletionGracePeriodSeconds = &now, &grace
+	c.Pods = slices.Delete(c.Pods, 1, 2)
+	resync()
+	waitFor(t, "web and db to terminate", func() bool {
+		_, webErr := k.GetPod(web.Uid)
+		_, dbErr := k.GetPod(db.Uid)
+		return webErr != nil && dbErr != nil
+	})
+	waitFor(t, "web's deletion to be confirmed", func() bool {
+		pods, _ := c.ListPods(t.Context())
+		return len(pods.Items) == 1
+	})
+}
+
+func TestPodUpdate(t *testing.T) {
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New(), Generation: 1},
+		Nodename:   "test-node",
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx:1"}}},
+	}
+	c := &client.FakeClient{Pods: []api.Pod{pod}}
+	rt := &runtime.FakeRuntime{}
+	k := NewKubeletWithRuntime(KubeletOpts{NodeName: "test-node"}, rt)
+	k.client = c
+	created := func() []string {
+		var images []string
+		for _, p := range rt.CreatedPods {
+			images = append(images, p.Spec.Containers[0].Image)
+		}
+		return images
+	}
+
+	k.updatePod(t.Context(), podUpdate{pod: pod})
+	waitFor(t, "the pod to run", func() bool { return hasPhase(k, pod.Uid, api.PodRunning) })
+
+	// metadata changes are picked up without touching the containers
+	labelled := pod
+	labelled.Labels = map[string]string{"tier": "frontend"}
+	k.updatePod(t.Context(), podUpdate{pod: labelled})
+	waitFor(t, "the label to be tracked", func() bool {
+		p, err := k.GetPod(pod.Uid)
+		return err == nil && p.Labels["tier"] == "frontend"
+	})
+	if p, _ := k.GetPod(pod.Uid); p.Spec.Containers[0].ContainerId == "" || p.Status.Phase != api.PodRunning {
+		t.Errorf("got %+v, expected the container id and status to carry over", p)
+	}
+
+	// a changed container recreates the pod with the new spec
+	updated := labelled
+	updated.Generation = 2
+	updated.Spec = api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx:2"}}}
+	k.updatePod(t.Context(), podUpdate{pod: updated})
+	waitFor(t, "the pod to be recreated", func() bool { return slices.Equal(created(), []string{"nginx:1", "nginx:2"}) })
+	waitFor(t, "the new container to run", func() bool {
+		p, err := k.GetPod(pod.Uid)
+		return err == nil && p.Spec.Containers[0].Image == "nginx:2" && p.Status.Phase == api.PodRunning
+	})
+	if len(rt.StoppedPods) != 1 || rt.StoppedPods[0].Pod.Spec.Containers[0].Image != "nginx:1" {
+		t.Errorf("got stopped pods %+v, expected the old containers to be stopped", rt.StoppedPods)
+	}
+
+	// a resync that listed the pod before the change doesn't bring the old spec back
+	k.updatePod(t.Context(), podUpdate{pod: pod})
+	time.Sleep(50 * time.Millisecond)
+	if got := created(); len(got) != 2 {
+		t.Errorf("got pods created with %v, expected a stale update to be ignored", got)
+	}
+}
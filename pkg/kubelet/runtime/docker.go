@@ -0,0 +1,721 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/google/uuid"
+	"github.com/moby/moby/api/pkg/stdcopy"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/api/types/jsonstream"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/portforward"
+	"superminikube/pkg/remotecommand"
+)
+
+// image of the container holding a pod's namespaces, it does nothing but sleep
+const sandboxImage = "registry.k8s.io/pause:3.10"
+
+// labels set on every container of a pod, sandbox included, so a restarted kubelet can tell whose containers are running
+const (
+	PodUidLabel        = "superminikube.pod.uid"
+	PodNamespaceLabel  = "superminikube.pod.namespace"
+	PodNameLabel       = "superminikube.pod.name"
+	ContainerNameLabel = "superminikube.container.name"
+	// container name label of sandboxes
+	sandboxContainerName = "POD"
+)
+
+func (dr DockerRuntime) Ping(ctx context.Context) error {
+	if _, err := dr.containerruntime.Ping(ctx, client.PingOptions{}); err != nil {
+		return fmt.Errorf("failed to ping docker: %v", err)
+	}
+	return nil
+}
+
+func (dr DockerRuntime) Info(ctx context.Context) (Info, error) {
+	res, err := dr.containerruntime.Info(ctx, client.InfoOptions{})
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to get docker info: %v", err)
+	}
+	return Info{
+		NumCPU:          res.Info.NCPU,
+		MemoryBytes:     res.Info.MemTotal,
+		OperatingSystem: res.Info.OSType,
+		Architecture:    res.Info.Architecture,
+		KernelVersion:   res.Info.KernelVersion,
+		Version:         "docker://" + res.Info.ServerVersion,
+	}, nil
+}
+
+// DeletePod removes every container of the pod, sandbox last.
+// Containers are found by name so this works even if creation failed half way.
+func (dr DockerRuntime) DeletePod(ctx context.Context, p api.Pod) error {
+	names := make([]string, 0, len(p.Spec.InitContainers)+len(p.Spec.Containers)+1)
+	for _, c := range p.Spec.InitContainers {
+		names = append(names, containerName(p, c.Name))
+	}
+	for _, c := range p.Spec.Containers {
+		names = append(names, containerName(p, c.Name))
+	}
+	names = append(names, sandboxName(p))
+	var errs []error
+	for _, name := range names {
+		slog.Info("removing container", "container", name)
+		_, err := dr.containerruntime.ContainerRemove(ctx, name, client.ContainerRemoveOptions{
+			Force:         true,
+			RemoveVolumes: true,
+		})
+		if err != nil && !cerrdefs.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove containers in pod: %v\nerr: %v", p.Uid, errors.Join(errs...))
+	}
+	return nil
+}
+
+// StopPod stops app containers in parallel so they all share the grace period, the sandbox goes last.
+// Containers that are already gone or stopped are skipped.
+func (dr DockerRuntime) StopPod(ctx context.Context, p api.Pod, gracePeriod time.Duration) error {
+	timeout := int(gracePeriod.Seconds())
+	errs := make([]error, len(p.Spec.Containers))
+	var wg sync.WaitGroup
+	for i, c := range p.Spec.Containers {
+		wg.Go(func() {
+			errs[i] = dr.stopContainer(ctx, containerName(p, c.Name), timeout)
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to stop containers in pod: %v\nerr: %v", p.Uid, err)
+	}
+	// nothing runs in the sandbox, no point waiting on it
+	return dr.stopContainer(ctx, sandboxName(p), 0)
+}
+
+func (dr DockerRuntime) stopContainer(ctx context.Context, name string, timeout int) error {
+	slog.Info("stopping container", "container", name, "timeout", timeout)
+	_, err := dr.containerruntime.ContainerStop(ctx, name, client.ContainerStopOptions{
+		Signal:  "SIGTERM",
+		Timeout: &timeout,
+	})
+	if err != nil && !cerrdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to stop container %s: %v", name, err)
+	}
+	return nil
+}
+
+func (dr DockerRuntime) CreatePod(ctx context.Context, p api.Pod) (CreatePodResponse, error) {
+	res := CreatePodResponse{ContainerIds: map[string]string{}}
+	images := []string{sandboxImage}
+	for _, c := range p.Spec.InitContainers {
+		images = append(images, c.Image)
+	}
+	for _, c := range p.Spec.Containers {
+		images = append(images, c.Image)
+	}
+	for _, image := range images {
+		if err := dr.pullImage(ctx, image); err != nil {
+			return CreatePodResponse{}, err
+		}
+	}
+
+	sandboxOpts, err := SandboxCreateOpts(p)
+	if err != nil {
+		return CreatePodResponse{}, fmt.Errorf("failed to create sandbox opts: %v", err)
+	}
+	sandboxId, err := dr.startContainer(ctx, sandboxOpts)
+	if err != nil {
+		return CreatePodResponse{}, fmt.Errorf("failed to start sandbox: %v", err)
+	}
+	res.SandboxId = sandboxId
+
+	// init containers run one at a time, each has to exit 0 before the next starts
+	for _, c := range p.Spec.InitContainers {
+		id, err := dr.startContainer(ctx, ContainerToCreateContainerOpts(p, c, sandboxId))
+		if err != nil {
+			return CreatePodResponse{}, fmt.Errorf("failed to start init container %s: %v", c.Name, err)
+		}
+		res.ContainerIds[c.Name] = id
+		code, err := dr.waitContainer(ctx, id)
+		if err != nil {
+			return CreatePodResponse{}, fmt.Errorf("failed waiting on init container %s: %v", c.Name, err)
+		}
+		if code != 0 {
+			return CreatePodResponse{}, fmt.Errorf("init container %s exited with code %d", c.Name, code)
+		}
+		slog.Info("init container completed", "pod", p.Uid, "container", c.Name)
+	}
+
+	// create everything first so a bad spec doesn't leave the pod half started
+	for _, c := range p.Spec.Containers {
+		createRes, err := dr.containerruntime.ContainerCreate(ctx, ContainerToCreateContainerOpts(p, c, sandboxId))
+		if err != nil {
+			return CreatePodResponse{}, fmt.Errorf("failed to create container %s: %v", c.Name, err)
+		}
+		res.ContainerIds[c.Name] = createRes.ID
+	}
+	for _, c := range p.Spec.Containers {
+		_, err := dr.containerruntime.ContainerStart(ctx, res.ContainerIds[c.Name], client.ContainerStartOptions{})
+		if err != nil {
+			return CreatePodResponse{}, fmt.Errorf("failed to start container %s: %v", c.Name, err)
+		}
+		slog.Info("Started", "container", c.Name, "id", res.ContainerIds[c.Name])
+	}
+	return res, nil
+}
+
+func (dr DockerRuntime) StartContainer(ctx context.Context, p api.Pod, name string) error {
+	_, err := dr.containerruntime.ContainerStart(ctx, containerName(p, name), client.ContainerStartOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to start container %s: %v", name, err)
+	}
+	slog.Info("Restarted", "container", containerName(p, name))
+	return nil
+}
+
+func (dr DockerRuntime) StopContainer(ctx context.Context, p api.Pod, name string, gracePeriod time.Duration) error {
+	return dr.stopContainer(ctx, containerName(p, name), int(gracePeriod.Seconds()))
+}
+
+func (dr DockerRuntime) ExecSync(ctx context.Context, p api.Pod, name string, cmd []string) (int, error) {
+	created, err := dr.containerruntime.ExecCreate(ctx, containerName(p, name), client.ExecCreateOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create exec in container %s: %v", name, err)
+	}
+	attached, err := dr.containerruntime.ExecAttach(ctx, created.ID, client.ExecAttachOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to start exec in container %s: %v", name, err)
+	}
+	defer attached.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		attached.Conn.SetDeadline(deadline)
+	}
+	// the output isn't kept, reading it to the end waits for the command to exit
+	if _, err := io.Copy(io.Discard, attached.Reader); err != nil {
+		return 0, fmt.Errorf("failed waiting on exec in container %s: %v", name, err)
+	}
+	res, err := dr.containerruntime.ExecInspect(ctx, created.ID, client.ExecInspectOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect exec in container %s: %v", name, err)
+	}
+	return res.ExitCode, nil
+}
+
+// Exec runs cmd through a docker exec attached to streams. Resizes go to the exec's TTY.
+func (dr DockerRuntime) Exec(ctx context.Context, p api.Pod, name string, cmd []string, streams remotecommand.Streams) (int, error) {
+	created, err := dr.containerruntime.ExecCreate(ctx, containerName(p, name), client.ExecCreateOptions{
+		Cmd:          cmd,
+		TTY:          streams.TTY,
+		AttachStdin:  streams.Stdin != nil,
+		AttachStdout: streams.Stdout != nil,
+		AttachStderr: streams.Stderr != nil,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create exec in container %s: %v", name, err)
+	}
+	attached, err := dr.containerruntime.ExecAttach(ctx, created.ID, client.ExecAttachOptions{TTY: streams.TTY})
+	if err != nil {
+		return 0, fmt.Errorf("failed to start exec in container %s: %v", name, err)
+	}
+	defer attached.Close()
+	if streams.TTY {
+		go resizeTTY(ctx, streams.Resize, func(size remotecommand.TerminalSize) error {
+			_, err := dr.containerruntime.ExecResize(ctx, created.ID, client.ExecResizeOptions{Height: uint(size.Height), Width: uint(size.Width)})
+			return err
+		})
+	}
+	if err := copyStreams(ctx, attached.HijackedResponse, streams); err != nil {
+		return 0, fmt.Errorf("failed streaming exec in container %s: %v", name, err)
+	}
+	res, err := dr.containerruntime.ExecInspect(ctx, created.ID, client.ExecInspectOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect exec in container %s: %v", name, err)
+	}
+	return res.ExitCode, nil
+}
+
+// Attach attaches streams to the container. Stdin only reaches containers created with Stdin set.
+func (dr DockerRuntime) Attach(ctx context.Context, p api.Pod, name string, streams remotecommand.Streams) error {
+	id := containerName(p, name)
+	attached, err := dr.containerruntime.ContainerAttach(ctx, id, client.ContainerAttachOptions{
+		Stream: true,
+		Stdin:  streams.Stdin != nil,
+		Stdout: streams.Stdout != nil,
+		Stderr: streams.Stderr != nil,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach to container %s: %v", name, err)
+	}
+	defer attached.Close()
+	if streams.TTY {
+		go resizeTTY(ctx, streams.Resize, func(size remotecommand.TerminalSize) error {
+			_, err := dr.containerruntime.ContainerResize(ctx, id, client.ContainerResizeOptions{Height: uint(size.Height), Width: uint(size.Width)})
+			return err
+		})
+	}
+	if err := copyStreams(ctx, attached.HijackedResponse, streams); err != nil {
+		return fmt.Errorf("failed streaming container %s: %v", name, err)
+	}
+	return nil
+}
+
+// PortForward dials the port on the pod's IP, the host reaches the sandbox's network over docker's bridge
+func (dr DockerRuntime) PortForward(ctx context.Context, p api.Pod, port uint16, stream portforward.Stream) error {
+	status, err := dr.GetPodStatus(ctx, p)
+	if err != nil {
+		return err
+	}
+	if status.IP == "" {
+		return fmt.Errorf("pod %s has no IP", p.Name)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(status.IP, strconv.Itoa(int(port))))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	return portforward.Join(stream, conn)
+}
+
+// copyStreams copies stdin into a hijacked connection and its output out to streams until the output ends or ctx is done.
+// Without a TTY docker multiplexes stdout and stderr, they're split back up here.
+func copyStreams(ctx context.Context, hr client.HijackedResponse, streams remotecommand.Streams) error {
+	if streams.Stdin != nil {
+		go func() {
+			io.Copy(hr.Conn, streams.Stdin)
+			// the process sees its stdin end
+			hr.CloseWrite()
+		}()
+	}
+	// unblocks the read below
+	stop := context.AfterFunc(ctx, hr.Close)
+	defer stop()
+	stdout, stderr := streams.Stdout, streams.Stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	var err error
+	if streams.TTY {
+		_, err = io.Copy(stdout, hr.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, hr.Reader)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// resizeTTY passes terminal sizes on until they stop coming or ctx is done
+func resizeTTY(ctx context.Context, sizes <-chan remotecommand.TerminalSize, resize func(remotecommand.TerminalSize) error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case size, ok := <-sizes:
+			if !ok {
+				return
+			}
+			if err := resize(size); err != nil {
+				slog.Warn("failed to resize tty", "size", size, "error", err)
+			}
+		}
+	}
+}
+
+// Logs demultiplexes docker's log stream, stdout and stderr come out interleaved as they were written.
+// A TTY container's stream isn't multiplexed, it's passed through as is.
+// Containers are restarted in place, so the output of previous runs is everything written before the current run started.
+func (dr DockerRuntime) Logs(ctx context.Context, p api.Pod, name string, opts api.PodLogOptions) (io.ReadCloser, error) {
+	logOpts := client.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+		Timestamps: opts.Timestamps,
+		Tail:       "all",
+	}
+	if opts.TailLines != nil {
+		logOpts.Tail = strconv.FormatInt(*opts.TailLines, 10)
+	}
+	if opts.SinceSeconds != nil {
+		logOpts.Since = fmt.Sprintf("%ds", *opts.SinceSeconds)
+	}
+	inspect, err := dr.containerruntime.ContainerInspect(ctx, containerName(p, name), client.ContainerInspectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %v", name, err)
+	}
+	if opts.Previous {
+		logOpts.Until = inspect.Container.State.StartedAt
+		// there's nothing more to come from runs that are over
+		logOpts.Follow = false
+	}
+	res, err := dr.containerruntime.ContainerLogs(ctx, containerName(p, name), logOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs of container %s: %v", name, err)
+	}
+	pr, pw := io.Pipe()
+	tty := inspect.Container.Config != nil && inspect.Container.Config.Tty
+	go func() {
+		var err error
+		if tty {
+			_, err = io.Copy(pw, res)
+		} else {
+			_, err = stdcopy.StdCopy(pw, pw, res)
+		}
+		pw.CloseWithError(err)
+	}()
+	return logStream{PipeReader: pr, src: res}, nil
+}
+
+// logStream closes docker's stream along with the demultiplexed one, which stops a followed stream
+type logStream struct {
+	*io.PipeReader
+	src io.Closer
+}
+
+func (s logStream) Close() error {
+	s.PipeReader.Close()
+	return s.src.Close()
+}
+
+// WatchContainerExits follows docker's die events, reconnecting if the event stream breaks
+func (dr DockerRuntime) WatchContainerExits(ctx context.Context) (<-chan ContainerExit, error) {
+	exits := make(chan ContainerExit)
+	go func() {
+		defer close(exits)
+		for ctx.Err() == nil {
+			res := dr.containerruntime.Events(ctx, client.EventsListOptions{
+				Filters: make(client.Filters).Add("type", string(events.ContainerEventType)).Add("event", string(events.ActionDie)),
+			})
+			if err := dr.forwardExits(ctx, res, exits); ctx.Err() == nil {
+				slog.Error("docker event stream broke, reconnecting", "error", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+				}
+			}
+		}
+	}()
+	return exits, nil
+}
+
+// forwardExits passes on exits of pod containers until the event stream ends
+func (dr DockerRuntime) forwardExits(ctx context.Context, res client.EventsResult, exits chan<- ContainerExit) error {
+	for {
+		select {
+		case m := <-res.Messages:
+			uid, name, ok := parseContainerName(m.Actor.Attributes["name"])
+			if !ok {
+				continue
+			}
+			code, _ := strconv.Atoi(m.Actor.Attributes["exitCode"])
+			select {
+			case exits <- ContainerExit{PodUid: uid, Name: name, ExitCode: code}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case err := <-res.Err:
+			return err
+		}
+	}
+}
+
+func (dr DockerRuntime) pullImage(ctx context.Context, image string) error {
+	pullOpts := client.ImagePullOptions{
+		Platforms: []ocispec.Platform{{Architecture: "amd64", OS: "linux"}},
+	}
+	slog.Info("Attempting to pull", "image", image)
+	resp, err := dr.containerruntime.ImagePull(ctx, image, pullOpts)
+	if err != nil {
+		return fmt.Errorf("failed to pull image: %v", err)
+	}
+	var pullErrs []*jsonstream.Error
+	for m := range resp.JSONMessages(ctx) {
+		if m.Error != nil {
+			pullErrs = append(pullErrs, m.Error)
+		} else {
+			slog.Info("Status:", "status", m.Status)
+		}
+	}
+	if len(pullErrs) > 0 {
+		return fmt.Errorf("failed to pull image: %v", pullErrs)
+	}
+	return nil
+}
+
+func (dr DockerRuntime) startContainer(ctx context.Context, opts client.ContainerCreateOptions) (string, error) {
+	createRes, err := dr.containerruntime.ContainerCreate(ctx, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to create container: %v", err)
+	}
+	slog.Info("Created", "container", createRes.ID)
+	_, err = dr.containerruntime.ContainerStart(ctx, createRes.ID, client.ContainerStartOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to start container: %v", err)
+	}
+	slog.Info("Started", "container", createRes.ID)
+	return createRes.ID, nil
+}
+
+// waitContainer blocks until the container exits and returns its exit code
+func (dr DockerRuntime) waitContainer(ctx context.Context, id string) (int64, error) {
+	wait := dr.containerruntime.ContainerWait(ctx, id, client.ContainerWaitOptions{
+		Condition: container.WaitConditionNotRunning,
+	})
+	select {
+	case res := <-wait.Result:
+		if res.Error != nil {
+			return 0, fmt.Errorf("%s", res.Error.Message)
+		}
+		return res.StatusCode, nil
+	case err := <-wait.Error:
+		return 0, err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func NewDockerRuntime() (DockerRuntime, error) {
+	cr, err := client.New(client.FromEnv)
+	if err != nil {
+		return DockerRuntime{}, err
+	}
+	return DockerRuntime{
+		containerruntime: cr,
+	}, nil
+}
+
+type DockerRuntime struct {
+	containerruntime *client.Client
+}
+
+// docker container names are unique, naming containers after the pod uid
+// lets us find them again without keeping track of ids
+func sandboxName(p api.Pod) string {
+	return containerName(p, sandboxContainerName)
+}
+
+func containerName(p api.Pod, name string) string {
+	return fmt.Sprintf("smk_%s_%s", name, p.Uid)
+}
+
+// parseContainerName undoes containerName, ok is false for sandboxes and containers that aren't ours.
+// Container names are labels, they can't contain an underscore.
+func parseContainerName(dockerName string) (uuid.UUID, string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimPrefix(dockerName, "/"), "smk_")
+	if !ok {
+		return uuid.Nil, "", false
+	}
+	name, rawUid, ok := strings.Cut(rest, "_")
+	if !ok || name == sandboxContainerName {
+		return uuid.Nil, "", false
+	}
+	uid, err := uuid.Parse(rawUid)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	return uid, name, true
+}
+
+func podLabels(p api.Pod, name string) map[string]string {
+	return map[string]string{
+		PodUidLabel:        p.Uid.String(),
+		PodNamespaceLabel:  p.Namespace,
+		PodNameLabel:       p.Name,
+		ContainerNameLabel: name,
+	}
+}
+
+// SandboxCreateOpts builds the pause container for a pod.
+// Containers share its network namespace, so every port the pod exposes is published here.
+func SandboxCreateOpts(p api.Pod) (client.ContainerCreateOptions, error) {
+	exposedPorts := network.PortSet{}
+	portBindings := network.PortMap{}
+	for _, c := range p.Spec.Containers {
+		for _, port := range c.Ports {
+			containerPort, err := network.ParsePort(port.Containerport + "/tcp")
+			if err != nil {
+				return client.ContainerCreateOptions{}, err
+			}
+			exposedPorts[containerPort] = struct{}{}
+			portBindings[containerPort] = append(portBindings[containerPort], network.PortBinding{HostPort: port.Hostport})
+		}
+	}
+	return client.ContainerCreateOptions{
+		Name:  sandboxName(p),
+		Image: sandboxImage,
+		Config: &container.Config{
+			ExposedPorts: exposedPorts,
+			Labels:       podLabels(p, sandboxContainerName),
+		},
+		HostConfig: &container.HostConfig{
+			PortBindings: portBindings,
+			IpcMode:      container.IPCModeShareable,
+		},
+	}, nil
+}
+
+// ContainerToCreateContainerOpts builds a container joined to the pod's sandbox
+func ContainerToCreateContainerOpts(p api.Pod, c api.Container, sandboxId string) client.ContainerCreateOptions {
+	// Convert env map to slice of "KEY=VALUE" strings
+	env := make([]string, 0, len(c.Env))
+	for k, v := range c.Env {
+		env = append(env, k+"="+v)
+	}
+
+	// Convert volumes to map[string]struct{}
+	volumes := make(map[string]struct{}, len(c.Volumes))
+	for _, v := range c.Volumes {
+		volumes[v] = struct{}{}
+	}
+
+	// validated by the apiserver, every mount names one of the pod's volumes
+	var binds []string
+	for _, m := range c.VolumeMounts {
+		for _, v := range p.Spec.Volumes {
+			if v.Name == m.Name && v.HostPath != nil {
+				binds = append(binds, v.HostPath.Path+":"+m.MountPath)
+			}
+		}
+	}
+
+	return client.ContainerCreateOptions{
+		Name:  containerName(p, c.Name),
+		Image: c.Image,
+		Config: &container.Config{
+			Env:       env,
+			Volumes:   volumes,
+			Labels:    podLabels(p, c.Name),
+			OpenStdin: c.Stdin,
+			Tty:       c.TTY,
+		},
+		HostConfig: &container.HostConfig{
+			NetworkMode: container.NetworkMode("container:" + sandboxId),
+			IpcMode:     container.IpcMode("container:" + sandboxId),
+			// docker creates missing host directories for binds
+			Binds: binds,
+			// zero leaves the container unlimited
+			Resources: container.Resources{
+				NanoCPUs: c.Resources.Limits.MilliCPU * 1e6,
+				Memory:   c.Resources.Limits.Memory,
+			},
+		},
+	}
+}
+
+// GetPodStatus inspects every container of the pod by name
+func (dr DockerRuntime) GetPodStatus(ctx context.Context, p api.Pod) (PodStatus, error) {
+	status := PodStatus{Containers: map[string]ContainerStatus{}}
+	sandbox, err := dr.containerruntime.ContainerInspect(ctx, sandboxName(p), client.ContainerInspectOptions{})
+	if err != nil && !cerrdefs.IsNotFound(err) {
+		return PodStatus{}, fmt.Errorf("failed to inspect sandbox: %v", err)
+	}
+	if err == nil && sandbox.Container.NetworkSettings != nil {
+		for _, n := range sandbox.Container.NetworkSettings.Networks {
+			if n != nil && n.IPAddress.IsValid() {
+				status.IP = n.IPAddress.String()
+				break
+			}
+		}
+	}
+	containers := append(append([]api.Container{}, p.Spec.InitContainers...), p.Spec.Containers...)
+	for _, c := range containers {
+		res, err := dr.containerruntime.ContainerInspect(ctx, containerName(p, c.Name), client.ContainerInspectOptions{})
+		if cerrdefs.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return PodStatus{}, fmt.Errorf("failed to inspect container %s: %v", c.Name, err)
+		}
+		status.Containers[c.Name] = ContainerStatus{
+			Id:    res.Container.ID,
+			Image: c.Image,
+			State: toContainerState(res.Container.State),
+		}
+	}
+	return status, nil
+}
+
+// ListPods groups the containers carrying our pod labels by the pod they belong to
+func (dr DockerRuntime) ListPods(ctx context.Context) ([]RunningPod, error) {
+	res, err := dr.containerruntime.ContainerList(ctx, client.ContainerListOptions{
+		All:     true,
+		Filters: make(client.Filters).Add("label", PodUidLabel),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %v", err)
+	}
+	var pods []RunningPod
+	index := map[uuid.UUID]int{}
+	for _, c := range res.Items {
+		uid, err := uuid.Parse(c.Labels[PodUidLabel])
+		if err != nil {
+			slog.Warn("container has a malformed pod uid label", "container", c.ID, "uid", c.Labels[PodUidLabel])
+			continue
+		}
+		i, seen := index[uid]
+		if !seen {
+			i = len(pods)
+			index[uid] = i
+			pods = append(pods, RunningPod{Uid: uid, Namespace: c.Labels[PodNamespaceLabel], Name: c.Labels[PodNameLabel]})
+		}
+		if name := c.Labels[ContainerNameLabel]; name != sandboxContainerName {
+			pods[i].Containers = append(pods[i].Containers, name)
+		}
+	}
+	return pods, nil
+}
+
+func toContainerState(s *container.State) api.ContainerState {
+	if s == nil {
+		return api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: "Unknown"}}
+	}
+	startedAt, _ := time.Parse(time.RFC3339Nano, s.StartedAt)
+	switch {
+	case s.Running:
+		return api.ContainerState{Running: &api.ContainerStateRunning{StartedAt: startedAt}}
+	case s.Status == container.StateExited || s.Status == container.StateDead:
+		finishedAt, _ := time.Parse(time.RFC3339Nano, s.FinishedAt)
+		reason := "Completed"
+		if s.OOMKilled {
+			reason = "OOMKilled"
+		} else if s.ExitCode != 0 {
+			reason = "Error"
+		}
+		return api.ContainerState{Terminated: &api.ContainerStateTerminated{
+			ExitCode:   s.ExitCode,
+			Reason:     reason,
+			Message:    s.Error,
+			StartedAt:  startedAt,
+			FinishedAt: finishedAt,
+		}}
+	default:
+		return api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: "ContainerCreating"}}
+	}
+}
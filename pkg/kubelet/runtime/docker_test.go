@@ -0,0 +1,117 @@
+package runtime
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moby/moby/client"
+
+	"superminikube/pkg/api"
+)
+
+// multiplexed frames payload the way docker does for containers without a TTY
+func multiplexed(stream byte, payload string) string {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return string(header) + payload
+}
+
+func TestLogs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tty      bool
+		logs     string
+		expected string
+	}{
+		{
+			name:     "multiplexed",
+			logs:     multiplexed(1, "out\n") + multiplexed(2, "err\n"),
+			expected: "out\nerr\n",
+		},
+		{
+			name:     "tty",
+			tty:      true,
+			logs:     "\x01\x00\x00\x00 raw\r\n",
+			expected: "\x01\x00\x00\x00 raw\r\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := api.Pod{ObjectMeta: api.ObjectMeta{Uid: uuid.New(), Namespace: "default", Name: "web"}}
+			name := containerName(p, "app")
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /{version}/containers/{name}/json", func(w http.ResponseWriter, r *http.Request) {
+				if r.PathValue("name") != name {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"Id":"abc","Name":"/%s","State":{"Status":"running"},"Config":{"Tty":%t}}`, name, tc.tty)
+			})
+			mux.HandleFunc("GET /{version}/containers/{name}/logs", func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tc.logs)
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+			cr, err := client.New(client.WithHost("tcp://" + strings.TrimPrefix(srv.URL, "http://")))
+			if err != nil {
+				t.Fatalf("failed to create docker client: %v", err)
+			}
+			dr := DockerRuntime{containerruntime: cr}
+
+			logs, err := dr.Logs(t.Context(), p, "app", api.PodLogOptions{})
+			if err != nil {
+				t.Fatalf("failed to get logs: %v", err)
+			}
+			defer logs.Close()
+			b, err := io.ReadAll(logs)
+			if err != nil {
+				t.Fatalf("failed to read logs: %v", err)
+			}
+			if string(b) != tc.expected {
+				t.Errorf("got logs %q, expected %q", b, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPing(t *testing.T) {
+	testCases := []struct {
+		name      string
+		status    int
+		expectErr bool
+	}{
+		{name: "up", status: http.StatusOK},
+		{name: "down", status: http.StatusInternalServerError, expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/_ping") {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Api-Version", "1.47")
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+			cr, err := client.New(client.WithHost("tcp://" + strings.TrimPrefix(srv.URL, "http://")))
+			if err != nil {
+				t.Fatalf("failed to create docker client: %v", err)
+			}
+			dr := DockerRuntime{containerruntime: cr}
+
+			err = dr.Ping(t.Context())
+			if (err != nil) != tc.expectErr {
+				t.Errorf("got error %v, expected error: %t", err, tc.expectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/portforward"
+	"superminikube/pkg/remotecommand"
+)
+
+func (fr *FakeRuntime) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (fr *FakeRuntime) Info(ctx context.Context) (Info, error) {
+	return Info{
+		NumCPU:          4,
+		MemoryBytes:     8 << 30,
+		OperatingSystem: "linux",
+		Architecture:    "amd64",
+		Version:         "fake://0.0.0",
+	}, nil
+}
+
+func (fr *FakeRuntime) StopPod(ctx context.Context, pod api.Pod, gracePeriod time.Duration) error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.StoppedPods = append(fr.StoppedPods, StoppedPod{Pod: pod, GracePeriod: gracePeriod})
+	return nil
+}
+
+func (fr *FakeRuntime) DeletePod(ctx context.Context, pod api.Pod) error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.DeletedPods = append(fr.DeletedPods, pod)
+	return nil
+}
+
+func (fr *FakeRuntime) CreatePod(ctx context.Context, pod api.Pod) (CreatePodResponse, error) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.CreatedPods = append(fr.CreatedPods, pod)
+	res := CreatePodResponse{
+		SandboxId:    fmt.Sprintf("fake-sandbox-%s", pod.Uid),
+		ContainerIds: map[string]string{},
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if fr.FailInitContainers[c.Name] {
+			return CreatePodResponse{}, fmt.Errorf("init container %s exited with code 1", c.Name)
+		}
+		res.ContainerIds[c.Name] = "fake-container-id"
+		fr.StartedContainers = append(fr.StartedContainers, c.Name)
+	}
+	for _, c := range pod.Spec.Containers {
+		res.ContainerIds[c.Name] = "fake-container-id"
+		fr.StartedContainers = append(fr.StartedContainers, c.Name)
+	}
+	return res, nil
+}
+
+// GetPodStatus reports init containers as completed and app containers as running
+// unless overridden through ContainerStates. Pods that were deleted since they were last created have no containers.
+func (fr *FakeRuntime) GetPodStatus(ctx context.Context, pod api.Pod) (PodStatus, error) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	status := PodStatus{
+		IP:         "10.0.0.2",
+		Containers: map[string]ContainerStatus{},
+	}
+	if fr.removed(pod.Uid) {
+		return status, nil
+	}
+	started := map[string]bool{}
+	for _, name := range fr.StartedContainers {
+		started[name] = true
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if !started[c.Name] {
+			continue
+		}
+		status.Containers[c.Name] = fr.containerStatus(c, api.ContainerState{
+			Terminated: &api.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"},
+		})
+	}
+	for _, c := range pod.Spec.Containers {
+		if !started[c.Name] {
+			continue
+		}
+		status.Containers[c.Name] = fr.containerStatus(c, api.ContainerState{
+			Running: &api.ContainerStateRunning{},
+		})
+	}
+	return status, nil
+}
+
+// ListPods lists the pods that were created more often than they were deleted
+func (fr *FakeRuntime) ListPods(ctx context.Context) ([]RunningPod, error) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	var pods []RunningPod
+	for _, p := range fr.CreatedPods {
+		if fr.removed(p.Uid) || slices.ContainsFunc(pods, func(rp RunningPod) bool { return rp.Uid == p.Uid }) {
+			continue
+		}
+		rp := RunningPod{Uid: p.Uid, Namespace: p.Namespace, Name: p.Name}
+		for _, c := range append(slices.Clone(p.Spec.InitContainers), p.Spec.Containers...) {
+			rp.Containers = append(rp.Containers, c.Name)
+		}
+		pods = append(pods, rp)
+	}
+	return pods, nil
+}
+
+// removed reports whether the pod has been deleted at least as often as it was created, fr.mu has to be held
+func (fr *FakeRuntime) removed(uid uuid.UUID) bool {
+	deleted := countPods(fr.DeletedPods, uid)
+	return deleted > 0 && deleted >= countPods(fr.CreatedPods, uid)
+}
+
+func countPods(pods []api.Pod, uid uuid.UUID) int {
+	n := 0
+	for _, p := range pods {
+		if p.Uid == uid {
+			n++
+		}
+	}
+	return n
+}
+
+// StartContainer clears any state override of the container, it shows up as running again
+func (fr *FakeRuntime) StartContainer(ctx context.Context, pod api.Pod, name string) error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.RestartedContainers = append(fr.RestartedContainers, name)
+	delete(fr.ContainerStates, name)
+	return nil
+}
+
+// StopContainer leaves the container exited as if it was killed
+func (fr *FakeRuntime) StopContainer(ctx context.Context, pod api.Pod, name string, gracePeriod time.Duration) error {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.StoppedContainers = append(fr.StoppedContainers, name)
+	if fr.ContainerStates == nil {
+		fr.ContainerStates = map[string]api.ContainerState{}
+	}
+	fr.ContainerStates[name] = api.ContainerState{Terminated: &api.ContainerStateTerminated{ExitCode: 137, Reason: "Error"}}
+	return nil
+}
+
+// ExecSync exits with the container's entry in ExecExitCodes, 0 if there's none
+func (fr *FakeRuntime) ExecSync(ctx context.Context, pod api.Pod, name string, cmd []string) (int, error) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.ExecExitCodes[name], nil
+}
+
+// Exec writes "+ <cmd>" to stderr like a shell tracing it, then echoes stdin back.
+// It exits with the container's entry in ExecExitCodes.
+func (fr *FakeRuntime) Exec(ctx context.Context, pod api.Pod, name string, cmd []string, streams remotecommand.Streams) (int, error) {
+	if streams.Stderr != nil {
+		fmt.Fprintf(streams.Stderr, "+ %s\n", strings.Join(cmd, " "))
+	}
+	if err := fakeEcho(streams); err != nil {
+		return 0, err
+	}
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.ExecExitCodes[name], nil
+}
+
+// Attach echoes stdin like Exec does, without stdin it's attached until ctx is done
+func (fr *FakeRuntime) Attach(ctx context.Context, pod api.Pod, name string, streams remotecommand.Streams) error {
+	if streams.Stdin == nil {
+		<-ctx.Done()
+		return nil
+	}
+	return fakeEcho(streams)
+}
+
+// PortForward echoes back what it's sent on any port but the ones in RefusedPorts, which refuse connections
+func (fr *FakeRuntime) PortForward(ctx context.Context, pod api.Pod, port uint16, stream portforward.Stream) error {
+	fr.mu.Lock()
+	refused := fr.RefusedPorts[port]
+	fr.mu.Unlock()
+	if refused {
+		return fmt.Errorf("dial tcp 10.0.0.2:%d: connection refused", port)
+	}
+	if _, err := io.Copy(stream, stream); err != nil {
+		return err
+	}
+	return stream.CloseWrite()
+}
+
+// fakeEcho echoes stdin back to stdout line by line until it's closed. On a TTY the sizes it was resized to
+// are written as "resize <width>x<height>" ahead of the line that came in after them.
+func fakeEcho(streams remotecommand.Streams) error {
+	if streams.Stdin == nil {
+		return nil
+	}
+	out := streams.Stdout
+	if out == nil {
+		out = io.Discard
+	}
+	resized := func() {
+		for {
+			select {
+			case size, ok := <-streams.Resize:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(out, "resize %dx%d\n", size.Width, size.Height)
+			default:
+				return
+			}
+		}
+	}
+	scanner := bufio.NewScanner(streams.Stdin)
+	for scanner.Scan() {
+		resized()
+		if _, err := fmt.Fprintln(out, scanner.Text()); err != nil {
+			return err
+		}
+	}
+	resized()
+	return scanner.Err()
+}
+
+// Logs writes fakeLogLines lines a second apart starting at FakeLogStart, "<container> line <n>",
+// or "<container> previous line <n>" for previous runs. SinceSeconds counts back from the last line.
+// A followed stream stays open after the lines until ctx is done.
+func (fr *FakeRuntime) Logs(ctx context.Context, pod api.Pod, name string, opts api.PodLogOptions) (io.ReadCloser, error) {
+	last := FakeLogStart.Add((fakeLogLines - 1) * time.Second)
+	var lines []string
+	for i := range fakeLogLines {
+		ts := FakeLogStart.Add(time.Duration(i) * time.Second)
+		if opts.SinceSeconds != nil && last.Sub(ts) > time.Duration(*opts.SinceSeconds)*time.Second {
+			continue
+		}
+		line := fmt.Sprintf("%s line %d\n", name, i+1)
+		if opts.Previous {
+			line = fmt.Sprintf("%s previous line %d\n", name, i+1)
+		}
+		if opts.Timestamps {
+			line = ts.Format(time.RFC3339Nano) + " " + line
+		}
+		lines = append(lines, line)
+	}
+	if opts.TailLines != nil && int(*opts.TailLines) < len(lines) {
+		lines = lines[len(lines)-int(*opts.TailLines):]
+	}
+	out := strings.Join(lines, "")
+	if !opts.Follow {
+		return io.NopCloser(strings.NewReader(out)), nil
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		if _, err := io.WriteString(pw, out); err != nil {
+			return
+		}
+		<-ctx.Done()
+		pw.CloseWithError(ctx.Err())
+	}()
+	return pr, nil
+}
+
+// WatchContainerExits hands out Exits, a nil Exits never reports anything
+func (fr *FakeRuntime) WatchContainerExits(ctx context.Context) (<-chan ContainerExit, error) {
+	return fr.Exits, nil
+}
+
+func (fr *FakeRuntime) containerStatus(c api.Container, state api.ContainerState) ContainerStatus {
+	if s, ok := fr.ContainerStates[c.Name]; ok {
+		state = s
+	}
+	return ContainerStatus{Id: "fake-container-id", Image: c.Image, State: state}
+}
+
+// lines the fake's containers log
+const fakeLogLines = 5
+
+// when the fake's containers wrote their first line
+var FakeLogStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type FakeRuntime struct {
+	// the kubelet stops pods in the background
+	mu          sync.Mutex
+	CreatedPods []api.Pod
+	StoppedPods []StoppedPod
+	DeletedPods []api.Pod
+	// container names in the order they were started
+	StartedContainers []string
+	// container names in the order they were restarted after exiting
+	RestartedContainers []string
+	// container names in the order they were stopped on their own
+	StoppedContainers []string
+	// init containers named here exit non-zero
+	FailInitContainers map[string]bool
+	// overrides the state GetPodStatus reports, keyed by container name
+	ContainerStates map[string]api.ContainerState
+	// what WatchContainerExits reports, tests send on it to fake exits
+	Exits chan ContainerExit
+	// what commands ExecSync and Exec run exit with, keyed by container name
+	ExecExitCodes map[string]int
+	// ports PortForward can't connect to
+	RefusedPorts map[uint16]bool
+}
+
+type StoppedPod struct {
+	Pod         api.Pod
+	GracePeriod time.Duration
+}
@@ -2,153 +2,95 @@ package runtime
 
 import (
 	"context"
-	"fmt"
-	"log/slog"
+	"io"
+	"time"
 
-	"github.com/moby/moby/api/types/container"
-	"github.com/moby/moby/api/types/jsonstream"
-	"github.com/moby/moby/api/types/network"
-	"github.com/moby/moby/client"
-	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/google/uuid"
 
 	"superminikube/pkg/api"
+	"superminikube/pkg/portforward"
+	"superminikube/pkg/remotecommand"
 )
 
-func (dr DockerRuntime) Ping(ctx context.Context) error {
-	return nil
-}
-
-func (dr DockerRuntime) DeletePod(ctx context.Context, p api.Pod) error {
-	cid := p.Spec.Container.ContainerId
-	slog.Info("removing container", "containerid", cid)
-	_, err := dr.containerruntime.ContainerRemove(ctx, cid, client.ContainerRemoveOptions{
-		Force:         true,
-		RemoveVolumes: true,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to remove container in pod: %v\nerr: %v", p.Uid, err)
-	}
-	return nil
-}
-
-func (dr DockerRuntime) CreatePod(ctx context.Context, spec api.PodSpec) (CreatePodResponse, error) {
-	pullOpts := client.ImagePullOptions{
-		Platforms: []ocispec.Platform{{Architecture: "amd64", OS: "linux"}},
-	}
-	slog.Info("Attempting to pull", "image", spec.Container.Image)
-	resp, err := dr.containerruntime.ImagePull(ctx, spec.Container.Image, pullOpts)
-	if err != nil {
-		return CreatePodResponse{}, fmt.Errorf("failed to pull image: %v", err)
-	}
-	var pullErrs []*jsonstream.Error
-	for m := range resp.JSONMessages(ctx) {
-		if m.Error != nil {
-			pullErrs = append(pullErrs, m.Error)
-		} else {
-			slog.Info("Status:", "status", m.Status)
-		}
-	}
-	if len(pullErrs) > 0 {
-		return CreatePodResponse{}, fmt.Errorf("failed to pull image: %v", pullErrs)
-	}
-	containerOpts, err := PodSpecToCreateContainerOpts(spec)
-	if err != nil {
-		return CreatePodResponse{}, fmt.Errorf("failed to create container opts: %v", err)
-	}
-	createRes, err := dr.containerruntime.ContainerCreate(ctx, containerOpts)
-	if err != nil {
-		return CreatePodResponse{}, fmt.Errorf("failed to create container: %v", err)
-	}
-	slog.Info("Created", "container", createRes.ID)
-	_, err = dr.containerruntime.ContainerStart(ctx, createRes.ID, client.ContainerStartOptions{})
-	if err != nil {
-		return CreatePodResponse{}, fmt.Errorf("failed to start container: %v", err)
-	}
-	slog.Info("Started", "container", createRes.ID)
-	return CreatePodResponse{ContainerId: createRes.ID}, nil
-}
-
-func NewDockerRuntime() (DockerRuntime, error) {
-	cr, err := client.New(client.FromEnv)
-	if err != nil {
-		return DockerRuntime{}, err
-	}
-	return DockerRuntime{
-		containerruntime: cr,
-	}, nil
-}
-
-type DockerRuntime struct {
-	containerruntime *client.Client
+type CreatePodResponse struct {
+	// id of the sandbox (pause) container holding the pod's network namespace
+	SandboxId string
+	// container ids keyed by container name, init containers included
+	ContainerIds map[string]string
 }
 
-func (fr *FakeRuntime) Ping(ctx context.Context) error {
-	return nil
+// PodStatus is what the runtime can see of a pod
+type PodStatus struct {
+	IP string
+	// keyed by container name, containers that don't exist (yet) are left out
+	Containers map[string]ContainerStatus
 }
 
-func (fr *FakeRuntime) DeletePod(ctx context.Context, pod api.Pod) error {
-	fr.DeletedPods = append(fr.DeletedPods, pod)
-	return nil
+type ContainerStatus struct {
+	Id    string
+	Image string
+	State api.ContainerState
 }
 
-func (fr *FakeRuntime) CreatePod(ctx context.Context, spec api.PodSpec) (CreatePodResponse, error) {
-	fr.CreatedPods = append(fr.CreatedPods, spec)
-	return CreatePodResponse{ContainerId: "fake-container-id"}, nil
+// ContainerExit is reported when one of a pod's containers stops
+type ContainerExit struct {
+	PodUid uuid.UUID
+	// name of the container in the pod
+	Name     string
+	ExitCode int
 }
 
-type FakeRuntime struct {
-	CreatedPods []api.PodSpec
-	DeletedPods []api.Pod
+// RunningPod is a pod the runtime has containers of, whether or not the kubelet knows about it
+type RunningPod struct {
+	Uid       uuid.UUID
+	Namespace string
+	Name      string
+	// names of the pod's containers, init containers included and the sandbox left out
+	Containers []string
 }
 
-type CreatePodResponse struct {
-	ContainerId string
+// Info describes the machine the runtime runs on, it's what the kubelet registers its node with
+type Info struct {
+	NumCPU          int
+	MemoryBytes     int64
+	OperatingSystem string
+	Architecture    string
+	KernelVersion   string
+	// name and version of the runtime, like docker://28.0.1
+	Version string
 }
 
 type ContainerRuntime interface {
 	Ping(context.Context) error
-	CreatePod(context.Context, api.PodSpec) (CreatePodResponse, error)
+	Info(context.Context) (Info, error)
+	// CreatePod runs init containers to completion one at a time,
+	// then starts every app container in a shared sandbox.
+	CreatePod(context.Context, api.Pod) (CreatePodResponse, error)
+	// StopPod sends SIGTERM to the pod's containers and SIGKILLs whatever is left after gracePeriod
+	StopPod(ctx context.Context, p api.Pod, gracePeriod time.Duration) error
 	DeletePod(context.Context, api.Pod) error
-}
-
-func PodSpecToCreateContainerOpts(spec api.PodSpec) (client.ContainerCreateOptions, error) {
-	c := spec.Container
-
-	// Convert env map to slice of "KEY=VALUE" strings
-	env := make([]string, 0, len(c.Env))
-	for k, v := range c.Env {
-		env = append(env, k+"="+v)
-	}
-
-	// Convert ports to ExposedPorts and PortBindings
-	exposedPorts := network.PortSet{}
-	portBindings := network.PortMap{}
-	for _, p := range c.Ports {
-		containerPort, err := network.ParsePort(p.Containerport + "/tcp")
-		if err != nil {
-			return client.ContainerCreateOptions{}, err
-		}
-		exposedPorts[containerPort] = struct{}{}
-		portBindings[containerPort] = []network.PortBinding{
-			{HostPort: p.Hostport},
-		}
-	}
-
-	// Convert volumes to map[string]struct{}
-	volumes := make(map[string]struct{}, len(c.Volumes))
-	for _, v := range c.Volumes {
-		volumes[v] = struct{}{}
-	}
-
-	return client.ContainerCreateOptions{
-		Image: c.Image,
-		Config: &container.Config{
-			Env:          env,
-			ExposedPorts: exposedPorts,
-			Volumes:      volumes,
-		},
-		HostConfig: &container.HostConfig{
-			PortBindings: portBindings,
-		},
-	}, nil
+	GetPodStatus(context.Context, api.Pod) (PodStatus, error)
+	// ListPods lists every pod that has a container or a sandbox left in the runtime, running or not,
+	// including pods created by an earlier run of the kubelet
+	ListPods(context.Context) ([]RunningPod, error)
+	// StartContainer starts an exited app container of the pod again, in place
+	StartContainer(ctx context.Context, p api.Pod, name string) error
+	// StopContainer stops one app container of the pod like StopPod does, leaving the rest running
+	StopContainer(ctx context.Context, p api.Pod, name string, gracePeriod time.Duration) error
+	// Logs streams what a container of the pod wrote to stdout and stderr until ctx is done or the reader is closed.
+	// The stream ends with the output unless opts ask to follow it.
+	Logs(ctx context.Context, p api.Pod, name string, opts api.PodLogOptions) (io.ReadCloser, error)
+	// ExecSync runs cmd in a running container of the pod and returns its exit code once it's done
+	ExecSync(ctx context.Context, p api.Pod, name string, cmd []string) (int, error)
+	// Exec runs cmd in a running container of the pod with streams attached and returns its exit code once it's done.
+	// The command is cut short when ctx is done.
+	Exec(ctx context.Context, p api.Pod, name string, cmd []string, streams remotecommand.Streams) (int, error)
+	// Attach connects streams to the process of a running container of the pod until it exits or ctx is done.
+	// The container was created with or without a TTY, streams.TTY has to match it.
+	Attach(ctx context.Context, p api.Pod, name string, streams remotecommand.Streams) error
+	// PortForward connects stream to port in the pod's network namespace until both are done or ctx is
+	PortForward(ctx context.Context, p api.Pod, port uint16, stream portforward.Stream) error
+	// WatchContainerExits reports containers of any pod exiting until ctx is done.
+	// Exits can be missed, e.g. while the runtime restarts, so they're only a hint to look at the pod sooner.
+	WatchContainerExits(context.Context) (<-chan ContainerExit, error)
 }
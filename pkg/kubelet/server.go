@@ -0,0 +1,210 @@
+package kubelet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/portforward"
+	"superminikube/pkg/remotecommand"
+)
+
+// port the kubelet's HTTP server listens on unless configured otherwise
+const defaultPort = 10250
+
+// serve runs the kubelet's HTTP server until ctx is done, the apiserver reaches containers through it
+func (k *Kubelet) serve(ctx context.Context) {
+	srv := &http.Server{
+		Addr:    ":" + strconv.Itoa(int(k.port)),
+		Handler: k.handler(),
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	slog.Info("kubelet server listening", "addr", srv.Addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("kubelet server failed", "error", err)
+	}
+}
+
+func (k *Kubelet) handler() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/containerLogs/{namespace}/{name}/{container}", k.containerLogs).Methods(http.MethodGet)
+	r.HandleFunc("/exec/{namespace}/{name}/{container}", k.exec).Methods(http.MethodGet)
+	r.HandleFunc("/attach/{namespace}/{name}/{container}", k.attach).Methods(http.MethodGet)
+	r.HandleFunc("/portForward/{namespace}/{name}", k.portForward).Methods(http.MethodGet)
+	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+	return r
+}
+
+// containerLogs streams a container's output, reading PodLogOptions from the query
+func (k *Kubelet) containerLogs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	opts, err := api.ParsePodLogOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p, ok := k.podByName(vars["namespace"], vars["name"])
+	if !ok {
+		http.Error(w, fmt.Sprintf("pod %s/%s is not on node %s", vars["namespace"], vars["name"], k.nodeName), http.StatusNotFound)
+		return
+	}
+	name := vars["container"]
+	if !slices.ContainsFunc(append(slices.Clone(p.Spec.InitContainers), p.Spec.Containers...), func(c api.Container) bool { return c.Name == name }) {
+		http.Error(w, fmt.Sprintf("pod %s/%s has no container %s", p.Namespace, p.Name, name), http.StatusNotFound)
+		return
+	}
+	if opts.Previous && !restarted(p, name) {
+		http.Error(w, fmt.Sprintf("container %s of pod %s/%s hasn't been restarted", name, p.Namespace, p.Name), http.StatusBadRequest)
+		return
+	}
+	logs, err := k.containerruntime.Logs(r.Context(), p, name, opts)
+	if err != nil {
+		slog.Error("failed to get container logs", "pod", p.Name, "container", name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer logs.Close()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	// followed output shows up as it's written rather than when a buffer fills
+	if _, err := io.Copy(flushWriter{w, http.NewResponseController(w)}, logs); err != nil && r.Context().Err() == nil {
+		slog.Error("failed to stream container logs", "pod", p.Name, "container", name, "error", err)
+	}
+}
+
+// exec runs the command PodExecOptions in the query name in a running app container,
+// the request is upgraded to a remotecommand stream
+func (k *Kubelet) exec(w http.ResponseWriter, r *http.Request) {
+	p, c, opts, ok := k.streamTarget(w, r)
+	if !ok {
+		return
+	}
+	if len(opts.Command) == 0 {
+		http.Error(w, "exec needs a command", http.StatusBadRequest)
+		return
+	}
+	slog.Info("executing in container", "pod", p.Name, "container", c.Name, "command", opts.Command)
+	remotecommand.Serve(w, r, opts, func(ctx context.Context, streams remotecommand.Streams) (int, error) {
+		return k.containerruntime.Exec(ctx, p, c.Name, opts.Command, streams)
+	})
+}
+
+// attach connects to the process of a running app container, the request is upgraded to a remotecommand stream
+func (k *Kubelet) attach(w http.ResponseWriter, r *http.Request) {
+	p, c, opts, ok := k.streamTarget(w, r)
+	if !ok {
+		return
+	}
+	if opts.Stdin && !c.Stdin {
+		http.Error(w, fmt.Sprintf("container %s of pod %s/%s doesn't keep stdin open", c.Name, p.Namespace, p.Name), http.StatusBadRequest)
+		return
+	}
+	// the container has a TTY or it doesn't, whatever was asked for
+	opts.TTY = c.TTY
+	slog.Info("attaching to container", "pod", p.Name, "container", c.Name)
+	remotecommand.Serve(w, r, opts, func(ctx context.Context, streams remotecommand.Streams) (int, error) {
+		return 0, k.containerruntime.Attach(ctx, p, c.Name, streams)
+	})
+}
+
+// portForward tunnels connections to the ports of a running pod, the request is upgraded to a portforward stream
+func (k *Kubelet) portForward(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	p, ok := k.podByName(vars["namespace"], vars["name"])
+	if !ok {
+		http.Error(w, fmt.Sprintf("pod %s/%s is not on node %s", vars["namespace"], vars["name"], k.nodeName), http.StatusNotFound)
+		return
+	}
+	if p.Status.Phase != api.PodRunning {
+		http.Error(w, fmt.Sprintf("pod %s/%s isn't running", p.Namespace, p.Name), http.StatusBadRequest)
+		return
+	}
+	slog.Info("forwarding ports", "pod", p.Name)
+	portforward.Serve(w, r, func(ctx context.Context, port uint16, stream portforward.Stream) error {
+		return k.containerruntime.PortForward(ctx, p, port, stream)
+	})
+}
+
+// streamTarget finds the running app container an exec or attach request is for, replying with an error if there's none
+func (k *Kubelet) streamTarget(w http.ResponseWriter, r *http.Request) (api.Pod, api.Container, api.PodExecOptions, bool) {
+	vars := mux.Vars(r)
+	opts, err := api.ParsePodExecOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return api.Pod{}, api.Container{}, api.PodExecOptions{}, false
+	}
+	p, ok := k.podByName(vars["namespace"], vars["name"])
+	if !ok {
+		http.Error(w, fmt.Sprintf("pod %s/%s is not on node %s", vars["namespace"], vars["name"], k.nodeName), http.StatusNotFound)
+		return api.Pod{}, api.Container{}, api.PodExecOptions{}, false
+	}
+	name := vars["container"]
+	i := slices.IndexFunc(p.Spec.Containers, func(c api.Container) bool { return c.Name == name })
+	if i < 0 {
+		http.Error(w, fmt.Sprintf("pod %s/%s has no app container %s", p.Namespace, p.Name, name), http.StatusNotFound)
+		return api.Pod{}, api.Container{}, api.PodExecOptions{}, false
+	}
+	if !containerRunning(p, name) {
+		http.Error(w, fmt.Sprintf("container %s of pod %s/%s isn't running", name, p.Namespace, p.Name), http.StatusBadRequest)
+		return api.Pod{}, api.Container{}, api.PodExecOptions{}, false
+	}
+	return p, p.Spec.Containers[i], opts, true
+}
+
+// podByName finds a tracked pod, names are only unique among pods that aren't being replaced
+// so the most recently created one wins
+func (k *Kubelet) podByName(namespace, name string) (api.Pod, bool) {
+	var found api.Pod
+	ok := false
+	for _, p := range k.ListPods() {
+		if p.Namespace == namespace && p.Name == name && (!ok || p.CreationTimestamp.After(found.CreationTimestamp)) {
+			found, ok = p, true
+		}
+	}
+	return found, ok
+}
+
+func containerRunning(p api.Pod, container string) bool {
+	for _, cs := range p.Status.ContainerStatuses {
+		if cs.Name == container {
+			return cs.State.Running != nil
+		}
+	}
+	return false
+}
+
+func restarted(p api.Pod, container string) bool {
+	for _, cs := range p.Status.ContainerStatuses {
+		if cs.Name == container {
+			return cs.RestartCount > 0
+		}
+	}
+	return false
+}
+
+// flushWriter flushes every write straight out to the client
+type flushWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (f flushWriter) Write(b []byte) (int, error) {
+	n, err := f.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+	return n, f.rc.Flush()
+}
@@ -0,0 +1,219 @@
+package kubelet
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/kubelet/runtime"
+	"superminikube/pkg/portforward"
+	"superminikube/pkg/remotecommand"
+)
+
+func TestContainerLogs(t *testing.T) {
+	k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: "http://localhost:8080", NodeName: "test-node"}, &runtime.FakeRuntime{})
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New()},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}},
+	}
+	k.createPod(t.Context(), pod)
+	srv := httptest.NewServer(k.handler())
+	defer srv.Close()
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected int
+		body     string
+	}{
+		{
+			name:     "everything",
+			path:     "/containerLogs/default/web/app",
+			expected: http.StatusOK,
+			body:     "app line 1\napp line 2\napp line 3\napp line 4\napp line 5\n",
+		},
+		{
+			name:     "tail with timestamps",
+			path:     "/containerLogs/default/web/app?tailLines=2&timestamps=true",
+			expected: http.StatusOK,
+			body:     "2025-01-01T00:00:03Z app line 4\n2025-01-01T00:00:04Z app line 5\n",
+		},
+		{
+			name:     "since",
+			path:     "/containerLogs/default/web/app?sinceSeconds=1",
+			expected: http.StatusOK,
+			body:     "app line 4\napp line 5\n",
+		},
+		{name: "bad tail", path: "/containerLogs/default/web/app?tailLines=-1", expected: http.StatusBadRequest},
+		{name: "previous without a restart", path: "/containerLogs/default/web/app?previous=true", expected: http.StatusBadRequest},
+		{name: "unknown container", path: "/containerLogs/default/web/db", expected: http.StatusNotFound},
+		{name: "unknown pod", path: "/containerLogs/default/api/app", expected: http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + tc.path)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tc.expected {
+				t.Fatalf("got status %d, expected %d: %s", resp.StatusCode, tc.expected, body)
+			}
+			if tc.body != "" && string(body) != tc.body {
+				t.Errorf("got logs %q, expected %q", body, tc.body)
+			}
+		})
+	}
+
+	t.Run("follow", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/containerLogs/default/web/app?follow=true&tailLines=1", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		// the line arrives while the stream is still open
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil || strings.TrimSpace(line) != "app line 5" {
+			t.Errorf("got %q, %v, expected the last line", line, err)
+		}
+	})
+}
+
+func TestExec(t *testing.T) {
+	rt := &runtime.FakeRuntime{ExecExitCodes: map[string]int{"app": 2}}
+	k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: "http://localhost:8080", NodeName: "test-node"}, rt)
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New()},
+		Spec: api.PodSpec{
+			// exited containers stay exited
+			RestartPolicy:  api.RestartPolicyNever,
+			InitContainers: []api.Container{{Name: "setup", Image: "busybox"}},
+			Containers:     []api.Container{{Name: "app", Image: "nginx", Stdin: true}, {Name: "tty", Image: "busybox", Stdin: true, TTY: true}},
+		},
+	}
+	k.createPod(t.Context(), pod)
+	srv := httptest.NewServer(k.handler())
+	defer srv.Close()
+	location := func(path string, opts api.PodExecOptions) *url.URL {
+		u, _ := url.Parse(srv.URL + path)
+		u.RawQuery = opts.Query().Encode()
+		return u
+	}
+
+	t.Run("exec", func(t *testing.T) {
+		var stdout, stderr strings.Builder
+		code, err := remotecommand.Stream(t.Context(), location("/exec/default/web/app", api.PodExecOptions{Command: []string{"cat", "-"}, Stdin: true, Stdout: true, Stderr: true}), nil, remotecommand.Streams{
+			Stdin:  strings.NewReader("hello\n"),
+			Stdout: &stdout,
+			Stderr: &stderr,
+		})
+		if err != nil {
+			t.Fatalf("exec failed: %v", err)
+		}
+		if code != 2 {
+			t.Errorf("got exit code %d, expected 2", code)
+		}
+		if stdout.String() != "hello\n" || stderr.String() != "+ cat -\n" {
+			t.Errorf("got stdout %q and stderr %q", stdout.String(), stderr.String())
+		}
+	})
+
+	t.Run("attach", func(t *testing.T) {
+		var stdout strings.Builder
+		// the container has a TTY, so attaching gets one without asking
+		_, err := remotecommand.Stream(t.Context(), location("/attach/default/web/tty", api.PodExecOptions{Stdin: true, Stdout: true}), nil, remotecommand.Streams{
+			Stdin:  strings.NewReader("ls\n"),
+			Stdout: &stdout,
+		})
+		if err != nil {
+			t.Fatalf("attach failed: %v", err)
+		}
+		if stdout.String() != "ls\n" {
+			t.Errorf("got stdout %q", stdout.String())
+		}
+	})
+
+	testCases := []struct {
+		name     string
+		path     string
+		opts     api.PodExecOptions
+		expected int
+	}{
+		{name: "no command", path: "/exec/default/web/app", opts: api.PodExecOptions{Stdout: true}, expected: http.StatusBadRequest},
+		{name: "no streams", path: "/exec/default/web/app", opts: api.PodExecOptions{Command: []string{"ls"}}, expected: http.StatusBadRequest},
+		{name: "init container", path: "/exec/default/web/setup", opts: api.PodExecOptions{Command: []string{"ls"}, Stdout: true}, expected: http.StatusNotFound},
+		{name: "unknown pod", path: "/exec/default/api/app", opts: api.PodExecOptions{Command: []string{"ls"}, Stdout: true}, expected: http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := remotecommand.Stream(t.Context(), location(tc.path, tc.opts), nil, remotecommand.Streams{Stdout: io.Discard})
+			if err == nil || !strings.Contains(err.Error(), strconv.Itoa(tc.expected)) {
+				t.Errorf("got %v, expected status %d", err, tc.expected)
+			}
+		})
+	}
+
+	t.Run("not running", func(t *testing.T) {
+		rt.StopContainer(t.Context(), pod, "app", 0)
+		k.syncPodStatus(t.Context(), pod.Uid)
+		_, err := remotecommand.Stream(t.Context(), location("/exec/default/web/app", api.PodExecOptions{Command: []string{"ls"}, Stdout: true}), nil, remotecommand.Streams{Stdout: io.Discard})
+		if err == nil || !strings.Contains(err.Error(), "isn't running") {
+			t.Errorf("got %v, expected the exited container to be refused", err)
+		}
+	})
+}
+
+func TestPortForward(t *testing.T) {
+	rt := &runtime.FakeRuntime{RefusedPorts: map[uint16]bool{9090: true}}
+	k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: "http://localhost:8080", NodeName: "test-node"}, rt)
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New()},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}},
+	}
+	k.createPod(t.Context(), pod)
+	srv := httptest.NewServer(k.handler())
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL + "/portForward/default/web")
+	f, err := portforward.Forward(t.Context(), u, nil, []portforward.Port{{Remote: 80}, {Remote: 9090}})
+	if err != nil {
+		t.Fatalf("failed to forward: %v", err)
+	}
+	roundTrip := func(port uint16) string {
+		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		defer conn.Close()
+		conn.Write([]byte("ping"))
+		conn.(*net.TCPConn).CloseWrite()
+		got, _ := io.ReadAll(conn)
+		return string(got)
+	}
+	if got := roundTrip(f.Ports()[0].Local); got != "ping" {
+		t.Errorf("got %q back, expected the fake to echo", got)
+	}
+	if got := roundTrip(f.Ports()[1].Local); got != "" {
+		t.Errorf("got %q back from a refused port", got)
+	}
+
+	u, _ = url.Parse(srv.URL + "/portForward/default/api")
+	if _, err := portforward.Forward(t.Context(), u, nil, []portforward.Port{{Remote: 80}}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("got %v, expected an unknown pod to be refused", err)
+	}
+}
@@ -0,0 +1,246 @@
+package kubelet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+	"superminikube/pkg/kubelet/runtime"
+)
+
+// how often pod statuses are recomputed even without any watch events
+const statusSyncPeriod = 10 * time.Second
+
+// statusLoop periodically refreshes the status of every pod on the node
+// so container exits and similar runtime changes get reported
+func (k *Kubelet) statusLoop(ctx context.Context) {
+	ticker := time.NewTicker(statusSyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("statusLoop stopped due to context cancellation")
+			return
+		case <-ticker.C:
+			for _, p := range k.ListPods() {
+				k.syncPodStatus(ctx, p.Uid)
+			}
+		}
+	}
+}
+
+// syncPodStatus restarts the pod's exited containers its restart policy asks for,
+// then recomputes its status from the runtime and reports it to the apiserver if it changed since the last report.
+// A pod that finished stays finished, whatever later happens to its exited containers.
+func (k *Kubelet) syncPodStatus(ctx context.Context, uid uuid.UUID) {
+	p, err := k.GetPod(uid)
+	if err != nil {
+		return
+	}
+	if finished(p) {
+		return
+	}
+	rs, err := k.containerruntime.GetPodStatus(ctx, p)
+	if err != nil {
+		slog.Error("failed to get pod status from runtime", "pod", p.Name, "error", err)
+		return
+	}
+	if k.restartContainers(ctx, p, rs) {
+		// the restart counts went up on the tracked pod
+		if p, err = k.GetPod(uid); err != nil {
+			return
+		}
+		if rs, err = k.containerruntime.GetPodStatus(ctx, p); err != nil {
+			slog.Error("failed to get pod status from runtime", "pod", p.Name, "error", err)
+			return
+		}
+	}
+	k.setPodStatus(ctx, uid, generatePodStatus(p, rs, k.prober.readiness(p), k.now()))
+}
+
+// setPodStatus records status on the tracked pod and reports it when it changed
+func (k *Kubelet) setPodStatus(ctx context.Context, uid uuid.UUID, status api.PodStatus) {
+	k.mu.Lock()
+	p, ok := k.pods[uid]
+	if !ok || reflect.DeepEqual(p.Status, status) {
+		k.mu.Unlock()
+		return
+	}
+	p.Status = status
+	k.pods[uid] = p
+	k.mu.Unlock()
+	k.reportPodStatus(ctx, p)
+}
+
+// reportPodStatus writes p's status at the resourceVersion the kubelet last saw.
+// The scheduler writes status too, so on a conflict the pod is read again
+// and the kubelet's part of the status is written over what's there now.
+func (k *Kubelet) reportPodStatus(ctx context.Context, p api.Pod) {
+	slog.Info("reporting pod status", "pod", p.Name, "phase", p.Status.Phase)
+	status := p.Status
+	for {
+		err := k.client.UpdatePodStatus(ctx, p)
+		if !errors.Is(err, client.ErrConflict) {
+			if err != nil {
+				slog.Error("failed to report pod status", "pod", p.Name, "error", err)
+			}
+			return
+		}
+		current, err := k.client.GetPod(ctx, p.Namespace, p.Name)
+		if err != nil {
+			slog.Error("failed to get pod to report its status", "pod", p.Name, "error", err)
+			return
+		}
+		// a pod of the same name made after this one was deleted
+		if current.Uid != p.Uid {
+			return
+		}
+		current.Status = mergeStatus(current.Status, status)
+		p = current
+	}
+}
+
+// mergeStatus is the kubelet's status with the PodScheduled condition of current,
+// that one belongs to the scheduler and everything else to the kubelet
+func mergeStatus(current, kubelet api.PodStatus) api.PodStatus {
+	status := kubelet
+	status.Conditions = slices.Clone(kubelet.Conditions)
+	scheduled := current.GetCondition(api.PodScheduled)
+	for i, c := range status.Conditions {
+		if c.Type == api.PodScheduled && scheduled != nil {
+			status.Conditions[i] = *scheduled
+		}
+	}
+	return status
+}
+
+// generatePodStatus builds the status of p from what the runtime reports.
+// Transition times, restart counts and last termination states are carried over from p.Status.
+// App containers that exited and are going to be restarted are reported as waiting in CrashLoopBackOff.
+// probed holds the probe verdict of containers with a readiness or startup probe, the rest are ready once they run.
+func generatePodStatus(p api.Pod, rs runtime.PodStatus, probed map[string]bool, now time.Time) api.PodStatus {
+	old := p.Status
+	status := api.PodStatus{
+		PodIP:     rs.IP,
+		StartTime: old.StartTime,
+	}
+	if status.StartTime == nil {
+		t := now.UTC()
+		status.StartTime = &t
+	}
+	// failed init containers fail the pod rather than being restarted
+	status.InitContainerStatuses = containerStatuses(p.Spec.InitContainers, rs, old.InitContainerStatuses, api.RestartPolicyNever, nil)
+	status.ContainerStatuses = containerStatuses(p.Spec.Containers, rs, old.ContainerStatuses, p.Spec.RestartPolicy, probed)
+
+	initialized := true
+	for _, cs := range status.InitContainerStatuses {
+		if cs.State.Terminated == nil || cs.State.Terminated.ExitCode != 0 {
+			initialized = false
+		}
+	}
+	ready := len(status.ContainerStatuses) > 0
+	for _, cs := range status.ContainerStatuses {
+		ready = ready && cs.Ready
+	}
+	status.Phase = podPhase(status)
+	status.Conditions = []api.PodCondition{
+		condition(old, api.PodScheduled, true, now),
+		condition(old, api.PodInitialized, initialized, now),
+		condition(old, api.ContainersReady, ready, now),
+		condition(old, api.PodReady, ready, now),
+	}
+	return status
+}
+
+func containerStatuses(containers []api.Container, rs runtime.PodStatus, old []api.ContainerStatus, policy api.RestartPolicy, probed map[string]bool) []api.ContainerStatus {
+	statuses := make([]api.ContainerStatus, 0, len(containers))
+	for _, c := range containers {
+		cs := api.ContainerStatus{
+			Name:  c.Name,
+			Image: c.Image,
+			State: api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: "ContainerCreating"}},
+		}
+		if s, ok := rs.Containers[c.Name]; ok {
+			cs.ContainerId = s.Id
+			cs.State = s.State
+			cs.Ready = s.State.Running != nil
+			if ready, ok := probed[c.Name]; ok {
+				cs.Ready = cs.Ready && ready
+			}
+		}
+		for _, o := range old {
+			if o.Name == c.Name {
+				cs.RestartCount = o.RestartCount
+				cs.LastTerminationState = o.LastTerminationState
+			}
+		}
+		if term := cs.State.Terminated; term != nil && policy.ShouldRestart(term.ExitCode) {
+			cs.LastTerminationState = cs.State
+			cs.State = api.ContainerState{Waiting: &api.ContainerStateWaiting{
+				Reason:  crashLoopBackOff,
+				Message: fmt.Sprintf("back-off restarting exited container %s", c.Name),
+			}}
+		}
+		statuses = append(statuses, cs)
+	}
+	return statuses
+}
+
+func podPhase(status api.PodStatus) api.PodPhase {
+	for _, cs := range status.InitContainerStatuses {
+		if cs.State.Terminated == nil {
+			return api.PodPending
+		}
+		if cs.State.Terminated.ExitCode != 0 {
+			return api.PodFailed
+		}
+	}
+	var running, succeeded, failed int
+	for _, cs := range status.ContainerStatuses {
+		switch {
+		// a container waiting to be restarted keeps the pod running
+		case cs.State.Running != nil, cs.State.Waiting != nil && cs.State.Waiting.Reason == crashLoopBackOff:
+			running++
+		case cs.State.Terminated != nil && cs.State.Terminated.ExitCode == 0:
+			succeeded++
+		case cs.State.Terminated != nil:
+			failed++
+		default:
+			return api.PodPending
+		}
+	}
+	switch {
+	case running > 0:
+		return api.PodRunning
+	case failed > 0:
+		return api.PodFailed
+	case succeeded > 0:
+		return api.PodSucceeded
+	default:
+		return api.PodPending
+	}
+}
+
+// condition builds a condition, keeping the old transition time if the status didn't flip
+func condition(old api.PodStatus, t api.PodConditionType, ok bool, now time.Time) api.PodCondition {
+	c := api.PodCondition{
+		Type:               t,
+		Status:             api.ConditionFalse,
+		LastTransitionTime: now.UTC(),
+	}
+	if ok {
+		c.Status = api.ConditionTrue
+	}
+	if prev := old.GetCondition(t); prev != nil && prev.Status == c.Status {
+		c.LastTransitionTime = prev.LastTransitionTime
+	}
+	return c
+}
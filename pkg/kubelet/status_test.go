@@ -0,0 +1,256 @@
+package kubelet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/kubelet/runtime"
+)
+
+func running() api.ContainerState {
+	return api.ContainerState{Running: &api.ContainerStateRunning{}}
+}
+
+func exited(code int) api.ContainerState {
+	return api.ContainerState{Terminated: &api.ContainerStateTerminated{ExitCode: code}}
+}
+
+func TestGeneratePodStatus(t *testing.T) {
+	pod := api.Pod{
+		Spec: api.PodSpec{
+			InitContainers: []api.Container{{Name: "init"}},
+			Containers:     []api.Container{{Name: "app"}, {Name: "sidecar"}},
+			RestartPolicy:  api.RestartPolicyNever,
+		},
+	}
+	testCases := []struct {
+		name          string
+		containers    map[string]api.ContainerState
+		expectedPhase api.PodPhase
+		expectReady   bool
+	}{
+		{
+			name:          "nothing started yet",
+			containers:    map[string]api.ContainerState{},
+			expectedPhase: api.PodPending,
+		},
+		{
+			name:          "init container still running",
+			containers:    map[string]api.ContainerState{"init": running()},
+			expectedPhase: api.PodPending,
+		},
+		{
+			name:          "init container failed",
+			containers:    map[string]api.ContainerState{"init": exited(1)},
+			expectedPhase: api.PodFailed,
+		},
+		{
+			name:          "all containers running",
+			containers:    map[string]api.ContainerState{"init": exited(0), "app": running(), "sidecar": running()},
+			expectedPhase: api.PodRunning,
+			expectReady:   true,
+		},
+		{
+			name:          "one container exited",
+			containers:    map[string]api.ContainerState{"init": exited(0), "app": running(), "sidecar": exited(1)},
+			expectedPhase: api.PodRunning,
+		},
+		{
+			name:          "all containers succeeded",
+			containers:    map[string]api.ContainerState{"init": exited(0), "app": exited(0), "sidecar": exited(0)},
+			expectedPhase: api.PodSucceeded,
+		},
+		{
+			name:          "a container failed",
+			containers:    map[string]api.ContainerState{"init": exited(0), "app": exited(0), "sidecar": exited(2)},
+			expectedPhase: api.PodFailed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := runtime.PodStatus{IP: "10.0.0.5", Containers: map[string]runtime.ContainerStatus{}}
+			for name, state := range tc.containers {
+				rs.Containers[name] = runtime.ContainerStatus{Id: name + "-id", State: state}
+			}
+			status := generatePodStatus(pod, rs, nil, time.Now())
+			if status.Phase != tc.expectedPhase {
+				t.Errorf("phase = %s, expected %s", status.Phase, tc.expectedPhase)
+			}
+			ready := status.GetCondition(api.PodReady)
+			if ready == nil || (ready.Status == api.ConditionTrue) != tc.expectReady {
+				t.Errorf("ready condition = %+v, expected ready %v", ready, tc.expectReady)
+			}
+			if status.PodIP != "10.0.0.5" || status.StartTime == nil {
+				t.Errorf("expected podIP and startTime to be set, got %+v", status)
+			}
+			if len(status.ContainerStatuses) != 2 || len(status.InitContainerStatuses) != 1 {
+				t.Errorf("expected a status per container, got %+v", status)
+			}
+		})
+	}
+}
+
+func TestGeneratePodStatusKeepsTransitionTimes(t *testing.T) {
+	pod := api.Pod{Spec: api.PodSpec{Containers: []api.Container{{Name: "app"}}}}
+	rs := runtime.PodStatus{Containers: map[string]runtime.ContainerStatus{"app": {State: running()}}}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := generatePodStatus(pod, rs, nil, start)
+
+	pod.Status = first
+	second := generatePodStatus(pod, rs, nil, start.Add(time.Minute))
+	if !second.GetCondition(api.PodReady).LastTransitionTime.Equal(start) {
+		t.Errorf("transition time changed without the condition flipping")
+	}
+	if !second.StartTime.Equal(*first.StartTime) {
+		t.Errorf("start time changed between syncs")
+	}
+
+	pod.Status = second
+	rs.Containers["app"] = runtime.ContainerStatus{State: exited(1)}
+	third := generatePodStatus(pod, rs, nil, start.Add(2*time.Minute))
+	if !third.GetCondition(api.PodReady).LastTransitionTime.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("transition time not updated when the condition flipped")
+	}
+}
+
+func TestSyncPodStatusReportsChanges(t *testing.T) {
+	var mu sync.Mutex
+	var reported []api.Pod
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v1/pods/default/web/status" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var p api.Pod
+		json.NewDecoder(r.Body).Decode(&p)
+		mu.Lock()
+		reported = append(reported, p)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	rt := &runtime.FakeRuntime{StartedContainers: []string{"app"}}
+	k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: srv.URL, NodeName: "test-node"}, rt)
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uuid.New(), ResourceVersion: "3"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app"}}, RestartPolicy: api.RestartPolicyNever},
+	}
+	k.AddPod(pod)
+
+	k.syncPodStatus(t.Context(), pod.Uid)
+	k.syncPodStatus(t.Context(), pod.Uid)
+	rt.ContainerStates = map[string]api.ContainerState{"app": exited(0)}
+	k.syncPodStatus(t.Context(), pod.Uid)
+	// a finished pod isn't brought back by its container showing up again
+	rt.ContainerStates = map[string]api.ContainerState{"app": running()}
+	k.syncPodStatus(t.Context(), pod.Uid)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 2 {
+		t.Fatalf("expected 2 status reports, got %d", len(reported))
+	}
+	if reported[0].ResourceVersion != "3" {
+		t.Errorf("status reported with resourceVersion %q, expected the tracked 3", reported[0].ResourceVersion)
+	}
+	if reported[0].Status.Phase != api.PodRunning || reported[1].Status.Phase != api.PodSucceeded {
+		t.Errorf("reported phases %s, %s, expected Running, Succeeded", reported[0].Status.Phase, reported[1].Status.Phase)
+	}
+}
+
+func TestReportPodStatusConflict(t *testing.T) {
+	uid := uuid.New()
+	scheduled := api.PodCondition{Type: api.PodScheduled, Status: api.ConditionTrue, Reason: "Bound"}
+	var mu sync.Mutex
+	var reported []api.Pod
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/pods/default/web":
+			// the scheduler wrote the pod since the kubelet last saw it
+			json.NewEncoder(w).Encode(api.Pod{
+				ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uid, ResourceVersion: "5"},
+				Status:     api.PodStatus{Phase: api.PodPending, Conditions: []api.PodCondition{scheduled}},
+			})
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/pods/default/web/status":
+			var p api.Pod
+			json.NewDecoder(r.Body).Decode(&p)
+			mu.Lock()
+			reported = append(reported, p)
+			mu.Unlock()
+			if p.ResourceVersion != "5" {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	k := NewKubeletWithRuntime(KubeletOpts{APIServerURL: srv.URL, NodeName: "test-node"}, &runtime.FakeRuntime{})
+	now := time.Now()
+	k.reportPodStatus(t.Context(), api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "web", Namespace: "default", Uid: uid, ResourceVersion: "3"},
+		Status: api.PodStatus{
+			Phase: api.PodRunning,
+			Conditions: []api.PodCondition{
+				{Type: api.PodScheduled, Status: api.ConditionTrue, LastTransitionTime: now},
+				{Type: api.PodReady, Status: api.ConditionTrue, LastTransitionTime: now},
+			},
+		},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 2 {
+		t.Fatalf("expected the status to be written again after the conflict, got %d writes", len(reported))
+	}
+	status := reported[1].Status
+	if status.Phase != api.PodRunning || status.GetCondition(api.PodReady) == nil {
+		t.Errorf("the kubelet's status wasn't written: %+v", status)
+	}
+	if c := status.GetCondition(api.PodScheduled); c == nil || c.Reason != "Bound" {
+		t.Errorf("expected the scheduler's PodScheduled condition to be kept, got %+v", c)
+	}
+}
+
+func TestGeneratePodStatusRestartPolicy(t *testing.T) {
+	testCases := []struct {
+		name          string
+		policy        api.RestartPolicy
+		state         api.ContainerState
+		expectedPhase api.PodPhase
+		expectBackOff bool
+	}{
+		{name: "always restarts a succeeded container", policy: api.RestartPolicyAlways, state: exited(0), expectedPhase: api.PodRunning, expectBackOff: true},
+		{name: "on failure restarts a failed container", policy: api.RestartPolicyOnFailure, state: exited(1), expectedPhase: api.PodRunning, expectBackOff: true},
+		{name: "on failure lets a succeeded container be", policy: api.RestartPolicyOnFailure, state: exited(0), expectedPhase: api.PodSucceeded},
+		{name: "never lets a failed container be", policy: api.RestartPolicyNever, state: exited(1), expectedPhase: api.PodFailed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := api.Pod{Spec: api.PodSpec{Containers: []api.Container{{Name: "app"}}, RestartPolicy: tc.policy}}
+			rs := runtime.PodStatus{Containers: map[string]runtime.ContainerStatus{"app": {State: tc.state}}}
+			status := generatePodStatus(pod, rs, nil, time.Now())
+			if status.Phase != tc.expectedPhase {
+				t.Errorf("phase = %s, expected %s", status.Phase, tc.expectedPhase)
+			}
+			cs := status.ContainerStatuses[0]
+			backingOff := cs.State.Waiting != nil && cs.State.Waiting.Reason == crashLoopBackOff
+			if backingOff != tc.expectBackOff {
+				t.Errorf("container state %+v, expected back-off %v", cs.State, tc.expectBackOff)
+			}
+			if backingOff && cs.LastTerminationState.Terminated == nil {
+				t.Errorf("expected the exit to be kept as the last termination state")
+			}
+		})
+	}
+}
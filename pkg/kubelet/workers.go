@@ -0,0 +1,174 @@
+package kubelet
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+)
+
+// podUpdate is the latest the kubelet heard about a pod, from the watch or from a resync
+type podUpdate struct {
+	pod api.Pod
+	// the pod is gone from the apiserver, there's nobody left to confirm its deletion to
+	removed bool
+}
+
+// terminates reports whether the update stops the pod
+func (u podUpdate) terminates() bool {
+	return u.removed || u.pod.DeletionTimestamp != nil
+}
+
+// updatePod hands u to the pod's worker, starting one if the pod has none.
+// Every pod gets its own worker so a slow pod, like one running init containers or shutting down, doesn't hold up the others,
+// while everything done to one pod happens in order. Only the latest update matters, one the worker hasn't picked up yet
+// is replaced, except that nothing replaces a termination.
+func (k *Kubelet) updatePod(ctx context.Context, u podUpdate) {
+	k.workersMu.Lock()
+	defer k.workersMu.Unlock()
+	updates, ok := k.podUpdates[u.pod.Uid]
+	if !ok {
+		updates = make(chan podUpdate, 1)
+		k.podUpdates[u.pod.Uid] = updates
+		go k.podWorker(ctx, u.pod.Uid, updates)
+	}
+	select {
+	case pending := <-updates:
+		if pending.terminates() && !u.terminates() {
+			u = pending
+		}
+	default:
+	}
+	updates <- u
+}
+
+// podWorker syncs one pod per update until the pod is no longer tracked and nothing is pending
+func (k *Kubelet) podWorker(ctx context.Context, uid uuid.UUID, updates chan podUpdate) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case u := <-updates:
+			k.syncPod(ctx, u)
+		}
+		k.workersMu.Lock()
+		if _, err := k.GetPod(uid); err != nil && len(updates) == 0 {
+			delete(k.podUpdates, uid)
+			k.workersMu.Unlock()
+			return
+		}
+		k.workersMu.Unlock()
+	}
+}
+
+// syncPod makes the runtime match what the update asks of the pod, whatever updates came before it.
+// A pod is created if it isn't tracked yet and created again if its containers have gone missing from the runtime
+// or its containers were changed, a pod that's deleted is terminated.
+func (k *Kubelet) syncPod(ctx context.Context, u podUpdate) {
+	if u.terminates() {
+		k.handlePodDelete(ctx, u.pod, !u.removed)
+		return
+	}
+	p, err := k.GetPod(u.pod.Uid)
+	if err != nil {
+		// a pod that finished before the kubelet started isn't run again
+		if finished(u.pod) {
+			return
+		}
+		k.createPod(ctx, u.pod)
+		return
+	}
+	if finished(p) {
+		return
+	}
+	// an older update, say from a resync that listed before the watch delivered this one, doesn't undo a newer one
+	if u.pod.Generation < p.Generation {
+		return
+	}
+	if u.pod.Generation > p.Generation && !sameContainers(p.Spec, u.pod.Spec) {
+		k.recreatePod(ctx, p, u.pod)
+		return
+	}
+	p = k.refreshPod(u.pod)
+	rs, err := k.containerruntime.GetPodStatus(ctx, p)
+	if err != nil {
+		slog.Error("failed to get pod status from runtime", "pod", p.Name, "error", err)
+		return
+	}
+	for _, c := range p.Spec.Containers {
+		if _, ok := rs.Containers[c.Name]; ok {
+			continue
+		}
+		slog.Warn("container is gone from the runtime, recreating pod", "pod", p.Name, "container", c.Name)
+		// clear out whatever is left of the pod first, its containers are recreated under the same names
+		if err := k.DeletePod(ctx, p); err != nil {
+			slog.Error("failed to delete pod", "pod", p.Name, "error", err)
+			return
+		}
+		k.createPod(ctx, p)
+		return
+	}
+}
+
+// recreatePod replaces the containers of p with the ones updated asks for.
+// Containers can't be changed in place, so the old ones are stopped within the pod's grace period first.
+func (k *Kubelet) recreatePod(ctx context.Context, p, updated api.Pod) {
+	slog.Info("pod containers changed, recreating pod", "pod", p.Name, "generation", updated.Generation)
+	k.prober.remove(p.Uid)
+	var gracePeriod time.Duration
+	if g := p.Spec.TerminationGracePeriodSeconds; g != nil {
+		gracePeriod = time.Duration(*g) * time.Second
+	}
+	if err := k.containerruntime.StopPod(ctx, p, gracePeriod); err != nil {
+		slog.Error("failed to stop pod", "pod", p.Name, "error", err)
+	}
+	if err := k.DeletePod(ctx, p); err != nil {
+		slog.Error("failed to delete pod", "pod", p.Name, "error", err)
+		return
+	}
+	k.mu.Lock()
+	k.forgetBackoffs(p.Uid)
+	k.mu.Unlock()
+	k.createPod(ctx, updated)
+}
+
+// refreshPod stores updated as the tracked pod, keeping the container ids and the status the kubelet has for it
+func (k *Kubelet) refreshPod(updated api.Pod) api.Pod {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	p, ok := k.pods[updated.Uid]
+	if !ok {
+		return updated
+	}
+	updated.Status = p.Status
+	ids := map[string]string{}
+	for _, c := range append(slices.Clone(p.Spec.InitContainers), p.Spec.Containers...) {
+		ids[c.Name] = c.ContainerId
+	}
+	for i, c := range updated.Spec.InitContainers {
+		updated.Spec.InitContainers[i].ContainerId = ids[c.Name]
+	}
+	for i, c := range updated.Spec.Containers {
+		updated.Spec.Containers[i].ContainerId = ids[c.Name]
+	}
+	k.pods[updated.Uid] = updated
+	return updated
+}
+
+// sameContainers reports whether a and b run the same containers, the ids the kubelet filled in aside
+func sameContainers(a, b api.PodSpec) bool {
+	same := func(x, y api.Container) bool {
+		x.ContainerId, y.ContainerId = "", ""
+		return reflect.DeepEqual(x, y)
+	}
+	return slices.EqualFunc(a.InitContainers, b.InitContainers, same) && slices.EqualFunc(a.Containers, b.Containers, same)
+}
+
+func finished(p api.Pod) bool {
+	return p.Status.Phase == api.PodSucceeded || p.Status.Phase == api.PodFailed
+}
@@ -0,0 +1,457 @@
+// Package portforward tunnels TCP connections to a pod's ports over a single WebSocket,
+// it's how port-forwards reach pods through the apiserver and the kubelet.
+//
+// Every binary message is a frame: the stream's id as 4 bytes big endian, the frame type, then the frame's data.
+// The client opens a stream for every connection it accepts, naming the pod's port in the open frame,
+// and both ends send data until they close their side. A stream that couldn't be connected gets an error frame instead.
+// Streams aren't flow controlled, data is buffered until it's read.
+package portforward
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Protocol is the WebSocket subprotocol both ends agree on
+const Protocol = "v1.portforward.superminikube"
+
+const (
+	// data is the pod's port, 2 bytes big endian
+	frameOpen byte = iota
+	frameData
+	// the sender is done writing, like a TCP half-close
+	frameClose
+	// data is why the stream failed, it's over for both ends
+	frameError
+)
+
+const (
+	headerLen = 5
+	// larger writes are split up
+	maxFrameData = 32 * 1024
+	// how long closing the connection waits for the close message to go out
+	closeTimeout = time.Second
+)
+
+// Stream is one tunneled connection
+type Stream interface {
+	io.ReadWriteCloser
+	// CloseWrite tells the other end nothing more is coming, reading goes on
+	CloseWrite() error
+}
+
+var upgrader = websocket.Upgrader{
+	Subprotocols: []string{Protocol},
+	// clients aren't browsers, there's no page whose origin could be checked
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// Serve upgrades the request and calls forward for every stream the client opens, until the client goes away.
+// forward connects the stream to the pod's port and returns once the connection is over, an error fails the stream.
+func Serve(w http.ResponseWriter, r *http.Request, forward func(ctx context.Context, port uint16, stream Stream) error) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already replied with an error
+		slog.Error("failed to upgrade to a port-forward", "url", r.URL, "error", err)
+		return
+	}
+	s := newSession(ws)
+	defer s.close()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	s.run(func(st *stream, port uint16) {
+		defer st.Close()
+		if port == 0 {
+			st.fail(errors.New("invalid port 0"))
+			return
+		}
+		if err := forward(ctx, port, st); err != nil && ctx.Err() == nil {
+			slog.Warn("port-forward failed", "port", port, "error", err)
+			st.fail(err)
+		}
+	})
+}
+
+// Join copies between stream and conn both ways until both are done, passing half-closes on.
+// Either side failing ends both.
+func Join(stream Stream, conn net.Conn) error {
+	errs := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(conn, stream)
+		if err == nil {
+			err = closeWrite(conn)
+		}
+		errs <- err
+	}()
+	go func() {
+		_, err := io.Copy(stream, conn)
+		if err == nil {
+			err = stream.CloseWrite()
+		}
+		errs <- err
+	}()
+	if err := <-errs; err != nil {
+		// unblocks the other copy
+		conn.Close()
+		stream.Close()
+		<-errs
+		return err
+	}
+	return <-errs
+}
+
+func closeWrite(conn net.Conn) error {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return conn.Close()
+}
+
+// Port is a local port forwarded to a port of the pod
+type Port struct {
+	// zero picks a free port
+	Local  uint16
+	Remote uint16
+}
+
+// ParsePort reads "local:remote", or "port" for the same port on both ends
+func ParsePort(s string) (Port, error) {
+	local, remote, found := strings.Cut(s, ":")
+	if !found {
+		remote = local
+	}
+	l, err := strconv.ParseUint(local, 10, 16)
+	if err != nil {
+		return Port{}, fmt.Errorf("invalid local port %q", local)
+	}
+	r, err := strconv.ParseUint(remote, 10, 16)
+	if err != nil || r == 0 {
+		return Port{}, fmt.Errorf("invalid remote port %q", remote)
+	}
+	return Port{Local: uint16(l), Remote: uint16(r)}, nil
+}
+
+// Forwarder listens on localhost and tunnels every connection it accepts to the pod
+type Forwarder struct {
+	session   *session
+	listeners []net.Listener
+	ports     []Port
+	done      chan struct{}
+	// why the forwarder stopped, set before done is closed
+	err error
+}
+
+// Forward listens on 127.0.0.1 for every port and tunnels the connections it accepts over a single connection to u,
+// an http:// or https:// URL upgraded to a WebSocket, header is sent along with the upgrade request and can be nil.
+// It forwards until ctx is done or the connection breaks.
+func Forward(ctx context.Context, u *url.URL, header http.Header, ports []Port) (*Forwarder, error) {
+	f := &Forwarder{done: make(chan struct{})}
+	for _, p := range ports {
+		l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(p.Local))))
+		if err != nil {
+			f.closeListeners()
+			return nil, fmt.Errorf("failed to listen on port %d: %v", p.Local, err)
+		}
+		f.listeners = append(f.listeners, l)
+		f.ports = append(f.ports, Port{Local: uint16(l.Addr().(*net.TCPAddr).Port), Remote: p.Remote})
+	}
+	wsURL := *u
+	wsURL.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	dialer := websocket.Dialer{Subprotocols: []string{Protocol}, HandshakeTimeout: 10 * time.Second}
+	ws, resp, err := dialer.DialContext(ctx, wsURL.String(), header)
+	if err != nil {
+		f.closeListeners()
+		if resp != nil {
+			defer resp.Body.Close()
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		}
+		return nil, fmt.Errorf("failed to connect: %v", err)
+	}
+	f.session = newSession(ws)
+	go f.session.run(nil)
+	for i, l := range f.listeners {
+		go f.accept(l, f.ports[i].Remote)
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-f.session.done:
+			f.err = f.session.err
+		}
+		f.closeListeners()
+		f.session.close()
+		close(f.done)
+	}()
+	return f, nil
+}
+
+// Ports are the ports forwarded, with the local ports that were picked filled in
+func (f *Forwarder) Ports() []Port {
+	return f.ports
+}
+
+// Wait blocks until the forwarder stopped, it returns why if it wasn't ctx
+func (f *Forwarder) Wait() error {
+	<-f.done
+	return f.err
+}
+
+func (f *Forwarder) accept(l net.Listener, port uint16) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			st, err := f.session.open(port)
+			if err != nil {
+				slog.Warn("failed to open port-forward stream", "port", port, "error", err)
+				return
+			}
+			defer st.Close()
+			slog.Debug("forwarding connection", "port", port, "from", conn.RemoteAddr())
+			if err := Join(st, conn); err != nil {
+				slog.Warn("port-forward failed", "port", port, "error", err)
+			}
+		}()
+	}
+}
+
+func (f *Forwarder) closeListeners() {
+	for _, l := range f.listeners {
+		l.Close()
+	}
+}
+
+// session multiplexes streams over one WebSocket
+type session struct {
+	ws *websocket.Conn
+	// a WebSocket only takes one writer at a time
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	streams map[uint32]*stream
+	lastID  uint32
+	done    chan struct{}
+	// why the connection broke, set before done is closed
+	err error
+}
+
+func newSession(ws *websocket.Conn) *session {
+	return &session{ws: ws, streams: map[uint32]*stream{}, done: make(chan struct{})}
+}
+
+// run reads frames until the connection breaks. open is called in its own goroutine for every stream
+// the other end opens, sessions that only open streams themselves pass nil.
+func (s *session) run(open func(st *stream, port uint16)) {
+	for {
+		_, msg, err := s.ws.ReadMessage()
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		if len(msg) < headerLen {
+			continue
+		}
+		id, typ, data := binary.BigEndian.Uint32(msg), msg[4], msg[headerLen:]
+		if typ == frameOpen {
+			if open == nil || len(data) != 2 {
+				continue
+			}
+			go open(s.add(id), binary.BigEndian.Uint16(data))
+			continue
+		}
+		s.mu.Lock()
+		st := s.streams[id]
+		s.mu.Unlock()
+		if st == nil {
+			// closed on this end already
+			continue
+		}
+		switch typ {
+		case frameData:
+			st.push(data)
+		case frameClose:
+			st.end(io.EOF)
+		case frameError:
+			st.end(errors.New(string(data)))
+			s.remove(id)
+		}
+	}
+}
+
+// open starts a stream to the pod's port
+func (s *session) open(port uint16) (*stream, error) {
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("connection lost: %v", s.err)
+	}
+	s.lastID++
+	id := s.lastID
+	s.mu.Unlock()
+	st := s.add(id)
+	if err := s.write(id, frameOpen, binary.BigEndian.AppendUint16(nil, port)); err != nil {
+		s.remove(id)
+		return nil, err
+	}
+	return st, nil
+}
+
+func (s *session) add(id uint32) *stream {
+	st := &stream{id: id, s: s}
+	st.cond = sync.NewCond(&st.mu)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams[id] = st
+	return st
+}
+
+func (s *session) remove(id uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, id)
+}
+
+func (s *session) write(id uint32, typ byte, data []byte) error {
+	msg := make([]byte, headerLen, headerLen+len(data))
+	binary.BigEndian.PutUint32(msg, id)
+	msg[4] = typ
+	msg = append(msg, data...)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.ws.WriteMessage(websocket.BinaryMessage, msg)
+}
+
+// fail ends every stream once the connection broke
+func (s *session) fail(err error) {
+	s.mu.Lock()
+	s.err = err
+	streams := s.streams
+	s.streams = map[uint32]*stream{}
+	s.mu.Unlock()
+	for _, st := range streams {
+		st.end(fmt.Errorf("connection lost: %v", err))
+	}
+	close(s.done)
+}
+
+// close tells the other end the session is over before closing the connection
+func (s *session) close() {
+	s.writeMu.Lock()
+	s.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(closeTimeout))
+	s.writeMu.Unlock()
+	s.ws.Close()
+}
+
+type stream struct {
+	id uint32
+	s  *session
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	buf  bytes.Buffer
+	// why reading is over once buf is drained, io.EOF once the other end closed its side
+	readErr error
+	closed  bool
+
+	// held across writes so nothing follows the close frame
+	writeMu     sync.Mutex
+	writeClosed bool
+}
+
+func (st *stream) push(data []byte) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.closed && st.readErr == nil {
+		st.buf.Write(data)
+	}
+	st.cond.Broadcast()
+}
+
+func (st *stream) end(err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.readErr == nil {
+		st.readErr = err
+	}
+	st.cond.Broadcast()
+}
+
+func (st *stream) Read(p []byte) (int, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for st.buf.Len() == 0 && st.readErr == nil && !st.closed {
+		st.cond.Wait()
+	}
+	if st.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if st.buf.Len() > 0 {
+		return st.buf.Read(p)
+	}
+	return 0, st.readErr
+}
+
+func (st *stream) Write(p []byte) (int, error) {
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+	if st.writeClosed {
+		return 0, io.ErrClosedPipe
+	}
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), maxFrameData)
+		if err := st.s.write(st.id, frameData, p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+func (st *stream) CloseWrite() error {
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+	if st.writeClosed {
+		return nil
+	}
+	st.writeClosed = true
+	return st.s.write(st.id, frameClose, nil)
+}
+
+// Close closes both sides, whatever the other end still sends is dropped
+func (st *stream) Close() error {
+	err := st.CloseWrite()
+	st.mu.Lock()
+	st.closed = true
+	st.cond.Broadcast()
+	st.mu.Unlock()
+	st.s.remove(st.id)
+	return err
+}
+
+// fail tells the other end the stream couldn't be connected
+func (st *stream) fail(err error) {
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+	st.writeClosed = true
+	st.s.write(st.id, frameError, []byte(err.Error()))
+}
@@ -0,0 +1,129 @@
+package portforward
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestForward(t *testing.T) {
+	// answers with what it was sent once the sender is done
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				b, _ := io.ReadAll(conn)
+				conn.Write([]byte(strings.ToUpper(string(b))))
+			}()
+		}
+	}()
+	echoPort := uint16(echo.Addr().(*net.TCPAddr).Port)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Serve(w, r, func(ctx context.Context, port uint16, stream Stream) error {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", fmt.Sprintf("127.0.0.1:%d", port))
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			return Join(stream, conn)
+		})
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	f, err := Forward(ctx, u, nil, []Port{{Remote: echoPort}, {Remote: 1}})
+	if err != nil {
+		t.Fatalf("failed to forward: %v", err)
+	}
+	ports := f.Ports()
+	if ports[0].Local == 0 || ports[0].Remote != echoPort {
+		t.Fatalf("got ports %+v, expected a local port to be picked", ports)
+	}
+
+	t.Run("concurrent connections", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", ports[0].Local))
+				if err != nil {
+					t.Errorf("failed to connect: %v", err)
+					return
+				}
+				defer conn.Close()
+				// large enough to take several frames
+				sent := strings.Repeat(fmt.Sprintf("connection %d ", i), 10000)
+				go func() {
+					conn.Write([]byte(sent))
+					conn.(*net.TCPConn).CloseWrite()
+				}()
+				got, err := io.ReadAll(conn)
+				if err != nil || string(got) != strings.ToUpper(sent) {
+					t.Errorf("connection %d got %d bytes, %v, expected its own data back", i, len(got), err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", ports[1].Local))
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		defer conn.Close()
+		// nothing listens on the remote port, the local connection is closed without data
+		if got, _ := io.ReadAll(conn); len(got) != 0 {
+			t.Errorf("got %q from a port nothing listens on", got)
+		}
+	})
+
+	cancel()
+	if err := f.Wait(); err != nil {
+		t.Errorf("forwarder stopped with %v, expected nothing once ctx is done", err)
+	}
+	if _, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", ports[0].Local)); err == nil {
+		t.Errorf("still listening after the forwarder stopped")
+	}
+}
+
+func TestParsePort(t *testing.T) {
+	testCases := []struct {
+		in       string
+		expected Port
+		err      bool
+	}{
+		{in: "8080", expected: Port{Local: 8080, Remote: 8080}},
+		{in: "9000:80", expected: Port{Local: 9000, Remote: 80}},
+		{in: "0:80", expected: Port{Remote: 80}},
+		{in: "80:0", err: true},
+		{in: "http", err: true},
+		{in: "70000:80", err: true},
+	}
+	for _, tc := range testCases {
+		got, err := ParsePort(tc.in)
+		if (err != nil) != tc.err || got != tc.expected {
+			t.Errorf("ParsePort(%q) = %+v, %v", tc.in, got, err)
+		}
+	}
+}
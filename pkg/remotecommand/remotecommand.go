@@ -0,0 +1,288 @@
+// Package remotecommand carries the streams of a command running in a container over a single WebSocket,
+// it's how exec and attach reach containers through the apiserver and the kubelet.
+//
+// Every binary message starts with the byte of the channel it belongs to, the rest is the channel's data.
+// An empty stdin message closes stdin, resize messages hold a JSON TerminalSize
+// and the kubelet ends the stream with a JSON Status on the error channel.
+package remotecommand
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"superminikube/pkg/api"
+)
+
+// Protocol is the WebSocket subprotocol both ends agree on
+const Protocol = "v1.channel.superminikube"
+
+const (
+	StdinChannel byte = iota
+	StdoutChannel
+	StderrChannel
+	ErrorChannel
+	ResizeChannel
+)
+
+// how long closing the connection waits on the other end
+const closeTimeout = time.Second
+
+type TerminalSize struct {
+	Width  uint16 `json:"width"`
+	Height uint16 `json:"height"`
+}
+
+// Streams are what a command is connected to, nil streams are left unattached
+type Streams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// stdout and stderr are a single stream on a TTY, all of it comes out of Stdout
+	TTY bool
+	// sizes the terminal is resized to, only used with a TTY
+	Resize <-chan TerminalSize
+}
+
+// Status is how the command ended
+type Status struct {
+	ExitCode int `json:"exitCode"`
+	// why the command couldn't run or was cut short, empty when it ran to its end
+	Message string `json:"message,omitempty"`
+}
+
+var upgrader = websocket.Upgrader{
+	Subprotocols: []string{Protocol},
+	// clients aren't browsers, there's no page whose origin could be checked
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// Serve upgrades the request and runs fn with the streams opts ask for attached to the connection.
+// fn's ctx is done once the client goes away. What fn returns is sent as the Status before the connection is closed.
+func Serve(w http.ResponseWriter, r *http.Request, opts api.PodExecOptions, fn func(ctx context.Context, streams Streams) (int, error)) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already replied with an error
+		slog.Error("failed to upgrade to a stream", "url", r.URL, "error", err)
+		return
+	}
+	c := &conn{ws: ws}
+	defer ws.Close()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	stdin, stdinWriter := io.Pipe()
+	resize := make(chan TerminalSize, 1)
+	streams := Streams{TTY: opts.TTY}
+	if opts.Stdin {
+		streams.Stdin = stdin
+	}
+	if opts.Stdout {
+		streams.Stdout = channelWriter{c, StdoutChannel}
+	}
+	if opts.Stderr && !opts.TTY {
+		streams.Stderr = channelWriter{c, StderrChannel}
+	}
+	if opts.TTY {
+		streams.Resize = resize
+	}
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		defer cancel()
+		defer close(resize)
+		for {
+			_, msg, err := ws.ReadMessage()
+			if err != nil {
+				stdinWriter.CloseWithError(err)
+				return
+			}
+			if len(msg) == 0 {
+				continue
+			}
+			switch msg[0] {
+			case StdinChannel:
+				if !opts.Stdin {
+					continue
+				}
+				if len(msg) == 1 {
+					stdinWriter.Close()
+					continue
+				}
+				// fails once stdin is closed, there's nobody left to read it
+				stdinWriter.Write(msg[1:])
+			case ResizeChannel:
+				var size TerminalSize
+				if err := json.Unmarshal(msg[1:], &size); err != nil {
+					slog.Warn("ignoring invalid terminal size", "error", err)
+					continue
+				}
+				// only the latest size matters, a size that wasn't picked up yet is replaced
+				select {
+				case <-resize:
+				default:
+				}
+				resize <- size
+			}
+		}
+	}()
+
+	code, err := fn(ctx, streams)
+	// the command is done reading stdin, whatever still comes in is dropped instead of blocking the reader
+	stdin.Close()
+	status := Status{ExitCode: code}
+	if err != nil {
+		status.Message = err.Error()
+	}
+	b, _ := json.Marshal(status)
+	if err := c.write(ErrorChannel, b); err != nil && ctx.Err() == nil {
+		slog.Error("failed to send status", "error", err)
+	}
+	// closing with input left unread resets the connection and can lose the status on its way out,
+	// the client gets a moment to close its end first
+	c.writeClose()
+	select {
+	case <-readDone:
+	case <-time.After(closeTimeout):
+	}
+}
+
+// Stream connects streams to the command behind u, an http:// or https:// URL upgraded to a WebSocket,
+// until the command is done. header is sent along with the upgrade request, it can be nil.
+// It returns the command's exit code, a Status with a message comes back as an error.
+func Stream(ctx context.Context, u *url.URL, header http.Header, streams Streams) (int, error) {
+	wsURL := *u
+	wsURL.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	dialer := websocket.Dialer{Subprotocols: []string{Protocol}, HandshakeTimeout: 10 * time.Second}
+	ws, resp, err := dialer.DialContext(ctx, wsURL.String(), header)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return 0, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		}
+		return 0, fmt.Errorf("failed to connect: %v", err)
+	}
+	c := &conn{ws: ws}
+	defer ws.Close()
+	defer c.writeClose()
+	stop := context.AfterFunc(ctx, func() { ws.Close() })
+	defer stop()
+
+	done := make(chan struct{})
+	defer close(done)
+	if streams.Stdin != nil {
+		go func() {
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := streams.Stdin.Read(buf)
+				if n > 0 {
+					if c.write(StdinChannel, buf[:n]) != nil {
+						return
+					}
+				}
+				if errors.Is(err, io.EOF) {
+					c.write(StdinChannel, nil)
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	if streams.TTY && streams.Resize != nil {
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case size, ok := <-streams.Resize:
+					if !ok {
+						return
+					}
+					b, _ := json.Marshal(size)
+					if c.write(ResizeChannel, b) != nil {
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			return 0, fmt.Errorf("stream ended before the command was done: %v", err)
+		}
+		if len(msg) == 0 {
+			continue
+		}
+		var out io.Writer
+		switch msg[0] {
+		case StdoutChannel:
+			out = streams.Stdout
+		case StderrChannel:
+			out = streams.Stderr
+		case ErrorChannel:
+			var status Status
+			if err := json.Unmarshal(msg[1:], &status); err != nil {
+				return 0, fmt.Errorf("invalid status: %v", err)
+			}
+			if status.Message != "" {
+				return status.ExitCode, errors.New(status.Message)
+			}
+			return status.ExitCode, nil
+		}
+		if out == nil {
+			continue
+		}
+		if _, err := out.Write(msg[1:]); err != nil {
+			return 0, fmt.Errorf("failed to write output: %v", err)
+		}
+	}
+}
+
+// conn serializes writes, a WebSocket only takes one writer at a time
+type conn struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+func (c *conn) write(channel byte, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteMessage(websocket.BinaryMessage, append([]byte{channel}, data...))
+}
+
+// writeClose tells the other end the stream is over, it sees a clean close rather than a dropped connection
+func (c *conn) writeClose() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(closeTimeout))
+}
+
+// channelWriter writes everything as messages on one channel
+type channelWriter struct {
+	c       *conn
+	channel byte
+}
+
+func (w channelWriter) Write(b []byte) (int, error) {
+	if err := w.c.write(w.channel, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,108 @@
+package remotecommand
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"superminikube/pkg/api"
+)
+
+func TestStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts, err := api.ParsePodExecOptions(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		Serve(w, r, opts, func(ctx context.Context, streams Streams) (int, error) {
+			if streams.Stderr != nil {
+				fmt.Fprintln(streams.Stderr, "starting")
+			}
+			if streams.Stdin == nil {
+				return 0, errors.New("no stdin to read")
+			}
+			scanner := bufio.NewScanner(streams.Stdin)
+			for scanner.Scan() {
+				// sizes come in ahead of the line sent after them
+				select {
+				case size := <-streams.Resize:
+					fmt.Fprintf(streams.Stdout, "%dx%d\n", size.Width, size.Height)
+				default:
+				}
+				fmt.Fprintln(streams.Stdout, strings.ToUpper(scanner.Text()))
+			}
+			return 3, nil
+		})
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	t.Run("stdin, stdout and stderr", func(t *testing.T) {
+		var stdout, stderr strings.Builder
+		code, err := Stream(t.Context(), withQuery(u, "stdin=1&stdout=1&stderr=1"), nil, Streams{
+			Stdin:  strings.NewReader("hello\nworld\n"),
+			Stdout: &stdout,
+			Stderr: &stderr,
+		})
+		if err != nil {
+			t.Fatalf("stream failed: %v", err)
+		}
+		if code != 3 {
+			t.Errorf("got exit code %d, expected 3", code)
+		}
+		if stdout.String() != "HELLO\nWORLD\n" || stderr.String() != "starting\n" {
+			t.Errorf("got stdout %q and stderr %q", stdout.String(), stderr.String())
+		}
+	})
+
+	t.Run("tty resize", func(t *testing.T) {
+		stdin, stdinWriter := io.Pipe()
+		resize := make(chan TerminalSize)
+		var stdout strings.Builder
+		done := make(chan error)
+		go func() {
+			_, err := Stream(t.Context(), withQuery(u, "stdin=1&stdout=1&tty=1"), nil, Streams{Stdin: stdin, Stdout: &stdout, TTY: true, Resize: resize})
+			done <- err
+		}()
+		size := TerminalSize{Width: 80, Height: 24}
+		resize <- size
+		// taken once the first size was sent, so it's ahead of stdin. The server only keeps the latest size.
+		resize <- size
+		io.WriteString(stdinWriter, "ls\n")
+		stdinWriter.Close()
+		if err := <-done; err != nil {
+			t.Fatalf("stream failed: %v", err)
+		}
+		if stdout.String() != "80x24\nLS\n" {
+			t.Errorf("got stdout %q", stdout.String())
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Stream(t.Context(), withQuery(u, "stdout=1"), nil, Streams{Stdout: io.Discard})
+		if err == nil || err.Error() != "no stdin to read" {
+			t.Errorf("got %v, expected the command's error", err)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		_, err := Stream(t.Context(), u, nil, Streams{Stdout: io.Discard})
+		if err == nil || !strings.Contains(err.Error(), "400") {
+			t.Errorf("got %v, expected the request to be rejected", err)
+		}
+	})
+}
+
+func withQuery(u *url.URL, query string) *url.URL {
+	q := *u
+	q.RawQuery = query
+	return &q
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"sort"
+
+	"superminikube/pkg/api"
+)
+
+// NodeInfo is a node together with the pods already bound to it
+type NodeInfo struct {
+	Node api.Node
+	Pods []api.Pod
+}
+
+// Requested sums the requests of every pod on the node
+func (n NodeInfo) Requested() api.ResourceList {
+	var total api.ResourceList
+	for _, p := range n.Pods {
+		total = total.Add(p.Requests())
+	}
+	return total
+}
+
+// nodeInfos groups pods by the node they're bound to, sorted by node name.
+// Finished pods don't hold on to anything so they're left out,
+// terminating ones still do until the kubelet has stopped them.
+func nodeInfos(nodes []api.Node, pods []api.Pod) []NodeInfo {
+	byName := make(map[string]int, len(nodes))
+	infos := make([]NodeInfo, 0, len(nodes))
+	for _, n := range nodes {
+		byName[n.Name] = len(infos)
+		infos = append(infos, NodeInfo{Node: n})
+	}
+	for _, p := range pods {
+		if p.Status.Phase == api.PodSucceeded || p.Status.Phase == api.PodFailed {
+			continue
+		}
+		if i, ok := byName[p.Nodename]; ok {
+			infos[i].Pods = append(infos[i].Pods, p)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Node.Name < infos[j].Node.Name })
+	return infos
+}
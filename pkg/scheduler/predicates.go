@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"errors"
+
+	"superminikube/pkg/api"
+)
+
+// Predicate filters out nodes the pod can't run on.
+// The error says why, it's counted across nodes for the pod's Unschedulable message
+// so it should be the same for every node failing for the same reason.
+type Predicate func(pod api.Pod, node NodeInfo) error
+
+var (
+	ErrNodeUnschedulable = errors.New("node(s) were unschedulable")
+	ErrInsufficientCPU   = errors.New("insufficient cpu")
+	ErrInsufficientMem   = errors.New("insufficient memory")
+	ErrTooManyPods       = errors.New("too many pods")
+	ErrNodeSelector      = errors.New("node(s) didn't match node selector")
+	ErrTaintsTolerations = errors.New("node(s) had taints that the pod didn't tolerate")
+	ErrHostPorts         = errors.New("node(s) didn't have free ports for the requested pod ports")
+)
+
+func DefaultPredicates() []Predicate {
+	return []Predicate{
+		NodeSchedulable,
+		PodFitsResources,
+		MatchNodeSelector,
+		PodToleratesNodeTaints,
+		PodFitsHostPorts,
+	}
+}
+
+// NodeSchedulable rejects nodes that have been cordoned
+func NodeSchedulable(pod api.Pod, node NodeInfo) error {
+	if node.Node.Spec.Unschedulable {
+		return ErrNodeUnschedulable
+	}
+	return nil
+}
+
+// PodFitsResources checks the node has room left for the pod's requests and for one more pod
+func PodFitsResources(pod api.Pod, node NodeInfo) error {
+	want := pod.Requests()
+	free := node.Node.Status.Allocatable
+	used := node.Requested()
+	if free.Pods > 0 && int64(len(node.Pods)) >= free.Pods {
+		return ErrTooManyPods
+	}
+	if want.MilliCPU > 0 && used.MilliCPU+want.MilliCPU > free.MilliCPU {
+		return ErrInsufficientCPU
+	}
+	if want.Memory > 0 && used.Memory+want.Memory > free.Memory {
+		return ErrInsufficientMem
+	}
+	return nil
+}
+
+// MatchNodeSelector checks the node carries every label in the pod's nodeSelector
+func MatchNodeSelector(pod api.Pod, node NodeInfo) error {
+	for k, v := range pod.Spec.NodeSelector {
+		if got, ok := node.Node.Labels[k]; !ok || got != v {
+			return ErrNodeSelector
+		}
+	}
+	return nil
+}
+
+// PodToleratesNodeTaints checks the pod tolerates every NoSchedule and NoExecute taint,
+// PreferNoSchedule taints only count against the node when scoring
+func PodToleratesNodeTaints(pod api.Pod, node NodeInfo) error {
+	for _, taint := range node.Node.Spec.Taints {
+		if taint.Effect == api.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !tolerated(pod, taint) {
+			return ErrTaintsTolerations
+		}
+	}
+	return nil
+}
+
+// PodFitsHostPorts checks none of the pod's host ports are taken by pods already on the node
+func PodFitsHostPorts(pod api.Pod, node NodeInfo) error {
+	used := map[string]bool{}
+	for _, p := range node.Pods {
+		for port := range hostPorts(p) {
+			used[port] = true
+		}
+	}
+	for port := range hostPorts(pod) {
+		if used[port] {
+			return ErrHostPorts
+		}
+	}
+	return nil
+}
+
+func hostPorts(pod api.Pod) map[string]bool {
+	ports := map[string]bool{}
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Hostport != "" {
+				ports[p.Hostport] = true
+			}
+		}
+	}
+	return ports
+}
+
+func tolerated(pod api.Pod, taint api.Taint) bool {
+	for _, t := range pod.Spec.Tolerations {
+		if t.Tolerates(taint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	"superminikube/pkg/api"
+)
+
+func testNode(name string, cpu, mem int64) api.Node {
+	return api.Node{
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Status:     api.NodeStatus{Allocatable: api.ResourceList{MilliCPU: cpu, Memory: mem}},
+	}
+}
+
+func testPod(name string, cpu, mem int64) api.Pod {
+	return api.Pod{
+		ObjectMeta: api.ObjectMeta{Namespace: "default", Name: name},
+		Spec: api.PodSpec{Containers: []api.Container{{
+			Name:      "app",
+			Image:     "nginx",
+			Resources: api.ResourceRequirements{Requests: api.ResourceList{MilliCPU: cpu, Memory: mem}},
+		}}},
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	withPort := func(p api.Pod, port string) api.Pod {
+		p.Spec.Containers[0].Ports = []api.Port{{Hostport: port, Containerport: "80"}}
+		return p
+	}
+	tainted := testNode("node", 1000, 1<<30)
+	tainted.Spec.Taints = []api.Taint{{Key: "gpu", Value: "true", Effect: api.TaintEffectNoSchedule}}
+	preferNot := testNode("node", 1000, 1<<30)
+	preferNot.Spec.Taints = []api.Taint{{Key: "spot", Effect: api.TaintEffectPreferNoSchedule}}
+	labelled := testNode("node", 1000, 1<<30)
+	labelled.Labels = map[string]string{"disk": "ssd"}
+	cordoned := testNode("node", 1000, 1<<30)
+	cordoned.Spec.Unschedulable = true
+
+	tolerating := testPod("web", 0, 0)
+	tolerating.Spec.Tolerations = []api.Toleration{{Key: "gpu", Operator: api.TolerationOpExists}}
+	selecting := testPod("web", 0, 0)
+	selecting.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+
+	testCases := []struct {
+		name      string
+		predicate Predicate
+		pod       api.Pod
+		node      NodeInfo
+		wantErr   error
+	}{
+		{
+			name:      "schedulable node",
+			predicate: NodeSchedulable,
+			pod:       testPod("web", 0, 0),
+			node:      NodeInfo{Node: testNode("node", 1000, 1<<30)},
+		},
+		{
+			name:      "cordoned node",
+			predicate: NodeSchedulable,
+			pod:       testPod("web", 0, 0),
+			node:      NodeInfo{Node: cordoned},
+			wantErr:   ErrNodeUnschedulable,
+		},
+		{
+			name:      "fits resources",
+			predicate: PodFitsResources,
+			pod:       testPod("web", 500, 1<<29),
+			node:      NodeInfo{Node: testNode("node", 1000, 1<<30), Pods: []api.Pod{testPod("db", 500, 1<<29)}},
+		},
+		{
+			name:      "not enough cpu left",
+			predicate: PodFitsResources,
+			pod:       testPod("web", 600, 0),
+			node:      NodeInfo{Node: testNode("node", 1000, 1<<30), Pods: []api.Pod{testPod("db", 500, 0)}},
+			wantErr:   ErrInsufficientCPU,
+		},
+		{
+			name:      "not enough memory",
+			predicate: PodFitsResources,
+			pod:       testPod("web", 0, 2<<30),
+			node:      NodeInfo{Node: testNode("node", 1000, 1<<30)},
+			wantErr:   ErrInsufficientMem,
+		},
+		{
+			name:      "node is full",
+			predicate: PodFitsResources,
+			pod:       testPod("web", 0, 0),
+			node: NodeInfo{
+				Node: api.Node{Status: api.NodeStatus{Allocatable: api.ResourceList{Pods: 1}}},
+				Pods: []api.Pod{testPod("db", 0, 0)},
+			},
+			wantErr: ErrTooManyPods,
+		},
+		{
+			name:      "no requests always fit",
+			predicate: PodFitsResources,
+			pod:       testPod("web", 0, 0),
+			node:      NodeInfo{Node: testNode("node", 0, 0)},
+		},
+		{
+			name:      "node selector matches",
+			predicate: MatchNodeSelector,
+			pod:       selecting,
+			node:      NodeInfo{Node: labelled},
+		},
+		{
+			name:      "node selector doesn't match",
+			predicate: MatchNodeSelector,
+			pod:       selecting,
+			node:      NodeInfo{Node: testNode("node", 1000, 1<<30)},
+			wantErr:   ErrNodeSelector,
+		},
+		{
+			name:      "untolerated taint",
+			predicate: PodToleratesNodeTaints,
+			pod:       testPod("web", 0, 0),
+			node:      NodeInfo{Node: tainted},
+			wantErr:   ErrTaintsTolerations,
+		},
+		{
+			name:      "tolerated taint",
+			predicate: PodToleratesNodeTaints,
+			pod:       tolerating,
+			node:      NodeInfo{Node: tainted},
+		},
+		{
+			name:      "prefer no schedule only affects scoring",
+			predicate: PodToleratesNodeTaints,
+			pod:       testPod("web", 0, 0),
+			node:      NodeInfo{Node: preferNot},
+		},
+		{
+			name:      "host port taken",
+			predicate: PodFitsHostPorts,
+			pod:       withPort(testPod("web", 0, 0), "8080"),
+			node:      NodeInfo{Node: testNode("node", 1000, 1<<30), Pods: []api.Pod{withPort(testPod("db", 0, 0), "8080")}},
+			wantErr:   ErrHostPorts,
+		},
+		{
+			name:      "host port free",
+			predicate: PodFitsHostPorts,
+			pod:       withPort(testPod("web", 0, 0), "8080"),
+			node:      NodeInfo{Node: testNode("node", 1000, 1<<30), Pods: []api.Pod{withPort(testPod("db", 0, 0), "8081")}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.predicate(tc.pod, tc.node)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
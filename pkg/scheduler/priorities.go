@@ -0,0 +1,47 @@
+package scheduler
+
+import "superminikube/pkg/api"
+
+const maxScore = 100
+
+// Priority scores the nodes that passed every predicate, from 0 to maxScore.
+// Each node's total is the weighted sum over all priorities and the highest total wins.
+type Priority struct {
+	Name   string
+	Weight int64
+	Score  func(pod api.Pod, node NodeInfo) int64
+}
+
+func DefaultPriorities() []Priority {
+	return []Priority{
+		{Name: "LeastRequested", Weight: 1, Score: LeastRequested},
+		{Name: "TaintToleration", Weight: 1, Score: TaintToleration},
+	}
+}
+
+// LeastRequested favours nodes with the most left over once the pod is placed,
+// spreading pods out across the cluster
+func LeastRequested(pod api.Pod, node NodeInfo) int64 {
+	requested := node.Requested().Add(pod.Requests())
+	allocatable := node.Node.Status.Allocatable
+	return (leastRequested(requested.MilliCPU, allocatable.MilliCPU) +
+		leastRequested(requested.Memory, allocatable.Memory)) / 2
+}
+
+func leastRequested(requested, allocatable int64) int64 {
+	if allocatable <= 0 || requested > allocatable {
+		return 0
+	}
+	return (allocatable - requested) * maxScore / allocatable
+}
+
+// TaintToleration steers pods away from nodes with PreferNoSchedule taints they don't tolerate
+func TaintToleration(pod api.Pod, node NodeInfo) int64 {
+	var untolerated int64
+	for _, taint := range node.Node.Spec.Taints {
+		if taint.Effect == api.TaintEffectPreferNoSchedule && !tolerated(pod, taint) {
+			untolerated++
+		}
+	}
+	return maxScore / (untolerated + 1)
+}
@@ -0,0 +1,238 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/apiserver/watch"
+	"superminikube/pkg/client"
+)
+
+// how often pods that didn't fit anywhere are tried again, nodes may have been added or freed up since
+const defaultRetryPeriod = 10 * time.Second
+
+// Scheduler binds pods that don't have a node yet to one that can run them.
+// Nodes are filtered by the predicates and whatever's left is ranked by the priorities.
+type Scheduler struct {
+	client      client.Client
+	predicates  []Predicate
+	priorities  []Priority
+	retryPeriod time.Duration
+}
+
+func NewScheduler(apiServerURL string) *Scheduler {
+	// no node name, the watch only sees pods waiting on a node
+	return NewSchedulerWithPlugins(client.NewHTTPClient(apiServerURL, ""), DefaultPredicates(), DefaultPriorities())
+}
+
+func NewSchedulerWithPlugins(c client.Client, predicates []Predicate, priorities []Priority) *Scheduler {
+	return &Scheduler{
+		client:      c,
+		predicates:  predicates,
+		priorities:  priorities,
+		retryPeriod: defaultRetryPeriod,
+	}
+}
+
+// FitError is returned when no node passes every predicate
+type FitError struct {
+	NumNodes int
+	// how many nodes failed with each reason
+	Reasons map[string]int
+}
+
+func (e *FitError) Error() string {
+	reasons := make([]string, 0, len(e.Reasons))
+	for reason, n := range e.Reasons {
+		reasons = append(reasons, fmt.Sprintf("%d %s", n, reason))
+	}
+	sort.Strings(reasons)
+	msg := fmt.Sprintf("0/%d nodes are available", e.NumNodes)
+	if len(reasons) == 0 {
+		return msg
+	}
+	return msg + ": " + strings.Join(reasons, ", ")
+}
+
+// Schedule picks the node for pod out of nodes, ties go to the node that sorts first by name
+func (s *Scheduler) Schedule(pod api.Pod, nodes []NodeInfo) (string, error) {
+	fitErr := &FitError{NumNodes: len(nodes), Reasons: map[string]int{}}
+	feasible := make([]NodeInfo, 0, len(nodes))
+	for _, n := range nodes {
+		if err := s.fits(pod, n); err != nil {
+			fitErr.Reasons[err.Error()]++
+			continue
+		}
+		feasible = append(feasible, n)
+	}
+	if len(feasible) == 0 {
+		return "", fitErr
+	}
+	sort.Slice(feasible, func(i, j int) bool { return feasible[i].Node.Name < feasible[j].Node.Name })
+	best, bestScore := "", int64(-1)
+	for _, n := range feasible {
+		var score int64
+		for _, p := range s.priorities {
+			score += p.Weight * p.Score(pod, n)
+		}
+		if score > bestScore {
+			best, bestScore = n.Node.Name, score
+		}
+	}
+	return best, nil
+}
+
+func (s *Scheduler) fits(pod api.Pod, node NodeInfo) error {
+	for _, predicate := range s.predicates {
+		if err := predicate(pod, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start schedules every pending pod, then keeps scheduling new ones as they show up until ctx is done
+func (s *Scheduler) Start(ctx context.Context) error {
+	if err := s.client.Ping(ctx); err != nil {
+		return fmt.Errorf("scheduler failed to start: %v", err)
+	}
+	pods, err := s.client.ListPods(ctx)
+	if err != nil {
+		return fmt.Errorf("scheduler failed to start: %v", err)
+	}
+	// watching from the list's resourceVersion means nothing created in between is missed
+	events, err := s.client.Watch(ctx, pods.ResourceVersion)
+	if err != nil {
+		return fmt.Errorf("failed to watch events: %v", err)
+	}
+	s.schedulePending(ctx, pods.Items)
+	ticker := time.NewTicker(s.retryPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("scheduler stopped due to context cancellation")
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return errors.New("watch channel closed")
+			}
+			if pods := drainPending(event, events); len(pods) > 0 {
+				s.schedulePending(ctx, pods)
+			}
+		case <-ticker.C:
+			s.schedulePending(ctx, nil)
+		}
+	}
+}
+
+// drainPending collects the pending pods of event and of the events already queued up behind it,
+// so a burst of new pods is scheduled in a single pass. A pod that shows up twice is taken as it was last seen.
+func drainPending(event watch.WatchEvent, events <-chan watch.WatchEvent) []api.Pod {
+	var pods []api.Pod
+	seen := map[uuid.UUID]int{}
+	for {
+		if event.EventType != watch.Delete && pending(event.Pod) {
+			if i, ok := seen[event.Pod.Uid]; ok {
+				pods[i] = event.Pod
+			} else {
+				seen[event.Pod.Uid] = len(pods)
+				pods = append(pods, event.Pod)
+			}
+		}
+		select {
+		case next, ok := <-events:
+			if !ok {
+				return pods
+			}
+			event = next
+		default:
+			return pods
+		}
+	}
+}
+
+// schedulePending schedules the pending pods among pods, or every pending pod if pods is nil, in one pass.
+// Nodes and pods are listed once per pass, pods bound during the pass are accounted for as they're bound.
+func (s *Scheduler) schedulePending(ctx context.Context, pods []api.Pod) {
+	nodes, err := s.client.ListNodes(ctx)
+	if err != nil {
+		slog.Error("failed to list nodes", "error", err)
+		return
+	}
+	all, err := s.client.ListPods(ctx)
+	if err != nil {
+		slog.Error("failed to list pods", "error", err)
+		return
+	}
+	if pods == nil {
+		pods = all.Items
+	}
+	infos := nodeInfos(nodes.Items, all.Items)
+	for _, p := range pods {
+		if !pending(p) {
+			continue
+		}
+		node := s.scheduleOne(ctx, p, infos)
+		if i := slices.IndexFunc(infos, func(n NodeInfo) bool { return n.Node.Name == node }); i >= 0 {
+			p.Nodename = node
+			infos[i].Pods = append(infos[i].Pods, p)
+		}
+	}
+}
+
+// scheduleOne binds pod to the best of nodes and returns its name, or marks the pod Unschedulable
+// with the reasons no node fit and returns "".
+func (s *Scheduler) scheduleOne(ctx context.Context, pod api.Pod, nodes []NodeInfo) string {
+	node, err := s.Schedule(pod, nodes)
+	var fitErr *FitError
+	if errors.As(err, &fitErr) {
+		slog.Info("pod is unschedulable", "namespace", pod.Namespace, "pod", pod.Name, "reason", err)
+		s.markUnschedulable(ctx, pod, fitErr)
+		return ""
+	}
+	if err != nil {
+		slog.Error("failed to schedule pod", "namespace", pod.Namespace, "pod", pod.Name, "error", err)
+		return ""
+	}
+	// a pod that was bound or deleted in the meantime fails here, there's nothing more to do for it
+	if err := s.client.BindPod(ctx, pod, node); err != nil {
+		slog.Error("failed to bind pod", "namespace", pod.Namespace, "pod", pod.Name, "node", node, "error", err)
+		return ""
+	}
+	slog.Info("bound pod", "namespace", pod.Namespace, "pod", pod.Name, "node", node)
+	return node
+}
+
+// markUnschedulable only writes when the reason changed,
+// the write comes back on the watch and would otherwise loop forever
+func (s *Scheduler) markUnschedulable(ctx context.Context, pod api.Pod, fitErr *FitError) {
+	changed := pod.Status.SetCondition(api.PodCondition{
+		Type:    api.PodScheduled,
+		Status:  api.ConditionFalse,
+		Reason:  "Unschedulable",
+		Message: fitErr.Error(),
+	}, time.Now().UTC())
+	if !changed {
+		return
+	}
+	if pod.Status.Phase == "" {
+		pod.Status.Phase = api.PodPending
+	}
+	if err := s.client.UpdatePodStatus(ctx, pod); err != nil {
+		slog.Error("failed to update pod status", "namespace", pod.Namespace, "pod", pod.Name, "error", err)
+	}
+}
+
+func pending(p api.Pod) bool {
+	return p.Nodename == "" && p.DeletionTimestamp == nil
+}
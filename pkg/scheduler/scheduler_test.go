@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+)
+
+func TestSchedule(t *testing.T) {
+	preferNot := testNode("node-a", 4000, 8<<30)
+	preferNot.Spec.Taints = []api.Taint{{Key: "spot", Effect: api.TaintEffectPreferNoSchedule}}
+	testCases := []struct {
+		name     string
+		pod      api.Pod
+		nodes    []NodeInfo
+		wantNode string
+		wantErr  string
+	}{
+		{
+			name: "least requested node wins",
+			pod:  testPod("web", 500, 1<<29),
+			nodes: []NodeInfo{
+				{Node: testNode("node-a", 2000, 4<<30), Pods: []api.Pod{testPod("db", 1000, 2<<30)}},
+				{Node: testNode("node-b", 2000, 4<<30)},
+			},
+			wantNode: "node-b",
+		},
+		{
+			name: "ties go to the first node by name",
+			pod:  testPod("web", 500, 1<<29),
+			nodes: []NodeInfo{
+				{Node: testNode("node-b", 2000, 4<<30)},
+				{Node: testNode("node-a", 2000, 4<<30)},
+			},
+			wantNode: "node-a",
+		},
+		{
+			name: "prefer no schedule taint is avoided",
+			pod:  testPod("web", 500, 1<<29),
+			nodes: []NodeInfo{
+				{Node: preferNot},
+				{Node: testNode("node-b", 2000, 4<<30)},
+			},
+			wantNode: "node-b",
+		},
+		{
+			name: "nothing fits",
+			pod:  testPod("web", 3000, 1<<29),
+			nodes: []NodeInfo{
+				{Node: testNode("node-a", 2000, 4<<30)},
+				{Node: testNode("node-b", 2000, 4<<30)},
+				{Node: testNode("node-c", 4000, 4<<30), Pods: []api.Pod{testPod("db", 0, 4<<30)}},
+			},
+			wantErr: "0/3 nodes are available: 1 insufficient memory, 2 insufficient cpu",
+		},
+		{
+			name:    "no nodes",
+			pod:     testPod("web", 0, 0),
+			wantErr: "0/0 nodes are available",
+		},
+	}
+	s := NewSchedulerWithPlugins(&client.FakeClient{}, DefaultPredicates(), DefaultPriorities())
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := s.Schedule(tc.pod, tc.nodes)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if node != tc.wantNode {
+				t.Errorf("scheduled to %s, expected %s", node, tc.wantNode)
+			}
+		})
+	}
+}
+
+func TestScheduleOne(t *testing.T) {
+	c := &client.FakeClient{
+		Nodes: []api.Node{testNode("node-a", 1000, 1<<30)},
+		Pods:  []api.Pod{testPod("first", 600, 0), testPod("second", 600, 0)},
+	}
+	s := NewSchedulerWithPlugins(c, DefaultPredicates(), DefaultPriorities())
+	s.schedulePending(t.Context(), c.Pods)
+
+	if c.Pods[0].Nodename != "node-a" {
+		t.Errorf("first pod bound to %q, expected node-a", c.Pods[0].Nodename)
+	}
+	// the first pod takes up the room the second one needs
+	if c.Pods[1].Nodename != "" {
+		t.Errorf("second pod bound to %q, expected it to stay pending", c.Pods[1].Nodename)
+	}
+	if len(c.PodStatuses) != 1 {
+		t.Fatalf("expected 1 status update, got %d", len(c.PodStatuses))
+	}
+	cond := c.PodStatuses[0].Status.GetCondition(api.PodScheduled)
+	if cond == nil || cond.Status != api.ConditionFalse || cond.Reason != "Unschedulable" {
+		t.Errorf("unexpected PodScheduled condition: %+v", cond)
+	}
+
+	// trying again for the same reason doesn't write the status again
+	s.schedulePending(t.Context(), []api.Pod{c.Pods[1]})
+	if len(c.PodStatuses) != 1 {
+		t.Errorf("expected no new status update, got %d", len(c.PodStatuses))
+	}
+}
+
+func TestStartStopsWithContext(t *testing.T) {
+	s := NewSchedulerWithPlugins(&client.FakeClient{}, DefaultPredicates(), DefaultPriorities())
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Start(ctx); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
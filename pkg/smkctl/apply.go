@@ -0,0 +1,105 @@
+package smkctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"superminikube/pkg/client"
+)
+
+func newApplyCommand(o *options) *cobra.Command {
+	var files []string
+	cmd := &cobra.Command{
+		Use:   "apply -f FILENAME",
+		Short: "Create the objects of manifests, or update them to match if they exist",
+		Long: "Create the objects of manifests, or update them to match if they exist.\n\n" +
+			"Manifests are YAML or JSON, a YAML file can hold several objects split by --- lines.\n" +
+			"Every object has a kind and a metadata.name, objects without a metadata.namespace go in the namespace worked in.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := o.client()
+			if err != nil {
+				return err
+			}
+			objs, err := readManifests(files, cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			// every object gets its turn, failures are reported together at the end
+			var errs []error
+			for _, obj := range objs {
+				result, err := apply(cmd, c, obj, namespace)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to apply %s/%s from %s: %v", strings.ToLower(obj.resource.kind), obj.name, obj.source, err))
+					continue
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s/%s %s\n", strings.ToLower(obj.resource.kind), obj.name, result)
+			}
+			return errors.Join(errs...)
+		},
+	}
+	cmd.Flags().StringSliceVarP(&files, "filename", "f", nil, "manifest file or directory of them to apply, - reads stdin")
+	cmd.MarkFlagRequired("filename")
+	return cmd
+}
+
+// apply creates obj, or replaces the object it names if that's there and differs from it.
+// It returns what was done.
+func apply(cmd *cobra.Command, c *client.HTTPClient, obj object, namespace string) (string, error) {
+	ctx := cmd.Context()
+	r := obj.resource
+	set := map[string]any{}
+	if r.namespaced {
+		if obj.namespace != "" {
+			namespace = obj.namespace
+		}
+		set["namespace"] = namespace
+	}
+	current, err := c.Do(ctx, http.MethodGet, r.path(namespace, obj.name), nil)
+	if errors.Is(err, client.ErrNotFound) {
+		// whatever the object was last read at doesn't apply to a new one
+		set["resourceVersion"] = nil
+		body, err := obj.withMeta(set)
+		if err != nil {
+			return "", err
+		}
+		if _, err := c.Do(ctx, http.MethodPost, r.path(namespace, ""), body); err != nil {
+			return "", err
+		}
+		return "created", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var have map[string]any
+	if err := json.Unmarshal(current, &have); err != nil {
+		return "", fmt.Errorf("failed to decode %s: %v", r.path(namespace, obj.name), err)
+	}
+	want := make(map[string]any, len(obj.fields))
+	for k, v := range obj.fields {
+		// the apiserver doesn't keep what kind an object is, it knows from where it's stored
+		if k != "kind" && k != "apiVersion" {
+			want[k] = v
+		}
+	}
+	if subsetOf(want, have) {
+		return "unchanged", nil
+	}
+	// the update only goes through if nobody changed the object since it was read
+	meta, _ := have["metadata"].(map[string]any)
+	set["resourceVersion"] = meta["resourceVersion"]
+	body, err := obj.withMeta(set)
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.Do(ctx, http.MethodPut, r.path(namespace, obj.name), body); err != nil {
+		return "", err
+	}
+	return "configured", nil
+}
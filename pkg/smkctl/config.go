@@ -0,0 +1,155 @@
+package smkctl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+
+	"superminikube/pkg/apiserver/utils"
+	"superminikube/pkg/client"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// Config is the kubeconfig-style file smkctl finds the apiserver and its credentials in.
+// A context pairs a cluster with a user and a default namespace, current-context picks the one used.
+type Config struct {
+	CurrentContext string         `json:"current-context"`
+	Clusters       []NamedCluster `json:"clusters"`
+	Users          []NamedUser    `json:"users"`
+	Contexts       []NamedContext `json:"contexts"`
+}
+
+type NamedCluster struct {
+	Name string `json:"name"`
+	// url of the apiserver
+	Server string `json:"server"`
+}
+
+type NamedUser struct {
+	Name string `json:"name"`
+	// sent as a bearer token, takes precedence over username and password
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+type NamedContext struct {
+	Name    string `json:"name"`
+	Cluster string `json:"cluster"`
+	// can be left out for clusters that don't need credentials
+	User string `json:"user,omitempty"`
+	// namespace commands work in unless told otherwise, defaults to default
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// LoadConfig reads the config at path, fields it doesn't know are rejected so typos don't go unnoticed
+func LoadConfig(path string) (Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+	var c Config
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// defaultConfigPath is $SMKCONFIG, or ~/.smk/config without it
+func defaultConfigPath() string {
+	if path := os.Getenv("SMKCONFIG"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".smk", "config")
+}
+
+// target is where commands send their requests, resolved from the config and the flags overriding it
+type target struct {
+	server      string
+	namespace   string
+	credentials client.Credentials
+}
+
+// resolve picks the context name, or the current context when name is empty, and looks up its cluster and user.
+// An empty config resolves to the apiserver on localhost.
+func (c Config) resolve(name string) (target, error) {
+	t := target{server: defaultServer, namespace: utils.DefaultNamespace}
+	if name == "" {
+		name = c.CurrentContext
+	}
+	if name == "" {
+		return t, nil
+	}
+	var ctx *NamedContext
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			ctx = &c.Contexts[i]
+		}
+	}
+	if ctx == nil {
+		return target{}, fmt.Errorf("context %q not found", name)
+	}
+	if ctx.Namespace != "" {
+		t.namespace = ctx.Namespace
+	}
+	found := false
+	for _, cluster := range c.Clusters {
+		if cluster.Name == ctx.Cluster {
+			t.server = cluster.Server
+			found = true
+		}
+	}
+	if !found {
+		return target{}, fmt.Errorf("cluster %q of context %q not found", ctx.Cluster, name)
+	}
+	if ctx.User == "" {
+		return t, nil
+	}
+	found = false
+	for _, user := range c.Users {
+		if user.Name == ctx.User {
+			t.credentials = client.Credentials{Token: user.Token, Username: user.Username, Password: user.Password}
+			found = true
+		}
+	}
+	if !found {
+		return target{}, fmt.Errorf("user %q of context %q not found", ctx.User, name)
+	}
+	return t, nil
+}
+
+// target resolves the config the flags point at, with --server and --namespace taking precedence.
+// A missing config is only an error when it was asked for by --kubeconfig.
+func (o *options) target() (target, error) {
+	path := o.kubeconfig
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	var c Config
+	if path != "" {
+		var err error
+		c, err = LoadConfig(path)
+		if err != nil && (o.kubeconfig != "" || !errors.Is(err, os.ErrNotExist)) {
+			return target{}, err
+		}
+	}
+	t, err := c.resolve(o.context)
+	if err != nil {
+		return target{}, err
+	}
+	if o.server != "" {
+		t.server = o.server
+	}
+	if o.namespace != "" {
+		t.namespace = o.namespace
+	}
+	return t, nil
+}
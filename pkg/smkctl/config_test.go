@@ -0,0 +1,101 @@
+package smkctl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"superminikube/pkg/client"
+)
+
+func TestTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	config := `current-context: dev
+clusters:
+- name: local
+  server: http://localhost:8080
+- name: remote
+  server: https://smk.example.com
+users:
+- name: admin
+  token: secret
+contexts:
+- name: dev
+  cluster: local
+  namespace: dev
+- name: prod
+  cluster: remote
+  user: admin
+- name: broken
+  cluster: missing
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		opts     options
+		expected target
+		err      bool
+	}{
+		{
+			name:     "current context",
+			opts:     options{kubeconfig: path},
+			expected: target{server: "http://localhost:8080", namespace: "dev"},
+		},
+		{
+			name:     "context with a user",
+			opts:     options{kubeconfig: path, context: "prod"},
+			expected: target{server: "https://smk.example.com", namespace: "default", credentials: client.Credentials{Token: "secret"}},
+		},
+		{
+			name:     "flags override the config",
+			opts:     options{kubeconfig: path, server: "http://other:8080", namespace: "test"},
+			expected: target{server: "http://other:8080", namespace: "test"},
+		},
+		{
+			name: "missing cluster",
+			opts: options{kubeconfig: path, context: "broken"},
+			err:  true,
+		},
+		{
+			name: "unknown context",
+			opts: options{kubeconfig: path, context: "staging"},
+			err:  true,
+		},
+		{
+			name: "missing config",
+			opts: options{kubeconfig: filepath.Join(t.TempDir(), "config")},
+			err:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.opts.target()
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, expected error %v", err, tc.err)
+			}
+			if got != tc.expected {
+				t.Errorf("got %+v, expected %+v", got, tc.expected)
+			}
+		})
+	}
+
+	t.Run("no config", func(t *testing.T) {
+		// without a config there's the apiserver on localhost
+		t.Setenv("SMKCONFIG", filepath.Join(t.TempDir(), "config"))
+		got, err := (&options{}).target()
+		if err != nil || got != (target{server: defaultServer, namespace: "default"}) {
+			t.Errorf("got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "config")
+		os.WriteFile(bad, []byte("current-context: dev\nclusterz: []\n"), 0o600)
+		if _, err := LoadConfig(bad); err == nil {
+			t.Errorf("expected a typo to be rejected")
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package smkctl
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"superminikube/pkg/client"
+)
+
+type deleteOptions struct {
+	files []string
+	// -1 leaves it to the pod's terminationGracePeriodSeconds
+	gracePeriod    int64
+	ignoreNotFound bool
+}
+
+func newDeleteCommand(o *options) *cobra.Command {
+	var opts deleteOptions
+	cmd := &cobra.Command{
+		Use:   "delete (TYPE NAME... | -f FILENAME)",
+		Short: "Delete the objects named, or the ones in manifests",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := o.client()
+			if err != nil {
+				return err
+			}
+			var targets []object
+			switch {
+			case len(opts.files) > 0 && len(args) > 0:
+				return errors.New("objects are either named or in manifests given with -f, not both")
+			case len(opts.files) > 0:
+				targets, err = readManifests(opts.files, cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+			case len(args) >= 2:
+				r, err := lookupResource(args[0])
+				if err != nil {
+					return err
+				}
+				for _, name := range args[1:] {
+					targets = append(targets, object{resource: r, name: name})
+				}
+			default:
+				return errors.New("expected a type and the names of the objects to delete, or -f")
+			}
+
+			q := url.Values{}
+			if opts.gracePeriod >= 0 {
+				q.Set("gracePeriodSeconds", strconv.FormatInt(opts.gracePeriod, 10))
+			}
+			var errs []error
+			for _, obj := range targets {
+				ns := namespace
+				if obj.namespace != "" {
+					ns = obj.namespace
+				}
+				path := obj.resource.path(ns, obj.name)
+				if len(q) > 0 {
+					path += "?" + q.Encode()
+				}
+				id := strings.ToLower(obj.resource.kind) + "/" + obj.name
+				_, err := c.Do(cmd.Context(), http.MethodDelete, path, nil)
+				if errors.Is(err, client.ErrNotFound) {
+					if !opts.ignoreNotFound {
+						errs = append(errs, fmt.Errorf("%s not found", id))
+					}
+					continue
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to delete %s: %v", id, err))
+					continue
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s deleted\n", id)
+			}
+			return errors.Join(errs...)
+		},
+	}
+	cmd.Flags().StringSliceVarP(&opts.files, "filename", "f", nil, "manifest file or directory of them whose objects are deleted, - reads stdin")
+	cmd.Flags().Int64Var(&opts.gracePeriod, "grace-period", -1, "seconds pods get to shut down, 0 kills them straight away. Defaults to the pod's own")
+	cmd.Flags().BoolVar(&opts.ignoreNotFound, "ignore-not-found", false, "objects that are already gone aren't an error")
+	return cmd
+}
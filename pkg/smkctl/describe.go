@@ -0,0 +1,123 @@
+package smkctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"superminikube/pkg/client"
+)
+
+func newDescribeCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "describe TYPE NAME...",
+		Short: "Show the objects named in detail",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r, err := lookupResource(args[0])
+			if err != nil {
+				return err
+			}
+			c, namespace, err := o.client()
+			if err != nil {
+				return err
+			}
+			var errs []error
+			for i, name := range args[1:] {
+				b, err := c.Do(cmd.Context(), http.MethodGet, r.path(namespace, name), nil)
+				if errors.Is(err, client.ErrNotFound) {
+					err = fmt.Errorf("%s %q not found", r.plural, name)
+				}
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				if i > 0 {
+					fmt.Fprintln(cmd.OutOrStdout())
+				}
+				if err := describe(cmd.OutOrStdout(), b); err != nil {
+					return err
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+}
+
+// describe writes the object's metadata as a summary, followed by the rest of it
+func describe(w io.Writer, b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return fmt.Errorf("failed to decode object: %v", err)
+	}
+	meta := objectMeta(b)
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", meta.Name)
+	if meta.Namespace != "" {
+		fmt.Fprintf(tw, "Namespace:\t%s\n", meta.Namespace)
+	}
+	fmt.Fprintf(tw, "Labels:\t%s\n", orNone(labels(meta.Labels)))
+	fmt.Fprintf(tw, "Annotations:\t%s\n", orNone(labels(meta.Annotations)))
+	fmt.Fprintf(tw, "Created:\t%s (%s ago)\n", meta.CreationTimestamp.Format(time.RFC3339), age(meta.CreationTimestamp))
+	if meta.DeletionTimestamp != nil {
+		fmt.Fprintf(tw, "Deleted:\t%s\n", meta.DeletionTimestamp.Format(time.RFC3339))
+	}
+	for _, ref := range meta.OwnerReferences {
+		fmt.Fprintf(tw, "Controlled By:\t%s/%s\n", ref.Kind, ref.Name)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// spec and status come first, whatever else there is after them
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if k != "metadata" {
+			keys = append(keys, k)
+		}
+	}
+	order := func(k string) int {
+		switch strings.ToLower(k) {
+		case "spec":
+			return 0
+		case "status":
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if order(keys[i]) != order(keys[j]) {
+			return order(keys[i]) < order(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		y, err := yaml.JSONToYAML(fields[k])
+		if err != nil {
+			return fmt.Errorf("failed to format %s: %v", k, err)
+		}
+		title := strings.ToUpper(k[:1]) + k[1:]
+		y = bytes.TrimSpace(y)
+		// scalars and empty objects go on the same line, anything bigger is indented under its title
+		raw := bytes.TrimSpace(fields[k])
+		if len(raw) == 0 || raw[0] != '{' && raw[0] != '[' || string(y) == "{}" || string(y) == "[]" {
+			fmt.Fprintf(w, "%s: %s\n", title, y)
+			continue
+		}
+		fmt.Fprintf(w, "%s:\n", title)
+		for _, line := range strings.Split(string(y), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+	return nil
+}
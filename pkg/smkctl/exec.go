@@ -0,0 +1,87 @@
+package smkctl
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/remotecommand"
+)
+
+func newExecCommand(o *options) *cobra.Command {
+	var container string
+	var stdin, tty bool
+	cmd := &cobra.Command{
+		Use:   "exec POD -- COMMAND [ARGS...]",
+		Short: "Run a command in a container of a pod",
+		Long: "Run a command in a container of a pod.\n\n" +
+			"smkctl exits with the command's exit code.",
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := o.client()
+			if err != nil {
+				return err
+			}
+			streams := remotecommand.Streams{Stdout: cmd.OutOrStdout(), Stderr: cmd.ErrOrStderr()}
+			if stdin {
+				streams.Stdin = cmd.InOrStdin()
+			}
+			if tty {
+				ctx, cancel := context.WithCancel(cmd.Context())
+				defer cancel()
+				restore, resize, err := setupTerminal(ctx, streams.Stdin)
+				if err != nil {
+					return err
+				}
+				if resize == nil {
+					fmt.Fprintln(cmd.ErrOrStderr(), "Unable to use a TTY, stdin isn't a terminal")
+				} else {
+					defer restore()
+					streams.TTY = true
+					streams.Resize = resize
+				}
+			}
+			code, err := c.PodExec(cmd.Context(), namespace, args[0], api.PodExecOptions{Container: container, Command: args[1:]}, streams)
+			if err != nil {
+				return err
+			}
+			if code != 0 {
+				return ExitError{Code: code}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&container, "container", "c", "", "container to run the command in, can be left out for pods with a single container")
+	cmd.Flags().BoolVarP(&stdin, "stdin", "i", false, "pass stdin to the command")
+	cmd.Flags().BoolVarP(&tty, "tty", "t", false, "run the command on a TTY, stdin has to be a terminal and passed with -i")
+	return cmd
+}
+
+// setupTerminal puts the terminal stdin is into raw mode, so keys go to the command as they're typed,
+// and watches its size until ctx is done. It returns no resize channel if stdin isn't a terminal.
+func setupTerminal(ctx context.Context, stdin any) (func(), <-chan remotecommand.TerminalSize, error) {
+	f, ok := stdin.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return nil, nil, nil
+	}
+	fd := int(f.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set up the terminal: %v", err)
+	}
+	restore := func() { term.Restore(fd, state) }
+	return restore, watchTerminalSize(ctx, fd), nil
+}
+
+// terminalSize is the size of the terminal fd is, if it can be told
+func terminalSize(fd int) (remotecommand.TerminalSize, bool) {
+	w, h, err := term.GetSize(fd)
+	if err != nil {
+		return remotecommand.TerminalSize{}, false
+	}
+	return remotecommand.TerminalSize{Width: uint16(w), Height: uint16(h)}, true
+}
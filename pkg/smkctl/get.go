@@ -0,0 +1,183 @@
+package smkctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+)
+
+type getOptions struct {
+	output        string
+	allNamespaces bool
+	watch         bool
+}
+
+func newGetCommand(o *options) *cobra.Command {
+	var opts getOptions
+	cmd := &cobra.Command{
+		Use:   "get TYPE [NAME...]",
+		Short: "Show objects of a type, all of them or the ones named",
+		Long: "Show objects of a type, all of them or the ones named.\n\n" +
+			"Types are pods (po), nodes (no), replicasets (rs), deployments (deploy), jobs, cronjobs (cj),\n" +
+			"daemonsets (ds) and statefulsets (sts).",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return get(cmd, o, opts, args[0], args[1:])
+		},
+	}
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "", "output format, one of wide, json, yaml, name or jsonpath=TEMPLATE, a table without it")
+	cmd.Flags().BoolVarP(&opts.allNamespaces, "all-namespaces", "A", false, "objects in every namespace")
+	cmd.Flags().BoolVarP(&opts.watch, "watch", "w", false, "keep going and show every change to the objects after the current ones")
+	return cmd
+}
+
+func get(cmd *cobra.Command, o *options, opts getOptions, kind string, names []string) error {
+	ctx := cmd.Context()
+	out := cmd.OutOrStdout()
+	r, err := lookupResource(kind)
+	if err != nil {
+		return err
+	}
+	p, err := newPrinter(opts.output)
+	if err != nil {
+		return err
+	}
+	c, namespace, err := o.client()
+	if err != nil {
+		return err
+	}
+	if opts.allNamespaces || !r.namespaced {
+		namespace = ""
+	}
+
+	var items []json.RawMessage
+	// what's printed in formats other than a table, a list unless a single object was asked for
+	var body []byte
+	var resourceVersion string
+	var errs []error
+	if len(names) == 0 || opts.watch {
+		b, err := c.Do(ctx, http.MethodGet, r.path(namespace, ""), nil)
+		if err != nil {
+			return err
+		}
+		var list struct {
+			ResourceVersion string            `json:"resourceVersion"`
+			Items           []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(b, &list); err != nil {
+			return fmt.Errorf("failed to decode %s: %v", r.plural, err)
+		}
+		resourceVersion = list.ResourceVersion
+		for _, item := range list.Items {
+			if len(names) == 0 || slices.Contains(names, objectMeta(item).Name) {
+				items = append(items, withTypeMeta(item, r.kind))
+			}
+		}
+		if len(names) == 0 {
+			body, _ = json.Marshal(map[string]any{"resourceVersion": resourceVersion, "items": items})
+		}
+	} else {
+		for _, name := range names {
+			b, err := c.Do(ctx, http.MethodGet, r.path(namespace, name), nil)
+			if err != nil {
+				if errors.Is(err, client.ErrNotFound) {
+					err = fmt.Errorf("%s %q not found", r.plural, name)
+				}
+				errs = append(errs, err)
+				continue
+			}
+			items = append(items, withTypeMeta(b, r.kind))
+		}
+	}
+	if body == nil {
+		if len(names) == 1 && len(items) == 1 {
+			body = items[0]
+		} else {
+			body, _ = json.Marshal(map[string]any{"items": items})
+		}
+	}
+
+	var table *tablePrinter
+	if p.table() || p.format == "name" {
+		table = newTablePrinter(out, r, p.format, namespace == "")
+		for _, item := range items {
+			if err := table.printRow(item); err != nil {
+				return err
+			}
+		}
+		if err := table.flush(); err != nil {
+			return err
+		}
+		if len(items) == 0 && len(errs) == 0 && !opts.watch {
+			fmt.Fprintln(cmd.ErrOrStderr(), "No resources found")
+		}
+	} else if len(items) > 0 || len(errs) == 0 {
+		if err := p.printObject(out, body); err != nil {
+			return err
+		}
+	}
+	if len(errs) > 0 || !opts.watch {
+		return errors.Join(errs...)
+	}
+
+	events, err := c.WatchResource(ctx, r.plural, resourceVersion)
+	if err != nil {
+		return err
+	}
+	for ev := range events {
+		meta := objectMeta(ev.Object)
+		if namespace != "" && meta.Namespace != namespace || len(names) > 0 && !slices.Contains(names, meta.Name) {
+			continue
+		}
+		if table != nil {
+			if err := table.printRow(ev.Object); err != nil {
+				return err
+			}
+			if err := table.flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := printWatched(out, p, withTypeMeta(ev.Object, r.kind)); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errors.New("watch ended before it was stopped")
+}
+
+// printWatched writes an object that changed, YAML documents are split so the output stays a valid stream
+func printWatched(w io.Writer, p *printer, b []byte) error {
+	if p.format == "yaml" {
+		if _, err := io.WriteString(w, "---\n"); err != nil {
+			return err
+		}
+	}
+	if err := p.printObject(w, b); err != nil {
+		return err
+	}
+	if p.format == "jsonpath" {
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	return nil
+}
+
+// objectMeta is the metadata of the object in b, an object that can't be decoded has none
+func objectMeta(b []byte) api.ObjectMeta {
+	var obj struct {
+		Metadata api.ObjectMeta `json:"metadata"`
+	}
+	json.Unmarshal(b, &obj)
+	return obj.Metadata
+}
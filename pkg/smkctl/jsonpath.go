@@ -0,0 +1,263 @@
+package smkctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a -o jsonpath= template, the part of kubectl's that gets used day to day.
+// Text outside braces is written as is, {.a.b[0].c} writes what the path finds with [*] going over every item,
+// {"\n"} writes a quoted string and {range .items[*]}...{end} repeats its body for every item the path finds,
+// paths inside it start from the item. $ is the object the template runs on. Keys that aren't there find nothing.
+type jsonPath struct {
+	nodes []jsonPathNode
+}
+
+type jsonPathNode struct {
+	// written as is when there's no path
+	text string
+	path *fieldPath
+	// the body of a range over path
+	body []jsonPathNode
+}
+
+type fieldPath struct {
+	// starts from the object the template runs on rather than the current item
+	root  bool
+	steps []pathStep
+}
+
+type pathStep struct {
+	field string
+	// a step with no field indexes into a list, or goes over every item with all
+	index int
+	all   bool
+}
+
+func parseJSONPath(template string) (*jsonPath, error) {
+	// the bottom of the stack is the template, every range being parsed goes on top
+	stack := []*jsonPathNode{{}}
+	rest := template
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			open = len(rest)
+		}
+		if open > 0 {
+			top := stack[len(stack)-1]
+			top.body = append(top.body, jsonPathNode{text: rest[:open]})
+			rest = rest[open:]
+			continue
+		}
+		end := closingBrace(rest)
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed { in jsonpath template %q", template)
+		}
+		expr := strings.TrimSpace(rest[1:end])
+		rest = rest[end+1:]
+		top := stack[len(stack)-1]
+		switch {
+		case expr == "end":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("{end} without a {range} in jsonpath template %q", template)
+			}
+			stack = stack[:len(stack)-1]
+			parent := stack[len(stack)-1]
+			parent.body = append(parent.body, *top)
+		case strings.HasPrefix(expr, "range "):
+			path, err := parseFieldPath(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, &jsonPathNode{path: path, body: []jsonPathNode{}})
+		case strings.HasPrefix(expr, `"`):
+			text, err := strconv.Unquote(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s in jsonpath template", expr)
+			}
+			top.body = append(top.body, jsonPathNode{text: text})
+		default:
+			path, err := parseFieldPath(expr)
+			if err != nil {
+				return nil, err
+			}
+			top.body = append(top.body, jsonPathNode{path: path})
+		}
+	}
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("{range} without an {end} in jsonpath template %q", template)
+	}
+	return &jsonPath{nodes: stack[0].body}, nil
+}
+
+// closingBrace is the index of the } closing the { s starts with, braces in quoted strings don't count
+func closingBrace(s string) int {
+	quoted := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quoted && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+func parseFieldPath(s string) (*fieldPath, error) {
+	p := &fieldPath{}
+	rest := s
+	if strings.HasPrefix(rest, "$") {
+		p.root = true
+		rest = rest[1:]
+	}
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		return nil, fmt.Errorf("invalid jsonpath %q, paths start with . or $", s)
+	}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			// a lone . is the current item
+			if end > 0 {
+				p.steps = append(p.steps, pathStep{field: rest[:end]})
+			}
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in jsonpath %q", s)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				p.steps = append(p.steps, pathStep{all: true})
+			case strings.HasPrefix(inner, "'") && strings.HasSuffix(inner, "'") && len(inner) > 1:
+				// ['a.b'] for keys with dots in them
+				p.steps = append(p.steps, pathStep{field: inner[1 : len(inner)-1]})
+			default:
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index [%s] in jsonpath %q", inner, s)
+				}
+				p.steps = append(p.steps, pathStep{index: i})
+			}
+		default:
+			return nil, fmt.Errorf("invalid jsonpath %q", s)
+		}
+	}
+	return p, nil
+}
+
+// execute runs the template on the JSON in b
+func (j *jsonPath) execute(w io.Writer, b []byte) error {
+	var root any
+	d := json.NewDecoder(bytes.NewReader(b))
+	// numbers are written the way they came in rather than as floats
+	d.UseNumber()
+	if err := d.Decode(&root); err != nil {
+		return fmt.Errorf("failed to decode object: %v", err)
+	}
+	return executeNodes(w, j.nodes, root, root)
+}
+
+func executeNodes(w io.Writer, nodes []jsonPathNode, root, cur any) error {
+	for _, n := range nodes {
+		switch {
+		case n.path == nil:
+			if _, err := io.WriteString(w, n.text); err != nil {
+				return err
+			}
+		case n.body != nil:
+			for _, item := range n.path.find(root, cur) {
+				if err := executeNodes(w, n.body, root, item); err != nil {
+					return err
+				}
+			}
+		default:
+			var out []string
+			for _, v := range n.path.find(root, cur) {
+				s, err := jsonPathString(v)
+				if err != nil {
+					return err
+				}
+				out = append(out, s)
+			}
+			if _, err := io.WriteString(w, strings.Join(out, " ")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// find is everything p reaches from cur
+func (p *fieldPath) find(root, cur any) []any {
+	found := []any{cur}
+	if p.root {
+		found = []any{root}
+	}
+	for _, step := range p.steps {
+		var next []any
+		for _, v := range found {
+			switch {
+			case step.field != "":
+				if m, ok := v.(map[string]any); ok {
+					if fv, ok := m[step.field]; ok {
+						next = append(next, fv)
+					}
+				}
+			case step.all:
+				switch v := v.(type) {
+				case []any:
+					next = append(next, v...)
+				case map[string]any:
+					keys := make([]string, 0, len(v))
+					for k := range v {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, v[k])
+					}
+				}
+			default:
+				if l, ok := v.([]any); ok {
+					i := step.index
+					if i < 0 {
+						i += len(l)
+					}
+					if i >= 0 && i < len(l) {
+						next = append(next, l[i])
+					}
+				}
+			}
+		}
+		found = next
+	}
+	return found
+}
+
+// jsonPathString writes strings without quotes, everything else as JSON
+func jsonPathString(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
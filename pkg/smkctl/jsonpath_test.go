@@ -0,0 +1,53 @@
+package smkctl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	list := `{"items": [
+		{"metadata": {"name": "web", "labels": {"app.kubernetes.io/name": "web"}}, "status": {"phase": "Running", "restarts": 3}},
+		{"metadata": {"name": "db"}, "status": {"phase": "Pending", "restarts": 0}}
+	]}`
+	testCases := []struct {
+		template string
+		expected string
+		err      bool
+	}{
+		{template: "{.items[0].metadata.name}", expected: "web"},
+		{template: "{.items[-1].metadata.name}", expected: "db"},
+		{template: "{.items[*].metadata.name}", expected: "web db"},
+		{template: "{$.items[1].status.restarts}", expected: "0"},
+		{template: "{.items[0].status}", expected: `{"phase":"Running","restarts":3}`},
+		{template: "{.items[0].metadata.labels['app.kubernetes.io/name']}", expected: "web"},
+		{template: `{range .items[*]}{.metadata.name}={.status.phase}{"\n"}{end}`, expected: "web=Running\ndb=Pending\n"},
+		{template: "names: {.items[*].metadata.name}!", expected: "names: web db!"},
+		{template: `{"{}"}`, expected: "{}"},
+		// missing keys find nothing rather than failing
+		{template: "{.items[5].metadata.name}{.nothing}", expected: ""},
+		{template: "{.items", err: true},
+		{template: "{range .items[*]}", err: true},
+		{template: "{end}", err: true},
+		{template: "{items}", err: true},
+		{template: "{.items[x]}", err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			j, err := parseJSONPath(tc.template)
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, expected error %v", err, tc.err)
+			}
+			if err != nil {
+				return
+			}
+			var out strings.Builder
+			if err := j.execute(&out, []byte(list)); err != nil {
+				t.Fatalf("failed to execute: %v", err)
+			}
+			if out.String() != tc.expected {
+				t.Errorf("got %q, expected %q", out.String(), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package smkctl
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"superminikube/pkg/api"
+)
+
+func newLogsCommand(o *options) *cobra.Command {
+	var opts api.PodLogOptions
+	var tail int64
+	var since time.Duration
+	cmd := &cobra.Command{
+		Use:   "logs POD",
+		Short: "Show the output of a container of a pod",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, namespace, err := o.client()
+			if err != nil {
+				return err
+			}
+			if tail >= 0 {
+				opts.TailLines = &tail
+			}
+			if since > 0 {
+				seconds := int64(since.Seconds())
+				opts.SinceSeconds = &seconds
+			}
+			logs, err := c.PodLogs(cmd.Context(), namespace, args[0], opts)
+			if err != nil {
+				return err
+			}
+			defer logs.Close()
+			_, err = io.Copy(cmd.OutOrStdout(), logs)
+			// following stops when smkctl is interrupted, that's not a failure
+			if err != nil && cmd.Context().Err() == nil {
+				return fmt.Errorf("failed to read logs: %v", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Container, "container", "c", "", "container to show, can be left out for pods with a single container")
+	cmd.Flags().BoolVarP(&opts.Follow, "follow", "f", false, "keep showing output as it's written")
+	cmd.Flags().BoolVarP(&opts.Previous, "previous", "p", false, "output of the container's previous run")
+	cmd.Flags().BoolVar(&opts.Timestamps, "timestamps", false, "prefix every line with the time it was written")
+	cmd.Flags().Int64Var(&tail, "tail", -1, "only this many of the last lines, all of them by default")
+	cmd.Flags().DurationVar(&since, "since", 0, "only output newer than this, e.g. 5m")
+	return cmd
+}
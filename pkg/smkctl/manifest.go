@@ -0,0 +1,186 @@
+package smkctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"superminikube/pkg/api"
+)
+
+// object is one object of a manifest, as JSON
+type object struct {
+	// the file the object came from
+	source    string
+	resource  resource
+	name      string
+	namespace string
+	fields    map[string]any
+}
+
+// readManifests reads every object in the files at paths, "-" being stdin.
+// Directories are read for their .yaml, .yml and .json files, but not the directories in them.
+func readManifests(paths []string, stdin io.Reader) ([]object, error) {
+	var objs []object
+	for _, path := range paths {
+		if path == "-" {
+			b, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read stdin: %v", err)
+			}
+			found, err := parseManifest("stdin", b)
+			if err != nil {
+				return nil, err
+			}
+			objs = append(objs, found...)
+			continue
+		}
+		files := []string{path}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		if info.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", path, err)
+			}
+			files = nil
+			for _, e := range entries {
+				switch filepath.Ext(e.Name()) {
+				case ".yaml", ".yml", ".json":
+					if !e.IsDir() {
+						files = append(files, filepath.Join(path, e.Name()))
+					}
+				}
+			}
+		}
+		for _, file := range files {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", file, err)
+			}
+			found, err := parseManifest(file, b)
+			if err != nil {
+				return nil, err
+			}
+			objs = append(objs, found...)
+		}
+	}
+	return objs, nil
+}
+
+// parseManifest reads the objects of a YAML or JSON manifest, YAML can hold several documents split by ---.
+// Each is strictly decoded as the kind it says it is, empty documents are skipped.
+func parseManifest(source string, b []byte) ([]object, error) {
+	var objs []object
+	for i, doc := range api.SplitYAML(b) {
+		j, err := api.YAMLToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid document %d of %s: %v", i+1, source, err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(j, &fields); err != nil {
+			return nil, fmt.Errorf("document %d of %s isn't an object", i+1, source)
+		}
+		if fields == nil {
+			continue
+		}
+		obj, err := newObject(source, j, fields)
+		if err != nil {
+			return nil, fmt.Errorf("document %d of %s: %v", i+1, source, err)
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// newObject checks the document j against its kind's type, fields is j as it's sent
+func newObject(source string, j []byte, fields map[string]any) (object, error) {
+	decoded, err := api.DecodeObject(j)
+	if err != nil {
+		return object{}, err
+	}
+	kind := api.KindOf(decoded)
+	r, err := lookupKind(kind)
+	if err != nil {
+		return object{}, err
+	}
+	meta := decoded.GetObjectMeta()
+	if meta.Name == "" {
+		return object{}, fmt.Errorf("%s has no metadata.name", kind)
+	}
+	return object{source: source, resource: r, name: meta.Name, namespace: meta.Namespace, fields: fields}, nil
+}
+
+// withTypeMeta is b with its apiVersion and kind filled in, the apiserver leaves them out.
+// It's what makes what get prints a manifest apply can read back.
+func withTypeMeta(b json.RawMessage, kind string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil || fields == nil {
+		return b
+	}
+	fields["apiVersion"], _ = json.Marshal(api.APIVersion)
+	fields["kind"], _ = json.Marshal(kind)
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return b
+	}
+	return out
+}
+
+// withMeta is the object's JSON with metadata fields set, nil values remove them
+func (o object) withMeta(set map[string]any) ([]byte, error) {
+	fields := make(map[string]any, len(o.fields))
+	for k, v := range o.fields {
+		fields[k] = v
+	}
+	meta := map[string]any{}
+	if m, ok := o.fields["metadata"].(map[string]any); ok {
+		for k, v := range m {
+			meta[k] = v
+		}
+	}
+	for k, v := range set {
+		if v == nil {
+			delete(meta, k)
+		} else {
+			meta[k] = v
+		}
+	}
+	fields["metadata"] = meta
+	return json.Marshal(fields)
+}
+
+// subsetOf reports whether everything set in want is the same in have, it's how apply tells nothing would change.
+// have can have more, e.g. the defaults the apiserver filled in.
+func subsetOf(want, have any) bool {
+	switch want := want.(type) {
+	case map[string]any:
+		h, ok := have.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range want {
+			if !subsetOf(v, h[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		h, ok := have.([]any)
+		if !ok || len(h) != len(want) {
+			return false
+		}
+		for i := range want {
+			if !subsetOf(want[i], h[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return want == have
+	}
+}
@@ -0,0 +1,100 @@
+package smkctl
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadManifests(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.yaml": `# the app and its database
+apiVersion: v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+---
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: db
+  namespace: data
+`,
+		"node.json": `{"apiVersion": "v1", "kind": "Node", "metadata": {"name": "worker"}}`,
+		"README.md": "not a manifest",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	objs, err := readManifests([]string{dir, "-"}, strings.NewReader("apiVersion: v1\nkind: Job\nmetadata:\n  name: migrate\n"))
+	if err != nil {
+		t.Fatalf("failed to read manifests: %v", err)
+	}
+	var got []string
+	for _, obj := range objs {
+		got = append(got, obj.resource.plural+"/"+obj.namespace+"/"+obj.name)
+	}
+	expected := []string{"deployments//web", "pods/data/db", "nodes//worker", "jobs//migrate"}
+	if strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+	if replicas := objs[0].fields["spec"].(map[string]any)["replicas"]; replicas != 2.0 {
+		t.Errorf("got replicas %v, expected the document to be decoded", replicas)
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		testCases := map[string]string{
+			"no kind":       "apiVersion: v1\nmetadata:\n  name: web\n",
+			"no apiVersion": "kind: Pod\nmetadata:\n  name: web\n",
+			"unknown kind":  "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n",
+			"no name":       "apiVersion: v1\nkind: Pod\nmetadata: {}\n",
+			"unknown field": "apiVersion: v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replica: 2\n",
+			"not yaml":      "kind: [Pod\n",
+			"not object":    "- kind: Pod\n",
+		}
+		for name, manifest := range testCases {
+			if _, err := parseManifest("test", []byte(manifest)); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+}
+
+func TestSubsetOf(t *testing.T) {
+	have := decode(t, `{"metadata": {"name": "web", "uid": "1"}, "spec": {"containers": [{"name": "app", "image": "nginx"}], "restartPolicy": "Always"}}`)
+	testCases := []struct {
+		want     string
+		expected bool
+	}{
+		{want: `{"metadata": {"name": "web"}}`, expected: true},
+		{want: `{"spec": {"containers": [{"name": "app", "image": "nginx"}]}}`, expected: true},
+		// the apiserver writes field names back the way they're tagged
+		{want: `{"Spec": {"containers": [{"name": "app", "image": "nginx"}]}}`, expected: false},
+		{want: `{"spec": {"containers": [{"name": "app", "image": "redis"}]}}`, expected: false},
+		{want: `{"spec": {"containers": []}}`, expected: false},
+		{want: `{"spec": {"paused": true}}`, expected: false},
+	}
+	for _, tc := range testCases {
+		if got := subsetOf(decode(t, tc.want), have); got != tc.expected {
+			t.Errorf("subsetOf(%s) = %v, expected %v", tc.want, got, tc.expected)
+		}
+	}
+}
+
+func decode(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("invalid JSON %s: %v", s, err)
+	}
+	return m
+}
@@ -0,0 +1,128 @@
+package smkctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+// printer writes objects in the format -o asked for
+type printer struct {
+	// "" for a table, wide, json, yaml, name or jsonpath
+	format   string
+	jsonPath *jsonPath
+}
+
+func newPrinter(output string) (*printer, error) {
+	switch {
+	case output == "" || output == "wide" || output == "json" || output == "yaml" || output == "name":
+		return &printer{format: output}, nil
+	case strings.HasPrefix(output, "jsonpath="):
+		j, err := parseJSONPath(strings.TrimPrefix(output, "jsonpath="))
+		if err != nil {
+			return nil, err
+		}
+		return &printer{format: "jsonpath", jsonPath: j}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, expected one of wide, json, yaml, name or jsonpath=", output)
+	}
+}
+
+func (p *printer) table() bool {
+	return p.format == "" || p.format == "wide"
+}
+
+// printObject writes b, a single object or a list, in one of the formats that aren't a table
+func (p *printer) printObject(w io.Writer, b []byte) error {
+	switch p.format {
+	case "json":
+		var out bytes.Buffer
+		if err := json.Indent(&out, b, "", "    "); err != nil {
+			return fmt.Errorf("failed to format object: %v", err)
+		}
+		out.WriteByte('\n')
+		_, err := w.Write(out.Bytes())
+		return err
+	case "yaml":
+		y, err := yaml.JSONToYAML(b)
+		if err != nil {
+			return fmt.Errorf("failed to format object: %v", err)
+		}
+		_, err = w.Write(y)
+		return err
+	case "jsonpath":
+		return p.jsonPath.execute(w, b)
+	default:
+		return fmt.Errorf("%q isn't a format for single objects", p.format)
+	}
+}
+
+// tablePrinter writes objects of one resource as rows, the header goes before the first
+type tablePrinter struct {
+	w        *tabwriter.Writer
+	resource resource
+	wide     bool
+	// adds a NAMESPACE column, for objects from every namespace
+	withNamespace bool
+	// names objects kind/name instead of printing rows
+	namesOnly     bool
+	headerWritten bool
+}
+
+func newTablePrinter(w io.Writer, r resource, format string, withNamespace bool) *tablePrinter {
+	return &tablePrinter{
+		w:             tabwriter.NewWriter(w, 0, 8, 3, ' ', 0),
+		resource:      r,
+		wide:          format == "wide",
+		withNamespace: withNamespace && r.namespaced,
+		namesOnly:     format == "name",
+	}
+}
+
+func (t *tablePrinter) printRow(b []byte) error {
+	meta := objectMeta(b)
+	if t.namesOnly {
+		_, err := fmt.Fprintf(t.w, "%s/%s\n", strings.ToLower(t.resource.kind), meta.Name)
+		return err
+	}
+	cells, err := t.resource.row(b)
+	if err != nil {
+		return err
+	}
+	if !t.headerWritten {
+		var header []string
+		if t.withNamespace {
+			header = append(header, "NAMESPACE")
+		}
+		header = append(header, "NAME")
+		for _, c := range t.resource.columns {
+			if !c.wide || t.wide {
+				header = append(header, c.name)
+			}
+		}
+		fmt.Fprintln(t.w, strings.Join(header, "\t"))
+		t.headerWritten = true
+	}
+	var row []string
+	if t.withNamespace {
+		row = append(row, meta.Namespace)
+	}
+	row = append(row, meta.Name)
+	for i, c := range t.resource.columns {
+		if !c.wide || t.wide {
+			row = append(row, cells[i])
+		}
+	}
+	_, err = fmt.Fprintln(t.w, strings.Join(row, "\t"))
+	return err
+}
+
+// flush writes the rows so far, columns are only lined up among rows written in one go
+func (t *tablePrinter) flush() error {
+	return t.w.Flush()
+}
@@ -0,0 +1,297 @@
+package smkctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"superminikube/pkg/api"
+)
+
+// resource is a kind of object the apiserver serves and how smkctl shows it in a table
+type resource struct {
+	// what manifests call it
+	kind string
+	// the url path segment, it's also what watches call the resource
+	plural string
+	// other names it goes by on the command line, besides its plural
+	aliases    []string
+	namespaced bool
+	// columns after NAME
+	columns []column
+	// the cells of an object's row, one per column
+	row func(b []byte) ([]string, error)
+}
+
+type column struct {
+	name string
+	// only shown with -o wide
+	wide bool
+}
+
+var resources = []resource{
+	{
+		kind: "Pod", plural: "pods", aliases: []string{"pod", "po"}, namespaced: true,
+		columns: []column{{name: "READY"}, {name: "STATUS"}, {name: "RESTARTS"}, {name: "AGE"}, {name: "IP", wide: true}, {name: "NODE", wide: true}},
+		row: rowOf(func(p api.Pod) []string {
+			ready, restarts := 0, int32(0)
+			for _, s := range p.Status.ContainerStatuses {
+				if s.Ready {
+					ready++
+				}
+				restarts += s.RestartCount
+			}
+			return []string{
+				fmt.Sprintf("%d/%d", ready, len(p.Spec.Containers)),
+				podStatus(p),
+				strconv.Itoa(int(restarts)),
+				age(p.CreationTimestamp),
+				orNone(p.Status.PodIP),
+				orNone(p.Nodename),
+			}
+		}),
+	},
+	{
+		kind: "Node", plural: "nodes", aliases: []string{"node", "no"},
+		columns: []column{{name: "STATUS"}, {name: "AGE"}, {name: "INTERNAL-IP", wide: true}, {name: "OS", wide: true}, {name: "KERNEL-VERSION", wide: true}, {name: "CONTAINER-RUNTIME", wide: true}},
+		row: rowOf(func(n api.Node) []string {
+			status := "NotReady"
+			for _, c := range n.Status.Conditions {
+				if c.Type == api.NodeReady && c.Status == api.ConditionTrue {
+					status = "Ready"
+				}
+			}
+			if n.Spec.Unschedulable {
+				status += ",SchedulingDisabled"
+			}
+			ip := ""
+			for _, a := range n.Status.Addresses {
+				if a.Type == api.NodeInternalIP {
+					ip = a.Address
+				}
+			}
+			info := n.Status.NodeInfo
+			return []string{status, age(n.CreationTimestamp), orNone(ip), orNone(info.OperatingSystem), orNone(info.KernelVersion), orNone(info.ContainerRuntimeVersion)}
+		}),
+	},
+	{
+		kind: "ReplicaSet", plural: "replicasets", aliases: []string{"replicaset", "rs"}, namespaced: true,
+		columns: []column{{name: "DESIRED"}, {name: "CURRENT"}, {name: "READY"}, {name: "AGE"}, {name: "IMAGES", wide: true}, {name: "SELECTOR", wide: true}},
+		row: rowOf(func(rs api.ReplicaSet) []string {
+			return []string{
+				replicas(rs.Spec.Replicas),
+				strconv.Itoa(int(rs.Status.Replicas)),
+				strconv.Itoa(int(rs.Status.ReadyReplicas)),
+				age(rs.CreationTimestamp),
+				images(rs.Spec.Template.Spec),
+				selector(rs.Spec.Selector),
+			}
+		}),
+	},
+	{
+		kind: "Deployment", plural: "deployments", aliases: []string{"deployment", "deploy"}, namespaced: true,
+		columns: []column{{name: "READY"}, {name: "UP-TO-DATE"}, {name: "AVAILABLE"}, {name: "AGE"}, {name: "IMAGES", wide: true}, {name: "SELECTOR", wide: true}},
+		row: rowOf(func(d api.Deployment) []string {
+			return []string{
+				fmt.Sprintf("%d/%s", d.Status.ReadyReplicas, replicas(d.Spec.Replicas)),
+				strconv.Itoa(int(d.Status.UpdatedReplicas)),
+				strconv.Itoa(int(d.Status.Replicas - d.Status.UnavailableReplicas)),
+				age(d.CreationTimestamp),
+				images(d.Spec.Template.Spec),
+				selector(d.Spec.Selector),
+			}
+		}),
+	},
+	{
+		kind: "Job", plural: "jobs", aliases: []string{"job"}, namespaced: true,
+		columns: []column{{name: "COMPLETIONS"}, {name: "DURATION"}, {name: "AGE"}, {name: "IMAGES", wide: true}},
+		row: rowOf(func(j api.Job) []string {
+			duration := "<none>"
+			if j.Status.StartTime != nil {
+				end := time.Now()
+				if j.Status.CompletionTime != nil {
+					end = *j.Status.CompletionTime
+				}
+				duration = shortDuration(end.Sub(*j.Status.StartTime))
+			}
+			return []string{
+				fmt.Sprintf("%d/%s", j.Status.Succeeded, replicas(j.Spec.Completions)),
+				duration,
+				age(j.CreationTimestamp),
+				images(j.Spec.Template.Spec),
+			}
+		}),
+	},
+	{
+		kind: "CronJob", plural: "cronjobs", aliases: []string{"cronjob", "cj"}, namespaced: true,
+		columns: []column{{name: "SCHEDULE"}, {name: "SUSPEND"}, {name: "ACTIVE"}, {name: "LAST SCHEDULE"}, {name: "AGE"}, {name: "IMAGES", wide: true}},
+		row: rowOf(func(cj api.CronJob) []string {
+			last := "<none>"
+			if cj.Status.LastScheduleTime != nil {
+				last = age(*cj.Status.LastScheduleTime)
+			}
+			return []string{
+				cj.Spec.Schedule,
+				strconv.FormatBool(cj.Spec.Suspend),
+				strconv.Itoa(len(cj.Status.Active)),
+				last,
+				age(cj.CreationTimestamp),
+				images(cj.Spec.JobTemplate.Spec.Template.Spec),
+			}
+		}),
+	},
+	{
+		kind: "DaemonSet", plural: "daemonsets", aliases: []string{"daemonset", "ds"}, namespaced: true,
+		columns: []column{{name: "DESIRED"}, {name: "CURRENT"}, {name: "READY"}, {name: "UP-TO-DATE"}, {name: "AVAILABLE"}, {name: "AGE"}, {name: "IMAGES", wide: true}, {name: "SELECTOR", wide: true}},
+		row: rowOf(func(ds api.DaemonSet) []string {
+			return []string{
+				strconv.Itoa(int(ds.Status.DesiredNumberScheduled)),
+				strconv.Itoa(int(ds.Status.CurrentNumberScheduled)),
+				strconv.Itoa(int(ds.Status.NumberReady)),
+				strconv.Itoa(int(ds.Status.UpdatedNumberScheduled)),
+				strconv.Itoa(int(ds.Status.DesiredNumberScheduled - ds.Status.NumberUnavailable)),
+				age(ds.CreationTimestamp),
+				images(ds.Spec.Template.Spec),
+				selector(ds.Spec.Selector),
+			}
+		}),
+	},
+	{
+		kind: "StatefulSet", plural: "statefulsets", aliases: []string{"statefulset", "sts"}, namespaced: true,
+		columns: []column{{name: "READY"}, {name: "AGE"}, {name: "IMAGES", wide: true}},
+		row: rowOf(func(ss api.StatefulSet) []string {
+			return []string{
+				fmt.Sprintf("%d/%s", ss.Status.ReadyReplicas, replicas(ss.Spec.Replicas)),
+				age(ss.CreationTimestamp),
+				images(ss.Spec.Template.Spec),
+			}
+		}),
+	},
+}
+
+// lookupResource finds a resource by its plural, one of its aliases or its kind, in any case
+func lookupResource(name string) (resource, error) {
+	name = strings.ToLower(name)
+	for _, r := range resources {
+		if name == r.plural || name == strings.ToLower(r.kind) || slices.Contains(r.aliases, name) {
+			return r, nil
+		}
+	}
+	return resource{}, fmt.Errorf("unknown resource type %q", name)
+}
+
+// lookupKind finds the resource of a manifest's kind
+func lookupKind(kind string) (resource, error) {
+	for _, r := range resources {
+		if r.kind == kind {
+			return r, nil
+		}
+	}
+	return resource{}, fmt.Errorf("unknown kind %q", kind)
+}
+
+// path is where the object lives, or the list it's in when name is empty
+func (r resource) path(namespace, name string) string {
+	p := r.plural
+	if r.namespaced && namespace != "" {
+		p += "/" + namespace
+	}
+	if name != "" {
+		p += "/" + name
+	}
+	return p
+}
+
+func rowOf[T any](fn func(T) []string) func([]byte) ([]string, error) {
+	return func(b []byte) ([]string, error) {
+		var obj T
+		if err := json.Unmarshal(b, &obj); err != nil {
+			return nil, fmt.Errorf("failed to decode object: %v", err)
+		}
+		return fn(obj), nil
+	}
+}
+
+// podStatus is the pod's phase unless something more telling is going on,
+// like it being deleted or a container that's stuck waiting
+func podStatus(p api.Pod) string {
+	if p.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	for _, s := range append(slices.Clone(p.Status.InitContainerStatuses), p.Status.ContainerStatuses...) {
+		if w := s.State.Waiting; w != nil && w.Reason != "" && w.Reason != "ContainerCreating" {
+			return w.Reason
+		}
+	}
+	if p.Status.Reason != "" {
+		return p.Status.Reason
+	}
+	if p.Status.Phase == "" {
+		return string(api.PodPending)
+	}
+	return string(p.Status.Phase)
+}
+
+// replicas is how many replicas are asked for, which defaults to 1
+func replicas(n *int32) string {
+	if n == nil {
+		return "1"
+	}
+	return strconv.Itoa(int(*n))
+}
+
+func images(spec api.PodSpec) string {
+	var out []string
+	for _, c := range spec.Containers {
+		out = append(out, c.Image)
+	}
+	return orNone(strings.Join(out, ","))
+}
+
+func selector(s api.LabelSelector) string {
+	return orNone(labels(s.MatchLabels))
+}
+
+// labels are written key=value, sorted by key
+func labels(m map[string]string) string {
+	out := make([]string, 0, len(m))
+	for k, v := range m {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return strings.Join(out, ",")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+// age is how long ago t was, rounded the way it's shown in tables
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return shortDuration(time.Since(t))
+}
+
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < 0:
+		return "0s"
+	case d < 2*time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < 2*time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
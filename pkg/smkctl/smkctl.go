@@ -0,0 +1,63 @@
+// Package smkctl is the command-line client for the apiserver.
+// It finds the apiserver through a kubeconfig-style Config and talks to it through pkg/client like the components do.
+package smkctl
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"superminikube/pkg/client"
+)
+
+// options are the flags every command takes
+type options struct {
+	kubeconfig string
+	context    string
+	server     string
+	namespace  string
+}
+
+// ExitError is returned when a command run through exec exits with a code other than 0,
+// smkctl exits with the same code
+type ExitError struct {
+	Code int
+}
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("command terminated with exit code %d", e.Code)
+}
+
+// NewCommand is smkctl and its subcommands
+func NewCommand() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:   "smkctl",
+		Short: "Command-line client for the apiserver",
+		// usage is for mistakes on the command line, not for requests that failed
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.PersistentFlags().StringVar(&o.kubeconfig, "kubeconfig", "", "config file with the apiserver's address and credentials, defaults to $SMKCONFIG or ~/.smk/config")
+	cmd.PersistentFlags().StringVar(&o.context, "context", "", "context of the config file to use, defaults to its current-context")
+	cmd.PersistentFlags().StringVar(&o.server, "server", "", "url of the apiserver, overrides the config file")
+	cmd.PersistentFlags().StringVarP(&o.namespace, "namespace", "n", "", "namespace to work in, overrides the config file")
+	cmd.AddCommand(
+		newApplyCommand(o),
+		newGetCommand(o),
+		newDescribeCommand(o),
+		newDeleteCommand(o),
+		newLogsCommand(o),
+		newExecCommand(o),
+	)
+	return cmd
+}
+
+// client connects to the apiserver the flags and config point at, it returns the namespace to work in along with it
+func (o *options) client() (*client.HTTPClient, string, error) {
+	t, err := o.target()
+	if err != nil {
+		return nil, "", err
+	}
+	return client.NewHTTPClientWithCredentials(t.server, "", t.credentials), t.namespace, nil
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package smkctl
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"superminikube/pkg/remotecommand"
+)
+
+// watchTerminalSize sends the size of the terminal fd is, and its new size every time it's resized, until ctx is done
+func watchTerminalSize(ctx context.Context, fd int) <-chan remotecommand.TerminalSize {
+	sizes := make(chan remotecommand.TerminalSize)
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	go func() {
+		defer signal.Stop(winch)
+		for {
+			if size, ok := terminalSize(fd); ok {
+				select {
+				case sizes <- size:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-winch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return sizes
+}
@@ -0,0 +1,22 @@
+package smkctl
+
+import (
+	"context"
+
+	"superminikube/pkg/remotecommand"
+)
+
+// watchTerminalSize sends the size of the terminal fd is. Windows has no signal for a resized terminal,
+// the size it started with is kept.
+func watchTerminalSize(ctx context.Context, fd int) <-chan remotecommand.TerminalSize {
+	sizes := make(chan remotecommand.TerminalSize)
+	go func() {
+		if size, ok := terminalSize(fd); ok {
+			select {
+			case sizes <- size:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return sizes
+}
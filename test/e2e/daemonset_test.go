@@ -0,0 +1,93 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"superminikube/pkg/api"
+)
+
+func TestDaemonSet(t *testing.T) {
+	labels := map[string]string{"app": "agent"}
+	grace := int64(1)
+	ds := api.DaemonSet{
+		ObjectMeta: api.ObjectMeta{Name: "agent"},
+		Spec: api.DaemonSetSpec{
+			Selector: api.LabelSelector{MatchLabels: labels},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: labels},
+				Spec: api.PodSpec{
+					TerminationGracePeriodSeconds: &grace,
+					NodeSelector:                  map[string]string{api.LabelHostname: testNodeName},
+					Containers:                    []api.Container{{Name: "agent", Image: "fluentd:1"}},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(ds)
+	if err != nil {
+		t.Fatalf("failed to marshal daemon set: %v", err)
+	}
+	resp, err := http.Post(fmt.Sprintf("%s/api/v1/daemonsets/default", testAPIServerURL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create daemon set: %v", err)
+	}
+	var created api.DaemonSet
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	// the pod is bound to the node straight away and the kubelet runs it
+	dsURL := fmt.Sprintf("%s/api/v1/daemonsets/default/agent", testAPIServerURL)
+	waitForDaemonSet := func(image string) []api.Pod {
+		var pods []api.Pod
+		waitFor(t, "daemon pod running "+image, func() bool {
+			var got api.DaemonSet
+			getJSON(t, dsURL, &got)
+			s := got.Status
+			if s.ObservedGeneration != got.Generation || s.DesiredNumberScheduled != 1 || s.UpdatedNumberScheduled != 1 || s.NumberReady != 1 {
+				return false
+			}
+			pods = ownedPods(t, created.Uid)
+			return len(pods) == 1 && pods[0].Spec.Containers[0].Image == image
+		})
+		return pods
+	}
+	pods := waitForDaemonSet("fluentd:1")
+	if pods[0].Nodename != testNodeName {
+		t.Errorf("expected the pod on %s, got %q", testNodeName, pods[0].Nodename)
+	}
+
+	// a new template replaces the pod
+	var current api.DaemonSet
+	getJSON(t, dsURL, &current)
+	current.Spec.Template.Spec.Containers = []api.Container{{Name: "agent", Image: "fluentd:2"}}
+	body, _ = json.Marshal(current)
+	req, _ := http.NewRequest(http.MethodPut, dsURL, bytes.NewReader(body))
+	putResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to update daemon set: %v", err)
+	}
+	putResp.Body.Close()
+	if putResp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", putResp.StatusCode)
+	}
+	if replaced := waitForDaemonSet("fluentd:2"); replaced[0].Uid == pods[0].Uid {
+		t.Error("expected the pod to be replaced")
+	}
+
+	req, _ = http.NewRequest(http.MethodDelete, dsURL, nil)
+	delResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to delete daemon set: %v", err)
+	}
+	delResp.Body.Close()
+	waitFor(t, "pods of the deleted daemon set to be removed", func() bool {
+		return len(ownedPods(t, created.Uid)) == 0
+	})
+}
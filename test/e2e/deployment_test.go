@@ -0,0 +1,107 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"superminikube/pkg/api"
+)
+
+func TestDeploymentRollout(t *testing.T) {
+	replicas := int32(2)
+	grace := int64(1)
+	labels := map[string]string{"app": "api"}
+	d := api.Deployment{
+		ObjectMeta: api.ObjectMeta{Name: "api"},
+		Spec: api.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: api.LabelSelector{MatchLabels: labels},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: labels},
+				Spec: api.PodSpec{
+					TerminationGracePeriodSeconds: &grace,
+					Containers:                    []api.Container{{Image: "nginx:1.0"}},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("failed to marshal deployment: %v", err)
+	}
+	resp, err := http.Post(fmt.Sprintf("%s/api/v1/deployments/default", testAPIServerURL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create deployment: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	dURL := fmt.Sprintf("%s/api/v1/deployments/default/api", testAPIServerURL)
+	var got api.Deployment
+	rolledOut := func() bool {
+		getJSON(t, dURL, &got)
+		c := got.Status.GetCondition(api.DeploymentProgressing)
+		return got.Status.ObservedGeneration == got.Generation && got.Status.UpdatedReplicas == 2 &&
+			got.Status.Replicas == 2 && got.Status.ReadyReplicas == 2 && c != nil && c.Reason == "NewReplicaSetAvailable"
+	}
+	waitFor(t, "deployment to roll out", rolledOut)
+
+	// a new image is rolled out through a second replica set
+	got.Spec.Template.Spec.Containers[0].Image = "nginx:2.0"
+	body, _ = json.Marshal(got)
+	req, _ := http.NewRequest(http.MethodPut, dURL, bytes.NewReader(body))
+	putResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to update deployment: %v", err)
+	}
+	putResp.Body.Close()
+	if putResp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", putResp.StatusCode)
+	}
+	waitFor(t, "new template to roll out", rolledOut)
+
+	var sets api.ReplicaSetList
+	getJSON(t, fmt.Sprintf("%s/api/v1/replicasets/default", testAPIServerURL), &sets)
+	var owned []api.ReplicaSet
+	for _, rs := range sets.Items {
+		if rs.IsControlledBy(got.Uid) {
+			owned = append(owned, rs)
+		}
+	}
+	if len(owned) != 2 {
+		t.Fatalf("expected 2 replica sets owned by the deployment, got %d", len(owned))
+	}
+	for _, rs := range owned {
+		want := int32(0)
+		if rs.Spec.Template.Spec.Containers[0].Image == "nginx:2.0" {
+			want = 2
+		}
+		if *rs.Spec.Replicas != want {
+			t.Errorf("replica set %s with image %s has %d replicas, expected %d", rs.Name, rs.Spec.Template.Spec.Containers[0].Image, *rs.Spec.Replicas, want)
+		}
+	}
+
+	// deleting the deployment takes its replica sets, and those their pods
+	req, _ = http.NewRequest(http.MethodDelete, dURL, nil)
+	delResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to delete deployment: %v", err)
+	}
+	delResp.Body.Close()
+	if delResp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", delResp.StatusCode)
+	}
+	waitFor(t, "pods of the deleted deployment to be removed", func() bool {
+		for _, rs := range owned {
+			if len(ownedPods(t, rs.Uid)) > 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
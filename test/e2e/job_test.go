@@ -0,0 +1,71 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"superminikube/pkg/api"
+)
+
+func TestJob(t *testing.T) {
+	completions := int32(2)
+	grace := int64(1)
+	j := api.Job{
+		ObjectMeta: api.ObjectMeta{Name: "batch"},
+		Spec: api.JobSpec{
+			Completions: &completions,
+			Template: api.PodTemplateSpec{
+				Spec: api.PodSpec{
+					TerminationGracePeriodSeconds: &grace,
+					Containers:                    []api.Container{{Name: "complete", Image: "busybox"}},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(j)
+	if err != nil {
+		t.Fatalf("failed to marshal job: %v", err)
+	}
+	resp, err := http.Post(fmt.Sprintf("%s/api/v1/jobs/default", testAPIServerURL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create job: %v", err)
+	}
+	var created api.Job
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	// the kubelet reports each pod Succeeded as soon as it's started, the controller runs them one at a time
+	jobURL := fmt.Sprintf("%s/api/v1/jobs/default/batch", testAPIServerURL)
+	var got api.Job
+	waitFor(t, "job to complete", func() bool {
+		getJSON(t, jobURL, &got)
+		f := got.Status.Finished()
+		return f != nil && f.Type == api.JobComplete
+	})
+	if got.Status.Succeeded != 2 || got.Status.CompletionTime == nil {
+		t.Errorf("unexpected status %+v", got.Status)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, jobURL, nil)
+	delResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to delete job: %v", err)
+	}
+	delResp.Body.Close()
+	waitFor(t, "pods of the deleted job to be removed", func() bool {
+		var list api.PodList
+		getJSON(t, fmt.Sprintf("%s/api/v1/pods/default", testAPIServerURL), &list)
+		for _, p := range list.Items {
+			if p.IsControlledBy(created.Uid) {
+				return false
+			}
+		}
+		return true
+	})
+}
@@ -13,21 +13,30 @@ import (
 
 	"superminikube/pkg/api"
 	"superminikube/pkg/apiserver"
+	"superminikube/pkg/client"
+	"superminikube/pkg/controller/daemonset"
+	"superminikube/pkg/controller/deployment"
+	"superminikube/pkg/controller/job"
+	"superminikube/pkg/controller/replicaset"
+	"superminikube/pkg/controller/statefulset"
 	"superminikube/pkg/kubelet"
 	"superminikube/pkg/kubelet/runtime"
+	"superminikube/pkg/scheduler"
 )
 
 const (
 	testAPIServerAddr = ":18080"
 	testAPIServerURL  = "http://localhost:18080"
 	testNodeName      = "test-node"
+	// the apiserver finds the kubelet's server through the node's reported address
+	testKubeletPort = 18250
 )
 
 var (
-	testServer   *apiserver.APIServer
-	testKubelet  *kubelet.Kubelet
-	fakeRuntime  *runtime.FakeRuntime
-	cancelFunc   context.CancelFunc
+	testServer  *apiserver.APIServer
+	testKubelet *kubelet.Kubelet
+	fakeRuntime *runtime.FakeRuntime
+	cancelFunc  context.CancelFunc
 )
 
 func TestMain(m *testing.M) {
@@ -42,12 +51,22 @@ func TestMain(m *testing.M) {
 
 	time.Sleep(100 * time.Millisecond)
 
-	fakeRuntime = &runtime.FakeRuntime{}
-	testKubelet = kubelet.NewKubeletWithRuntime(testAPIServerURL, testNodeName, fakeRuntime)
+	// containers named complete exit straight away, for pods that run to completion
+	fakeRuntime = &runtime.FakeRuntime{ContainerStates: map[string]api.ContainerState{
+		"complete": {Terminated: &api.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"}},
+	}}
+	testKubelet = kubelet.NewKubeletWithRuntime(kubelet.KubeletOpts{APIServerURL: testAPIServerURL, NodeName: testNodeName, Port: testKubeletPort}, fakeRuntime)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cancelFunc = cancel
+	// the kubelet registers its node on start, giving the scheduler somewhere to put pods
 	go testKubelet.Start(ctx)
+	go scheduler.NewScheduler(testAPIServerURL).Start(ctx)
+	go replicaset.NewController(client.NewHTTPClient(testAPIServerURL, ""), replicaset.Opts{}).Start(ctx)
+	go deployment.NewController(client.NewHTTPClient(testAPIServerURL, ""), deployment.Opts{}).Start(ctx)
+	go job.NewController(client.NewHTTPClient(testAPIServerURL, ""), job.Opts{}).Start(ctx)
+	go daemonset.NewController(client.NewHTTPClient(testAPIServerURL, ""), daemonset.Opts{}).Start(ctx)
+	go statefulset.NewController(client.NewHTTPClient(testAPIServerURL, ""), statefulset.Opts{}).Start(ctx)
 
 	time.Sleep(100 * time.Millisecond)
 
@@ -61,19 +80,23 @@ func TestMain(m *testing.M) {
 }
 
 func TestPodCreation(t *testing.T) {
-	spec := api.PodSpec{
-		Container: api.Container{
-			Image: "nginx:latest",
-			Env:   map[string]string{},
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "nginx"},
+		Spec: api.PodSpec{
+			Containers: []api.Container{{
+				Image: "nginx:latest",
+				Env:   map[string]string{},
+			}},
 		},
 	}
 
-	body, err := json.Marshal(spec)
+	body, err := json.Marshal(pod)
 	if err != nil {
 		t.Fatalf("failed to marshal spec: %v", err)
 	}
 
-	url := fmt.Sprintf("%s/api/v1/pod?nodename=%s", testAPIServerURL, testNodeName)
+	// no nodename, the scheduler binds it to the only node
+	url := fmt.Sprintf("%s/api/v1/pods/default", testAPIServerURL)
 	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
 	if err != nil {
 		t.Fatalf("failed to create pod: %v", err)
@@ -89,7 +112,7 @@ func TestPodCreation(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	t.Logf("Created pod with UID: %s", createdPod.Uid)
+	t.Logf("Created pod %s with UID: %s", createdPod.Name, createdPod.Uid)
 
 	// NOTE: bad. ideally there is some notify mechanism that alerts that a pod has been created which leads to updating fields like PodStatus
 	time.Sleep(500 * time.Millisecond)
@@ -103,4 +126,21 @@ func TestPodCreation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to get pod: %v", err)
 	}
+
+	// kubelet reports status back through the status subresource
+	getResp, err := http.Get(fmt.Sprintf("%s/api/v1/pods/default/%s", testAPIServerURL, createdPod.Name))
+	if err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	defer getResp.Body.Close()
+	var storedPod api.Pod
+	if err := json.NewDecoder(getResp.Body).Decode(&storedPod); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if storedPod.Nodename != testNodeName {
+		t.Errorf("expected pod to be scheduled to %s, got %q", testNodeName, storedPod.Nodename)
+	}
+	if storedPod.Status.Phase != api.PodRunning {
+		t.Errorf("expected pod phase Running, got %s", storedPod.Status.Phase)
+	}
 }
@@ -0,0 +1,76 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"superminikube/pkg/api"
+)
+
+func TestPodDeletion(t *testing.T) {
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "to-delete"},
+		// bound up front, skipping the scheduler
+		Nodename: testNodeName,
+		Spec: api.PodSpec{
+			Containers: []api.Container{{Image: "nginx:latest"}},
+		},
+	}
+	body, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("failed to marshal spec: %v", err)
+	}
+	url := fmt.Sprintf("%s/api/v1/pods/default", testAPIServerURL)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	var createdPod api.Pod
+	json.NewDecoder(resp.Body).Decode(&createdPod)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	if _, err := testKubelet.GetPod(createdPod.Uid); err != nil {
+		t.Fatalf("kubelet never picked up the pod: %v", err)
+	}
+
+	podURL := fmt.Sprintf("%s/api/v1/pods/default/%s", testAPIServerURL, createdPod.Name)
+	req, _ := http.NewRequest(http.MethodDelete, podURL+"?gracePeriodSeconds=1", nil)
+	delResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to delete pod: %v", err)
+	}
+	var deleting api.Pod
+	json.NewDecoder(delResp.Body).Decode(&deleting)
+	delResp.Body.Close()
+	if delResp.StatusCode != http.StatusOK || deleting.DeletionTimestamp == nil {
+		t.Fatalf("expected pod to be marked for deletion, got status %d and %+v", delResp.StatusCode, deleting.ObjectMeta)
+	}
+
+	// the pod stays around until the kubelet confirms its containers are gone
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		getResp, err := http.Get(podURL)
+		if err != nil {
+			t.Fatalf("failed to get pod: %v", err)
+		}
+		getResp.Body.Close()
+		if getResp.StatusCode == http.StatusNotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pod still stored 5s after deletion")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if _, err := testKubelet.GetPod(createdPod.Uid); err == nil {
+		t.Errorf("kubelet still tracks the deleted pod")
+	}
+}
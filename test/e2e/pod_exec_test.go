@@ -0,0 +1,54 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+	"superminikube/pkg/remotecommand"
+)
+
+func TestPodExec(t *testing.T) {
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "shell"},
+		Nodename:   testNodeName,
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "busybox"}}},
+	}
+	body, _ := json.Marshal(pod)
+	resp, err := http.Post(fmt.Sprintf("%s/api/v1/pods/default", testAPIServerURL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	var created api.Pod
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	defer func() {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/v1/pods/default/shell?gracePeriodSeconds=0", testAPIServerURL), nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	waitFor(t, "the pod to be running on the kubelet", func() bool {
+		p, err := testKubelet.GetPod(created.Uid)
+		return err == nil && p.Status.Phase == api.PodRunning
+	})
+
+	// apiserver to kubelet to runtime and back, over one upgraded connection
+	var stdout, stderr strings.Builder
+	code, err := client.NewHTTPClient(testAPIServerURL, "").PodExec(t.Context(), "default", "shell", api.PodExecOptions{Command: []string{"cat"}}, remotecommand.Streams{
+		Stdin:  strings.NewReader("hello\n"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		t.Fatalf("failed to exec: %v", err)
+	}
+	if code != 0 || stdout.String() != "hello\n" || stderr.String() != "+ cat\n" {
+		t.Errorf("got exit code %d, stdout %q and stderr %q", code, stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,54 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+)
+
+func TestPodLogs(t *testing.T) {
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "chatty"},
+		Nodename:   testNodeName,
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "busybox"}}},
+	}
+	body, _ := json.Marshal(pod)
+	resp, err := http.Post(fmt.Sprintf("%s/api/v1/pods/default", testAPIServerURL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	var created api.Pod
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	defer func() {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/v1/pods/default/chatty?gracePeriodSeconds=0", testAPIServerURL), nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	waitFor(t, "the kubelet to pick up the pod", func() bool {
+		_, err := testKubelet.GetPod(created.Uid)
+		return err == nil
+	})
+
+	// apiserver to kubelet to runtime and back
+	tail := int64(2)
+	logs, err := client.NewHTTPClient(testAPIServerURL, "").PodLogs(t.Context(), "default", "chatty", api.PodLogOptions{TailLines: &tail})
+	if err != nil {
+		t.Fatalf("failed to get logs: %v", err)
+	}
+	defer logs.Close()
+	got, err := io.ReadAll(logs)
+	if err != nil {
+		t.Fatalf("failed to read logs: %v", err)
+	}
+	if string(got) != "app line 4\napp line 5\n" {
+		t.Errorf("got logs %q", got)
+	}
+}
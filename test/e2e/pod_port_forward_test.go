@@ -0,0 +1,61 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"superminikube/pkg/api"
+	"superminikube/pkg/client"
+	"superminikube/pkg/portforward"
+)
+
+func TestPodPortForward(t *testing.T) {
+	pod := api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: "server"},
+		Nodename:   testNodeName,
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app", Image: "nginx"}}},
+	}
+	body, _ := json.Marshal(pod)
+	resp, err := http.Post(fmt.Sprintf("%s/api/v1/pods/default", testAPIServerURL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	var created api.Pod
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	defer func() {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/v1/pods/default/server?gracePeriodSeconds=0", testAPIServerURL), nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	waitFor(t, "the pod to be running on the kubelet", func() bool {
+		p, err := testKubelet.GetPod(created.Uid)
+		return err == nil && p.Status.Phase == api.PodRunning
+	})
+
+	// local port to apiserver to kubelet to runtime and back, the fake runtime echoes
+	f, err := client.NewHTTPClient(testAPIServerURL, "").PortForward(t.Context(), "default", "server", []portforward.Port{{Remote: 80}})
+	if err != nil {
+		t.Fatalf("failed to forward: %v", err)
+	}
+	for i := range 2 {
+		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", f.Ports()[0].Local))
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		msg := fmt.Sprintf("hello %d", i)
+		conn.Write([]byte(msg))
+		conn.(*net.TCPConn).CloseWrite()
+		got, err := io.ReadAll(conn)
+		conn.Close()
+		if err != nil || string(got) != msg {
+			t.Errorf("got %q, %v, expected %q echoed", got, err, msg)
+		}
+	}
+}
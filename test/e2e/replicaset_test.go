@@ -0,0 +1,110 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"superminikube/pkg/api"
+)
+
+func TestReplicaSet(t *testing.T) {
+	replicas := int32(2)
+	grace := int64(1)
+	labels := map[string]string{"app": "web"}
+	rs := api.ReplicaSet{
+		ObjectMeta: api.ObjectMeta{Name: "web"},
+		Spec: api.ReplicaSetSpec{
+			Replicas: &replicas,
+			Selector: api.LabelSelector{MatchLabels: labels},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: labels},
+				Spec: api.PodSpec{
+					TerminationGracePeriodSeconds: &grace,
+					Containers:                    []api.Container{{Image: "nginx:latest"}},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(rs)
+	if err != nil {
+		t.Fatalf("failed to marshal replica set: %v", err)
+	}
+	resp, err := http.Post(fmt.Sprintf("%s/api/v1/replicasets/default", testAPIServerURL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create replica set: %v", err)
+	}
+	var created api.ReplicaSet
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	// the controller creates the pods, the scheduler binds them and the kubelet reports them ready
+	rsURL := fmt.Sprintf("%s/api/v1/replicasets/default/web", testAPIServerURL)
+	waitFor(t, "replica set to report 2 ready replicas", func() bool {
+		var got api.ReplicaSet
+		getJSON(t, rsURL, &got)
+		return got.Status.Replicas == 2 && got.Status.ReadyReplicas == 2
+	})
+	if owned := ownedPods(t, created.Uid); len(owned) != 2 {
+		t.Fatalf("expected 2 pods owned by the replica set, got %d", len(owned))
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, rsURL, nil)
+	delResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to delete replica set: %v", err)
+	}
+	delResp.Body.Close()
+	if delResp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", delResp.StatusCode)
+	}
+	waitFor(t, "pods of the deleted replica set to be removed", func() bool {
+		return len(ownedPods(t, created.Uid)) == 0
+	})
+}
+
+// ownedPods lists the pods in the default namespace controlled by the object with uid, terminating ones included
+func ownedPods(t *testing.T, uid uuid.UUID) []api.Pod {
+	t.Helper()
+	var list api.PodList
+	getJSON(t, fmt.Sprintf("%s/api/v1/pods/default", testAPIServerURL), &list)
+	var owned []api.Pod
+	for _, p := range list.Items {
+		if p.IsControlledBy(uid) {
+			owned = append(owned, p)
+		}
+	}
+	return owned
+}
+
+func getJSON(t *testing.T, url string, v any) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("failed to decode %s: %v", url, err)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after 10s
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,196 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"superminikube/pkg/smkctl"
+)
+
+func TestSmkctl(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config")
+	writeFile(t, config, fmt.Sprintf(`current-context: test
+clusters:
+- name: test
+  server: %s
+users:
+- name: admin
+  token: secret
+contexts:
+- name: test
+  cluster: test
+  user: admin
+  namespace: default
+`, testAPIServerURL))
+	manifests := filepath.Join(dir, "manifests")
+	os.Mkdir(manifests, 0o755)
+	writeFile(t, filepath.Join(manifests, "app.yaml"), `apiVersion: v1
+kind: Pod
+metadata:
+  name: cli
+spec:
+  containers:
+  - name: app
+    image: busybox
+---
+apiVersion: v1
+kind: CronJob
+metadata:
+  name: nightly
+spec:
+  schedule: "0 3 * * *"
+  suspend: true
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+          - name: backup
+            image: busybox
+`)
+	run := func(args ...string) (string, error) {
+		return runSmkctl(t.Context(), &strings.Builder{}, append([]string{"--kubeconfig", config}, args...)...)
+	}
+	defer run("delete", "-f", manifests, "--grace-period", "0", "--ignore-not-found")
+
+	out, err := run("apply", "-f", manifests)
+	if err != nil || out != "pod/cli created\ncronjob/nightly created\n" {
+		t.Fatalf("apply got %q, %v", out, err)
+	}
+	// the apiserver filling in defaults isn't a change
+	out, err = run("apply", "-f", manifests)
+	if err != nil || out != "pod/cli unchanged\ncronjob/nightly unchanged\n" {
+		t.Errorf("second apply got %q, %v", out, err)
+	}
+	writeFile(t, filepath.Join(manifests, "app.yaml"), strings.Replace(readFile(t, filepath.Join(manifests, "app.yaml")), "0 3 * * *", "0 4 * * *", 1))
+	out, err = run("apply", "-f", manifests)
+	if err != nil || !strings.Contains(out, "cronjob/nightly configured") {
+		t.Errorf("apply after a change got %q, %v", out, err)
+	}
+
+	out, err = run("get", "cj", "nightly", "-o", "jsonpath={.spec.schedule}")
+	if err != nil || out != "0 4 * * *" {
+		t.Errorf("get -o jsonpath got %q, %v", out, err)
+	}
+	out, err = run("get", "cronjobs")
+	if err != nil || !strings.HasPrefix(out, "NAME ") || !strings.Contains(out, "nightly") || !strings.Contains(out, "0 4 * * *") {
+		t.Errorf("get got %q, %v", out, err)
+	}
+	if _, err := run("get", "pods", "missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("get of a missing pod got %v", err)
+	}
+
+	waitFor(t, "the pod to be running", func() bool {
+		out, err := run("get", "pod", "cli", "-o", "jsonpath={.status.phase}")
+		return err == nil && out == "Running"
+	})
+	out, err = run("describe", "po", "cli")
+	if err != nil || !strings.Contains(out, "Name:        cli\n") || !strings.Contains(out, "Status:\n  conditions:") {
+		t.Errorf("describe got %q, %v", out, err)
+	}
+	// what get prints is a manifest apply reads back
+	out, err = run("get", "pod", "cli", "-o", "yaml")
+	if err != nil || !strings.Contains(out, "apiVersion: v1\nkind: Pod\n") {
+		t.Errorf("get -o yaml got %q, %v", out, err)
+	}
+	writeFile(t, filepath.Join(dir, "cli.yaml"), out)
+	if out, err := run("apply", "-f", filepath.Join(dir, "cli.yaml")); err != nil || out != "pod/cli unchanged\n" {
+		t.Errorf("applying what get printed got %q, %v", out, err)
+	}
+	out, err = run("logs", "cli", "--tail", "1")
+	if err != nil || out != "app line 5\n" {
+		t.Errorf("logs got %q, %v", out, err)
+	}
+	var stderr strings.Builder
+	out, err = runSmkctl(t.Context(), &stderr, "--kubeconfig", config, "exec", "cli", "--", "ls", "/")
+	if err != nil || out != "" || stderr.String() != "+ ls /\n" {
+		t.Errorf("exec got stdout %q, stderr %q, %v", out, stderr.String(), err)
+	}
+
+	t.Run("watch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		var watched syncBuilder
+		done := make(chan error)
+		go func() {
+			cmd := smkctl.NewCommand()
+			cmd.SetArgs([]string{"--kubeconfig", config, "get", "cronjobs", "nightly", "--watch", "-o", "name"})
+			cmd.SetOut(&watched)
+			cmd.SetErr(&strings.Builder{})
+			done <- cmd.ExecuteContext(ctx)
+		}()
+		waitFor(t, "the watch to list the cron job", func() bool { return watched.String() == "cronjob/nightly\n" })
+		if out, err := run("delete", "cronjob", "nightly"); err != nil || out != "cronjob/nightly deleted\n" {
+			t.Errorf("delete got %q, %v", out, err)
+		}
+		waitFor(t, "the watch to see the cron job deleted", func() bool { return watched.String() == "cronjob/nightly\ncronjob/nightly\n" })
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("watch stopped with %v, expected nothing once it's cancelled", err)
+		}
+	})
+
+	if _, err := run("delete", "cronjob", "nightly"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("deleting twice got %v, expected not found", err)
+	}
+	if _, err := run("delete", "cronjob", "nightly", "--ignore-not-found"); err != nil {
+		t.Errorf("deleting twice with --ignore-not-found got %v", err)
+	}
+}
+
+// runSmkctl runs smkctl with args and returns what it wrote to stdout
+func runSmkctl(ctx context.Context, stderr *strings.Builder, args ...string) (string, error) {
+	cmd := smkctl.NewCommand()
+	var stdout strings.Builder
+	cmd.SetArgs(args)
+	cmd.SetIn(strings.NewReader(""))
+	cmd.SetOut(&stdout)
+	cmd.SetErr(stderr)
+	// a deadline keeps a stuck command from hanging the test
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	err := cmd.ExecuteContext(ctx)
+	return stdout.String(), err
+}
+
+// syncBuilder is a strings.Builder that can be read while it's written to
+type syncBuilder struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (s *syncBuilder) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuilder) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(b)
+}
@@ -0,0 +1,84 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"superminikube/pkg/api"
+)
+
+func TestStatefulSet(t *testing.T) {
+	labels := map[string]string{"app": "db"}
+	grace := int64(1)
+	replicas := int32(2)
+	ss := api.StatefulSet{
+		ObjectMeta: api.ObjectMeta{Name: "db"},
+		Spec: api.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: api.LabelSelector{MatchLabels: labels},
+			Template: api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{Labels: labels},
+				Spec: api.PodSpec{
+					TerminationGracePeriodSeconds: &grace,
+					Containers: []api.Container{{
+						Name:         "postgres",
+						Image:        "postgres:16",
+						VolumeMounts: []api.VolumeMount{{Name: "data", MountPath: "/var/lib/postgresql/data"}},
+					}},
+				},
+			},
+			VolumeClaimTemplates: []api.VolumeClaimTemplate{{Name: "data"}},
+		},
+	}
+	body, err := json.Marshal(ss)
+	if err != nil {
+		t.Fatalf("failed to marshal stateful set: %v", err)
+	}
+	resp, err := http.Post(fmt.Sprintf("%s/api/v1/statefulsets/default", testAPIServerURL), "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create stateful set: %v", err)
+	}
+	var created api.StatefulSet
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+
+	ssURL := fmt.Sprintf("%s/api/v1/statefulsets/default/db", testAPIServerURL)
+	var pods []api.Pod
+	waitFor(t, "stateful pods ready", func() bool {
+		var got api.StatefulSet
+		getJSON(t, ssURL, &got)
+		if got.Status.ReadyReplicas != 2 {
+			return false
+		}
+		pods = ownedPods(t, created.Uid)
+		return len(pods) == 2
+	})
+	names := []string{pods[0].Name, pods[1].Name}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"db-0", "db-1"}) {
+		t.Errorf("expected pods db-0 and db-1, got %v", names)
+	}
+	for _, p := range pods {
+		want := fmt.Sprintf("%s/default/data-%s", api.DefaultVolumeClaimDir, p.Name)
+		if len(p.Spec.Volumes) != 1 || p.Spec.Volumes[0].HostPath == nil || p.Spec.Volumes[0].HostPath.Path != want {
+			t.Errorf("expected pod %s to mount %s, got %+v", p.Name, want, p.Spec.Volumes)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ssURL, nil)
+	delResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to delete stateful set: %v", err)
+	}
+	delResp.Body.Close()
+	waitFor(t, "pods of the deleted stateful set to be removed", func() bool {
+		return len(ownedPods(t, created.Uid)) == 0
+	})
+}